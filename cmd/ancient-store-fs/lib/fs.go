@@ -0,0 +1,364 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	freezerRemoteHashTable       = "hashes"
+	freezerRemoteHeaderTable     = "headers"
+	freezerRemoteBodiesTable     = "bodies"
+	freezerRemoteReceiptTable    = "receipts"
+	freezerRemoteDifficultyTable = "diffs"
+
+	// protocolVersion is the version of the remote freezer protocol implemented by the server,
+	// matching rawdb.FreezerRemoteProtocolVersion.
+	protocolVersion = 2
+
+	// indexEntrySize is the size of an index entry, the big endian end offset of an item.
+	indexEntrySize = 8
+)
+
+// tableKinds are the ancient kinds stored by the server, in the order of the
+// AppendAncient arguments.
+var tableKinds = []string{
+	freezerRemoteHashTable,
+	freezerRemoteHeaderTable,
+	freezerRemoteBodiesTable,
+	freezerRemoteReceiptTable,
+	freezerRemoteDifficultyTable,
+}
+
+var (
+	errOutOfBounds   = errors.New("out of bounds")
+	errOutOfOrder    = errors.New("out of order")
+	errFieldMismatch = errors.New("field counts mismatch")
+	errUnknownKind   = errors.New("unknown ancient kind")
+)
+
+// fsTable is an append-only table of items, made of a data file holding the
+// concatenated items and an index file holding the end offset of each item.
+type fsTable struct {
+	data  *os.File
+	index *os.File
+	size  uint64 // Length of the data file
+	items uint64 // Number of items
+}
+
+// openTable opens or creates the table files of a kind, discarding any partially
+// written trailing item.
+func openTable(dir, kind string) (*fsTable, error) {
+	data, err := os.OpenFile(filepath.Join(dir, kind+".dat"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, kind+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	t := &fsTable{data: data, index: index}
+
+	dataStat, err := data.Stat()
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	indexStat, err := index.Stat()
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	// An item is only complete if both its index entry and its data were written
+	items := uint64(indexStat.Size()) / indexEntrySize
+	for ; items > 0; items-- {
+		end, err := t.offset(items)
+		if err != nil {
+			t.close()
+			return nil, err
+		}
+		if end <= uint64(dataStat.Size()) {
+			break
+		}
+	}
+	t.items, t.size = items, uint64(dataStat.Size())
+	if err := t.truncate(items); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// offset returns the end offset of the data of the first n items.
+func (t *fsTable) offset(n uint64) (uint64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	var entry [indexEntrySize]byte
+	if _, err := t.index.ReadAt(entry[:], int64((n-1)*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(entry[:]), nil
+}
+
+// retrieve returns the item at number n.
+func (t *fsTable) retrieve(n uint64) ([]byte, error) {
+	if n >= t.items {
+		return nil, errOutOfBounds
+	}
+	start, err := t.offset(n)
+	if err != nil {
+		return nil, err
+	}
+	end, err := t.offset(n + 1)
+	if err != nil {
+		return nil, err
+	}
+	item := make([]byte, end-start)
+	if _, err := t.data.ReadAt(item, int64(start)); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// append writes an item at the end of the table. The data is written before the
+// index entry, so an interrupted append is discarded when the table is reopened.
+func (t *fsTable) append(item []byte) error {
+	if _, err := t.data.WriteAt(item, int64(t.size)); err != nil {
+		return err
+	}
+	var entry [indexEntrySize]byte
+	binary.BigEndian.PutUint64(entry[:], t.size+uint64(len(item)))
+	if _, err := t.index.WriteAt(entry[:], int64(t.items*indexEntrySize)); err != nil {
+		return err
+	}
+	t.size += uint64(len(item))
+	t.items++
+	return nil
+}
+
+// truncate discards the items from number n on.
+func (t *fsTable) truncate(n uint64) error {
+	if n > t.items {
+		return nil
+	}
+	size, err := t.offset(n)
+	if err != nil {
+		return err
+	}
+	if err := t.index.Truncate(int64(n * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.items, t.size = n, size
+	return nil
+}
+
+// sync flushes the table files to disk.
+func (t *fsTable) sync() error {
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+func (t *fsTable) close() {
+	t.data.Close()
+	t.index.Close()
+}
+
+// FSFreezerRemoteServerAPI is a remote freezer server persisting ancients into
+// flat append-only files, one data and one index file per ancient kind.
+type FSFreezerRemoteServerAPI struct {
+	tables map[string]*fsTable
+	count  uint64
+	mu     sync.Mutex
+}
+
+// NewFSFreezerRemoteServerAPI opens or creates the ancient store in a directory.
+// Tables of different lengths, eg. after a crash, are truncated to the shortest one.
+func NewFSFreezerRemoteServerAPI(dir string) (*FSFreezerRemoteServerAPI, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f := &FSFreezerRemoteServerAPI{tables: make(map[string]*fsTable)}
+	for i, kind := range tableKinds {
+		t, err := openTable(dir, kind)
+		if err != nil {
+			for _, t := range f.tables {
+				t.close()
+			}
+			return nil, fmt.Errorf("open %s table: %v", kind, err)
+		}
+		f.tables[kind] = t
+		if i == 0 || t.items < f.count {
+			f.count = t.items
+		}
+	}
+	if err := f.truncate(f.count); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *FSFreezerRemoteServerAPI) table(kind string) (*fsTable, error) {
+	t, ok := f.tables[kind]
+	if !ok {
+		return nil, errUnknownKind
+	}
+	return t, nil
+}
+
+func (f *FSFreezerRemoteServerAPI) ProtocolVersion() (uint64, error) {
+	return protocolVersion, nil
+}
+
+func (f *FSFreezerRemoteServerAPI) HasAncient(kind string, number uint64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.table(kind)
+	if err != nil {
+		return false, err
+	}
+	return number < t.items, nil
+}
+
+func (f *FSFreezerRemoteServerAPI) Ancient(kind string, number uint64) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.table(kind)
+	if err != nil {
+		return nil, err
+	}
+	return t.retrieve(number)
+}
+
+// AncientRange returns up to count consecutive items of a kind starting at number,
+// stopping early once maxBytes is exceeded. At least one item is returned.
+func (f *FSFreezerRemoteServerAPI) AncientRange(kind string, number, count, maxBytes uint64) ([][]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.table(kind)
+	if err != nil {
+		return nil, err
+	}
+	if number >= t.items {
+		return nil, errOutOfBounds
+	}
+	var (
+		res  [][]byte
+		size uint64
+	)
+	for n := number; n < number+count && n < t.items; n++ {
+		item, err := t.retrieve(n)
+		if err != nil {
+			return nil, err
+		}
+		if len(res) > 0 && size+uint64(len(item)) > maxBytes {
+			break
+		}
+		res = append(res, item)
+		size += uint64(len(item))
+	}
+	return res, nil
+}
+
+func (f *FSFreezerRemoteServerAPI) Ancients() (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.count, nil
+}
+
+func (f *FSFreezerRemoteServerAPI) AncientSize(kind string) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, err := f.table(kind)
+	if err != nil {
+		return 0, err
+	}
+	return t.size + t.items*indexEntrySize, nil
+}
+
+func (f *FSFreezerRemoteServerAPI) AppendAncient(number uint64, hash, header, body, receipt, td []byte) error {
+	return f.AppendAncients(number, [][]byte{hash}, [][]byte{header}, [][]byte{body}, [][]byte{receipt}, [][]byte{td})
+}
+
+func (f *FSFreezerRemoteServerAPI) AppendAncients(number uint64, hashes, headers, bodies, receipts, tds [][]byte) error {
+	if len(headers) != len(hashes) || len(bodies) != len(hashes) || len(receipts) != len(hashes) || len(tds) != len(hashes) {
+		return errFieldMismatch
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if number != f.count {
+		return errOutOfOrder
+	}
+	for i := range hashes {
+		for j, item := range [][]byte{hashes[i], headers[i], bodies[i], receipts[i], tds[i]} {
+			if err := f.tables[tableKinds[j]].append(item); err != nil {
+				// Roll back the partially written block
+				f.truncate(f.count)
+				return err
+			}
+		}
+		f.count++
+	}
+	return nil
+}
+
+func (f *FSFreezerRemoteServerAPI) TruncateAncients(n uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.truncate(n)
+}
+
+func (f *FSFreezerRemoteServerAPI) truncate(n uint64) error {
+	for _, t := range f.tables {
+		if err := t.truncate(n); err != nil {
+			return err
+		}
+	}
+	if n < f.count {
+		f.count = n
+	}
+	return nil
+}
+
+func (f *FSFreezerRemoteServerAPI) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.tables {
+		if err := t.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes the tables to disk. The files stay open, since the server
+// outlives the connections of its clients.
+func (f *FSFreezerRemoteServerAPI) Close() error {
+	return f.Sync()
+}
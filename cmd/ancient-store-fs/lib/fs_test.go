@@ -0,0 +1,127 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testItem(kind string, n uint64) []byte {
+	return bytes.Repeat([]byte(kind), int(n%7)+1)
+}
+
+func appendTestItems(t *testing.T, f *FSFreezerRemoteServerAPI, from, to uint64) {
+	for n := from; n < to; n++ {
+		if err := f.AppendAncient(n, testItem(freezerRemoteHashTable, n), testItem(freezerRemoteHeaderTable, n),
+			testItem(freezerRemoteBodiesTable, n), testItem(freezerRemoteReceiptTable, n), testItem(freezerRemoteDifficultyTable, n)); err != nil {
+			t.Fatalf("append %d: %v", n, err)
+		}
+	}
+}
+
+func checkTestItems(t *testing.T, f *FSFreezerRemoteServerAPI, count uint64) {
+	if n, _ := f.Ancients(); n != count {
+		t.Fatalf("ancients mismatch: have %d, want %d", n, count)
+	}
+	for _, kind := range tableKinds {
+		for n := uint64(0); n < count; n++ {
+			item, err := f.Ancient(kind, n)
+			if err != nil {
+				t.Fatalf("%s %d: %v", kind, n, err)
+			}
+			if !bytes.Equal(item, testItem(kind, n)) {
+				t.Fatalf("%s %d mismatch: have %x, want %x", kind, n, item, testItem(kind, n))
+			}
+		}
+		if ok, _ := f.HasAncient(kind, count); ok {
+			t.Fatalf("%s %d should not exist", kind, count)
+		}
+	}
+}
+
+func TestFSFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ancient-store-fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := NewFSFreezerRemoteServerAPI(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendTestItems(t, f, 0, 100)
+	if err := f.AppendAncient(101, nil, nil, nil, nil, nil); err != errOutOfOrder {
+		t.Fatalf("out of order append error mismatch: have %v, want %v", err, errOutOfOrder)
+	}
+	checkTestItems(t, f, 100)
+
+	items, err := f.AncientRange(freezerRemoteBodiesTable, 90, 20, 1024)
+	if err != nil || len(items) != 10 {
+		t.Fatalf("ancient range: have %d items (%v), want 10", len(items), err)
+	}
+	if items, _ := f.AncientRange(freezerRemoteBodiesTable, 0, 100, 1); len(items) != 1 {
+		t.Fatalf("size limited ancient range: have %d items, want 1", len(items))
+	}
+
+	// Truncate and append, then reopen the store
+	if err := f.TruncateAncients(60); err != nil {
+		t.Fatal(err)
+	}
+	appendTestItems(t, f, 60, 80)
+	if err := f.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	f, err = NewFSFreezerRemoteServerAPI(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestItems(t, f, 80)
+
+	// Simulate a crash in the middle of appending a block: the receipt and difficulty
+	// tables lag behind, and the bodies index has an entry without data.
+	appendTestItems(t, f, 80, 81)
+	for _, kind := range []string{freezerRemoteReceiptTable, freezerRemoteDifficultyTable} {
+		if err := f.tables[kind].truncate(80); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bodies := f.tables[freezerRemoteBodiesTable]
+	if err := bodies.data.Truncate(int64(bodies.size - 1)); err != nil {
+		t.Fatal(err)
+	}
+	f, err = NewFSFreezerRemoteServerAPI(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestItems(t, f, 80)
+	appendTestItems(t, f, 80, 90)
+	checkTestItems(t, f, 90)
+
+	size, err := f.AncientSize(freezerRemoteBodiesTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, _ := os.Stat(filepath.Join(dir, freezerRemoteBodiesTable+".dat"))
+	if size != uint64(fi.Size())+90*indexEntrySize {
+		t.Fatalf("ancient size mismatch: have %d, want %d", size, fi.Size()+90*indexEntrySize)
+	}
+}
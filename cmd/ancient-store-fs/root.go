@@ -0,0 +1,95 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/cmd/ancient-store-fs/lib"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/spf13/cobra"
+)
+
+// rootCmd represents the base command when called without any subcommands
+var rootCmd = &cobra.Command{
+	Use:   "ancient-store-fs <datadir> [ipc-path]",
+	Short: "Filesystem-backed remote ancient store application",
+	Long: `Uses flat append-only files to store ancient data.
+
+Expects a first argument for the directory in which ancient data is stored,
+and an optional second argument for an IPC path, or the directory in which
+a default 'freezer.ipc' path should be created. The IPC path defaults
+to the data directory.
+
+Package 'lib' logic may be imported and used in testing contexts as well.
+`,
+	Args: cobra.RangeArgs(1, 2),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		dataDir := args[0]
+		ipcPath := dataDir
+		if len(args) > 1 {
+			ipcPath = args[1]
+		}
+		fi, err := os.Stat(ipcPath)
+		if err != nil && !os.IsNotExist(err) {
+			log.Fatalln(err)
+		}
+		if fi != nil && fi.IsDir() {
+			ipcPath = filepath.Join(ipcPath, "freezer.ipc")
+		}
+		store, err := lib.NewFSFreezerRemoteServerAPI(dataDir)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		listener, server, err := rpc.StartIPCEndpoint(ipcPath, nil)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer os.Remove(ipcPath)
+		err = server.RegisterName("freezer", store)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		go func() {
+			log.Println("Serving", listener.Addr())
+			log.Fatalln(server.ServeListener(listener))
+		}()
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+		<-sigc
+		log.Println("Shutting down")
+		server.Stop()
+		if err := store.Sync(); err != nil {
+			log.Fatalln(err)
+		}
+	},
+}
+
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
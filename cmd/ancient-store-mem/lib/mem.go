@@ -32,9 +32,14 @@ const (
 	freezerRemoteDifficultyTable = "diffs"
 )
 
+// protocolVersion is the version of the remote freezer protocol implemented by the server,
+// matching rawdb.FreezerRemoteProtocolVersion.
+const protocolVersion = 2
+
 var (
-	errOutOfBounds = errors.New("out of bounds")
-	errOutOfOrder  = errors.New("out of order")
+	errOutOfBounds   = errors.New("out of bounds")
+	errOutOfOrder    = errors.New("out of order")
+	errFieldMismatch = errors.New("field counts mismatch")
 )
 
 // MemFreezerRemoteServerAPI is a mock freezer server implementation.
@@ -117,6 +122,57 @@ func (f *MemFreezerRemoteServerAPI) AppendAncient(number uint64, hash, header, b
 	return nil
 }
 
+func (f *MemFreezerRemoteServerAPI) ProtocolVersion() (uint64, error) {
+	return protocolVersion, nil
+}
+
+func (f *MemFreezerRemoteServerAPI) AppendAncients(number uint64, hashes, headers, bodies, receipts, tds [][]byte) error {
+	if len(headers) != len(hashes) || len(bodies) != len(hashes) || len(receipts) != len(hashes) || len(tds) != len(hashes) {
+		return errFieldMismatch
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if number != f.count {
+		return errOutOfOrder
+	}
+	for i := range hashes {
+		n := number + uint64(i)
+		f.store[f.storeKey(freezerRemoteHashTable, n)] = hashes[i]
+		f.store[f.storeKey(freezerRemoteHeaderTable, n)] = headers[i]
+		f.store[f.storeKey(freezerRemoteBodiesTable, n)] = bodies[i]
+		f.store[f.storeKey(freezerRemoteReceiptTable, n)] = receipts[i]
+		f.store[f.storeKey(freezerRemoteDifficultyTable, n)] = tds[i]
+	}
+	f.count = number + uint64(len(hashes))
+	return nil
+}
+
+// AncientRange returns up to count consecutive items of a kind starting at number,
+// stopping early once maxBytes is exceeded. At least one item is returned.
+func (f *MemFreezerRemoteServerAPI) AncientRange(kind string, number, count, maxBytes uint64) ([][]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if number >= f.count {
+		return nil, errOutOfBounds
+	}
+	var (
+		res  [][]byte
+		size uint64
+	)
+	for n := number; n < number+count && n < f.count; n++ {
+		v, ok := f.store[f.storeKey(kind, n)]
+		if !ok {
+			return nil, errOutOfBounds
+		}
+		if len(res) > 0 && size+uint64(len(v)) > maxBytes {
+			break
+		}
+		res = append(res, v)
+		size += uint64(len(v))
+	}
+	return res, nil
+}
+
 func (f *MemFreezerRemoteServerAPI) TruncateAncients(n uint64) error {
 	// fmt.Println("mock server called", "method=TruncateAncients")
 	f.count = n
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/params/confp"
+	"github.com/ethereum/go-ethereum/params/types/coregeth"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var diffJSONFlag = cli.BoolFlag{
+	Name:  "json",
+	Usage: "Print the report as JSON",
+}
+
+var diffCommand = cli.Command{
+	Name:  "diff",
+	Usage: "Compare two chain configurations",
+	Description: `Reports the chain configuration parameters, genesis fields and accounts which differ
+between two configurations, along with the first block at which their fork ids differ.
+
+Each configuration is either the name of a default configuration, or a <format>:<path>
+pair naming the client format and path of a configuration file.`,
+	ArgsUsage: "<default|format:path> <default|format:path>",
+	Flags:     []cli.Flag{diffJSONFlag},
+	Action:    diff,
+}
+
+var errDiffArgs = errors.New("expected two configurations to compare")
+
+// maxDiffAccounts is the number of differing genesis accounts listed by the text report.
+const maxDiffAccounts = 10
+
+// forkIDDiff is the first block at which two configurations produce a different
+// fork id.
+type forkIDDiff struct {
+	Block uint64     `json:"block"`
+	A     forkIDJSON `json:"a"`
+	B     forkIDJSON `json:"b"`
+}
+
+// forkIDJSON is the JSON representation of a fork id.
+type forkIDJSON struct {
+	Hash hexutil.Bytes `json:"hash"`
+	Next uint64        `json:"next"`
+}
+
+// diffReport is the result of comparing two configurations.
+type diffReport struct {
+	Equivalent bool          `json:"equivalent"`
+	Reason     string        `json:"reason,omitempty"`
+	Diffs      []confp.DiffT `json:"diffs"`
+	ForkID     *forkIDDiff   `json:"forkid"`
+}
+
+func diff(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errDiffArgs
+	}
+	a, err := readChainspecArg(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	b, err := readChainspecArg(ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	report, err := diffChainspecs(a, b)
+	if err != nil {
+		return err
+	}
+	if ctx.Bool(diffJSONFlag.Name) {
+		out, err := jsonMarshalPretty(report)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	fmt.Print(report)
+	return nil
+}
+
+// readChainspecArg reads a configuration given either as the name of a default,
+// or as a <format>:<path> pair.
+func readChainspecArg(arg string) (ctypes.Configurator, error) {
+	if v, ok := defaultChainspecValues[arg]; ok {
+		return v, nil
+	}
+	i := strings.Index(arg, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("error: %v, name: %s", errInvalidDefaultValue, arg)
+	}
+	data, err := ioutil.ReadFile(arg[i+1:])
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalChainSpec(arg[:i], data)
+}
+
+// diffChainspecs compares two configurations.
+func diffChainspecs(a, b ctypes.Configurator) (*diffReport, error) {
+	report := &diffReport{
+		Equivalent: true,
+		Diffs:      confp.Diff(a, b),
+	}
+	if err := confp.Equivalent(a, b); err != nil {
+		report.Equivalent = false
+		report.Reason = err.Error()
+	}
+	genesisA, err := genesisHash(a)
+	if err != nil {
+		return nil, err
+	}
+	genesisB, err := genesisHash(b)
+	if err != nil {
+		return nil, err
+	}
+	// Fork ids only change at fork blocks, so only those need to be checked
+	blocks := append([]uint64{0}, confp.Forks(a)...)
+	blocks = append(blocks, confp.Forks(b)...)
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i] < blocks[j]
+	})
+	for _, n := range blocks {
+		idA, idB := forkid.NewID(a, genesisA, n), forkid.NewID(b, genesisB, n)
+		if idA != idB {
+			report.ForkID = &forkIDDiff{
+				Block: n,
+				A:     forkIDJSON{Hash: idA.Hash[:], Next: idA.Next},
+				B:     forkIDJSON{Hash: idB.Hash[:], Next: idB.Next},
+			}
+			break
+		}
+	}
+	return report, nil
+}
+
+// genesisHash returns the hash of the genesis block of a configuration, or the
+// zero hash if it does not configure the genesis block.
+func genesisHash(c ctypes.Configurator) (common.Hash, error) {
+	if _, ok := c.(ctypes.GenesisBlocker); !ok {
+		return common.Hash{}, nil
+	}
+	genesis, ok := c.(*genesisT.Genesis)
+	if !ok {
+		genesis = &genesisT.Genesis{Config: &coregeth.CoreGethChainConfig{}}
+		if err := confp.Convert(c, genesis); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	return core.GenesisToBlock(genesis, nil).Hash(), nil
+}
+
+// String implements the fmt.Stringer interface, formatting the report as text.
+func (r *diffReport) String() string {
+	var s strings.Builder
+	if r.Equivalent {
+		fmt.Fprintln(&s, "Equivalent: yes")
+	} else {
+		fmt.Fprintf(&s, "Equivalent: no (%s)\n", r.Reason)
+	}
+	if len(r.Diffs) == 0 {
+		fmt.Fprintln(&s, "Differences: none")
+	} else {
+		fmt.Fprintln(&s, "Differences:")
+		accounts := 0
+		for _, d := range r.Diffs {
+			// Genesis allocations can be huge, only list the first few accounts
+			if strings.HasPrefix(d.Field, "Account(") {
+				if accounts++; accounts > maxDiffAccounts {
+					continue
+				}
+			}
+			fmt.Fprintf(&s, "\t%s: %s / %s\n", d.Field, formatDiffValue(d.A), formatDiffValue(d.B))
+		}
+		if accounts > maxDiffAccounts {
+			fmt.Fprintf(&s, "\t... and %d more accounts\n", accounts-maxDiffAccounts)
+		}
+	}
+	if r.ForkID == nil {
+		fmt.Fprintln(&s, "ForkID: same at all forks")
+	} else {
+		fmt.Fprintf(&s, "ForkID: first differs at block %d: %s (next %d) / %s (next %d)\n",
+			r.ForkID.Block, r.ForkID.A.Hash, r.ForkID.A.Next, r.ForkID.B.Hash, r.ForkID.B.Next)
+	}
+	return s.String()
+}
+
+// formatDiffValue formats a compared value, printing unset values as a dash.
+func formatDiffValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case []byte:
+		return hexutil.Encode(v)
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Map, reflect.Ptr:
+		if b, err := json.Marshal(v); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(v)
+}
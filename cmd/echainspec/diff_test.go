@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/types/goethereum"
+)
+
+// forksGenesis returns a genesis activating Homestead, EIP150 and EIP155 at the
+// given blocks, nil meaning never.
+func forksGenesis(homestead, eip150, eip155 *big.Int, extra string) *genesisT.Genesis {
+	return &genesisT.Genesis{
+		Config: &goethereum.ChainConfig{
+			ChainID:        big.NewInt(1),
+			HomesteadBlock: homestead,
+			EIP150Block:    eip150,
+			EIP155Block:    eip155,
+		},
+		ExtraData: []byte(extra),
+	}
+}
+
+func TestDiffChainspecsForkID(t *testing.T) {
+	var (
+		one   = big.NewInt(1)
+		two   = big.NewInt(2)
+		three = big.NewInt(3)
+	)
+	tests := []struct {
+		name  string
+		a, b  *genesisT.Genesis
+		block *uint64 // First block with differing fork ids, nil if none
+	}{
+		{
+			name: "equal",
+			a:    forksGenesis(one, two, three, ""),
+			b:    forksGenesis(one, two, three, ""),
+		},
+		{
+			// The ids first differ when their next fork differs
+			name:  "diverge",
+			a:     forksGenesis(one, two, nil, ""),
+			b:     forksGenesis(one, three, nil, ""),
+			block: newUint64(1),
+		},
+		{
+			// The shorter schedule announces no next fork after its last one
+			name:  "prefix",
+			a:     forksGenesis(one, two, nil, ""),
+			b:     forksGenesis(one, two, three, ""),
+			block: newUint64(2),
+		},
+		{
+			name:  "prefix reversed",
+			a:     forksGenesis(one, two, three, ""),
+			b:     forksGenesis(one, two, nil, ""),
+			block: newUint64(2),
+		},
+		{
+			name:  "genesis",
+			a:     forksGenesis(one, two, three, "a"),
+			b:     forksGenesis(one, two, three, "b"),
+			block: newUint64(0),
+		},
+	}
+	for _, tt := range tests {
+		report, err := diffChainspecs(tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("%s: failed to compare: %v", tt.name, err)
+		}
+		switch {
+		case tt.block == nil && report.ForkID != nil:
+			t.Errorf("%s: unexpected fork id difference at block %d", tt.name, report.ForkID.Block)
+		case tt.block != nil && report.ForkID == nil:
+			t.Errorf("%s: missing fork id difference, want at block %d", tt.name, *tt.block)
+		case tt.block != nil && report.ForkID.Block != *tt.block:
+			t.Errorf("%s: fork id difference block mismatch: have %d, want %d", tt.name, report.ForkID.Block, *tt.block)
+		}
+		if report.ForkID != nil && report.ForkID.A.Hash.String() == report.ForkID.B.Hash.String() && report.ForkID.A.Next == report.ForkID.B.Next {
+			t.Errorf("%s: reported fork ids are equal: %+v", tt.name, report.ForkID)
+		}
+	}
+}
+
+func newUint64(n uint64) *uint64 { return &n }
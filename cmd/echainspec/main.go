@@ -9,6 +9,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/params/confp"
+	"github.com/ethereum/go-ethereum/params/types/besu"
 	"github.com/ethereum/go-ethereum/params/types/coregeth"
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
@@ -32,7 +33,9 @@ var (
 		"geth": &genesisT.Genesis{
 			Config: &goethereum.ChainConfig{},
 		},
-		"parity": &parity.ParityChainSpec{},
+		"parity":       &parity.ParityChainSpec{},
+		"openethereum": &parity.ParityChainSpec{},
+		"besu":         besu.NewBesuGenesis(),
 		// TODO
 		// "aleth"
 		// "retesteth"
@@ -105,6 +108,10 @@ func mustGetChainspecValue(ctx *cli.Context) error {
 		if strings.HasPrefix(ctx.Args().First(), "ls-") {
 			return nil
 		}
+		// The diff command reads its own configurations.
+		if ctx.Args().First() == diffCommand.Name {
+			return nil
+		}
 		if strings.Contains(ctx.Args().First(), "help") {
 			return nil
 		}
@@ -203,10 +210,18 @@ EXAMPLES:
 
 		> {{.Name}} --inputf parity --file my-parity-spec.json --outputf [geth|coregeth]
 
+	Convert an external coregeth chain configuration to a Besu genesis file:
+
+		> {{.Name}} --inputf coregeth --file my-genesis.json --outputf besu
+
 	Print a default Ethereum Classic network chain configuration in coregeth format:
 
 		> {{.Name}} --default classic --outputf coregeth
 
+	Compare a chain configuration file against a default network configuration:
+
+		> {{.Name}} diff classic coregeth:my-classic.json
+
 	Validate a default Kotti network chain configuration for block #3000000:
 
 		> {{.Name}} --default kotti validate 3000000
@@ -234,6 +249,7 @@ GLOBAL OPTIONS:
 		validateCommand,
 		forksCommand,
 		ipsCommand,
+		diffCommand,
 	}
 	app.Before = mustGetChainspecValue
 	app.Action = convertf
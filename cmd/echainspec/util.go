@@ -2,14 +2,13 @@ package main
 
 import (
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"os"
-	"strings"
+	"reflect"
 
+	"github.com/ethereum/go-ethereum/params/types/besu"
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
-	"github.com/ethereum/go-ethereum/params/types/parity"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -20,17 +19,31 @@ func readInputData(ctx *cli.Context) ([]byte, error) {
 	return ioutil.ReadFile(ctx.GlobalString(fileInFlag.Name))
 }
 
-func unmarshalChainSpec(format string, data []byte) (conf ctypes.Configurator, err error) {
-	conf, ok := chainspecFormatTypes[format]
+// newChainspecValue returns a new, empty value of the given chainspec format.
+func newChainspecValue(format string) (ctypes.Configurator, error) {
+	configurator, ok := chainspecFormatTypes[format]
 	if !ok {
 		return nil, errInvalidChainspecValue
 	}
-	err = json.Unmarshal(data, conf)
+	switch t := configurator.(type) {
+	case *genesisT.Genesis:
+		config := reflect.New(reflect.TypeOf(t.Config).Elem()).Interface()
+		return &genesisT.Genesis{Config: config.(ctypes.ChainConfigurator)}, nil
+	case *besu.BesuGenesis:
+		return besu.NewBesuGenesis(), nil
+	}
+	return reflect.New(reflect.TypeOf(configurator).Elem()).Interface().(ctypes.Configurator), nil
+}
+
+func unmarshalChainSpec(format string, data []byte) (conf ctypes.Configurator, err error) {
+	conf, err = newChainspecValue(format)
 	if err != nil {
-		return conf, err
+		return nil, err
 	}
-	if !strings.Contains(format, "geth") {
-		return
+	genesis, ok := conf.(*genesisT.Genesis)
+	if !ok {
+		// Don't need to do anything else here; the other types already conform to ChainConfigurator.
+		return conf, json.Unmarshal(data, conf)
 	}
 	// Logic in params/types/gen_genesis.go already "auto-magically"
 	// handles genesis Config unmarshaling, and IT PREFERS COREGETH,
@@ -40,27 +53,17 @@ func unmarshalChainSpec(format string, data []byte) (conf ctypes.Configurator, e
 	type dec struct {
 		Config ctypes.ChainConfigurator `json:"config"`
 	}
-	var d dec
-	configurator, ok := chainspecFormatTypes[format]
-	if !ok {
-		return nil, fmt.Errorf("unknown chainspec format type: %v", format)
-	}
-	switch t := configurator.(type) {
-	case *genesisT.Genesis:
-		d.Config = t.Config
-	case *parity.ParityChainSpec:
-		// Don't need to do anything here; the Parity type already conforms to ChainConfigurator.
-	default:
-		return nil, fmt.Errorf("unhandled chainspec type: %v %v", format, t)
+	d := dec{Config: genesis.Config}
+	err = json.Unmarshal(data, genesis)
+	if err != nil {
+		return conf, err
 	}
-	t := chainspecFormatTypes[format].(*genesisT.Genesis)
 	err = json.Unmarshal(data, &d)
 	if err != nil {
 		return conf, err
 	}
-	t.Config = d.Config
-	conf = t
-	return
+	genesis.Config = d.Config
+	return conf, nil
 }
 
 func jsonMarshalPretty(i interface{}) ([]byte, error) {
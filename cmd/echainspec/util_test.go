@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/confp"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+)
+
+// TestOpenEthereumRoundTrip tests that the default networks expressible in the
+// OpenEthereum chain spec format (which has no ECBP1100 setting) survive a
+// conversion to it, through JSON, and back.
+func TestOpenEthereumRoundTrip(t *testing.T) {
+	for name, genesis := range map[string]*genesisT.Genesis{
+		"foundation": params.DefaultGenesisBlock(),
+		"ropsten":    params.DefaultRopstenGenesisBlock(),
+		"goerli":     params.DefaultGoerliGenesisBlock(),
+		"kotti":      params.DefaultKottiGenesisBlock(),
+	} {
+		spec, err := newChainspecValue("openethereum")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := confp.Convert(genesis, spec); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		b, err := jsonMarshalPretty(spec)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		read, err := unmarshalChainSpec("openethereum", b)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		back, err := newChainspecValue("coregeth")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := confp.Convert(read, back); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := confp.Equivalent(genesis.Config, back.(*genesisT.Genesis).Config); err != nil {
+			t.Errorf("%s: %v\n%s", name, err, b)
+		}
+	}
+}
@@ -80,8 +80,15 @@ The dumpgenesis command dumps the genesis block configuration in JSON format to
 		ArgsUsage: "<filename> (<filename 2> ... <filename N>) ",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.AncientFlag,
 			utils.AncientRPCFlag,
+			utils.AncientRPCTLSCertFlag,
+			utils.AncientRPCTLSKeyFlag,
+			utils.AncientRPCTLSCAFlag,
+			utils.AncientRPCTokenFlag,
+			utils.AncientRPCJWTSecretFlag,
+			utils.AncientRPCRetryFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
@@ -161,8 +168,15 @@ The export-preimages command export hash preimages to an RLP encoded stream`,
 		ArgsUsage: "<sourceChaindataDir>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.AncientFlag,
 			utils.AncientRPCFlag,
+			utils.AncientRPCTLSCertFlag,
+			utils.AncientRPCTLSKeyFlag,
+			utils.AncientRPCTLSCAFlag,
+			utils.AncientRPCTokenFlag,
+			utils.AncientRPCJWTSecretFlag,
+			utils.AncientRPCRetryFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 			utils.FakePoWFlag,
@@ -220,6 +234,7 @@ Use "ethereum dump 0" to dump the genesis block.`,
 		ArgsUsage: " ",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.AncientFlag,
 			utils.CacheFlag,
 			utils.ClassicFlag,
@@ -485,7 +500,7 @@ func copyDb(ctx *cli.Context) error {
 	dl := downloader.New(0, chainDb, syncBloom, new(event.TypeMux), chain, nil, nil)
 
 	// Create a source peer to satisfy downloader requests from
-	db, err := rawdb.NewLevelDBDatabaseWithFreezer(root, ctx.GlobalInt(utils.CacheFlag.Name)/2, 256, freezer, "")
+	db, err := rawdb.NewPersistentDatabaseWithFreezer("", root, ctx.GlobalInt(utils.CacheFlag.Name)/2, 256, freezer, "")
 	if err != nil {
 		return err
 	}
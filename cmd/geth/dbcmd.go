@@ -0,0 +1,384 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of core-geth.
+//
+// core-geth is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// core-geth is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with core-geth. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// dbFlags are the flags shared by all database subcommands, selecting the
+// database to operate on.
+var dbFlags = []cli.Flag{
+	utils.DataDirFlag,
+	utils.DBEngineFlag,
+	utils.AncientFlag,
+	utils.CacheFlag,
+	utils.CacheDatabaseFlag,
+	utils.SyncModeFlag,
+	utils.ClassicFlag,
+	utils.MordorFlag,
+	utils.KottiFlag,
+	utils.SocialFlag,
+	utils.EthersocialFlag,
+	utils.LegacyTestnetFlag,
+	utils.RopstenFlag,
+	utils.RinkebyFlag,
+	utils.GoerliFlag,
+	utils.YoloV2Flag,
+}
+
+// dbRemoteFlags select a remote freezer instead of the local one.
+var dbRemoteFlags = []cli.Flag{
+	utils.AncientRPCFlag,
+	utils.AncientRPCTLSCertFlag,
+	utils.AncientRPCTLSKeyFlag,
+	utils.AncientRPCTLSCAFlag,
+	utils.AncientRPCTokenFlag,
+	utils.AncientRPCJWTSecretFlag,
+	utils.AncientRPCRetryFlag,
+}
+
+var (
+	dbCommand = cli.Command{
+		Name:      "db",
+		Usage:     "Low level database operations",
+		ArgsUsage: "",
+		Category:  "DATABASE COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:        "inspect",
+				Usage:       "Inspect the storage size for each type of data in the database",
+				ArgsUsage:   " ",
+				Action:      utils.MigrateFlags(inspect),
+				Flags:       dbFlags,
+				Description: `This command iterates the entire database and reports the size of every category of data.`,
+			},
+			{
+				Name:        "stats",
+				Usage:       "Print leveldb statistics",
+				ArgsUsage:   " ",
+				Action:      utils.MigrateFlags(dbStats),
+				Flags:       dbFlags,
+				Description: `This command prints the compaction and I/O statistics of the key-value store.`,
+			},
+			{
+				Name:      "compact",
+				Usage:     "Compact leveldb database. WARNING: May take a very long time",
+				ArgsUsage: "[<start-hex> <limit-hex>]",
+				Action:    utils.MigrateFlags(dbCompact),
+				Flags:     dbFlags,
+				Description: `
+geth db compact [<start-hex> <limit-hex>]
+flattens the key-value store between the given keys, discarding deleted and
+overwritten entries. Without a range, the whole database is compacted one key
+prefix at a time, reporting progress in between.
+
+WARNING: This operation may take a very long time to finish, and may cause
+database corruption if it is aborted during execution.`,
+			},
+			{
+				Name:        "get",
+				Usage:       "Show the value of a database key",
+				ArgsUsage:   "<hex-encoded key>",
+				Action:      utils.MigrateFlags(dbGet),
+				Flags:       dbFlags,
+				Description: `This command looks up the specified database key from the key-value store.`,
+			},
+			{
+				Name:      "put",
+				Usage:     "Set the value of a database key (WARNING: may corrupt your database)",
+				ArgsUsage: "<hex-encoded key> <hex-encoded value>",
+				Action:    utils.MigrateFlags(dbPut),
+				Flags:     dbFlags,
+				Description: `
+This command sets a given database key to the given value.
+WARNING: This is a low-level operation which may cause database corruption!`,
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a database key (WARNING: may corrupt your database)",
+				ArgsUsage: "<hex-encoded key>",
+				Action:    utils.MigrateFlags(dbDelete),
+				Flags:     dbFlags,
+				Description: `
+This command deletes the specified database key from the key-value store.
+WARNING: This is a low-level operation which may cause database corruption!`,
+			},
+			{
+				Name:      "freezer-index",
+				Usage:     "Dump out the index of a given freezer table",
+				ArgsUsage: "<table> [<start> [<end>]]",
+				Action:    utils.MigrateFlags(freezerInspect),
+				Flags:     dbFlags,
+				Description: `
+geth db freezer-index <table> [<start> [<end>]]
+prints the index entries (data file number and end offset) of the items in
+[start, end) of a local freezer table, one of headers, hashes, bodies, receipts
+or diffs. Without a range the whole index is printed.
+
+The node must not be running, the table is repaired if found inconsistent.`,
+			},
+			{
+				Name:      "check-ancients",
+				Usage:     "Validate the freezer against the key-value store",
+				ArgsUsage: " ",
+				Action:    utils.MigrateFlags(checkAncients),
+				Flags:     append(dbFlags, dbRemoteFlags...),
+				Description: `
+geth db check-ancients
+walks every block of the freezer, local or remote (--ancient.rpc), verifying
+that it is present in all the tables, that its hash matches its header and
+links to its parent, that the key-value store maps the hash to the block
+number and that the first block of the key-value store extends the freezer.`,
+			},
+		},
+	}
+)
+
+func dbStats(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	showLeveldbStats(db)
+	return nil
+}
+
+func dbCompact(ctx *cli.Context) error {
+	var ranges [][2][]byte
+	switch ctx.NArg() {
+	case 0:
+		// Compact prefix by prefix, so progress can be reported
+		for i := 0; i < 256; i++ {
+			start, limit := []byte{byte(i)}, []byte{byte(i + 1)}
+			if i == 255 {
+				limit = nil
+			}
+			ranges = append(ranges, [2][]byte{start, limit})
+		}
+	case 2:
+		start, err := parseHexArg(ctx.Args().Get(0))
+		if err != nil {
+			return fmt.Errorf("invalid start key: %v", err)
+		}
+		limit, err := parseHexArg(ctx.Args().Get(1))
+		if err != nil {
+			return fmt.Errorf("invalid limit key: %v", err)
+		}
+		ranges = append(ranges, [2][]byte{start, limit})
+	default:
+		return errors.New("expected either no arguments or a start and limit key")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	log.Info("Stats before compaction")
+	showLeveldbStats(db)
+
+	var (
+		start  = time.Now()
+		logged time.Time
+	)
+	for i, r := range ranges {
+		if err := db.Compact(r[0], r[1]); err != nil {
+			log.Error("Compaction failed", "start", formatKey(r[0]), "limit", formatKey(r[1]), "err", err)
+			return err
+		}
+		if time.Since(logged) > 8*time.Second || i == len(ranges)-1 {
+			log.Info("Compacting database", "done", i+1, "ranges", len(ranges), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	log.Info("Stats after compaction")
+	showLeveldbStats(db)
+	return nil
+}
+
+func dbGet(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("required argument: <hex-encoded key>")
+	}
+	key, err := parseHexArg(ctx.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("invalid key: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	data, err := db.Get(key)
+	if err != nil {
+		log.Info("Get operation failed", "key", fmt.Sprintf("%#x", key), "err", err)
+		return err
+	}
+	fmt.Printf("key %#x: %#x\n", key, data)
+	return nil
+}
+
+func dbPut(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return errors.New("required arguments: <hex-encoded key> <hex-encoded value>")
+	}
+	key, err := parseHexArg(ctx.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("invalid key: %v", err)
+	}
+	value, err := parseHexArg(ctx.Args().Get(1))
+	if err != nil {
+		return fmt.Errorf("invalid value: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	if previous, err := db.Get(key); err == nil {
+		fmt.Printf("Previous value: %#x\n", previous)
+	}
+	if err := db.Put(key, value); err != nil {
+		log.Info("Put operation failed", "key", fmt.Sprintf("%#x", key), "err", err)
+		return err
+	}
+	return nil
+}
+
+func dbDelete(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("required argument: <hex-encoded key>")
+	}
+	key, err := parseHexArg(ctx.Args().Get(0))
+	if err != nil {
+		return fmt.Errorf("invalid key: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	if previous, err := db.Get(key); err == nil {
+		fmt.Printf("Previous value: %#x\n", previous)
+	}
+	if err := db.Delete(key); err != nil {
+		log.Info("Delete operation failed", "key", fmt.Sprintf("%#x", key), "err", err)
+		return err
+	}
+	return nil
+}
+
+func freezerInspect(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 3 {
+		return errors.New("required arguments: <table> [<start> [<end>]]")
+	}
+	var (
+		table = ctx.Args().Get(0)
+		start = int64(0)
+		end   = int64(-1)
+		err   error
+	)
+	if ctx.NArg() > 1 {
+		if start, err = strconv.ParseInt(ctx.Args().Get(1), 10, 64); err != nil {
+			return fmt.Errorf("invalid start item: %v", err)
+		}
+	}
+	if ctx.NArg() > 2 {
+		if end, err = strconv.ParseInt(ctx.Args().Get(2), 10, 64); err != nil {
+			return fmt.Errorf("invalid end item: %v", err)
+		}
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	ancient := ctx.GlobalString(utils.AncientFlag.Name)
+	switch {
+	case ancient == "":
+		ancient = filepath.Join(stack.ResolvePath(dbChainDataName(ctx)), "ancient")
+	case !filepath.IsAbs(ancient):
+		ancient = stack.ResolvePath(ancient)
+	}
+	log.Info("Inspecting freezer table", "ancient", ancient, "table", table, "start", start, "end", end)
+	return rawdb.InspectFreezerTable(ancient, table, start, end)
+}
+
+func checkAncients(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	return rawdb.CheckAncients(db)
+}
+
+// dbChainDataName returns the name of the chain database directory, matching
+// the one opened by utils.MakeChainDatabase.
+func dbChainDataName(ctx *cli.Context) string {
+	if ctx.GlobalString(utils.SyncModeFlag.Name) == "light" {
+		return "lightchaindata"
+	}
+	return "chaindata"
+}
+
+// showLeveldbStats logs the compaction statistics of the key-value store.
+func showLeveldbStats(db ethdb.Stater) {
+	if stats, err := db.Stat("leveldb.stats"); err != nil {
+		log.Warn("Failed to read database stats", "error", err)
+	} else {
+		fmt.Println(stats)
+	}
+	if ioStats, err := db.Stat("leveldb.iostats"); err != nil {
+		log.Warn("Failed to read database iostats", "error", err)
+	} else {
+		fmt.Println(ioStats)
+	}
+}
+
+// parseHexArg decodes a hex encoded command line argument, with or without
+// the 0x prefix.
+func parseHexArg(arg string) ([]byte, error) {
+	arg = strings.TrimPrefix(strings.TrimPrefix(arg, "0x"), "0X")
+	return hex.DecodeString(arg)
+}
+
+// formatKey formats a range bound for logging, nil meaning unbounded.
+func formatKey(key []byte) string {
+	if key == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%#x", key)
+}
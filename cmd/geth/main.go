@@ -41,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/tracing"
 	gopsutil "github.com/shirou/gopsutil/mem"
 	cli "gopkg.in/urfave/cli.v1"
 )
@@ -65,8 +66,15 @@ var (
 		utils.LegacyBootnodesV4Flag,
 		utils.LegacyBootnodesV5Flag,
 		utils.DataDirFlag,
+		utils.DBEngineFlag,
 		utils.AncientFlag,
 		utils.AncientRPCFlag,
+		utils.AncientRPCTLSCertFlag,
+		utils.AncientRPCTLSKeyFlag,
+		utils.AncientRPCTLSCAFlag,
+		utils.AncientRPCTokenFlag,
+		utils.AncientRPCJWTSecretFlag,
+		utils.AncientRPCRetryFlag,
 		utils.KeyStoreDirFlag,
 		utils.ExternalSignerFlag,
 		utils.NoUSBFlag,
@@ -133,6 +141,9 @@ var (
 		utils.LegacyMinerExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerfiyFlag,
+		utils.MinerStratumFlag,
+		utils.MinerStratumDifficultyFlag,
+		utils.MinerStratumShareTimeFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -198,6 +209,13 @@ var (
 		utils.LegacyWSApiFlag,
 		utils.WSAllowedOriginsFlag,
 		utils.LegacyWSAllowedOriginsFlag,
+		utils.RPCJWTSecretFlag,
+		utils.RPCBatchLimitFlag,
+		utils.RPCResponseLimitFlag,
+		utils.RPCRateLimitFlag,
+		utils.RPCRateBurstFlag,
+		utils.RPCConcurrencyLimitFlag,
+		utils.RPCMethodCostsFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
@@ -224,6 +242,13 @@ var (
 		utils.MetricsInfluxDBPasswordFlag,
 		utils.MetricsInfluxDBTagsFlag,
 	}
+
+	tracingFlags = []cli.Flag{
+		utils.TracingEnabledFlag,
+		utils.TracingEndpointFlag,
+		utils.TracingHeadersFlag,
+		utils.TracingSampleRatioFlag,
+	}
 )
 
 func init() {
@@ -243,6 +268,9 @@ func init() {
 		dumpCommand,
 		dumpGenesisCommand,
 		inspectCommand,
+		dbCommand,
+		// See snapshotcmd.go:
+		snapshotCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
@@ -271,11 +299,13 @@ func init() {
 	app.Flags = append(app.Flags, debug.DeprecatedFlags...)
 	app.Flags = append(app.Flags, whisperFlags...)
 	app.Flags = append(app.Flags, metricsFlags...)
+	app.Flags = append(app.Flags, tracingFlags...)
 
 	app.Before = func(ctx *cli.Context) error {
 		return debug.Setup(ctx)
 	}
 	app.After = func(ctx *cli.Context) error {
+		tracing.Stop()
 		debug.Exit()
 		prompt.Stdin.Close() // Resets terminal mode.
 		return nil
@@ -388,6 +418,9 @@ func prepare(ctx *cli.Context) {
 
 	// Start system runtime metrics collection
 	go metrics.CollectProcessMetrics(3 * time.Second)
+
+	// Start tracing span export if enabled
+	utils.SetupTracing(ctx)
 }
 
 // geth is the main entry point into the system if no special subcommand is ran.
@@ -35,8 +35,15 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 		Flags: []cli.Flag{
 			configFileFlag,
 			utils.DataDirFlag,
+			utils.DBEngineFlag,
 			utils.AncientFlag,
 			utils.AncientRPCFlag,
+			utils.AncientRPCTLSCertFlag,
+			utils.AncientRPCTLSKeyFlag,
+			utils.AncientRPCTLSCAFlag,
+			utils.AncientRPCTokenFlag,
+			utils.AncientRPCJWTSecretFlag,
+			utils.AncientRPCRetryFlag,
 			utils.KeyStoreDirFlag,
 			utils.NoUSBFlag,
 			utils.SmartCardDaemonPathFlag,
@@ -151,6 +158,13 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.RPCJWTSecretFlag,
+			utils.RPCBatchLimitFlag,
+			utils.RPCResponseLimitFlag,
+			utils.RPCRateLimitFlag,
+			utils.RPCRateBurstFlag,
+			utils.RPCConcurrencyLimitFlag,
+			utils.RPCMethodCostsFlag,
 			utils.GraphQLEnabledFlag,
 			utils.GraphQLCORSDomainFlag,
 			utils.GraphQLVirtualHostsFlag,
@@ -193,6 +207,9 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerfiyFlag,
+			utils.MinerStratumFlag,
+			utils.MinerStratumDifficultyFlag,
+			utils.MinerStratumShareTimeFlag,
 		},
 	},
 	{
@@ -223,6 +240,10 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 		Name:  "METRICS AND STATS",
 		Flags: metricsFlags,
 	},
+	{
+		Name:  "TRACING",
+		Flags: tracingFlags,
+	},
 	{
 		Name:  "WHISPER (deprecated)",
 		Flags: whisperFlags,
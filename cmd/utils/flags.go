@@ -68,6 +68,8 @@ import (
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
 	"github.com/ethereum/go-ethereum/params/vars"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/tracing"
 	pcsclite "github.com/gballet/go-libpcsclite"
 	cli "gopkg.in/urfave/cli.v1"
 )
@@ -114,6 +116,10 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: DirectoryString(vars.DefaultDataDir()),
 	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Backing database implementation to use ('leveldb' or 'pebble', default = engine of the existing database or leveldb)",
+	}
 	AncientFlag = DirectoryFlag{
 		Name:  "datadir.ancient",
 		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
@@ -123,6 +129,31 @@ var (
 		Usage: "Connect to a remote freezer via RPC. Value must an HTTP(S), WS(S), unix socket, or 'stdio' URL. Incompatible with --datadir.ancient",
 		Value: "",
 	}
+	AncientRPCTLSCertFlag = cli.StringFlag{
+		Name:  "ancient.rpc.tls.cert",
+		Usage: "PEM encoded client certificate presented to the remote freezer",
+	}
+	AncientRPCTLSKeyFlag = cli.StringFlag{
+		Name:  "ancient.rpc.tls.key",
+		Usage: "PEM encoded key of the remote freezer client certificate",
+	}
+	AncientRPCTLSCAFlag = cli.StringFlag{
+		Name:  "ancient.rpc.tls.ca",
+		Usage: "PEM encoded CA certificates verifying the remote freezer (default = system CAs)",
+	}
+	AncientRPCTokenFlag = cli.StringFlag{
+		Name:  "ancient.rpc.token",
+		Usage: "Bearer token authorizing requests to the remote freezer",
+	}
+	AncientRPCJWTSecretFlag = cli.StringFlag{
+		Name:  "ancient.rpc.jwtsecret",
+		Usage: "File containing a hex encoded 32 byte secret to sign HS256 JWT bearer tokens for the remote freezer with",
+	}
+	AncientRPCRetryFlag = cli.DurationFlag{
+		Name:  "ancient.rpc.retry",
+		Usage: "Time to keep retrying requests while the remote freezer is unreachable, 0 to fail immediately",
+		Value: rawdb.DefaultFreezerRemoteConfig.RetryTimeout,
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -230,7 +261,7 @@ var (
 	defaultSyncMode = eth.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "full", "snap" or "light")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
@@ -431,6 +462,12 @@ var (
 		Name:  "cache.noprefetch",
 		Usage: "Disable heuristic state prefetch during block import (less CPU and disk IO, more time waiting for data)",
 	}
+	// State pruning settings
+	BloomFilterSizeFlag = cli.Uint64Flag{
+		Name:  "bloomfilter.size",
+		Usage: "Megabytes of memory allocated to bloom-filter for pruning",
+		Value: 2048,
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -478,6 +515,20 @@ var (
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
 	}
+	MinerStratumFlag = cli.StringFlag{
+		Name:  "miner.stratum",
+		Usage: "Stratum server listening address for remote ethash miners (EthereumStratum/1.0.0 and eth-proxy), e.g. 127.0.0.1:8008",
+	}
+	MinerStratumDifficultyFlag = cli.Uint64Flag{
+		Name:  "miner.stratum.difficulty",
+		Usage: "Initial share difficulty of stratum miners, in hashes",
+		Value: eth.DefaultConfig.Ethash.StratumDifficulty,
+	}
+	MinerStratumShareTimeFlag = cli.DurationFlag{
+		Name:  "miner.stratum.sharetime",
+		Usage: "Share interval stratum miner share difficulties are adjusted for",
+		Value: eth.DefaultConfig.Ethash.StratumShareTime,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -605,6 +656,34 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	RPCJWTSecretFlag = cli.StringFlag{
+		Name:  "rpc.jwtsecret",
+		Usage: "File containing a hex encoded 32 byte secret, requiring HS256 JWT bearer tokens signed with it for HTTP and WS calls",
+	}
+	RPCBatchLimitFlag = cli.IntFlag{
+		Name:  "rpc.batchlimit",
+		Usage: "Maximum number of requests in an HTTP or WS batch (0 = unlimited)",
+	}
+	RPCResponseLimitFlag = cli.IntFlag{
+		Name:  "rpc.responselimit",
+		Usage: "Maximum size in bytes of the results of an HTTP or WS response (0 = unlimited)",
+	}
+	RPCRateLimitFlag = cli.Float64Flag{
+		Name:  "rpc.ratelimit",
+		Usage: "Request cost units an HTTP or WS client may spend per second, clients identified by verified JWT subject or IP (0 = unlimited)",
+	}
+	RPCRateBurstFlag = cli.IntFlag{
+		Name:  "rpc.rateburst",
+		Usage: "Request cost units an HTTP or WS client may spend at once (0 = one second's worth)",
+	}
+	RPCConcurrencyLimitFlag = cli.IntFlag{
+		Name:  "rpc.concurrencylimit",
+		Usage: "Maximum number of calls an HTTP or WS client may run at once (0 = unlimited)",
+	}
+	RPCMethodCostsFlag = cli.StringFlag{
+		Name:  "rpc.methodcosts",
+		Usage: "Comma separated request cost units of methods, other methods cost one unit (e.g. debug_traceBlockByNumber=100,eth_getLogs=20)",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -764,6 +843,26 @@ var (
 		Usage: "Comma-separated InfluxDB tags (key/values) attached to all measurements",
 		Value: "host=localhost",
 	}
+
+	// Tracing settings
+	TracingEnabledFlag = cli.BoolFlag{
+		Name:  "tracing",
+		Usage: "Enable OpenTelemetry tracing span export over OTLP/HTTP",
+	}
+	TracingEndpointFlag = cli.StringFlag{
+		Name:  "tracing.endpoint",
+		Usage: "OTLP/HTTP traces endpoint of the collector",
+		Value: tracing.DefaultConfig.Endpoint,
+	}
+	TracingHeadersFlag = cli.StringFlag{
+		Name:  "tracing.headers",
+		Usage: "Comma-separated HTTP headers (key=values) sent to the collector, e.g. for authorization",
+	}
+	TracingSampleRatioFlag = cli.Float64Flag{
+		Name:  "tracing.sample",
+		Usage: "Fraction of new traces recorded (0-1), traces continued from callers keep their sampling decision",
+		Value: tracing.DefaultConfig.SampleRatio,
+	}
 	EWASMInterpreterFlag = cli.StringFlag{
 		Name:  "vm.ewasm",
 		Usage: "External ewasm configuration (default = built-in interpreter)",
@@ -1011,6 +1110,44 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(HTTPVirtualHostsFlag.Name) {
 		cfg.HTTPVirtualHosts = SplitAndTrim(ctx.GlobalString(HTTPVirtualHostsFlag.Name))
 	}
+
+	if ctx.GlobalIsSet(RPCJWTSecretFlag.Name) {
+		cfg.JWTSecret = ctx.GlobalString(RPCJWTSecretFlag.Name)
+	}
+	setRPCLimits(ctx, &cfg.RPCLimits)
+}
+
+// setRPCLimits applies the RPC server limits from the command line flags.
+func setRPCLimits(ctx *cli.Context, limits *rpc.Limits) {
+	if ctx.GlobalIsSet(RPCBatchLimitFlag.Name) {
+		limits.BatchItems = ctx.GlobalInt(RPCBatchLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCResponseLimitFlag.Name) {
+		limits.ResponseBytes = ctx.GlobalInt(RPCResponseLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCRateLimitFlag.Name) {
+		limits.RequestRate = ctx.GlobalFloat64(RPCRateLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCRateBurstFlag.Name) {
+		limits.RequestBurst = ctx.GlobalInt(RPCRateBurstFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCConcurrencyLimitFlag.Name) {
+		limits.ConcurrentCalls = ctx.GlobalInt(RPCConcurrencyLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCMethodCostsFlag.Name) {
+		limits.MethodCosts = make(map[string]int)
+		for _, entry := range SplitAndTrim(ctx.GlobalString(RPCMethodCostsFlag.Name)) {
+			parts := strings.SplitN(entry, "=", 2)
+			if len(parts) != 2 {
+				Fatalf("Invalid --%s entry %q, want method=cost", RPCMethodCostsFlag.Name, entry)
+			}
+			cost, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil || cost < 0 {
+				Fatalf("Invalid --%s cost of %s: %q", RPCMethodCostsFlag.Name, parts[0], parts[1])
+			}
+			limits.MethodCosts[strings.TrimSpace(parts[0])] = cost
+		}
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1284,6 +1421,14 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
 
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		engine := ctx.GlobalString(DBEngineFlag.Name)
+		if engine != rawdb.DBLeveldb && engine != rawdb.DBPebble {
+			Fatalf("Invalid choice for db.engine '%s', allowed 'leveldb' or 'pebble'", engine)
+		}
+		cfg.DBEngine = engine
+	}
+
 	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
 		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
 	}
@@ -1446,6 +1591,28 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	}
 }
 
+// setFreezerRemote configures the connection to a remote freezer from the command line flags.
+func setFreezerRemote(ctx *cli.Context, cfg *rawdb.FreezerRemoteConfig) {
+	if ctx.GlobalIsSet(AncientRPCTLSCertFlag.Name) {
+		cfg.TLSCert = ctx.GlobalString(AncientRPCTLSCertFlag.Name)
+	}
+	if ctx.GlobalIsSet(AncientRPCTLSKeyFlag.Name) {
+		cfg.TLSKey = ctx.GlobalString(AncientRPCTLSKeyFlag.Name)
+	}
+	if ctx.GlobalIsSet(AncientRPCTLSCAFlag.Name) {
+		cfg.TLSCA = ctx.GlobalString(AncientRPCTLSCAFlag.Name)
+	}
+	if ctx.GlobalIsSet(AncientRPCTokenFlag.Name) {
+		cfg.BearerToken = ctx.GlobalString(AncientRPCTokenFlag.Name)
+	}
+	if ctx.GlobalIsSet(AncientRPCJWTSecretFlag.Name) {
+		cfg.JWTSecret = ctx.GlobalString(AncientRPCJWTSecretFlag.Name)
+	}
+	if ctx.GlobalIsSet(AncientRPCRetryFlag.Name) {
+		cfg.RetryTimeout = ctx.GlobalDuration(AncientRPCRetryFlag.Name)
+	}
+}
+
 func setEthashDatasetDir(ctx *cli.Context, cfg *eth.Config) {
 	switch {
 	case ctx.GlobalIsSet(EthashDatasetDirFlag.Name):
@@ -1506,6 +1673,15 @@ func setEthash(ctx *cli.Context, cfg *eth.Config) {
 	if ctx.GlobalIsSet(EthashDatasetsLockMmapFlag.Name) {
 		cfg.Ethash.DatasetsLockMmap = ctx.GlobalBool(EthashDatasetsLockMmapFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerStratumFlag.Name) {
+		cfg.Ethash.StratumAddr = ctx.GlobalString(MinerStratumFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerStratumDifficultyFlag.Name) {
+		cfg.Ethash.StratumDifficulty = ctx.GlobalUint64(MinerStratumDifficultyFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerStratumShareTimeFlag.Name) {
+		cfg.Ethash.StratumShareTime = ctx.GlobalDuration(MinerStratumShareTimeFlag.Name)
+	}
 }
 
 func setMiner(ctx *cli.Context, cfg *miner.Config) {
@@ -1659,6 +1835,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(AncientRPCFlag.Name) {
 		cfg.DatabaseFreezerRemote = ctx.GlobalString(AncientRPCFlag.Name)
 	}
+	setFreezerRemote(ctx, &cfg.DatabaseFreezerRemoteConfig)
 
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
@@ -1687,7 +1864,8 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheSnapshotFlag.Name) {
 		cfg.SnapshotCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheSnapshotFlag.Name) / 100
 	}
-	if !ctx.GlobalIsSet(SnapshotFlag.Name) {
+	if !ctx.GlobalIsSet(SnapshotFlag.Name) && cfg.SyncMode != downloader.SnapSync {
+		// Snap sync needs snapshots to serve the retrieved state afterwards
 		cfg.TrieCleanCache += cfg.SnapshotCache
 		cfg.SnapshotCache = 0 // Disabled
 	}
@@ -1945,6 +2123,22 @@ func SetupMetrics(ctx *cli.Context) {
 	}
 }
 
+// SetupTracing starts the tracing span exporter if enabled.
+func SetupTracing(ctx *cli.Context) {
+	if !ctx.GlobalBool(TracingEnabledFlag.Name) {
+		return
+	}
+	config := tracing.DefaultConfig
+	config.Endpoint = ctx.GlobalString(TracingEndpointFlag.Name)
+	config.Headers = SplitTagsFlag(ctx.GlobalString(TracingHeadersFlag.Name))
+	config.SampleRatio = ctx.GlobalFloat64(TracingSampleRatioFlag.Name)
+	config.ServiceVersion = params.VersionWithMeta
+
+	if err := tracing.Start(config); err != nil {
+		Fatalf("Failed to start tracing: %v", err)
+	}
+}
+
 func SplitTagsFlag(tagsFlag string) map[string]string {
 	tags := strings.Split(tagsFlag, ",")
 	tagsMap := map[string]string{}
@@ -1977,7 +2171,9 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 		name = "lightchaindata"
 	}
 	if ctx.GlobalIsSet(AncientRPCFlag.Name) {
-		chainDb, err = stack.OpenDatabaseWithFreezerRemote(name, cache, handles, ctx.GlobalString(AncientRPCFlag.Name))
+		config := rawdb.DefaultFreezerRemoteConfig
+		setFreezerRemote(ctx, &config)
+		chainDb, err = stack.OpenDatabaseWithFreezerRemote(name, cache, handles, ctx.GlobalString(AncientRPCFlag.Name), &config)
 	} else {
 		chainDb, err = stack.OpenDatabaseWithFreezer(name, cache, handles, ctx.GlobalString(AncientFlag.Name), "")
 	}
@@ -110,3 +110,15 @@ func (api *API) SubmitHashRate(rate hexutil.Uint64, id common.Hash) bool {
 func (api *API) GetHashrate() uint64 {
 	return uint64(api.ethash.Hashrate())
 }
+
+// GetStratumWorkers returns the share accounting of the workers of the stratum server.
+func (api *API) GetStratumWorkers() ([]StratumWorker, error) {
+	api.ethash.lock.Lock()
+	server := api.ethash.stratum
+	api.ethash.lock.Unlock()
+
+	if server == nil {
+		return nil, errors.New("stratum server not running")
+	}
+	return server.stats(), nil
+}
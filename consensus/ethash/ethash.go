@@ -51,7 +51,7 @@ var (
 	two256 = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), big.NewInt(0))
 
 	// sharedEthash is a full instance that can be shared between multiple users.
-	sharedEthash = New(Config{"", 3, 0, false, "", 1, 0, false, ModeNormal, nil, nil}, nil, false)
+	sharedEthash = New(Config{"", 3, 0, false, "", 1, 0, false, ModeNormal, "", 0, 0, nil, nil}, nil, false)
 
 	// algorithmRevision is the data structure version used for file naming.
 	algorithmRevision = 23
@@ -533,6 +533,11 @@ type Config struct {
 	DatasetsLockMmap bool
 	PowMode          Mode
 
+	// Stratum server, see StartStratum
+	StratumAddr       string        `toml:",omitempty"` // TCP listen address, the server is disabled if empty
+	StratumDifficulty uint64        `toml:",omitempty"` // Initial share difficulty in hashes, adjusted per connection
+	StratumShareTime  time.Duration `toml:",omitempty"` // Share interval the share difficulties are adjusted for
+
 	Log log.Logger `toml:"-"`
 	// ECIP-1099
 	ECIP1099Block *uint64 `toml:"-"`
@@ -552,6 +557,7 @@ type Ethash struct {
 	update   chan struct{} // Notification channel to update mining parameters
 	hashrate metrics.Meter // Meter tracking the average hashrate
 	remote   *remoteSealer
+	stratum  *stratumServer
 
 	// The fields below are hooks for testing
 	shared    *Ethash       // Shared PoW verifier to avoid cache regeneration
@@ -679,12 +685,35 @@ func (ethash *Ethash) Close() error {
 		if ethash.remote == nil {
 			return
 		}
+		ethash.lock.Lock()
+		if ethash.stratum != nil {
+			ethash.stratum.close()
+		}
+		ethash.lock.Unlock()
+
 		close(ethash.remote.requestExit)
 		<-ethash.remote.exitCh
 	})
 	return err
 }
 
+// StartStratum starts a stratum mining server on the configured address, feeding
+// the remote sealer's work to the connected miners.
+func (ethash *Ethash) StartStratum() error {
+	ethash.lock.Lock()
+	defer ethash.lock.Unlock()
+
+	if ethash.stratum != nil {
+		return errors.New("stratum server already running")
+	}
+	server, err := startStratumServer(ethash, ethash.config.StratumAddr, ethash.config.StratumDifficulty, ethash.config.StratumShareTime)
+	if err != nil {
+		return err
+	}
+	ethash.stratum = server
+	return nil
+}
+
 // cache tries to retrieve a verification cache for the specified block number
 // by first checking against a list of in-memory caches, then against caches
 // stored on disk, and finally generating one if none can be found.
@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
 	exprand "golang.org/x/exp/rand"
 	"gonum.org/v1/gonum/stat/distuv"
 )
@@ -253,6 +254,8 @@ type remoteSealer struct {
 	submitWorkCh chan *mineResult // Channel used for remote sealer to submit their mining result
 	fetchRateCh  chan chan uint64 // Channel used to gather submitted hash rate for local or remote sealer.
 	submitRateCh chan *hashrate   // Channel used for remote sealer to submit their mining hashrate
+	workFeed     event.Feed       // Feed of the work packages created for remote miners
+	workPending  chan [4]string   // Latest work package not yet sent to the feed
 	requestExit  chan struct{}
 	exitCh       chan struct{}
 }
@@ -302,13 +305,38 @@ func startRemoteSealer(ethash *Ethash, urls []string, noverify bool) *remoteSeal
 		submitWorkCh: make(chan *mineResult),
 		fetchRateCh:  make(chan chan uint64),
 		submitRateCh: make(chan *hashrate),
+		workPending:  make(chan [4]string, 1),
 		requestExit:  make(chan struct{}),
 		exitCh:       make(chan struct{}),
 	}
 	go s.loop()
+	go s.feedLoop()
 	return s
 }
 
+// feedLoop sends the work packages to the feed subscribers. It runs apart from
+// the main loop so that slow subscribers can't hold up the remote sealer.
+func (s *remoteSealer) feedLoop() {
+	for {
+		select {
+		case work := <-s.workPending:
+			s.workFeed.Send(work)
+		case <-s.exitCh:
+			return
+		}
+	}
+}
+
+// sendWork queues a work package for the feed subscribers, replacing the
+// previous one if they didn't pick it up yet.
+func (s *remoteSealer) sendWork(work [4]string) {
+	select {
+	case <-s.workPending:
+	default:
+	}
+	s.workPending <- work
+}
+
 func (s *remoteSealer) loop() {
 	defer func() {
 		s.ethash.config.Log.Trace("Ethash remote sealer is exiting")
@@ -328,6 +356,7 @@ func (s *remoteSealer) loop() {
 			s.results = work.results
 			s.makeWork(work.block)
 			s.notifyWork()
+			s.sendWork(s.currentWork)
 
 		case work := <-s.fetchWorkCh:
 			// Return current mining work to remote miner.
@@ -222,3 +222,31 @@ func TestStaleSubmission(t *testing.T) {
 		}
 	}
 }
+
+// Tests that work feed subscribers not keeping up don't block the remote sealer.
+func TestRemoteSlowWorkSubscriber(t *testing.T) {
+	ethash := NewTester(nil, true)
+	defer ethash.Close()
+
+	// Subscribe to the work packages without ever reading them
+	sub := ethash.remote.workFeed.Subscribe(make(chan [4]string))
+	defer sub.Unsubscribe()
+
+	results := make(chan *types.Block, 1)
+	var block *types.Block
+	for i := 1; i <= 3; i++ {
+		block = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(100)})
+		select {
+		case ethash.remote.workCh <- &sealTask{block: block, results: results}:
+		case <-time.After(time.Second):
+			t.Fatalf("work %d not accepted by the remote sealer", i)
+		}
+	}
+	work, err := (&API{ethash}).GetWork()
+	if err != nil {
+		t.Fatalf("failed to get work: %v", err)
+	}
+	if work[0] != ethash.SealHash(block.Header()).Hex() {
+		t.Fatalf("work mismatch: have %s, want %s", work[0], ethash.SealHash(block.Header()).Hex())
+	}
+}
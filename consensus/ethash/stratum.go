@@ -0,0 +1,971 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"bufio"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// The stratum server lets miners connect to the node over TCP instead of polling
+// eth_getWork. It is fed by the remote sealer's work packages, and supports two
+// stratum dialects, chosen by the first request of a connection:
+//
+//   - EthereumStratum/1.0.0 (mining.subscribe, mining.authorize, mining.submit),
+//     where every connection is assigned an extranonce, a nonce prefix
+//     partitioning the nonce space between the miners.
+//   - eth-proxy (eth_submitLogin, eth_getWork, eth_submitWork, eth_submitHashrate),
+//     which is JSON-RPC getWork mining with work pushed on new jobs.
+//
+// Miners submit shares at a per-connection share difficulty, which is adjusted
+// (vardiff) for every connection to submit a share about every share interval.
+// Shares meeting the block difficulty are submitted to the remote sealer.
+
+const (
+	stratumProtocol        = "EthereumStratum/1.0.0"
+	stratumExtranonceBytes = 2                // Length of the nonce prefix assigned to EthereumStratum connections
+	stratumMaxRequestSize  = 16 * 1024        // Maximum length of a request line
+	stratumIdleTimeout     = 10 * time.Minute // Connections without requests for this long are dropped
+	stratumWriteTimeout    = 10 * time.Second // Timeout of writes to a connection
+	stratumHashrateWindow  = 10 * time.Minute // Window over which worker hashrates are estimated from shares
+	stratumReportInterval  = 5 * time.Second  // Interval of worker hashrate reports to the remote sealer
+	stratumRetargetShares  = 6                // Number of share intervals between share difficulty adjustments
+	stratumMinDifficulty   = 1 << 20          // Minimum share difficulty set by vardiff
+	stratumMaxRetarget     = 4                // Maximum factor of a single share difficulty adjustment
+)
+
+// stratumDifficultyOne is the share difficulty, in hashes, of an EthereumStratum
+// difficulty of 1.
+var stratumDifficultyOne = new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 32))
+
+var (
+	stratumConnGauge     = metrics.NewRegisteredGauge("ethash/stratum/connections", nil)
+	stratumAcceptedMeter = metrics.NewRegisteredMeter("ethash/stratum/shares/accepted", nil)
+	stratumRejectedMeter = metrics.NewRegisteredMeter("ethash/stratum/shares/rejected", nil)
+	stratumStaleMeter    = metrics.NewRegisteredMeter("ethash/stratum/shares/stale", nil)
+	stratumBlockMeter    = metrics.NewRegisteredMeter("ethash/stratum/blocks", nil)
+)
+
+// Stratum protocol dialects.
+const (
+	stratumDialectUnknown = iota
+	stratumDialectEthereumStratum
+	stratumDialectEthProxy
+)
+
+// stratumError is an error reported to stratum miners, using the error codes
+// of the stratum protocol.
+type stratumError struct {
+	code    int
+	message string
+}
+
+func (e *stratumError) Error() string { return e.message }
+
+var (
+	errStratumOther         = &stratumError{20, "Other/Unknown"}
+	errStratumJobNotFound   = &stratumError{21, "Job not found"}
+	errStratumDuplicate     = &stratumError{22, "Duplicate share"}
+	errStratumLowDifficulty = &stratumError{23, "Low difficulty share"}
+	errStratumUnauthorized  = &stratumError{24, "Unauthorized worker"}
+	errStratumNotSubscribed = &stratumError{25, "Not subscribed"}
+	errStratumInvalidParams = &stratumError{20, "Invalid parameters"}
+	errStratumUnknownMethod = &stratumError{20, "Method not found"}
+	errStratumDialect       = &stratumError{20, "Stratum dialect mismatch"}
+	errStratumProtocol      = &stratumError{20, "Unsupported protocol, want " + stratumProtocol}
+	errStratumNoWork        = &stratumError{20, "No mining work available"}
+	errStratumServerFull    = &stratumError{20, "No extranonce available"}
+	errStratumInvalidMix    = &stratumError{20, "Invalid mix digest"}
+)
+
+// stratumRequest is a request sent by a stratum miner.
+type stratumRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Worker string          `json:"worker"` // eth-proxy worker name
+}
+
+// stratumResponse is the response to a stratum request. The error field is
+// always present, as required by EthereumStratum.
+type stratumResponse struct {
+	ID      json.RawMessage `json:"id"`
+	Version string          `json:"jsonrpc,omitempty"`
+	Result  interface{}     `json:"result"`
+	Error   interface{}     `json:"error"`
+}
+
+// stratumNotification is an EthereumStratum server to client notification.
+type stratumNotification struct {
+	ID     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// stratumJob is a work package offered to stratum miners.
+type stratumJob struct {
+	sealhash   common.Hash
+	seedhash   common.Hash
+	number     uint64
+	target     *big.Int            // Block target, 2^256/difficulty
+	difficulty *big.Int            // Block difficulty
+	nonces     map[uint64]struct{} // Nonces submitted for the job, to reject duplicate shares
+}
+
+// StratumWorker contains the share accounting of a stratum worker.
+type StratumWorker struct {
+	Name             string `json:"name"`
+	Connections      int    `json:"connections"`
+	Accepted         uint64 `json:"accepted"`
+	Rejected         uint64 `json:"rejected"`
+	Stale            uint64 `json:"stale"`
+	Blocks           uint64 `json:"blocks"`
+	Hashrate         uint64 `json:"hashrate"`         // Estimated from the accepted shares
+	ReportedHashrate uint64 `json:"reportedHashrate"` // Reported by the miner, if any
+	LastShare        int64  `json:"lastShare"`        // Unix time of the last accepted share
+}
+
+// stratumShare is an accepted share, for hashrate estimation.
+type stratumShare struct {
+	time       time.Time
+	difficulty uint64
+}
+
+// stratumWorker tracks the shares of the connections of a worker.
+type stratumWorker struct {
+	name     string
+	id       common.Hash // Identifier of the hashrate reported to the remote sealer
+	created  time.Time
+	conns    int
+	shares   []stratumShare // Accepted shares within the hashrate window
+	reported uint64         // Hashrate reported by the miner
+	seen     time.Time      // Time of the last hashrate report
+
+	accepted, rejected, stale, blocks uint64
+}
+
+// hashrate estimates the hashrate of the worker from its accepted shares,
+// dropping the shares which fell out of the estimation window.
+func (w *stratumWorker) hashrate(now time.Time) uint64 {
+	for len(w.shares) > 0 && now.Sub(w.shares[0].time) > stratumHashrateWindow {
+		w.shares = w.shares[1:]
+	}
+	window := stratumHashrateWindow
+	if age := now.Sub(w.created); age < window {
+		window = age
+	}
+	if window < time.Second {
+		return 0
+	}
+	var hashes float64
+	for _, share := range w.shares {
+		hashes += float64(share.difficulty)
+	}
+	return uint64(hashes / window.Seconds())
+}
+
+// stratumConn is a miner connection.
+type stratumConn struct {
+	conn  net.Conn
+	wlock sync.Mutex // Serializes writes to the connection
+
+	// Fields below are protected by the server lock
+	dialect       int
+	subscribed    bool
+	extranonce    uint64 // Nonce prefix, EthereumStratum only
+	hasExtranonce bool
+	worker        *stratumWorker // Set once authorized
+	difficulty    uint64         // Share difficulty, in hashes
+	previous      uint64         // Share difficulty before the last adjustment, accepted for jobs sent before it
+	retargeted    time.Time      // Time of the last share difficulty adjustment
+	shares        int            // Accepted shares since the last share difficulty adjustment
+	retarget      bool           // Whether the miner is to be sent an adjusted share difficulty
+}
+
+// stratumServer is a stratum mining server fed by the remote sealer.
+type stratumServer struct {
+	ethash     *Ethash
+	api        *API
+	listener   net.Listener
+	difficulty uint64        // Initial share difficulty, in hashes
+	shareTime  time.Duration // Share interval the share difficulties are adjusted for
+
+	lock        sync.Mutex
+	conns       map[*stratumConn]struct{}
+	workers     map[string]*stratumWorker
+	jobs        map[common.Hash]*stratumJob
+	current     *stratumJob
+	extranonces map[uint64]struct{} // Extranonces assigned to connections
+	extranonce  uint64              // Next extranonce to try assigning
+
+	workCh  chan [4]string
+	workSub event.Subscription
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// startStratumServer starts a stratum server listening on addr.
+func startStratumServer(ethash *Ethash, addr string, difficulty uint64, shareTime time.Duration) (*stratumServer, error) {
+	if ethash.remote == nil {
+		return nil, errors.New("stratum requires the remote sealer")
+	}
+	if difficulty == 0 || shareTime <= 0 {
+		return nil, errors.New("invalid stratum share difficulty or interval")
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &stratumServer{
+		ethash:      ethash,
+		api:         &API{ethash},
+		listener:    listener,
+		difficulty:  difficulty,
+		shareTime:   shareTime,
+		conns:       make(map[*stratumConn]struct{}),
+		workers:     make(map[string]*stratumWorker),
+		jobs:        make(map[common.Hash]*stratumJob),
+		extranonces: make(map[uint64]struct{}),
+		workCh:      make(chan [4]string, 16),
+		quit:        make(chan struct{}),
+	}
+	s.workSub = ethash.remote.workFeed.Subscribe(s.workCh)
+
+	// Pick up the work created before the server was started
+	if work, err := s.api.GetWork(); err == nil {
+		s.newJob(work)
+	}
+	s.wg.Add(3)
+	go s.acceptLoop()
+	go s.loop()
+	go s.reportLoop()
+
+	ethash.config.Log.Info("Stratum server started", "addr", listener.Addr(), "difficulty", difficulty, "sharetime", shareTime)
+	return s, nil
+}
+
+// close stops the server and drops all connections.
+func (s *stratumServer) close() {
+	close(s.quit)
+	s.workSub.Unsubscribe()
+	s.listener.Close()
+
+	s.lock.Lock()
+	for c := range s.conns {
+		c.conn.Close()
+	}
+	s.lock.Unlock()
+	s.wg.Wait()
+}
+
+// acceptLoop accepts miner connections.
+func (s *stratumServer) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+				return
+			default:
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			s.ethash.config.Log.Error("Stratum listener failed", "err", err)
+			return
+		}
+		c := &stratumConn{conn: conn, difficulty: s.difficulty, previous: s.difficulty, retargeted: time.Now()}
+
+		s.lock.Lock()
+		s.conns[c] = struct{}{}
+		s.lock.Unlock()
+		stratumConnGauge.Inc(1)
+
+		s.wg.Add(1)
+		go s.serve(c)
+	}
+}
+
+// loop distributes new work to the miners and adjusts their share difficulties.
+func (s *stratumServer) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.shareTime)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case work := <-s.workCh:
+			clean := s.newJob(work)
+			s.broadcast(clean)
+
+		case <-ticker.C:
+			now := time.Now()
+			var retargeted []*stratumConn
+
+			s.lock.Lock()
+			for c := range s.conns {
+				if c.worker != nil && now.Sub(c.retargeted) >= stratumRetargetShares*s.shareTime && s.retarget(c, now) {
+					retargeted = append(retargeted, c)
+				}
+			}
+			// Forget the disconnected workers which stopped submitting shares
+			for name, w := range s.workers {
+				if w.conns == 0 && w.hashrate(now) == 0 {
+					delete(s.workers, name)
+				}
+			}
+			s.lock.Unlock()
+
+			for _, c := range retargeted {
+				s.sendDifficulty(c, false)
+			}
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// reportLoop reports the hashrates of the workers to the remote sealer, so they
+// are included in the hashrate of the node.
+func (s *stratumServer) reportLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(stratumReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			rates := make(map[common.Hash]uint64)
+
+			s.lock.Lock()
+			for _, w := range s.workers {
+				rate := w.hashrate(now)
+				if w.reported > 0 && now.Sub(w.seen) < stratumHashrateWindow {
+					rate = w.reported
+				}
+				if w.conns > 0 && rate > 0 {
+					rates[w.id] = rate
+				}
+			}
+			s.lock.Unlock()
+
+			for id, rate := range rates {
+				s.api.SubmitHashRate(hexutil.Uint64(rate), id)
+			}
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// newJob makes a job of a remote sealer work package, returning whether it
+// invalidates the previous jobs.
+func (s *stratumServer) newJob(work [4]string) bool {
+	number, err := hexutil.DecodeUint64(work[3])
+	if err != nil {
+		s.ethash.config.Log.Error("Invalid stratum work number", "number", work[3], "err", err)
+		return false
+	}
+	job := &stratumJob{
+		sealhash: common.HexToHash(work[0]),
+		seedhash: common.HexToHash(work[1]),
+		number:   number,
+		target:   new(big.Int).SetBytes(common.FromHex(work[2])),
+		nonces:   make(map[uint64]struct{}),
+	}
+	if job.target.Sign() == 0 {
+		job.target.SetInt64(1)
+	}
+	job.difficulty = new(big.Int).Div(two256, job.target)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	clean := s.current == nil || s.current.number != job.number
+	if existing, ok := s.jobs[job.sealhash]; ok {
+		job = existing
+	}
+	s.jobs[job.sealhash] = job
+	s.current = job
+
+	// Drop the jobs too old for their solutions to be accepted
+	for hash, old := range s.jobs {
+		if old.number+staleThreshold <= job.number {
+			delete(s.jobs, hash)
+		}
+	}
+	return clean
+}
+
+// broadcast sends the current job to all miners.
+func (s *stratumServer) broadcast(clean bool) {
+	s.lock.Lock()
+	conns := make([]*stratumConn, 0, len(s.conns))
+	for c := range s.conns {
+		if c.worker != nil {
+			conns = append(conns, c)
+		}
+	}
+	s.lock.Unlock()
+
+	for _, c := range conns {
+		s.sendJob(c, clean)
+	}
+}
+
+// shareDifficulty returns the share difficulty of a connection for a job, which
+// is never above the block difficulty.
+func (s *stratumServer) shareDifficulty(c *stratumConn, job *stratumJob) *big.Int {
+	difficulty := new(big.Int).SetUint64(c.difficulty)
+	if job != nil && difficulty.Cmp(job.difficulty) > 0 {
+		difficulty.Set(job.difficulty)
+	}
+	return difficulty
+}
+
+// sendJob sends the current job to a miner.
+func (s *stratumServer) sendJob(c *stratumConn, clean bool) {
+	s.lock.Lock()
+	job, dialect := s.current, c.dialect
+	var target *big.Int
+	if job != nil {
+		target = new(big.Int).Div(two256, s.shareDifficulty(c, job))
+	}
+	s.lock.Unlock()
+
+	if job == nil {
+		return
+	}
+	switch dialect {
+	case stratumDialectEthereumStratum:
+		s.write(c, &stratumNotification{
+			Method: "mining.notify",
+			Params: []interface{}{
+				hex.EncodeToString(job.sealhash[:]),
+				hex.EncodeToString(job.seedhash[:]),
+				hex.EncodeToString(job.sealhash[:]),
+				clean,
+			},
+		})
+	case stratumDialectEthProxy:
+		s.write(c, &stratumResponse{
+			ID:      json.RawMessage("0"),
+			Version: "2.0",
+			Result:  ethProxyWork(job, target),
+		})
+	}
+}
+
+// sendDifficulty informs a miner of its share difficulty, followed by the
+// current job, as the difficulty only applies to the jobs sent after it.
+func (s *stratumServer) sendDifficulty(c *stratumConn, clean bool) {
+	s.lock.Lock()
+	dialect, difficulty := c.dialect, s.shareDifficulty(c, s.current)
+	s.lock.Unlock()
+
+	if dialect == stratumDialectEthereumStratum {
+		value, _ := new(big.Float).Quo(new(big.Float).SetInt(difficulty), stratumDifficultyOne).Float64()
+		s.write(c, &stratumNotification{Method: "mining.set_difficulty", Params: []interface{}{value}})
+	}
+	s.sendJob(c, clean)
+}
+
+// ethProxyWork returns the eth-proxy work package of a job.
+func ethProxyWork(job *stratumJob, target *big.Int) [4]string {
+	return [4]string{
+		job.sealhash.Hex(),
+		job.seedhash.Hex(),
+		common.BytesToHash(target.Bytes()).Hex(),
+		hexutil.EncodeUint64(job.number),
+	}
+}
+
+// retarget adjusts the share difficulty of a connection towards a share per
+// share interval, returning whether it changed. The server lock must be held.
+func (s *stratumServer) retarget(c *stratumConn, now time.Time) bool {
+	elapsed := now.Sub(c.retargeted)
+	if elapsed <= 0 {
+		return false
+	}
+	current := float64(c.difficulty)
+	next := current / stratumMaxRetarget
+	if c.shares > 0 {
+		// Scale the difficulty by the ratio of the wanted and the observed share intervals
+		next = current * float64(s.shareTime) * float64(c.shares) / float64(elapsed)
+		if next > current*stratumMaxRetarget {
+			next = current * stratumMaxRetarget
+		}
+		if next < current/stratumMaxRetarget {
+			next = current / stratumMaxRetarget
+		}
+	}
+	if next < stratumMinDifficulty {
+		next = stratumMinDifficulty
+	}
+	if s.current != nil && s.current.difficulty.IsUint64() && next > float64(s.current.difficulty.Uint64()) {
+		next = float64(s.current.difficulty.Uint64())
+	}
+	c.retargeted, c.shares = now, 0
+
+	// Avoid bothering the miner with insignificant changes
+	if next > current*0.9 && next < current*1.1 {
+		return false
+	}
+	c.previous, c.difficulty = c.difficulty, uint64(next)
+	return true
+}
+
+// serve reads and handles the requests of a miner connection.
+func (s *stratumServer) serve(c *stratumConn) {
+	defer s.wg.Done()
+	defer s.drop(c)
+
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 0, 4096), stratumMaxRequestSize)
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(stratumIdleTimeout))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				s.ethash.config.Log.Debug("Stratum connection failed", "remote", c.conn.RemoteAddr(), "err", err)
+			}
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req stratumRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			s.ethash.config.Log.Debug("Invalid stratum request", "remote", c.conn.RemoteAddr(), "err", err)
+			return
+		}
+		result, err := s.handle(c, &req)
+
+		s.lock.Lock()
+		dialect, retarget := c.dialect, c.retarget
+		c.retarget = false
+		s.lock.Unlock()
+
+		res := &stratumResponse{ID: req.ID, Result: result}
+		if res.ID == nil {
+			res.ID = json.RawMessage("null")
+		}
+		if err != nil {
+			res.Result = nil
+			if dialect == stratumDialectEthProxy {
+				res.Result = false
+			}
+			res.Error = stratumErrorValue(dialect, err)
+		}
+		if dialect == stratumDialectEthProxy {
+			res.Version = "2.0"
+		}
+		if !s.write(c, res) {
+			return
+		}
+		// Miners are sent their first job once authorized
+		switch {
+		case err == nil && req.Method == "mining.authorize":
+			s.sendDifficulty(c, true)
+		case err == nil && req.Method == "eth_submitLogin":
+			s.sendJob(c, true)
+		case retarget:
+			s.sendDifficulty(c, false)
+		}
+	}
+}
+
+// stratumErrorValue encodes an error in the format of a stratum dialect.
+func stratumErrorValue(dialect int, err error) interface{} {
+	serr, ok := err.(*stratumError)
+	if !ok {
+		serr = &stratumError{errStratumOther.code, err.Error()}
+	}
+	if dialect == stratumDialectEthProxy {
+		return map[string]interface{}{"code": serr.code, "message": serr.message}
+	}
+	return []interface{}{serr.code, serr.message, nil}
+}
+
+// drop releases the resources of a closed connection.
+func (s *stratumServer) drop(c *stratumConn) {
+	c.conn.Close()
+
+	s.lock.Lock()
+	delete(s.conns, c)
+	if c.hasExtranonce {
+		delete(s.extranonces, c.extranonce)
+	}
+	if c.worker != nil {
+		c.worker.conns--
+	}
+	s.lock.Unlock()
+	stratumConnGauge.Dec(1)
+}
+
+// write sends a message to a miner, closing the connection if it fails.
+func (s *stratumServer) write(c *stratumConn, msg interface{}) bool {
+	blob, err := json.Marshal(msg)
+	if err != nil {
+		s.ethash.config.Log.Error("Failed to encode stratum message", "err", err)
+		return false
+	}
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+	if _, err := c.conn.Write(append(blob, '\n')); err != nil {
+		s.ethash.config.Log.Debug("Stratum write failed", "remote", c.conn.RemoteAddr(), "err", err)
+		c.conn.Close()
+		return false
+	}
+	return true
+}
+
+// stratumParams decodes the positional string parameters of a request.
+// Parameters which are not strings are returned empty.
+func stratumParams(raw json.RawMessage) ([]string, error) {
+	var params []interface{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, errStratumInvalidParams
+		}
+	}
+	strs := make([]string, len(params))
+	for i, param := range params {
+		strs[i], _ = param.(string)
+	}
+	return strs, nil
+}
+
+// handle handles a request, returning its result.
+func (s *stratumServer) handle(c *stratumConn, req *stratumRequest) (interface{}, error) {
+	dialect := stratumDialectEthProxy
+	if strings.HasPrefix(req.Method, "mining.") {
+		dialect = stratumDialectEthereumStratum
+	}
+	s.lock.Lock()
+	if c.dialect == stratumDialectUnknown {
+		c.dialect = dialect
+	}
+	mismatch := c.dialect != dialect
+	s.lock.Unlock()
+
+	if mismatch {
+		return nil, errStratumDialect
+	}
+	params, err := stratumParams(req.Params)
+	if err != nil {
+		return nil, err
+	}
+	switch req.Method {
+	case "mining.subscribe":
+		return s.subscribe(c, params)
+
+	case "mining.extranonce.subscribe":
+		// Extranonces are assigned for the lifetime of connections
+		return true, nil
+
+	case "mining.authorize":
+		if len(params) < 1 || params[0] == "" {
+			return nil, errStratumInvalidParams
+		}
+		s.lock.Lock()
+		subscribed := c.subscribed
+		s.lock.Unlock()
+		if !subscribed {
+			return nil, errStratumNotSubscribed
+		}
+		s.authorize(c, params[0])
+		return true, nil
+
+	case "mining.submit":
+		if len(params) < 3 {
+			return nil, errStratumInvalidParams
+		}
+		sealhash, err := hexutil.Decode("0x" + strings.TrimPrefix(params[1], "0x"))
+		if err != nil || len(sealhash) != common.HashLength {
+			return nil, errStratumJobNotFound
+		}
+		s.lock.Lock()
+		extranonce := c.extranonce
+		s.lock.Unlock()
+
+		suffix := strings.TrimPrefix(params[2], "0x")
+		if len(suffix) != 2*(8-stratumExtranonceBytes) {
+			return nil, errStratumInvalidParams
+		}
+		nonce, err := strconv.ParseUint(suffix, 16, 64)
+		if err != nil {
+			return nil, errStratumInvalidParams
+		}
+		nonce |= extranonce << (64 - 8*stratumExtranonceBytes)
+		if err := s.submit(c, common.BytesToHash(sealhash), nonce, nil); err != nil {
+			return nil, err
+		}
+		return true, nil
+
+	case "eth_submitLogin":
+		if len(params) < 1 || params[0] == "" {
+			return nil, errStratumInvalidParams
+		}
+		name := params[0]
+		if req.Worker != "" {
+			name += "." + req.Worker
+		}
+		s.authorize(c, name)
+		return true, nil
+
+	case "eth_getWork":
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if c.worker == nil {
+			return nil, errStratumUnauthorized
+		}
+		if s.current == nil {
+			return nil, errStratumNoWork
+		}
+		return ethProxyWork(s.current, new(big.Int).Div(two256, s.shareDifficulty(c, s.current))), nil
+
+	case "eth_submitWork":
+		if len(params) < 3 {
+			return nil, errStratumInvalidParams
+		}
+		var (
+			nonce types.BlockNonce
+			hash  common.Hash
+			mix   common.Hash
+		)
+		if nonce.UnmarshalText([]byte(params[0])) != nil || hash.UnmarshalText([]byte(params[1])) != nil || mix.UnmarshalText([]byte(params[2])) != nil {
+			return nil, errStratumInvalidParams
+		}
+		if err := s.submit(c, hash, nonce.Uint64(), &mix); err != nil {
+			return nil, err
+		}
+		return true, nil
+
+	case "eth_submitHashrate":
+		if len(params) < 1 {
+			return nil, errStratumInvalidParams
+		}
+		rate, err := hexutil.DecodeUint64(params[0])
+		if err != nil {
+			return nil, errStratumInvalidParams
+		}
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		if c.worker == nil {
+			return nil, errStratumUnauthorized
+		}
+		c.worker.reported, c.worker.seen = rate, time.Now()
+		return true, nil
+	}
+	return nil, errStratumUnknownMethod
+}
+
+// subscribe assigns an extranonce to an EthereumStratum connection.
+func (s *stratumServer) subscribe(c *stratumConn, params []string) (interface{}, error) {
+	if len(params) > 1 && params[1] != "" && !strings.HasPrefix(params[1], "EthereumStratum/") {
+		return nil, errStratumProtocol
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !c.hasExtranonce {
+		const extranonces = 1 << (8 * stratumExtranonceBytes)
+		for i := uint64(0); i < extranonces && !c.hasExtranonce; i++ {
+			extranonce := (s.extranonce + i) % extranonces
+			if _, used := s.extranonces[extranonce]; !used {
+				s.extranonces[extranonce] = struct{}{}
+				s.extranonce = extranonce + 1
+				c.extranonce, c.hasExtranonce = extranonce, true
+			}
+		}
+		if !c.hasExtranonce {
+			return nil, errStratumServerFull
+		}
+	}
+	c.subscribed = true
+
+	id := make([]byte, 8)
+	crand.Read(id)
+	return []interface{}{
+		[]string{"mining.notify", hex.EncodeToString(id), stratumProtocol},
+		fmt.Sprintf("%0*x", 2*stratumExtranonceBytes, c.extranonce),
+	}, nil
+}
+
+// authorize assigns a connection to a worker.
+func (s *stratumServer) authorize(c *stratumConn, name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if c.worker != nil {
+		if c.worker.name == name {
+			return
+		}
+		c.worker.conns--
+	}
+	w, ok := s.workers[name]
+	if !ok {
+		w = &stratumWorker{
+			name:    name,
+			id:      crypto.Keccak256Hash([]byte("stratum"), []byte(name)),
+			created: time.Now(),
+		}
+		s.workers[name] = w
+	}
+	w.conns++
+	c.worker = w
+	s.ethash.config.Log.Debug("Stratum worker authorized", "worker", name, "remote", c.conn.RemoteAddr())
+}
+
+// submit verifies a share, and submits it to the remote sealer if it solves the
+// block. The mix digest is checked if the miner provides it.
+func (s *stratumServer) submit(c *stratumConn, sealhash common.Hash, nonce uint64, mix *common.Hash) error {
+	s.lock.Lock()
+	var (
+		worker   = c.worker
+		job      = s.jobs[sealhash]
+		current  = s.current
+		previous = c.previous
+	)
+	if worker == nil {
+		s.lock.Unlock()
+		return errStratumUnauthorized
+	}
+	if job == nil {
+		worker.stale++
+		s.lock.Unlock()
+		stratumStaleMeter.Mark(1)
+		return errStratumJobNotFound
+	}
+	if _, ok := job.nonces[nonce]; ok {
+		worker.rejected++
+		s.lock.Unlock()
+		stratumRejectedMeter.Mark(1)
+		return errStratumDuplicate
+	}
+	job.nonces[nonce] = struct{}{}
+
+	// Shares for jobs sent before the last difficulty change are held to the lower difficulty
+	difficulty := s.shareDifficulty(c, job)
+	if prev := new(big.Int).SetUint64(previous); prev.Cmp(difficulty) < 0 {
+		difficulty = prev
+	}
+	stale := job.number < current.number
+	s.lock.Unlock()
+
+	// Compute the PoW outside of the lock, it may take a while without a dataset
+	digest, result := s.ethash.hashimoto(job.number, sealhash.Bytes(), nonce, true)
+	err := error(nil)
+	switch {
+	case mix != nil && *mix != common.BytesToHash(digest):
+		err = errStratumInvalidMix
+	case new(big.Int).SetBytes(result).Cmp(new(big.Int).Div(two256, difficulty)) > 0:
+		err = errStratumLowDifficulty
+	}
+	block := false
+	if err == nil && new(big.Int).SetBytes(result).Cmp(job.target) <= 0 {
+		block = s.api.SubmitWork(types.EncodeNonce(nonce), sealhash, common.BytesToHash(digest))
+		s.ethash.config.Log.Info("Stratum worker found block", "worker", worker.name, "number", job.number, "sealhash", sealhash, "accepted", block)
+	}
+	now := time.Now()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch {
+	case err != nil:
+		worker.rejected++
+		stratumRejectedMeter.Mark(1)
+		return err
+	case stale && !block:
+		worker.stale++
+		stratumStaleMeter.Mark(1)
+	default:
+		worker.accepted++
+		worker.shares = append(worker.shares, stratumShare{time: now, difficulty: difficulty.Uint64()})
+		stratumAcceptedMeter.Mark(1)
+	}
+	if block {
+		worker.blocks++
+		stratumBlockMeter.Mark(1)
+	}
+	// Shares of replaced jobs are only accepted if they solve their block
+	if stale && !block {
+		return errStratumJobNotFound
+	}
+	// Ramp up the difficulty of miners submitting shares much faster than wanted
+	if c.shares++; c.shares >= 2*stratumRetargetShares && s.retarget(c, now) {
+		c.retarget = true
+	}
+	return nil
+}
+
+// stats returns the share accounting of the workers.
+func (s *stratumServer) stats() []StratumWorker {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	workers := make([]StratumWorker, 0, len(s.workers))
+	for _, w := range s.workers {
+		worker := StratumWorker{
+			Name:             w.name,
+			Connections:      w.conns,
+			Accepted:         w.accepted,
+			Rejected:         w.rejected,
+			Stale:            w.stale,
+			Blocks:           w.blocks,
+			Hashrate:         w.hashrate(now),
+			ReportedHashrate: w.reported,
+		}
+		if n := len(w.shares); n > 0 {
+			worker.LastShare = w.shares[n-1].time.Unix()
+		}
+		workers = append(workers, worker)
+	}
+	return workers
+}
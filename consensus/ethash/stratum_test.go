@@ -0,0 +1,329 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// stratumTestClient is a line based JSON client of a stratum server.
+type stratumTestClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	id     int
+}
+
+func newStratumTestClient(t *testing.T, s *stratumServer) *stratumTestClient {
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial stratum server: %v", err)
+	}
+	return &stratumTestClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// read reads the next message from the server.
+func (c *stratumTestClient) read() map[string]json.RawMessage {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatalf("failed to read stratum message: %v", err)
+	}
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		c.t.Fatalf("invalid stratum message %q: %v", line, err)
+	}
+	return msg
+}
+
+// call sends a request, returning the result and error of its response.
+func (c *stratumTestClient) call(method string, params ...interface{}) (json.RawMessage, json.RawMessage) {
+	c.id++
+	blob, _ := json.Marshal(map[string]interface{}{"id": c.id, "method": method, "params": params})
+	if _, err := c.conn.Write(append(blob, '\n')); err != nil {
+		c.t.Fatalf("failed to send stratum request: %v", err)
+	}
+	msg := c.read()
+	if id := string(msg["id"]); id != strconv.Itoa(c.id) {
+		c.t.Fatalf("response id mismatch: have %s, want %d", id, c.id)
+	}
+	return msg["result"], msg["error"]
+}
+
+// expectNotification reads a notification, returning its parameters.
+func (c *stratumTestClient) expectNotification(method string) []interface{} {
+	msg := c.read()
+	var have string
+	json.Unmarshal(msg["method"], &have)
+	if have != method {
+		c.t.Fatalf("notification method mismatch: have %q, want %q", have, method)
+	}
+	var params []interface{}
+	json.Unmarshal(msg["params"], &params)
+	return params
+}
+
+// findNonce searches a nonce with the given prefix in its top bits solving the work.
+func findNonce(ethash *Ethash, number uint64, sealhash common.Hash, difficulty *big.Int, prefix uint64, bits uint) (uint64, common.Hash) {
+	target := new(big.Int).Div(two256, difficulty)
+	for suffix := uint64(0); ; suffix++ {
+		nonce := prefix<<(64-bits) | suffix
+		digest, result := ethash.hashimoto(number, sealhash.Bytes(), nonce, false)
+		if new(big.Int).SetBytes(result).Cmp(target) <= 0 {
+			return nonce, common.BytesToHash(digest)
+		}
+	}
+}
+
+func newStratumTester(t *testing.T) (*Ethash, *stratumServer, chan *types.Block, *types.Header) {
+	ethash := NewTester(nil, false)
+	server, err := startStratumServer(ethash, "127.0.0.1:0", 1, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to start stratum server: %v", err)
+	}
+	ethash.stratum = server
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(100)}
+	results := make(chan *types.Block, 1)
+	ethash.remote.workCh <- &sealTask{block: types.NewBlockWithHeader(header), results: results}
+
+	// Wait for the work to reach the server
+	for i := 0; i < 100; i++ {
+		server.lock.Lock()
+		current := server.current
+		server.lock.Unlock()
+		if current != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return ethash, server, results, header
+}
+
+func TestStratumEthereumStratum(t *testing.T) {
+	ethash, server, results, header := newStratumTester(t)
+	defer ethash.Close()
+
+	client := newStratumTestClient(t, server)
+	defer client.conn.Close()
+
+	// Workers must subscribe before authorizing
+	if _, err := client.call("mining.authorize", "worker", "x"); string(err) == "null" {
+		t.Fatalf("unsubscribed worker authorized")
+	}
+	res, err := client.call("mining.subscribe", "miner/1.0", stratumProtocol)
+	if string(err) != "null" {
+		t.Fatalf("subscribe failed: %s", err)
+	}
+	var subscription []interface{}
+	if json.Unmarshal(res, &subscription) != nil || len(subscription) != 2 {
+		t.Fatalf("invalid subscription: %s", res)
+	}
+	extranonce, _ := strconv.ParseUint(subscription[1].(string), 16, 64)
+	if len(subscription[1].(string)) != 2*stratumExtranonceBytes {
+		t.Fatalf("extranonce length mismatch: have %q", subscription[1])
+	}
+	if res, err := client.call("mining.authorize", "worker", "x"); string(res) != "true" {
+		t.Fatalf("authorize failed: %s", err)
+	}
+	// Authorized workers are sent their share difficulty and the current job
+	if params := client.expectNotification("mining.set_difficulty"); len(params) != 1 || params[0].(float64) != 1/float64(1<<32) {
+		t.Fatalf("share difficulty mismatch: have %v", params)
+	}
+	sealhash := ethash.SealHash(header)
+	params := client.expectNotification("mining.notify")
+	if len(params) != 4 || params[0] != hex.EncodeToString(sealhash[:]) || params[3] != true {
+		t.Fatalf("job mismatch: have %v", params)
+	}
+	// Submit a share solving the block
+	nonce, _ := findNonce(ethash, 1, sealhash, header.Difficulty, extranonce, 8*stratumExtranonceBytes)
+	suffix := fmt.Sprintf("%0*x", 2*(8-stratumExtranonceBytes), nonce&(1<<(64-8*stratumExtranonceBytes)-1))
+	if res, err := client.call("mining.submit", "worker", params[0], suffix); string(res) != "true" {
+		t.Fatalf("share rejected: %s", err)
+	}
+	select {
+	case block := <-results:
+		if block.Nonce() != nonce {
+			t.Fatalf("block nonce mismatch: have %x, want %x", block.Nonce(), nonce)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("block not submitted")
+	}
+	// Duplicate shares and shares of unknown jobs are rejected
+	if _, err := client.call("mining.submit", "worker", params[0], suffix); string(err) != `[22,"Duplicate share",null]` {
+		t.Fatalf("duplicate share error mismatch: have %s", err)
+	}
+	if _, err := client.call("mining.submit", "worker", hex.EncodeToString(make([]byte, 32)), suffix); string(err) != `[21,"Job not found",null]` {
+		t.Fatalf("unknown job error mismatch: have %s", err)
+	}
+	workers := server.stats()
+	if len(workers) != 1 || workers[0].Name != "worker" || workers[0].Accepted != 1 || workers[0].Blocks != 1 || workers[0].Rejected != 1 || workers[0].Stale != 1 {
+		t.Fatalf("worker accounting mismatch: have %+v", workers)
+	}
+}
+
+func TestStratumEthProxy(t *testing.T) {
+	ethash, server, results, header := newStratumTester(t)
+	defer ethash.Close()
+
+	client := newStratumTestClient(t, server)
+	defer client.conn.Close()
+
+	if _, err := client.call("eth_getWork"); string(err) == "null" {
+		t.Fatalf("work sent before login")
+	}
+	if res, err := client.call("eth_submitLogin", "0x0000000000000000000000000000000000000001"); string(res) != "true" {
+		t.Fatalf("login failed: %s", err)
+	}
+	// Logged in miners are pushed the current work
+	push := client.read()
+	res, _ := client.call("eth_getWork")
+	if string(push["result"]) != string(res) {
+		t.Fatalf("pushed work mismatch: have %s, want %s", push["result"], res)
+	}
+	var work [4]string
+	if err := json.Unmarshal(res, &work); err != nil {
+		t.Fatalf("invalid work: %v", err)
+	}
+	sealhash := ethash.SealHash(header)
+	if work[0] != sealhash.Hex() {
+		t.Fatalf("work hash mismatch: have %s, want %s", work[0], sealhash.Hex())
+	}
+	// Shares with an invalid mix digest are rejected
+	nonce, digest := findNonce(ethash, 1, sealhash, header.Difficulty, 0, 0)
+	if res, _ := client.call("eth_submitWork", types.EncodeNonce(nonce), sealhash, common.Hash{}); string(res) != "false" {
+		t.Fatalf("share with invalid mix digest accepted")
+	}
+	if res, err := client.call("eth_submitWork", types.EncodeNonce(nonce+1<<62), sealhash, digest); string(res) == "true" {
+		t.Fatalf("share with mismatching mix digest accepted: %s", err)
+	}
+	if res, err := client.call("eth_submitWork", types.EncodeNonce(nonce), sealhash, digest); string(res) != "false" || string(err) == "null" {
+		t.Fatalf("duplicate share accepted: %s", err)
+	}
+	nonce, digest = findNonce(ethash, 1, sealhash, header.Difficulty, 1, 1)
+	if res, err := client.call("eth_submitWork", types.EncodeNonce(nonce), sealhash, digest); string(res) != "true" {
+		t.Fatalf("share rejected: %s", err)
+	}
+	select {
+	case <-results:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("block not submitted")
+	}
+	if res, err := client.call("eth_submitHashrate", "0x100", common.Hash{}); string(res) != "true" {
+		t.Fatalf("hashrate rejected: %s", err)
+	}
+	workers := server.stats()
+	if len(workers) != 1 || workers[0].Accepted != 1 || workers[0].Rejected != 3 || workers[0].ReportedHashrate != 0x100 {
+		t.Fatalf("worker accounting mismatch: have %+v", workers)
+	}
+}
+
+func TestStratumRetarget(t *testing.T) {
+	var (
+		s   = &stratumServer{shareTime: 10 * time.Second}
+		now = time.Now()
+	)
+	tests := []struct {
+		difficulty uint64
+		shares     int
+		elapsed    time.Duration
+		want       uint64
+	}{
+		{1 << 30, 6, time.Minute, 1 << 30},      // On target
+		{1 << 30, 12, time.Minute, 1 << 31},     // Twice too fast
+		{1 << 30, 3, time.Minute, 1 << 29},      // Twice too slow
+		{1 << 30, 0, time.Minute, 1 << 28},      // No shares
+		{1 << 30, 600, time.Minute, 1 << 32},    // Clamped increase
+		{1 << 21, 0, time.Minute, 1 << 20},      // Minimum difficulty
+		{1 << 30, 13, 2 * time.Minute, 1 << 30}, // Insignificant change
+	}
+	for i, tt := range tests {
+		c := &stratumConn{difficulty: tt.difficulty, shares: tt.shares, retargeted: now.Add(-tt.elapsed)}
+		changed := s.retarget(c, now)
+		if c.difficulty != tt.want {
+			t.Errorf("test %d: difficulty mismatch: have %d, want %d", i, c.difficulty, tt.want)
+		}
+		if changed != (tt.want != tt.difficulty) {
+			t.Errorf("test %d: change mismatch: have %v", i, changed)
+		}
+		if c.shares != 0 || !c.retargeted.Equal(now) {
+			t.Errorf("test %d: retarget state not reset", i)
+		}
+	}
+}
+
+// Tests that shares of replaced jobs solving their block are submitted and
+// accepted, while the others are reported stale.
+func TestStratumStaleBlock(t *testing.T) {
+	ethash, server, results, header := newStratumTester(t)
+	defer ethash.Close()
+
+	client := newStratumTestClient(t, server)
+	defer client.conn.Close()
+
+	res, _ := client.call("mining.subscribe", "miner/1.0", stratumProtocol)
+	var subscription []interface{}
+	json.Unmarshal(res, &subscription)
+	extranonce, _ := strconv.ParseUint(subscription[1].(string), 16, 64)
+	client.call("mining.authorize", "worker", "x")
+	client.expectNotification("mining.set_difficulty")
+	params := client.expectNotification("mining.notify")
+
+	// Replace the job by one of the next block
+	next := &types.Header{Number: big.NewInt(2), Difficulty: big.NewInt(100)}
+	ethash.remote.workCh <- &sealTask{block: types.NewBlockWithHeader(next), results: results}
+	client.expectNotification("mining.notify")
+
+	suffixOf := func(nonce uint64) string {
+		return fmt.Sprintf("%0*x", 2*(8-stratumExtranonceBytes), nonce&(1<<(64-8*stratumExtranonceBytes)-1))
+	}
+	// A share of the replaced job solving its block is submitted
+	sealhash := ethash.SealHash(header)
+	nonce, _ := findNonce(ethash, 1, sealhash, header.Difficulty, extranonce, 8*stratumExtranonceBytes)
+	if res, err := client.call("mining.submit", "worker", params[0], suffixOf(nonce)); string(res) != "true" {
+		t.Fatalf("stale block rejected: %s", err)
+	}
+	select {
+	case block := <-results:
+		if block.NumberU64() != 1 || block.Nonce() != nonce {
+			t.Fatalf("block mismatch: have #%d nonce %x, want #1 nonce %x", block.NumberU64(), block.Nonce(), nonce)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stale block not submitted")
+	}
+	// A share of the replaced job not solving its block is stale
+	target := new(big.Int).Div(two256, header.Difficulty)
+	for share := nonce + 1; ; share++ {
+		if _, result := ethash.hashimoto(1, sealhash.Bytes(), share, false); new(big.Int).SetBytes(result).Cmp(target) > 0 {
+			if _, err := client.call("mining.submit", "worker", params[0], suffixOf(share)); string(err) != `[21,"Job not found",null]` {
+				t.Fatalf("stale share error mismatch: have %s", err)
+			}
+			break
+		}
+	}
+}
@@ -18,6 +18,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -43,6 +44,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tracing"
 	"github.com/ethereum/go-ethereum/trie"
 	lru "github.com/hashicorp/golang-lru"
 )
@@ -173,15 +175,17 @@ type BlockChain struct {
 	//  * nil: disable tx reindexer/deleter, but still index new blocks
 	txLookupLimit uint64
 
-	hc            *HeaderChain
-	rmLogsFeed    event.Feed
-	chainFeed     event.Feed
-	chainSideFeed event.Feed
-	chainHeadFeed event.Feed
-	logsFeed      event.Feed
-	blockProcFeed event.Feed
-	scope         event.SubscriptionScope
-	genesisBlock  *types.Block
+	hc                *HeaderChain
+	rmLogsFeed        event.Feed
+	chainFeed         event.Feed
+	chainSideFeed     event.Feed
+	chainHeadFeed     event.Feed
+	logsFeed          event.Feed
+	blockProcFeed     event.Feed
+	rejectedReorgFeed event.Feed
+	rejectedReorgs    []RejectedReorgEvent // Rejected reorgs to announce once the chain mutex is released
+	scope             event.SubscriptionScope
+	genesisBlock      *types.Block
 
 	chainmu sync.RWMutex // blockchain insertion lock
 
@@ -1195,6 +1199,12 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 	// this function only accepts canonical chain data. All side chain will be reverted
 	// eventually.
 	writeAncient := func(blockChain types.Blocks, receiptChain []types.Receipts) (int, error) {
+		_, span := tracing.StartSpan(context.Background(), "core.writeAncient",
+			tracing.Int64("blocks", int64(len(blockChain))),
+			tracing.Uint64("block.first", blockChain[0].NumberU64()),
+		)
+		defer span.End()
+
 		var (
 			previous = bc.CurrentFastBlock()
 			batch    = bc.db.NewBatch()
@@ -1520,9 +1530,12 @@ func (bc *BlockChain) writeKnownBlockAsHead(block *types.Block) error {
 // WriteBlockWithState writes the block and all associated state to the database.
 func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.Receipt, logs []*types.Log, state *state.StateDB, emitHeadEvent bool) (status WriteStatus, err error) {
 	bc.chainmu.Lock()
-	defer bc.chainmu.Unlock()
+	status, err = bc.writeBlockWithState(block, receipts, logs, state, emitHeadEvent)
+	reorgs := bc.takeRejectedReorgs()
+	bc.chainmu.Unlock()
 
-	return bc.writeBlockWithState(block, receipts, logs, state, emitHeadEvent)
+	bc.sendRejectedReorgs(reorgs)
+	return status, err
 }
 
 // writeBlockWithState writes the block and all associated state to the database,
@@ -1755,9 +1768,11 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 	bc.wg.Add(1)
 	bc.chainmu.Lock()
 	n, err := bc.insertChain(chain, true)
+	reorgs := bc.takeRejectedReorgs()
 	bc.chainmu.Unlock()
 	bc.wg.Done()
 
+	bc.sendRejectedReorgs(reorgs)
 	return n, err
 }
 
@@ -1774,8 +1789,17 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 	if atomic.LoadInt32(&bc.procInterrupt) == 1 {
 		return 0, nil
 	}
-	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
+	ctx, span := tracing.StartSpan(context.Background(), "core.insertChain",
+		tracing.Int64("blocks", int64(len(chain))),
+		tracing.Uint64("block.first", chain[0].NumberU64()),
+	)
+	defer span.End()
+
+	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss).
+	// The span only covers scheduling, waiting on senders is part of execution.
+	_, recoverSpan := tracing.StartSpan(ctx, "core.recoverSenders")
 	senderCacher.recoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
+	recoverSpan.End()
 
 	var (
 		stats = insertStats{
@@ -1974,12 +1998,19 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		// Retrieve the parent block and it's state to execute on top
 		start := time.Now()
 
+		blockCtx, blockSpan := tracing.StartSpan(ctx, "core.insertBlock",
+			tracing.Uint64("block.number", block.NumberU64()),
+			tracing.String("block.hash", block.Hash().Hex()),
+			tracing.Int64("block.txs", int64(len(block.Transactions()))),
+		)
 		parent := it.previous()
 		if parent == nil {
 			parent = bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
 		}
 		statedb, err := state.New(parent.Root, bc.stateCache, bc.snaps)
 		if err != nil {
+			blockSpan.RecordError(err)
+			blockSpan.End()
 			return it.index, err
 		}
 		// If we have a followup block, run that against the current state to pre-cache
@@ -2000,10 +2031,16 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		}
 		// Process block using the parent state as reference point
 		substart := time.Now()
+		_, stageSpan := tracing.StartSpan(blockCtx, "core.execute")
 		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+		stageSpan.SetAttributes(tracing.Uint64("gas.used", usedGas))
+		stageSpan.RecordError(err)
+		stageSpan.End()
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
+			blockSpan.RecordError(err)
+			blockSpan.End()
 			return it.index, err
 		}
 		// Update the metrics touched during block processing
@@ -2022,9 +2059,15 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 
 		// Validate the state using the default validator
 		substart = time.Now()
-		if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
+		_, stageSpan = tracing.StartSpan(blockCtx, "core.validate")
+		err = bc.validator.ValidateState(block, statedb, receipts, usedGas)
+		stageSpan.RecordError(err)
+		stageSpan.End()
+		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
+			blockSpan.RecordError(err)
+			blockSpan.End()
 			return it.index, err
 		}
 		proctime := time.Since(start)
@@ -2037,11 +2080,18 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 
 		// Write the block to the chain and get the status.
 		substart = time.Now()
+		_, stageSpan = tracing.StartSpan(blockCtx, "core.commit")
 		status, err := bc.writeBlockWithState(block, receipts, logs, statedb, false)
+		stageSpan.RecordError(err)
+		stageSpan.End()
 		atomic.StoreUint32(&followupInterrupt, 1)
 		if err != nil {
+			blockSpan.RecordError(err)
+			blockSpan.End()
 			return it.index, err
 		}
+		blockSpan.SetAttributes(tracing.Bool("block.canonical", status == CanonStatTy))
+		blockSpan.End()
 
 		// Update the metrics touched during block commit
 		accountCommitTimer.Update(statedb.AccountCommits)   // Account commits are complete, we can mark them
@@ -2752,6 +2802,11 @@ func (bc *BlockChain) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.Su
 	return bc.scope.Track(bc.chainSideFeed.Subscribe(ch))
 }
 
+// SubscribeRejectedReorgEvent registers a subscription of RejectedReorgEvent.
+func (bc *BlockChain) SubscribeRejectedReorgEvent(ch chan<- RejectedReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.rejectedReorgFeed.Subscribe(ch))
+}
+
 // SubscribeLogsEvent registers a subscription of []*types.Log.
 func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
@@ -9,8 +9,12 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
 )
 
 // errReorgFinality represents an error caused by artificial finality mechanisms.
@@ -86,10 +90,49 @@ func (bc *BlockChain) getTDRatio(commonAncestor, current, proposed *types.Header
 	return tdRatio
 }
 
-// ecbp1100 implements the "MESS" artificial finality mechanism
-// "Modified Exponential Subjective Scoring" used to prefer known chain segments
-// over later-to-come counterparts, especially proposed segments stretching far into the past.
-func (bc *BlockChain) ecbp1100(commonAncestor, current, proposed *types.Header) error {
+// ECBP1100Result is the outcome of ECBP1100 (MESS) arbitration of a proposed
+// chain segment against the local one.
+type ECBP1100Result struct {
+	CommonAncestor       common.Hash          `json:"commonAncestor"`
+	CommonAncestorNumber uint64               `json:"commonAncestorNumber"`
+	Span                 uint64               `json:"span"` // Seconds between the common ancestor and the local head
+	Curve                ctypes.ECBP1100Curve `json:"curve"`
+
+	// TDRatio is the total difficulty ratio of the proposed over the local segment.
+	// It is zero if the proposed segment does not replace any local blocks.
+	TDRatio float64 `json:"tdRatio"`
+	// Antigravity is the TD ratio required by the curve for the span.
+	Antigravity float64 `json:"antigravity"`
+
+	Accept bool `json:"accept"`
+	// Enabled tells if ECBP1100 is currently enforced by the blockchain.
+	Enabled bool `json:"enabled"`
+}
+
+// EvaluateECBP1100 arbitrates a proposed header against the current chain
+// using ECBP1100 (MESS), without importing it. The parent of the header must be known.
+// The decision is returned regardless of whether ECBP1100 is enabled.
+func (bc *BlockChain) EvaluateECBP1100(proposed *types.Header) (*ECBP1100Result, error) {
+	if proposed.Number == nil || proposed.Number.Sign() == 0 || proposed.Difficulty == nil {
+		return nil, errors.New("invalid proposed header")
+	}
+	if bc.GetTd(proposed.ParentHash, proposed.Number.Uint64()-1) == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	current := bc.CurrentBlock().Header()
+	commonAncestor := rawdb.FindCommonAncestor(bc.db, current, proposed)
+	if commonAncestor == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	res := bc.evaluateECBP1100(commonAncestor, current, proposed)
+	res.Enabled = bc.IsArtificialFinalityEnabled() &&
+		bc.chainConfig.IsEnabled(bc.chainConfig.GetECBP1100Transition, current.Number)
+	return res, nil
+}
+
+// evaluateECBP1100 arbitrates a proposed chain segment against the local one,
+// both descending from the common ancestor.
+func (bc *BlockChain) evaluateECBP1100(commonAncestor, current, proposed *types.Header) *ECBP1100Result {
 
 	// Get the total difficulties of the proposed chain segment and the existing one.
 	commonAncestorTD := bc.GetTd(commonAncestor.Hash(), commonAncestor.Number.Uint64())
@@ -101,29 +144,94 @@ func (bc *BlockChain) ecbp1100(commonAncestor, current, proposed *types.Header)
 	proposedSubchainTD := new(big.Int).Sub(proposedTD, commonAncestorTD)
 	localSubchainTD := new(big.Int).Sub(localTD, commonAncestorTD)
 
-	xBig := big.NewInt(int64(current.Time - commonAncestor.Time))
-	eq := ecbp1100PolynomialV(xBig)
+	res := &ECBP1100Result{
+		CommonAncestor:       commonAncestor.Hash(),
+		CommonAncestorNumber: commonAncestor.Number.Uint64(),
+		Span:                 current.Time - commonAncestor.Time,
+		Curve:                bc.chainConfig.GetECBP1100CurveSchedule().CurveAt(current.Number.Uint64()),
+	}
+
+	denominator := new(big.Int).SetUint64(res.Curve.Denominator)
+	eq := ecbp1100Polynomial(res.Curve, new(big.Int).SetUint64(res.Span))
+	res.Antigravity, _ = new(big.Float).Quo(new(big.Float).SetInt(eq), new(big.Float).SetInt(denominator)).Float64()
+	if localSubchainTD.Sign() > 0 {
+		res.TDRatio, _ = new(big.Float).Quo(new(big.Float).SetInt(proposedSubchainTD), new(big.Float).SetInt(localSubchainTD)).Float64()
+	}
+
 	want := eq.Mul(eq, localSubchainTD)
+	got := new(big.Int).Mul(proposedSubchainTD, denominator)
+	res.Accept = got.Cmp(want) >= 0
+	return res
+}
 
-	got := new(big.Int).Mul(proposedSubchainTD, ecbp1100PolynomialVCurveFunctionDenominator)
-
-	if got.Cmp(want) < 0 {
-		prettyRatio, _ := new(big.Float).Quo(
-			new(big.Float).SetInt(got),
-			new(big.Float).SetInt(want),
-		).Float64()
-		return fmt.Errorf(`%w: ECBP1100-MESS 🔒 status=rejected age=%v current.span=%v proposed.span=%v tdr/gravity=%0.6f common.bno=%d common.hash=%s current.bno=%d current.hash=%s proposed.bno=%d proposed.hash=%s`,
-			errReorgFinality,
-			common.PrettyAge(time.Unix(int64(commonAncestor.Time), 0)),
-			common.PrettyDuration(time.Duration(current.Time-commonAncestor.Time)*time.Second),
-			common.PrettyDuration(time.Duration(int32(xBig.Uint64()))*time.Second),
-			prettyRatio,
-			commonAncestor.Number.Uint64(), commonAncestor.Hash().Hex(),
-			current.Number.Uint64(), current.Hash().Hex(),
-			proposed.Number.Uint64(), proposed.Hash().Hex(),
-		)
+// ecbp1100 implements the "MESS" artificial finality mechanism
+// "Modified Exponential Subjective Scoring" used to prefer known chain segments
+// over later-to-come counterparts, especially proposed segments stretching far into the past.
+func (bc *BlockChain) ecbp1100(commonAncestor, current, proposed *types.Header) error {
+	res := bc.evaluateECBP1100(commonAncestor, current, proposed)
+
+	ecbp1100SpanHistogram.Update(int64(res.Span))
+	ecbp1100DepthHistogram.Update(int64(current.Number.Uint64() - commonAncestor.Number.Uint64()))
+	// The ratio is only computed if the proposed segment replaces local blocks
+	if res.TDRatio > 0 && res.Antigravity > 0 {
+		ecbp1100RatioHistogram.Update(int64(res.TDRatio / res.Antigravity * 1000))
+	}
+	if res.Accept {
+		ecbp1100AcceptMeter.Mark(1)
+		return nil
+	}
+	ecbp1100RejectMeter.Mark(1)
+	bc.writeRejectedReorg(commonAncestor, current, proposed, res)
+
+	return fmt.Errorf(`%w: ECBP1100-MESS 🔒 status=rejected age=%v current.span=%v proposed.span=%v tdr/gravity=%0.6f common.bno=%d common.hash=%s current.bno=%d current.hash=%s proposed.bno=%d proposed.hash=%s`,
+		errReorgFinality,
+		common.PrettyAge(time.Unix(int64(commonAncestor.Time), 0)),
+		common.PrettyDuration(time.Duration(current.Time-commonAncestor.Time)*time.Second),
+		common.PrettyDuration(time.Duration(int32(res.Span))*time.Second),
+		res.TDRatio/res.Antigravity,
+		commonAncestor.Number.Uint64(), commonAncestor.Hash().Hex(),
+		current.Number.Uint64(), current.Hash().Hex(),
+		proposed.Number.Uint64(), proposed.Hash().Hex(),
+	)
+}
+
+// maxRejectedReorgs is the number of most recent rejected reorgs kept in the database.
+const maxRejectedReorgs = 1024
+
+// writeRejectedReorg persists a reorg rejected by ECBP1100 and queues it for
+// announcement to subscribers once the chain mutex is released.
+func (bc *BlockChain) writeRejectedReorg(commonAncestor, current, proposed *types.Header, res *ECBP1100Result) {
+	reorg := &rawdb.RejectedReorg{
+		Time:                 uint64(time.Now().Unix()),
+		CommonAncestorHash:   commonAncestor.Hash(),
+		CommonAncestorNumber: commonAncestor.Number.Uint64(),
+		CurrentHeadHash:      current.Hash(),
+		CurrentHeadNumber:    current.Number.Uint64(),
+		ProposedHeadHash:     proposed.Hash(),
+		ProposedHeadNumber:   proposed.Number.Uint64(),
+		TDRatio:              res.TDRatio,
+		RequiredRatio:        res.Antigravity,
+		Span:                 res.Span,
+	}
+	rawdb.WriteRejectedReorg(bc.db, reorg)
+	rawdb.DeleteRejectedReorgs(bc.db, maxRejectedReorgs)
+	bc.rejectedReorgs = append(bc.rejectedReorgs, RejectedReorgEvent{Reorg: reorg})
+}
+
+// takeRejectedReorgs returns and clears the rejected reorgs waiting to be
+// announced. It expects the chain mutex to be held.
+func (bc *BlockChain) takeRejectedReorgs() []RejectedReorgEvent {
+	reorgs := bc.rejectedReorgs
+	bc.rejectedReorgs = nil
+	return reorgs
+}
+
+// sendRejectedReorgs announces rejected reorgs to subscribers. It must be called
+// without holding the chain mutex, so slow subscribers don't block block import.
+func (bc *BlockChain) sendRejectedReorgs(reorgs []RejectedReorgEvent) {
+	for _, reorg := range reorgs {
+		bc.rejectedReorgFeed.Send(reorg)
 	}
-	return nil
 }
 
 /*
@@ -148,20 +256,27 @@ The if tdRatio < antiGravity check would then be
 if proposed_subchain_td * CURVE_FUNCTION_DENOMINATOR < get_curve_function_numerator(current.Time - commonAncestor.Time) * local_subchain_td.
 */
 func ecbp1100PolynomialV(x *big.Int) *big.Int {
+	return ecbp1100Polynomial(ctypes.ECBP1100CurveV, x)
+}
+
+// ecbp1100Polynomial returns the curve function numerator for x, generalizing
+// ecbp1100PolynomialV to any curve configuration, where
+// xcap = TimeWindow and height = CURVE_FUNCTION_DENOMINATOR * (Ceiling - 1).
+func ecbp1100Polynomial(curve ctypes.ECBP1100Curve, x *big.Int) *big.Int {
+	denominator := new(big.Int).SetUint64(curve.Denominator)
+	xcap := new(big.Int).SetUint64(curve.TimeWindow)
+	height := new(big.Int).Mul(denominator, new(big.Int).SetUint64(curve.Ceiling-1))
 
 	// Make a copy; do not mutate argument value.
 
 	// if x > xcap:
 	//    x = xcap
 	xA := new(big.Int).Set(x)
-	if xA.Cmp(ecbp1100PolynomialVXCap) > 0 {
-		xA.Set(ecbp1100PolynomialVXCap)
+	if xA.Cmp(xcap) > 0 {
+		xA.Set(xcap)
 	}
 
-	xB := new(big.Int).Set(x)
-	if xB.Cmp(ecbp1100PolynomialVXCap) > 0 {
-		xB.Set(ecbp1100PolynomialVXCap)
-	}
+	xB := new(big.Int).Set(xA)
 
 	out := big.NewInt(0)
 
@@ -169,25 +284,25 @@ func ecbp1100PolynomialV(x *big.Int) *big.Int {
 	xA.Exp(xA, big2, nil)
 	xA.Mul(xA, big3)
 
-	// 3 * x**2 // xcap
+	// 2 * x**3 // xcap
 	xB.Exp(xB, big3, nil)
 	xB.Mul(xB, big2)
-	xB.Div(xB, ecbp1100PolynomialVXCap)
+	xB.Div(xB, xcap)
 
 	// (3 * x**2 - 2 * x**3 // xcap)
 	out.Sub(xA, xB)
 
 	// // (3 * x**2 - 2 * x**3 // xcap) * height
-	out.Mul(out, ecbp1100PolynomialVHeight)
+	out.Mul(out, height)
 
 	// xcap ** 2
-	xcap2 := new(big.Int).Exp(ecbp1100PolynomialVXCap, big2, nil)
+	xcap2 := new(big.Int).Exp(xcap, big2, nil)
 
 	// (3 * x**2 - 2 * x**3 // xcap) * height // xcap ** 2
 	out.Div(out, xcap2)
 
 	// CURVE_FUNCTION_DENOMINATOR + (3 * x**2 - 2 * x**3 // xcap) * height // xcap ** 2
-	out.Add(out, ecbp1100PolynomialVCurveFunctionDenominator)
+	out.Add(out, denominator)
 	return out
 }
 
@@ -196,19 +311,20 @@ var big3 = big.NewInt(3)
 
 // ecbp1100PolynomialVCurveFunctionDenominator
 // CURVE_FUNCTION_DENOMINATOR = 128
-var ecbp1100PolynomialVCurveFunctionDenominator = big.NewInt(128)
-
-// ecbp1100PolynomialVXCap
-// xcap = 25132 # = floor(8000*pi)
-var ecbp1100PolynomialVXCap = big.NewInt(25132)
-
-// ecbp1100PolynomialVAmpl
-// ampl = 15
-var ecbp1100PolynomialVAmpl = big.NewInt(15)
-
-// ecbp1100PolynomialVHeight
-// height = CURVE_FUNCTION_DENOMINATOR * (ampl * 2)
-var ecbp1100PolynomialVHeight = new(big.Int).Mul(new(big.Int).Mul(ecbp1100PolynomialVCurveFunctionDenominator, ecbp1100PolynomialVAmpl), big2)
+var ecbp1100PolynomialVCurveFunctionDenominator = new(big.Int).SetUint64(ctypes.ECBP1100CurveV.Denominator)
+
+var (
+	ecbp1100AcceptMeter = metrics.NewRegisteredMeter("chain/ecbp1100/accepted", nil)
+	ecbp1100RejectMeter = metrics.NewRegisteredMeter("chain/ecbp1100/rejected", nil)
+
+	// ecbp1100SpanHistogram tracks the seconds between the common ancestor and the local head of arbitrated reorgs.
+	ecbp1100SpanHistogram = metrics.NewRegisteredHistogram("chain/ecbp1100/span", nil, metrics.NewExpDecaySample(1028, 0.015))
+	// ecbp1100DepthHistogram tracks the number of local blocks arbitrated reorgs would drop.
+	ecbp1100DepthHistogram = metrics.NewRegisteredHistogram("chain/ecbp1100/depth", nil, metrics.NewExpDecaySample(1028, 0.015))
+	// ecbp1100RatioHistogram tracks the TD ratio over the required antigravity of arbitrated reorgs,
+	// in thousandths. Reorgs below 1000 are rejected.
+	ecbp1100RatioHistogram = metrics.NewRegisteredHistogram("chain/ecbp1100/ratio", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
 
 /*
 ecbp1100AGSinusoidalA is a sinusoidal function.
@@ -11,11 +11,13 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/vg"
@@ -242,6 +244,158 @@ func TestAFKnownBlock(t *testing.T) {
 	}
 }
 
+// TestEvaluateECBP1100 tests the arbitration of a candidate header against the
+// current chain, using both the default and a configured antigravity curve.
+func TestEvaluateECBP1100(t *testing.T) {
+	engine := ethash.NewFaker()
+
+	db := rawdb.NewMemoryDatabase()
+	genesis := params.DefaultMessNetGenesisBlock()
+	genesisB := MustCommitGenesis(db, genesis)
+
+	chain, err := NewBlockChain(db, nil, genesis.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	chain.EnableArtificialFinality(true)
+
+	easy, _ := GenerateChain(genesis.Config, genesisB, engine, db, 1000, func(i int, gen *BlockGen) {
+		gen.OffsetTime(0)
+	})
+	if _, err := chain.InsertChain(easy); err != nil {
+		t.Fatal(err)
+	}
+	commonAncestor := easy[len(easy)-301]
+	hard, _ := GenerateChain(genesis.Config, commonAncestor, engine, db, 300, func(i int, gen *BlockGen) {
+		gen.OffsetTime(-7)
+	})
+	// Import all but the candidate; the segment stays a side chain.
+	if _, err := chain.InsertChain(hard[:len(hard)-1]); err != nil {
+		t.Fatal(err)
+	}
+	candidate := hard[len(hard)-1].Header()
+
+	res, err := chain.EvaluateECBP1100(candidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CommonAncestor != commonAncestor.Hash() || res.CommonAncestorNumber != commonAncestor.NumberU64() {
+		t.Errorf("common ancestor mismatch: have %d %x, want %d %x", res.CommonAncestorNumber, res.CommonAncestor, commonAncestor.NumberU64(), commonAncestor.Hash())
+	}
+	if res.Curve != ctypes.ECBP1100CurveV {
+		t.Errorf("curve mismatch: have %v, want %v", res.Curve, ctypes.ECBP1100CurveV)
+	}
+	if res.TDRatio <= 1 {
+		t.Errorf("TD ratio should favor the candidate: %v", res.TDRatio)
+	}
+	if res.Accept || res.TDRatio >= res.Antigravity {
+		t.Errorf("candidate should be rejected: TD ratio %v, antigravity %v", res.TDRatio, res.Antigravity)
+	}
+	if !res.Enabled {
+		t.Error("ECBP1100 should be enabled")
+	}
+	if chain.CurrentBlock().Hash() != easy[len(easy)-1].Hash() {
+		t.Fatal("evaluation should not change the chain head")
+	}
+
+	// A flat curve requires no more than a greater total difficulty.
+	// The configuration is shared with other tests, so restore it afterwards.
+	flat := ctypes.ECBP1100Curve{Denominator: 128, Ceiling: 1, TimeWindow: 1}
+	if err := chain.Config().SetECBP1100CurveSchedule(ctypes.ECBP1100CurveSchedule{0: flat}); err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Config().SetECBP1100CurveSchedule(nil)
+	res, err = chain.EvaluateECBP1100(candidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Curve != flat || res.Antigravity != 1 || !res.Accept {
+		t.Errorf("candidate should be accepted by flat curve: %+v", res)
+	}
+
+	// The parent of the candidate must be known.
+	orphan := types.CopyHeader(candidate)
+	orphan.ParentHash = common.Hash{0x1}
+	if _, err := chain.EvaluateECBP1100(orphan); err != consensus.ErrUnknownAncestor {
+		t.Errorf("orphan error mismatch: have %v, want %v", err, consensus.ErrUnknownAncestor)
+	}
+}
+
+// TestRejectedReorgs tests that reorgs rejected by ECBP1100 are persisted and announced.
+func TestRejectedReorgs(t *testing.T) {
+	engine := ethash.NewFaker()
+
+	db := rawdb.NewMemoryDatabase()
+	genesis := params.DefaultMessNetGenesisBlock()
+	genesisB := MustCommitGenesis(db, genesis)
+
+	chain, err := NewBlockChain(db, nil, genesis.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	chain.EnableArtificialFinality(true)
+
+	events := make(chan RejectedReorgEvent)
+	sub := chain.SubscribeRejectedReorgEvent(events)
+	defer sub.Unsubscribe()
+	received := make(chan []*rawdb.RejectedReorg)
+	go func() {
+		var reorgs []*rawdb.RejectedReorg
+		for {
+			select {
+			case ev := <-events:
+				reorgs = append(reorgs, ev.Reorg)
+			case <-sub.Err():
+				received <- reorgs
+				return
+			}
+		}
+	}()
+
+	easy, _ := GenerateChain(genesis.Config, genesisB, engine, db, 1000, func(i int, gen *BlockGen) {
+		gen.OffsetTime(0)
+	})
+	if _, err := chain.InsertChain(easy); err != nil {
+		t.Fatal(err)
+	}
+	commonAncestor := easy[len(easy)-301]
+	hard, _ := GenerateChain(genesis.Config, commonAncestor, engine, db, 300, func(i int, gen *BlockGen) {
+		gen.OffsetTime(-7)
+	})
+	if _, err := chain.InsertChain(hard); err != nil {
+		t.Fatal(err)
+	}
+	if chain.CurrentBlock().Hash() != easy[len(easy)-1].Hash() {
+		t.Fatal("hard chain should have been rejected")
+	}
+	sub.Unsubscribe()
+	announced := <-received
+
+	stored := rawdb.ReadRejectedReorgs(db)
+	if len(stored) == 0 {
+		t.Fatal("no rejected reorgs stored")
+	}
+	if len(stored) != len(announced) {
+		t.Fatalf("stored and announced mismatch: %d / %d", len(stored), len(announced))
+	}
+	for i, r := range stored {
+		if *r != *announced[i] {
+			t.Errorf("reorg %d: stored %+v, announced %+v", i, r, announced[i])
+		}
+		if r.CommonAncestorHash != commonAncestor.Hash() || r.CurrentHeadHash != easy[len(easy)-1].Hash() {
+			t.Errorf("reorg %d: unexpected segments: %+v", i, r)
+		}
+		if r.TDRatio >= r.RequiredRatio {
+			t.Errorf("reorg %d: TD ratio %v should be below required %v", i, r.TDRatio, r.RequiredRatio)
+		}
+	}
+	if last := stored[len(stored)-1]; last.ProposedHeadHash != hard[len(hard)-1].Hash() {
+		t.Errorf("last rejected head mismatch: have %x, want %x", last.ProposedHeadHash, hard[len(hard)-1].Hash())
+	}
+}
+
 // TestEcbp1100PolynomialV tests the general shape and return values of the ECBP1100 polynomial curve.
 // It makes sure domain values above the 'cap' do indeed get limited, as well
 // as sanity check some normal domain values.
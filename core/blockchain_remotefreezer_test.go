@@ -24,6 +24,7 @@ import (
 	"testing"
 	"time"
 
+	fslib "github.com/ethereum/go-ethereum/cmd/ancient-store-fs/lib"
 	"github.com/ethereum/go-ethereum/cmd/ancient-store-mem/lib"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
@@ -46,13 +47,36 @@ var (
 	// testRPCFreezerURL defines an optional environment variable that, if set,
 	// will be used in lieu of a default ephemeral remote freezer store.
 	testRPCFreezerURL = os.Getenv("GETH_ANCIENT_RPC")
+
+	// testRPCFreezerStore defines an optional environment variable restricting the
+	// ephemeral remote freezer stores tested to either 'mem' or 'fs'. Both are
+	// tested by default.
+	testRPCFreezerStore = os.Getenv("GETH_ANCIENT_RPC_STORE")
 )
 
+// testRPCFreezerStores runs a remote freezer test against each ephemeral store
+// as a subtest, or once if an external freezer server is configured.
+func testRPCFreezerStores(t *testing.T, test func(t *testing.T, store string)) {
+	if testRPCFreezerURL != "" {
+		test(t, "")
+		return
+	}
+	for _, store := range []string{"mem", "fs"} {
+		if testRPCFreezerStore != "" && testRPCFreezerStore != store {
+			continue
+		}
+		store := store
+		t.Run(store, func(t *testing.T) {
+			test(t, store)
+		})
+	}
+}
+
 // testRPCRemoteFreezer provides a configuration option to use an external
 // remote freezer server, or to default (with no configured flags) to a built in
 // ephemeral in-memory server over a temporary unix socket.
 // If an external URL is configured the return value for 'server' will be nil.
-func testRPCRemoteFreezer(t *testing.T) (rpcFreezerEndpoint string, server *rpc.Server, ancientDB ethdb.Database) {
+func testRPCRemoteFreezer(t *testing.T, store string) (rpcFreezerEndpoint string, server *rpc.Server, ancientDB ethdb.Database) {
 	if testRPCFreezerURL == "" {
 		// If an external freezer server is not provided, spin up an ephemeral
 		// freezer over IPC.
@@ -66,7 +90,17 @@ func testRPCRemoteFreezer(t *testing.T) (rpcFreezerEndpoint string, server *rpc.
 		if err != nil {
 			t.Fatal(err)
 		}
-		mock := lib.NewMemFreezerRemoteServerAPI()
+		var mock interface{}
+		switch store {
+		case "mem":
+			mock = lib.NewMemFreezerRemoteServerAPI()
+		case "fs":
+			if mock, err = fslib.NewFSFreezerRemoteServerAPI(filepath.Join(frdir, "ancients")); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			t.Fatalf("unknown remote freezer store: %s", store)
+		}
 		err = server.RegisterName("freezer", mock)
 		if err != nil {
 			t.Fatal(err)
@@ -80,7 +114,7 @@ func testRPCRemoteFreezer(t *testing.T) (rpcFreezerEndpoint string, server *rpc.
 		t.Log("Using external freezer:", rpcFreezerEndpoint)
 	}
 
-	ancientDb, err := rawdb.NewDatabaseWithFreezerRemote(rawdb.NewMemoryDatabase(), rpcFreezerEndpoint)
+	ancientDb, err := rawdb.NewDatabaseWithFreezerRemote(rawdb.NewMemoryDatabase(), rpcFreezerEndpoint, nil)
 	if err != nil {
 		t.Fatalf("failed to create temp freezer db: %v", err)
 	}
@@ -94,6 +128,10 @@ func testRPCRemoteFreezer(t *testing.T) (rpcFreezerEndpoint string, server *rpc.
 // that ensure that the ancient store methods are completely exercised; eg. a rollback step is
 // used to call TruncateAncients.
 func TestFastVsFullChains_RemoteFreezer(t *testing.T) {
+	testRPCFreezerStores(t, testFastVsFullChainsRemoteFreezer)
+}
+
+func testFastVsFullChainsRemoteFreezer(t *testing.T, store string) {
 	// Configure and generate a sample block chain
 	var (
 		gendb   = rawdb.NewMemoryDatabase()
@@ -153,7 +191,7 @@ func TestFastVsFullChains_RemoteFreezer(t *testing.T) {
 	}
 
 	// Freezer style fast import the chain.
-	freezerRPCEndpoint, server, ancientDb := testRPCRemoteFreezer(t)
+	freezerRPCEndpoint, server, ancientDb := testRPCRemoteFreezer(t, store)
 	if n, err := ancientDb.Ancients(); err != nil {
 		t.Fatalf("ancients: %v", err)
 	} else if n != 0 {
@@ -252,6 +290,10 @@ func TestFastVsFullChains_RemoteFreezer(t *testing.T) {
 }
 
 func TestIncompleteAncientReceiptChainInsertion_RemoteFreezer(t *testing.T) {
+	testRPCFreezerStores(t, testIncompleteAncientReceiptChainInsertionRemoteFreezer)
+}
+
+func testIncompleteAncientReceiptChainInsertionRemoteFreezer(t *testing.T, store string) {
 	// Configure and generate a sample block chain
 	var (
 		gendb   = rawdb.NewMemoryDatabase()
@@ -265,7 +307,7 @@ func TestIncompleteAncientReceiptChainInsertion_RemoteFreezer(t *testing.T) {
 	blocks, receipts := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, int(height), nil)
 
 	// Import the chain as a ancient-first node and ensure all pointers are updated
-	freezerRPCEndpoint, server, ancientDb := testRPCRemoteFreezer(t)
+	freezerRPCEndpoint, server, ancientDb := testRPCRemoteFreezer(t, store)
 	if n, err := ancientDb.Ancients(); err != nil {
 		t.Fatalf("ancients: %v", err)
 	} else if n != 0 {
@@ -322,6 +364,10 @@ func TestIncompleteAncientReceiptChainInsertion_RemoteFreezer(t *testing.T) {
 }
 
 func TestTransactionIndices_RemoteFreezer(t *testing.T) {
+	testRPCFreezerStores(t, testTransactionIndicesRemoteFreezer)
+}
+
+func testTransactionIndicesRemoteFreezer(t *testing.T, store string) {
 	// Configure and generate a sample block chain
 	var (
 		gendb   = rawdb.NewMemoryDatabase()
@@ -378,7 +424,7 @@ func TestTransactionIndices_RemoteFreezer(t *testing.T) {
 			}
 		}
 	}
-	freezerRPCEndpoint, server, ancientDb := testRPCRemoteFreezer(t)
+	freezerRPCEndpoint, server, ancientDb := testRPCRemoteFreezer(t, store)
 	if n, err := ancientDb.Ancients(); err != nil {
 		t.Fatalf("ancients: %v", err)
 	} else if n != 0 {
@@ -424,7 +470,7 @@ func TestTransactionIndices_RemoteFreezer(t *testing.T) {
 	// Init block chain with external ancients, check all needed indices has been indexed.
 	limit := []uint64{0, 32, 64, 128}
 	for _, l := range limit {
-		ancientDb, err := rawdb.NewDatabaseWithFreezerRemote(rawdb.NewMemoryDatabase(), freezerRPCEndpoint)
+		ancientDb, err := rawdb.NewDatabaseWithFreezerRemote(rawdb.NewMemoryDatabase(), freezerRPCEndpoint, nil)
 		if err != nil {
 			t.Fatalf("failed to create temp freezer db: %v", err)
 		}
@@ -444,7 +490,7 @@ func TestTransactionIndices_RemoteFreezer(t *testing.T) {
 	}
 
 	// Reconstruct a block chain which only reserves HEAD-64 tx indices
-	ancientDb, err = rawdb.NewDatabaseWithFreezerRemote(rawdb.NewMemoryDatabase(), freezerRPCEndpoint)
+	ancientDb, err = rawdb.NewDatabaseWithFreezerRemote(rawdb.NewMemoryDatabase(), freezerRPCEndpoint, nil)
 	if err != nil {
 		t.Fatalf("failed to create temp freezer db: %v", err)
 	}
@@ -465,6 +511,10 @@ func TestTransactionIndices_RemoteFreezer(t *testing.T) {
 }
 
 func TestSkipStaleTxIndicesInFastSync_RemoteFreezer(t *testing.T) {
+	testRPCFreezerStores(t, testSkipStaleTxIndicesInFastSyncRemoteFreezer)
+}
+
+func testSkipStaleTxIndicesInFastSyncRemoteFreezer(t *testing.T, store string) {
 	// Configure and generate a sample block chain
 	var (
 		gendb   = rawdb.NewMemoryDatabase()
@@ -518,7 +568,7 @@ func TestSkipStaleTxIndicesInFastSync_RemoteFreezer(t *testing.T) {
 		}
 	}
 
-	freezerRPCEndpoint, server, ancientDb := testRPCRemoteFreezer(t)
+	freezerRPCEndpoint, server, ancientDb := testRPCRemoteFreezer(t, store)
 	if n, err := ancientDb.Ancients(); err != nil {
 		t.Fatalf("ancients: %v", err)
 	} else if n != 0 {
@@ -362,6 +362,68 @@ func checkReceiptsRLP(have, want types.Receipts) error {
 	return nil
 }
 
+func TestCheckAncients(t *testing.T) {
+	frdir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temp freezer dir: %v", err)
+	}
+	defer os.RemoveAll(frdir)
+
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), frdir, "")
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend")
+	}
+	defer db.Close()
+
+	// Freeze a chain of three blocks, keeping the hash to number mappings
+	var headers []*types.Header
+	for i := 0; i < 4; i++ {
+		header := &types.Header{
+			Number:      big.NewInt(int64(i)),
+			Extra:       []byte("test block"),
+			UncleHash:   types.EmptyUncleHash,
+			TxHash:      types.EmptyRootHash,
+			ReceiptHash: types.EmptyRootHash,
+		}
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash()
+		}
+		headers = append(headers, header)
+	}
+	for _, header := range headers[:3] {
+		WriteAncientBlock(db, types.NewBlockWithHeader(header), nil, big.NewInt(100))
+		WriteHeaderNumber(db, header.Hash(), header.Number.Uint64())
+	}
+	WriteCanonicalHash(db, headers[0].Hash(), 0)
+	if err := CheckAncients(db); err != nil {
+		t.Fatalf("valid freezer rejected: %v", err)
+	}
+	// The key-value store must extend the freezer
+	WriteHeader(db, headers[3])
+	WriteCanonicalHash(db, headers[3].Hash(), 3)
+	WriteHeadHeaderHash(db, headers[3].Hash())
+	if err := CheckAncients(db); err != nil {
+		t.Fatalf("valid key-value extension rejected: %v", err)
+	}
+	fork := types.CopyHeader(headers[3])
+	fork.ParentHash = common.Hash{0x01}
+	WriteHeader(db, fork)
+	WriteCanonicalHash(db, fork.Hash(), 3)
+	if err := CheckAncients(db); err == nil {
+		t.Fatal("disconnected key-value store accepted")
+	}
+	DeleteCanonicalHash(db, 3)
+	if err := CheckAncients(db); err == nil {
+		t.Fatal("gap between freezer and key-value store accepted")
+	}
+	// Frozen blocks must be mapped to their numbers
+	WriteCanonicalHash(db, headers[3].Hash(), 3)
+	DeleteHeaderNumber(db, headers[1].Hash())
+	if err := CheckAncients(db); err == nil {
+		t.Fatal("unmapped frozen block accepted")
+	}
+}
+
 func TestAncientStorage(t *testing.T) {
 	// Freezer style fast import the chain.
 	frdir, err := ioutil.TempDir("", "")
@@ -0,0 +1,128 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// RejectedReorg is a chain reorganization rejected by artificial finality.
+type RejectedReorg struct {
+	Time uint64 `json:"time"` // Unix time of the rejection
+
+	CommonAncestorHash   common.Hash `json:"commonAncestorHash"`
+	CommonAncestorNumber uint64      `json:"commonAncestorNumber"`
+	CurrentHeadHash      common.Hash `json:"currentHeadHash"`
+	CurrentHeadNumber    uint64      `json:"currentHeadNumber"`
+	ProposedHeadHash     common.Hash `json:"proposedHeadHash"`
+	ProposedHeadNumber   uint64      `json:"proposedHeadNumber"`
+
+	TDRatio       float64 `json:"tdRatio"`       // Total difficulty ratio of the proposed over the current segment
+	RequiredRatio float64 `json:"requiredRatio"` // Total difficulty ratio required for the reorg to be accepted
+	Span          uint64  `json:"span"`          // Seconds between the common ancestor and the current head
+}
+
+// storedRejectedReorg is the RLP representation of a rejected reorg, RLP not
+// supporting floating point numbers the ratios are stored as their IEEE 754 bits.
+type storedRejectedReorg struct {
+	Time                 uint64
+	CommonAncestorHash   common.Hash
+	CommonAncestorNumber uint64
+	CurrentHeadHash      common.Hash
+	CurrentHeadNumber    uint64
+	ProposedHeadHash     common.Hash
+	ProposedHeadNumber   uint64
+	TDRatio              uint64
+	RequiredRatio        uint64
+	Span                 uint64
+}
+
+// ReadRejectedReorgs retrieves all the stored rejected reorgs, oldest first.
+func ReadRejectedReorgs(db ethdb.Iteratee) []*RejectedReorg {
+	it := db.NewIterator(rejectedReorgPrefix, nil)
+	defer it.Release()
+
+	var reorgs []*RejectedReorg
+	for it.Next() {
+		if len(it.Key()) != len(rejectedReorgPrefix)+16+common.HashLength {
+			continue
+		}
+		var stored storedRejectedReorg
+		if err := rlp.DecodeBytes(it.Value(), &stored); err != nil {
+			log.Error("Invalid rejected reorg RLP", "key", common.Bytes2Hex(it.Key()), "err", err)
+			continue
+		}
+		reorgs = append(reorgs, &RejectedReorg{
+			Time:                 stored.Time,
+			CommonAncestorHash:   stored.CommonAncestorHash,
+			CommonAncestorNumber: stored.CommonAncestorNumber,
+			CurrentHeadHash:      stored.CurrentHeadHash,
+			CurrentHeadNumber:    stored.CurrentHeadNumber,
+			ProposedHeadHash:     stored.ProposedHeadHash,
+			ProposedHeadNumber:   stored.ProposedHeadNumber,
+			TDRatio:              math.Float64frombits(stored.TDRatio),
+			RequiredRatio:        math.Float64frombits(stored.RequiredRatio),
+			Span:                 stored.Span,
+		})
+	}
+	return reorgs
+}
+
+// WriteRejectedReorg stores a rejected reorg into the database.
+func WriteRejectedReorg(db ethdb.KeyValueWriter, reorg *RejectedReorg) {
+	data, err := rlp.EncodeToBytes(&storedRejectedReorg{
+		Time:                 reorg.Time,
+		CommonAncestorHash:   reorg.CommonAncestorHash,
+		CommonAncestorNumber: reorg.CommonAncestorNumber,
+		CurrentHeadHash:      reorg.CurrentHeadHash,
+		CurrentHeadNumber:    reorg.CurrentHeadNumber,
+		ProposedHeadHash:     reorg.ProposedHeadHash,
+		ProposedHeadNumber:   reorg.ProposedHeadNumber,
+		TDRatio:              math.Float64bits(reorg.TDRatio),
+		RequiredRatio:        math.Float64bits(reorg.RequiredRatio),
+		Span:                 reorg.Span,
+	})
+	if err != nil {
+		log.Crit("Failed to encode rejected reorg", "err", err)
+	}
+	if err := db.Put(rejectedReorgKey(reorg.Time, reorg.ProposedHeadNumber, reorg.ProposedHeadHash), data); err != nil {
+		log.Crit("Failed to store rejected reorg", "err", err)
+	}
+}
+
+// DeleteRejectedReorgs removes all but the newest limit rejected reorgs from the database.
+func DeleteRejectedReorgs(db ethdb.KeyValueStore, limit int) {
+	it := db.NewIterator(rejectedReorgPrefix, nil)
+	defer it.Release()
+
+	var keys [][]byte
+	for it.Next() {
+		if len(it.Key()) == len(rejectedReorgPrefix)+16+common.HashLength {
+			keys = append(keys, common.CopyBytes(it.Key()))
+		}
+	}
+	for i := 0; i < len(keys)-limit; i++ {
+		if err := db.Delete(keys[i]); err != nil {
+			log.Crit("Failed to delete rejected reorg", "err", err)
+		}
+	}
+}
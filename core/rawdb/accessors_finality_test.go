@@ -0,0 +1,65 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests rejected reorg storage, ordering and pruning.
+func TestRejectedReorgStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	if reorgs := ReadRejectedReorgs(db); len(reorgs) != 0 {
+		t.Fatalf("non existent reorgs returned: %v", reorgs)
+	}
+	// Write the reorgs out of order, they are read back by time and number
+	var want []*RejectedReorg
+	for i := uint64(0); i < 6; i++ {
+		want = append(want, &RejectedReorg{
+			Time:               100 + i/2,
+			ProposedHeadHash:   common.Hash{byte(10 - i)},
+			ProposedHeadNumber: i,
+			TDRatio:            1.5,
+			RequiredRatio:      2.25,
+		})
+	}
+	for _, i := range []int{3, 0, 5, 1, 4, 2} {
+		WriteRejectedReorg(db, want[i])
+	}
+	have := ReadRejectedReorgs(db)
+	if len(have) != len(want) {
+		t.Fatalf("reorg count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i := range want {
+		if *have[i] != *want[i] {
+			t.Errorf("reorg %d mismatch: have %+v, want %+v", i, have[i], want[i])
+		}
+	}
+	// Prune all but the newest reorgs
+	DeleteRejectedReorgs(db, 2)
+	have = ReadRejectedReorgs(db)
+	if len(have) != 2 || *have[0] != *want[4] || *have[1] != *want[5] {
+		t.Fatalf("pruned reorgs mismatch: have %v", have)
+	}
+	DeleteRejectedReorgs(db, 2)
+	if have = ReadRejectedReorgs(db); len(have) != 2 {
+		t.Fatalf("reorgs pruned below limit: have %d", len(have))
+	}
+}
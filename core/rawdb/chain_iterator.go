@@ -29,6 +29,10 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
+// freezerInitRange is the number of frozen block hashes read at once when
+// reinitializing the database from the freezer.
+const freezerInitRange = 1024
+
 // InitDatabaseFromFreezer reinitializes an empty database from a previous batch
 // of frozen ancient blocks. The method iterates over all the frozen blocks and
 // injects into the database the block hash->number mappings.
@@ -44,17 +48,25 @@ func InitDatabaseFromFreezer(db ethdb.Database) {
 		logged = start.Add(-7 * time.Second) // Unindex during import is fast, don't double log
 		hash   common.Hash
 	)
-	for i := uint64(0); i < frozen; i++ {
+	for i := uint64(0); i < frozen; {
 		// Since the freezer has all data in sequential order on a file,
-		// it would be 'neat' to read more data in one go, and let the
-		// freezerdb return N items (e.g up to 1000 items per go)
-		// That would require an API change in Ancients though
-		if h, err := db.Ancient(freezerHashTable, i); err != nil {
+		// read the hashes in ranges, which remote freezers serve in one call
+		count := frozen - i
+		if count > freezerInitRange {
+			count = freezerInitRange
+		}
+		hashes, err := readAncientRange(db, freezerHashTable, i, count)
+		if err != nil {
 			log.Crit("Failed to init database from freezer", "err", err)
-		} else {
+		}
+		if len(hashes) == 0 {
+			log.Crit("Failed to init database from freezer", "err", errOutOfBounds, "number", i)
+		}
+		for _, h := range hashes {
 			hash = common.BytesToHash(h)
+			WriteHeaderNumber(batch, hash, i)
+			i++
 		}
-		WriteHeaderNumber(batch, hash, i)
 		// If enough data was accumulated in memory or we're at the last block, dump to disk
 		if batch.ValueSize() > ethdb.IdealBatchSize {
 			if err := batch.Write(); err != nil {
@@ -21,15 +21,19 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/leveldb"
 	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/olekukonko/tablewriter"
 )
 
@@ -121,12 +125,12 @@ func NewDatabase(db ethdb.KeyValueStore) ethdb.Database {
 
 // NewDatabaseWithFreezerRemote creates a high level database on top of a given key-
 // value data store with a freezer moving immutable chain segments into cold
-// storage.
-func NewDatabaseWithFreezerRemote(db ethdb.KeyValueStore, freezerURL string) (ethdb.Database, error) {
+// storage. The connection to the freezer is configured by config, which may be nil.
+func NewDatabaseWithFreezerRemote(db ethdb.KeyValueStore, freezerURL string, config *FreezerRemoteConfig) (ethdb.Database, error) {
 	// Create the idle freezer instance
 	log.Info("New remote freezer", "freezer", freezerURL)
 
-	frdb, err := newFreezerRemoteClient(freezerURL)
+	frdb, err := newFreezerRemoteClient(freezerURL, config)
 	if err != nil {
 		log.Error("NewDatabaseWithFreezerRemote error", "error", err)
 		return nil, err
@@ -293,24 +297,92 @@ func NewMemoryDatabaseWithCap(size int) ethdb.Database {
 	return NewDatabase(memorydb.NewWithCap(size))
 }
 
+// Key-value database engines available for persistent databases.
+const (
+	DBLeveldb = "leveldb"
+	DBPebble  = "pebble"
+)
+
+// PreexistingDatabase checks the given directory for an existing key-value
+// database, returning the engine it was created with or an empty string if
+// there is none. Both engines keep a CURRENT file, but only pebble writes
+// OPTIONS files next to it.
+func PreexistingDatabase(path string) string {
+	if _, err := os.Stat(filepath.Join(path, "CURRENT")); err != nil {
+		return ""
+	}
+	if matches, err := filepath.Glob(filepath.Join(path, "OPTIONS*")); len(matches) > 0 || err != nil {
+		return DBPebble
+	}
+	return DBLeveldb
+}
+
+// NewKeyValueStore opens a persistent key-value store with the given engine. If
+// no engine is given, the one the existing database was created with is used,
+// defaulting to leveldb for new databases. Opening an existing database with a
+// different engine than the one it was created with is refused.
+func NewKeyValueStore(engine string, file string, cache int, handles int, namespace string) (ethdb.KeyValueStore, error) {
+	existing := PreexistingDatabase(file)
+	if engine == "" {
+		engine = existing
+	}
+	if engine == "" {
+		engine = DBLeveldb
+	}
+	if existing != "" && existing != engine {
+		return nil, fmt.Errorf("database engine %q requested, but the database at %s was created with %q", engine, file, existing)
+	}
+	switch engine {
+	case DBLeveldb:
+		db, err := leveldb.New(file, cache, handles, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	case DBPebble:
+		return newPebbleDatabase(file, cache, handles, namespace)
+	default:
+		return nil, fmt.Errorf("unknown database engine %q, want %q or %q", engine, DBLeveldb, DBPebble)
+	}
+}
+
 // NewLevelDBDatabase creates a persistent key-value database without a freezer
 // moving immutable chain segments into cold storage.
 func NewLevelDBDatabase(file string, cache int, handles int, namespace string) (ethdb.Database, error) {
-	db, err := leveldb.New(file, cache, handles, namespace)
+	return NewPersistentDatabase(DBLeveldb, file, cache, handles, namespace)
+}
+
+// NewLevelDBDatabaseWithFreezerRemote creates a persistent key-value database
+// with a remote freezer moving immutable chain segments into cold storage.
+func NewLevelDBDatabaseWithFreezerRemote(file string, cache int, handles int, freezerURL string, config *FreezerRemoteConfig) (ethdb.Database, error) {
+	return NewPersistentDatabaseWithFreezerRemote(DBLeveldb, file, cache, handles, freezerURL, config)
+}
+
+// NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
+// freezer moving immutable chain segments into cold storage.
+func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string) (ethdb.Database, error) {
+	return NewPersistentDatabaseWithFreezer(DBLeveldb, file, cache, handles, freezer, namespace)
+}
+
+// NewPersistentDatabase creates a persistent key-value database with the given
+// engine, without a freezer moving immutable chain segments into cold storage.
+func NewPersistentDatabase(engine string, file string, cache int, handles int, namespace string) (ethdb.Database, error) {
+	db, err := NewKeyValueStore(engine, file, cache, handles, namespace)
 	if err != nil {
 		return nil, err
 	}
 	return NewDatabase(db), nil
 }
 
-// NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
-// freezer moving immutable chain segments into cold storage.
-func NewLevelDBDatabaseWithFreezerRemote(file string, cache int, handles int, freezerURL string) (ethdb.Database, error) {
-	kvdb, err := leveldb.New(file, cache, handles, "eth/db/chaindata")
+// NewPersistentDatabaseWithFreezerRemote creates a persistent key-value database
+// with the given engine and a remote freezer moving immutable chain segments
+// into cold storage.
+func NewPersistentDatabaseWithFreezerRemote(engine string, file string, cache int, handles int, freezerURL string, config *FreezerRemoteConfig) (ethdb.Database, error) {
+	kvdb, err := NewKeyValueStore(engine, file, cache, handles, "eth/db/chaindata")
 	if err != nil {
 		return nil, err
 	}
-	frdb, err := NewDatabaseWithFreezerRemote(kvdb, freezerURL)
+	frdb, err := NewDatabaseWithFreezerRemote(kvdb, freezerURL, config)
 	if err != nil {
 		kvdb.Close()
 		return nil, err
@@ -318,10 +390,11 @@ func NewLevelDBDatabaseWithFreezerRemote(file string, cache int, handles int, fr
 	return frdb, nil
 }
 
-// NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
-// freezer moving immutable chain segments into cold storage.
-func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string) (ethdb.Database, error) {
-	kvdb, err := leveldb.New(file, cache, handles, namespace)
+// NewPersistentDatabaseWithFreezer creates a persistent key-value database with
+// the given engine and a freezer moving immutable chain segments into cold
+// storage.
+func NewPersistentDatabaseWithFreezer(engine string, file string, cache int, handles int, freezer string, namespace string) (ethdb.Database, error) {
+	kvdb, err := NewKeyValueStore(engine, file, cache, handles, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -389,6 +462,7 @@ func InspectDatabase(db ethdb.Database) error {
 		preimages       stat
 		bloomBits       stat
 		cliqueSnaps     stat
+		rejectedReorgs  stat
 
 		// Ancient store statistics
 		ancientHeadersSize  common.StorageSize
@@ -442,6 +516,8 @@ func InspectDatabase(db ethdb.Database) error {
 			preimages.Add(size)
 		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
 			bloomBits.Add(size)
+		case bytes.HasPrefix(key, rejectedReorgPrefix) && len(key) == (len(rejectedReorgPrefix)+16+common.HashLength):
+			rejectedReorgs.Add(size)
 		case bytes.HasPrefix(key, []byte("clique-")) && len(key) == 7+common.HashLength:
 			cliqueSnaps.Add(size)
 		case bytes.HasPrefix(key, []byte("cht-")) && len(key) == 4+common.HashLength:
@@ -496,6 +572,7 @@ func InspectDatabase(db ethdb.Database) error {
 		{"Key-Value store", "Account snapshot", accountSnaps.Size(), accountSnaps.Count()},
 		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
 		{"Key-Value store", "Clique snapshots", cliqueSnaps.Size(), cliqueSnaps.Count()},
+		{"Key-Value store", "Rejected reorgs", rejectedReorgs.Size(), rejectedReorgs.Count()},
 		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
 		{"Ancient store", "Headers", ancientHeadersSize.String(), ancients.String()},
 		{"Ancient store", "Bodies", ancientBodiesSize.String(), ancients.String()},
@@ -567,6 +644,89 @@ func validateFreezerVsKV(freezerdb *freezer, db ethdb.KeyValueStore) error {
 	return nil
 }
 
+// CheckAncients validates the chain segment held by the freezer of db, which may
+// be local or remote, against the canonical chain tracked by the key-value store.
+// Every frozen item must be present in all the tables, its hash must match the
+// stored header, link to its parent and be mapped to its number by the key-value
+// store. The first block left in the key-value store must extend the freezer.
+func CheckAncients(db ethdb.Database) error {
+	frozen, err := db.Ancients()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve ancient count: %v", err)
+	}
+	if frozen == 0 {
+		log.Info("Freezer is empty, nothing to check")
+		return nil
+	}
+	if kvgenesis, _ := db.Get(headerHashKey(0)); len(kvgenesis) > 0 {
+		frgenesis, err := db.Ancient(freezerHashTable, 0)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve genesis from ancient: %v", err)
+		}
+		if !bytes.Equal(kvgenesis, frgenesis) {
+			return fmt.Errorf("genesis mismatch: %#x (leveldb) != %#x (ancients)", kvgenesis, frgenesis)
+		}
+	}
+	var (
+		parent common.Hash
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for number := uint64(0); number < frozen; number++ {
+		blob, err := db.Ancient(freezerHashTable, number)
+		if err != nil {
+			return fmt.Errorf("block #%d: missing ancient hash: %v", number, err)
+		}
+		hash := common.BytesToHash(blob)
+
+		blob, err = db.Ancient(freezerHeaderTable, number)
+		if err != nil {
+			return fmt.Errorf("block #%d: missing ancient header: %v", number, err)
+		}
+		if have := crypto.Keccak256Hash(blob); have != hash {
+			return fmt.Errorf("block #%d: header hash mismatch: have %x, want %x", number, have, hash)
+		}
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(blob, header); err != nil {
+			return fmt.Errorf("block #%d: invalid ancient header: %v", number, err)
+		}
+		if header.Number.Uint64() != number {
+			return fmt.Errorf("block #%d: header number mismatch: have %d", number, header.Number)
+		}
+		if number > 0 && header.ParentHash != parent {
+			return fmt.Errorf("block #%d: parent hash mismatch: have %x, want %x", number, header.ParentHash, parent)
+		}
+		for _, kind := range []string{freezerBodiesTable, freezerReceiptTable, freezerDifficultyTable} {
+			if has, err := db.HasAncient(kind, number); !has || err != nil {
+				return fmt.Errorf("block #%d: missing ancient %s: %v", number, kind, err)
+			}
+		}
+		if mapped := ReadHeaderNumber(db, hash); mapped == nil || *mapped != number {
+			return fmt.Errorf("block #%d: hash %x not mapped to its number by the key-value store", number, hash)
+		}
+		parent = hash
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Checking ancients", "number", number, "frozen", frozen, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	// The key-value store must continue where the freezer left off
+	if blob, _ := db.Get(headerHashKey(frozen)); len(blob) > 0 {
+		header := ReadHeader(db, common.BytesToHash(blob), frozen)
+		if header == nil {
+			return fmt.Errorf("block #%d: canonical header missing from key-value store", frozen)
+		}
+		if header.ParentHash != parent {
+			return fmt.Errorf("block #%d: key-value store does not extend the freezer: parent %x, frozen head %x", frozen, header.ParentHash, parent)
+		}
+	} else if head := ReadHeaderNumber(db, ReadHeadHeaderHash(db)); head != nil && *head >= frozen {
+		return fmt.Errorf("gap (chaindb=#%d frozen=#%d) in the chain between ancients and leveldb", *head, frozen)
+	}
+	log.Info("Checked ancients", "frozen", frozen, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
 func truncateKVtoFreezer(freezerdb *freezer, db ethdb.KeyValueStore) {
 	hhh := ReadHeadHeaderHash(db)
 	n := *ReadHeaderNumber(db, hhh)
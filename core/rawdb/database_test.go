@@ -0,0 +1,72 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build (arm64 || amd64) && !openbsd
+// +build arm64 amd64
+// +build !openbsd
+
+package rawdb
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// Tests that the engine of a database is detected when reopening it, and that
+// it's never opened with the other engine.
+func TestKeyValueStoreEngine(t *testing.T) {
+	for _, engine := range []string{DBLeveldb, DBPebble} {
+		other := DBPebble
+		if engine == DBPebble {
+			other = DBLeveldb
+		}
+		t.Run(engine, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "rawdb-engine-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			if have := PreexistingDatabase(dir); have != "" {
+				t.Fatalf("engine of empty directory: have %q, want none", have)
+			}
+			db, err := NewKeyValueStore(engine, dir, 0, 0, "")
+			if err != nil {
+				t.Fatalf("failed to create database: %v", err)
+			}
+			if err := db.Put([]byte("key"), []byte("value")); err != nil {
+				t.Fatal(err)
+			}
+			db.Close()
+
+			if have := PreexistingDatabase(dir); have != engine {
+				t.Fatalf("engine of existing database: have %q, want %q", have, engine)
+			}
+			if _, err := NewKeyValueStore(other, dir, 0, 0, ""); err == nil {
+				t.Fatalf("opened %s database with %s", engine, other)
+			}
+			db, err = NewKeyValueStore("", dir, 0, 0, "")
+			if err != nil {
+				t.Fatalf("failed to reopen database: %v", err)
+			}
+			defer db.Close()
+			if value, err := db.Get([]byte("key")); err != nil || string(value) != "value" {
+				t.Fatalf("value mismatch after reopen: have %q, %v", value, err)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@
 package rawdb
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -31,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params/vars"
+	"github.com/ethereum/go-ethereum/tracing"
 	"github.com/prometheus/tsdb/fileutil"
 )
 
@@ -87,9 +89,9 @@ type freezer struct {
 func newFreezer(datadir string, namespace string) (*freezer, error) {
 	// Create the initial freezer object
 	var (
-		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
-		writeMeter = metrics.NewRegisteredMeter(namespace+"ancient/write", nil)
-		sizeGauge  = metrics.NewRegisteredGauge(namespace+"ancient/size", nil)
+		readMeter  = metrics.NewRegisteredMeterVec(namespace+"ancient/read", []string{"table"}, nil)
+		writeMeter = metrics.NewRegisteredMeterVec(namespace+"ancient/write", []string{"table"}, nil)
+		sizeGauge  = metrics.NewRegisteredGaugeVec(namespace+"ancient/size", []string{"table"}, nil)
 	)
 	// Ensure the datadir is not a symbolic link if it exists.
 	if info, err := os.Lstat(datadir); !os.IsNotExist(err) {
@@ -113,7 +115,7 @@ func newFreezer(datadir string, namespace string) (*freezer, error) {
 		quit:         make(chan struct{}),
 	}
 	for name, disableSnappy := range freezerNoSnappy {
-		table, err := newTable(datadir, name, readMeter, writeMeter, sizeGauge, disableSnappy)
+		table, err := newTable(datadir, name, readMeter.With(name), writeMeter.With(name), sizeGauge.With(name), disableSnappy)
 		if err != nil {
 			for _, table := range freezer.tables {
 				table.Close()
@@ -336,6 +338,7 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 			first    = f.frozen
 			ancients = make([]common.Hash, 0, limit-f.frozen)
 		)
+		_, span := tracing.StartSpan(context.Background(), "rawdb.freeze", tracing.Uint64("first", first))
 		for f.frozen <= limit {
 			// Retrieves all the components of the canonical block
 			hash := ReadCanonicalHash(nfdb, f.frozen)
@@ -447,6 +450,9 @@ func (f *freezer) freeze(db ethdb.KeyValueStore) {
 		}
 		log.Info("Deep froze chain segment", context...)
 
+		span.SetAttributes(tracing.Uint64("blocks", f.frozen-first))
+		span.End()
+
 		// Avoid database thrashing with tiny writes
 		if f.frozen-first < freezerBatchLimit {
 			backoff = true
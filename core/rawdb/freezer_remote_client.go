@@ -1,6 +1,11 @@
 package rawdb
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
 	"sync"
 	"time"
 
@@ -16,10 +21,14 @@ import (
 // that is responsible for managing an actual ancient store.
 type FreezerRemoteClient struct {
 	client    *rpc.Client
+	dialer    *freezerRemoteDialer // Reconnects to the freezer, nil if the client cannot be redialed
+	retry     time.Duration        // Time to keep retrying calls while the freezer is unreachable
+	lock      sync.Mutex           // Protects the client during reconnects
 	quit      chan struct{}
 	threshold uint64             // Number of recent blocks not to freeze (params.FullImmutabilityThreshold apart from tests)
 	trigger   chan chan struct{} // Manual blocking freeze trigger, test determinism
 	closeOnce sync.Once
+	version   uint64 // Protocol version negotiated with the server, batch methods are used from version 2
 }
 
 const (
@@ -31,39 +40,210 @@ const (
 	FreezerMethodAppendAncient    = "freezer_appendAncient"
 	FreezerMethodTruncateAncients = "freezer_truncateAncients"
 	FreezerMethodSync             = "freezer_sync"
+
+	// Batch methods, available from protocol version 2.
+	FreezerMethodProtocolVersion = "freezer_protocolVersion"
+	FreezerMethodAppendAncients  = "freezer_appendAncients"
+	FreezerMethodAncientRange    = "freezer_ancientRange"
 )
 
+// FreezerRemoteProtocolVersion is the latest version of the remote freezer protocol.
+// Version 1 servers implement the per-item methods only, and are not required to
+// implement freezer_protocolVersion. Version 2 adds freezer_appendAncients and
+// freezer_ancientRange.
+const FreezerRemoteProtocolVersion = 2
+
+// freezerRemoteBatchBytes is the maximum size of the blobs sent or requested in one
+// batch call, keeping requests below the RPC transports' message size limits.
+const freezerRemoteBatchBytes = 3 * 1024 * 1024
+
+// ancientBatchAppender is implemented by ancient stores which can append
+// a contiguous range of blocks at once.
+type ancientBatchAppender interface {
+	AppendAncients(number uint64, hashes, headers, bodies, receipts, tds [][]byte) error
+}
+
+// ancientRangeReader is implemented by ancient stores which can retrieve
+// a contiguous range of items at once.
+type ancientRangeReader interface {
+	AncientRange(kind string, number, count uint64) ([][]byte, error)
+}
+
+// readAncientRange retrieves up to count consecutive items of a kind starting at
+// number, in one call if the ancient store supports it and item by item otherwise.
+// Fewer items are returned if the range runs past the frozen items.
+func readAncientRange(db ethdb.AncientReader, kind string, number, count uint64) ([][]byte, error) {
+	if fdb, ok := db.(*freezerdb); ok {
+		db = fdb.AncientStore
+	}
+	if r, ok := db.(ancientRangeReader); ok {
+		return r.AncientRange(kind, number, count)
+	}
+	items := make([][]byte, 0, count)
+	for i := uint64(0); i < count; i++ {
+		item, err := db.Ancient(kind, number+i)
+		if err != nil {
+			// Running out of items after the first is not an error
+			if len(items) > 0 {
+				break
+			}
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
 // newFreezerRemoteClient constructs a rpc client to connect to a remote freezer
-func newFreezerRemoteClient(endpoint string) (*FreezerRemoteClient, error) {
-	client, err := rpc.Dial(endpoint)
+func newFreezerRemoteClient(endpoint string, config *FreezerRemoteConfig) (*FreezerRemoteClient, error) {
+	if config == nil {
+		config = new(FreezerRemoteConfig)
+	}
+	dialer, err := newFreezerRemoteDialer(endpoint, config)
 	if err != nil {
 		return nil, err
 	}
-	return &FreezerRemoteClient{
-		client:    client,
+	api := &FreezerRemoteClient{
+		dialer:    dialer,
+		retry:     config.RetryTimeout,
 		threshold: vars.FullImmutabilityThreshold,
 		quit:      make(chan struct{}),
 		trigger:   make(chan chan struct{}),
-	}, nil
+	}
+	if err := api.negotiate(); err != nil {
+		return nil, err
+	}
+	return api, nil
+}
+
+// negotiate retrieves the protocol version of the server. Servers which don't
+// implement the version method are assumed to speak version 1.
+func (api *FreezerRemoteClient) negotiate() error {
+	var version uint64
+	if err := api.call(&version, FreezerMethodProtocolVersion); err != nil {
+		if _, ok := err.(rpc.Error); !ok {
+			return err
+		}
+		log.Debug("Remote freezer protocol version unavailable, using per-item methods", "err", err)
+		version = 1
+	}
+	if version > FreezerRemoteProtocolVersion {
+		version = FreezerRemoteProtocolVersion
+	}
+	api.version = version
+	log.Info("Negotiated remote freezer protocol", "version", version)
+	return nil
+}
+
+// conn returns the connection to the freezer, dialing it if necessary.
+func (api *FreezerRemoteClient) conn() (*rpc.Client, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	if api.client == nil {
+		client, err := api.dialer.dial()
+		if err != nil {
+			return nil, err
+		}
+		api.client = client
+		return client, nil
+	}
+	if api.dialer != nil {
+		if err := api.dialer.refresh(api.client); err != nil {
+			return nil, err
+		}
+	}
+	return api.client, nil
+}
+
+// drop closes a connection which failed, so that the next call redials.
+func (api *FreezerRemoteClient) drop(client *rpc.Client) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	if api.dialer != nil && api.client == client {
+		api.client.Close()
+		api.client = nil
+	}
+}
+
+// call performs a JSON-RPC call against the freezer. Calls failing to reach
+// the freezer are retried with an exponential backoff, reconnecting in between,
+// until the configured retry timeout passes. Any other error, such as those
+// returned by the freezer itself or rejected authorization, fails the call
+// straight away.
+func (api *FreezerRemoteClient) call(result interface{}, method string, args ...interface{}) error {
+	var (
+		deadline = time.Now().Add(api.retry)
+		delay    = freezerRemoteRetryMinDelay
+	)
+	for {
+		client, err := api.conn()
+		if err == nil {
+			if err = client.Call(result, method, args...); err == nil {
+				return nil
+			}
+			if !freezerRemoteRetryable(err) {
+				return err
+			}
+			api.drop(client)
+		}
+		if api.dialer == nil || !freezerRemoteRetryable(err) || time.Now().Add(delay).After(deadline) {
+			return err
+		}
+		log.Warn("Remote freezer unreachable, retrying", "method", method, "delay", delay, "err", err)
+		select {
+		case <-time.After(delay):
+		case <-api.quit:
+			return err
+		}
+		if delay *= 2; delay > freezerRemoteRetryMaxDelay {
+			delay = freezerRemoteRetryMaxDelay
+		}
+	}
+}
+
+// freezerRemoteRetryable tells if a failed call may succeed once the freezer is
+// reachable again, that is if connecting to it failed or the connection was lost.
+// Errors of the freezer, HTTP status, TLS and authorization errors are final.
+func freezerRemoteRetryable(err error) bool {
+	if _, ok := err.(rpc.Error); ok {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	// The HTTP client wraps all errors into url.Error, which is a net.Error itself
+	if uerr, ok := err.(*url.Error); ok {
+		err = uerr.Err
+	}
+	var operr *net.OpError
+	if errors.As(err, &operr) && operr.Op == "remote error" {
+		return false // TLS alert sent by the freezer
+	}
+	var nerr net.Error
+	return errors.As(err, &nerr)
 }
 
 // Close terminates the chain freezer, unmapping all the data files.
 func (api *FreezerRemoteClient) Close() error {
-	return api.client.Call(nil, FreezerMethodClose)
+	// Stop the background freezing and abort any calls being retried
+	api.closeOnce.Do(func() { close(api.quit) })
+	return api.call(nil, FreezerMethodClose)
 }
 
 // HasAncient returns an indicator whether the specified ancient data exists
 // in the freezer.
 func (api *FreezerRemoteClient) HasAncient(kind string, number uint64) (bool, error) {
 	var res bool
-	err := api.client.Call(&res, FreezerMethodHasAncient, kind, number)
+	err := api.call(&res, FreezerMethodHasAncient, kind, number)
 	return res, err
 }
 
 // Ancient retrieves an ancient binary blob from the append-only immutable files.
 func (api *FreezerRemoteClient) Ancient(kind string, number uint64) ([]byte, error) {
 	res := []byte{}
-	if err := api.client.Call(&res, FreezerMethodAncient, kind, number); err != nil {
+	if err := api.call(&res, FreezerMethodAncient, kind, number); err != nil {
 		return nil, err
 	}
 	return res, nil
@@ -72,14 +252,14 @@ func (api *FreezerRemoteClient) Ancient(kind string, number uint64) ([]byte, err
 // Ancients returns the length of the frozen items.
 func (api *FreezerRemoteClient) Ancients() (uint64, error) {
 	var res uint64
-	err := api.client.Call(&res, FreezerMethodAncients)
+	err := api.call(&res, FreezerMethodAncients)
 	return res, err
 }
 
 // AncientSize returns the ancient size of the specified category.
 func (api *FreezerRemoteClient) AncientSize(kind string) (uint64, error) {
 	var res uint64
-	err := api.client.Call(&res, FreezerMethodAncientSize, kind)
+	err := api.call(&res, FreezerMethodAncientSize, kind)
 	return res, err
 }
 
@@ -92,17 +272,85 @@ func (api *FreezerRemoteClient) AncientSize(kind string) (uint64, error) {
 //
 // Note that the frozen marker is updated outside of the service calls.
 func (api *FreezerRemoteClient) AppendAncient(number uint64, hash, header, body, receipts, td []byte) (err error) {
-	return api.client.Call(nil, FreezerMethodAppendAncient, number, hash, header, body, receipts, td)
+	return api.call(nil, FreezerMethodAppendAncient, number, hash, header, body, receipts, td)
+}
+
+// AppendAncients injects the binary blobs of a contiguous range of blocks, starting
+// at number, at the end of the append-only immutable table files.
+// Servers not supporting batches are sent the blocks one by one.
+func (api *FreezerRemoteClient) AppendAncients(number uint64, hashes, headers, bodies, receipts, tds [][]byte) error {
+	if len(headers) != len(hashes) || len(bodies) != len(hashes) || len(receipts) != len(hashes) || len(tds) != len(hashes) {
+		return errors.New("ancient field counts mismatch")
+	}
+	if api.version < 2 {
+		for i := range hashes {
+			if err := api.AppendAncient(number+uint64(i), hashes[i], headers[i], bodies[i], receipts[i], tds[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for start := 0; start < len(hashes); {
+		// Fill the request up to the size limit, sending at least one block
+		end, size := start+1, len(hashes[start])+len(headers[start])+len(bodies[start])+len(receipts[start])+len(tds[start])
+		for ; end < len(hashes); end++ {
+			next := len(hashes[end]) + len(headers[end]) + len(bodies[end]) + len(receipts[end]) + len(tds[end])
+			if size+next > freezerRemoteBatchBytes {
+				break
+			}
+			size += next
+		}
+		if err := api.call(nil, FreezerMethodAppendAncients, number+uint64(start),
+			hashes[start:end], headers[start:end], bodies[start:end], receipts[start:end], tds[start:end]); err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+// AncientRange retrieves count consecutive ancient blobs of the specified kind,
+// starting at number. Fewer items are returned if the range runs past the
+// frozen items.
+func (api *FreezerRemoteClient) AncientRange(kind string, number, count uint64) ([][]byte, error) {
+	var res [][]byte
+	for uint64(len(res)) < count {
+		var (
+			next  = number + uint64(len(res))
+			items [][]byte
+			err   error
+		)
+		if api.version < 2 {
+			var item []byte
+			if item, err = api.Ancient(kind, next); err == nil {
+				items = [][]byte{item}
+			}
+		} else {
+			err = api.call(&items, FreezerMethodAncientRange, kind, next, count-uint64(len(res)), uint64(freezerRemoteBatchBytes))
+		}
+		if err != nil {
+			// Running out of items after the first is not an error
+			if len(res) > 0 {
+				break
+			}
+			return nil, err
+		}
+		if len(items) == 0 {
+			break
+		}
+		res = append(res, items...)
+	}
+	return res, nil
 }
 
 // TruncateAncients discards any recent data above the provided threshold number.
 func (api *FreezerRemoteClient) TruncateAncients(items uint64) error {
-	return api.client.Call(nil, FreezerMethodTruncateAncients, items)
+	return api.call(nil, FreezerMethodTruncateAncients, items)
 }
 
 // Sync flushes all data tables to disk.
 func (api *FreezerRemoteClient) Sync() error {
-	return api.client.Call(nil, FreezerMethodSync)
+	return api.call(nil, FreezerMethodSync)
 }
 
 // freezeRemote is a background thread that periodically checks the blockchain for any
@@ -191,40 +439,58 @@ func freezeRemote(db ethdb.KeyValueStore, f ethdb.AncientStore, threshold uint64
 			start    = time.Now()
 			first    = numFrozen
 			ancients = make([]common.Hash, 0, limit-numFrozen)
+			pending  = &ancientBatch{number: first}
 		)
-		for numFrozen <= limit {
+		for number := first; number <= limit; number++ {
 			// Retrieves all the components of the canonical block
-			hash := ReadCanonicalHash(nfdb, numFrozen)
+			hash := ReadCanonicalHash(nfdb, number)
 			if hash == (common.Hash{}) {
-				log.Error("Canonical hash missing, can't freeze", "number", numFrozen)
+				log.Error("Canonical hash missing, can't freeze", "number", number)
 				break
 			}
-			header := ReadHeaderRLP(nfdb, hash, numFrozen)
+			header := ReadHeaderRLP(nfdb, hash, number)
 			if len(header) == 0 {
-				log.Error("Block header missing, can't freeze", "number", numFrozen, "hash", hash)
+				log.Error("Block header missing, can't freeze", "number", number, "hash", hash)
 				break
 			}
-			body := ReadBodyRLP(nfdb, hash, numFrozen)
+			body := ReadBodyRLP(nfdb, hash, number)
 			if len(body) == 0 {
-				log.Error("Block body missing, can't freeze", "number", numFrozen, "hash", hash)
+				log.Error("Block body missing, can't freeze", "number", number, "hash", hash)
 				break
 			}
-			receipts := ReadReceiptsRLP(nfdb, hash, numFrozen)
+			receipts := ReadReceiptsRLP(nfdb, hash, number)
 			if len(receipts) == 0 {
-				log.Error("Block receipts missing, can't freeze", "number", numFrozen, "hash", hash)
+				log.Error("Block receipts missing, can't freeze", "number", number, "hash", hash)
 				break
 			}
-			td := ReadTdRLP(nfdb, hash, numFrozen)
+			td := ReadTdRLP(nfdb, hash, number)
 			if len(td) == 0 {
-				log.Error("Total difficulty missing, can't freeze", "number", numFrozen, "hash", hash)
-				break
-			}
-			log.Trace("Deep froze ancient block", "number", numFrozen, "hash", hash)
-			// Inject all the components into the relevant data tables
-			if err := f.AppendAncient(numFrozen, hash[:], header, body, receipts, td); err != nil {
+				log.Error("Total difficulty missing, can't freeze", "number", number, "hash", hash)
 				break
 			}
+			log.Trace("Deep froze ancient block", "number", number, "hash", hash)
 			ancients = append(ancients, hash)
+			pending.add(hash, header, body, receipts, td)
+
+			// Inject all the components into the relevant data tables, in batches if supported
+			if pending.size >= freezerRemoteBatchBytes {
+				if err = pending.flush(f); err != nil {
+					break
+				}
+			}
+		}
+		if err == nil {
+			err = pending.flush(f)
+		}
+		if err != nil {
+			log.Error("Failed to freeze ancient blocks", "number", pending.number, "err", err)
+		}
+		// Only wipe the blocks which made it into the freezer
+		if numFrozen, err = f.Ancients(); err != nil {
+			log.Crit("ancient db freeze", "error", err)
+		}
+		if frozen := numFrozen - first; numFrozen >= first && frozen < uint64(len(ancients)) {
+			ancients = ancients[:frozen]
 		}
 		// Batch of blocks have been frozen, flush them before wiping from leveldb
 		if err := f.Sync(); err != nil {
@@ -309,3 +575,41 @@ func freezeRemote(db ethdb.KeyValueStore, f ethdb.AncientStore, threshold uint64
 		}
 	}
 }
+
+// ancientBatch accumulates the blobs of a contiguous range of blocks to freeze.
+type ancientBatch struct {
+	number                                 uint64 // Number of the first block
+	hashes, headers, bodies, receipts, tds [][]byte
+	size                                   int
+}
+
+// add appends the blobs of the next block to the batch.
+func (b *ancientBatch) add(hash common.Hash, header, body, receipts, td []byte) {
+	b.hashes = append(b.hashes, hash.Bytes())
+	b.headers = append(b.headers, header)
+	b.bodies = append(b.bodies, body)
+	b.receipts = append(b.receipts, receipts)
+	b.tds = append(b.tds, td)
+	b.size += common.HashLength + len(header) + len(body) + len(receipts) + len(td)
+}
+
+// flush appends the batch to an ancient store, in one call if the store supports
+// it and block by block otherwise, and resets the batch to start after it.
+func (b *ancientBatch) flush(f ethdb.AncientStore) error {
+	if len(b.hashes) == 0 {
+		return nil
+	}
+	if a, ok := f.(ancientBatchAppender); ok {
+		if err := a.AppendAncients(b.number, b.hashes, b.headers, b.bodies, b.receipts, b.tds); err != nil {
+			return err
+		}
+	} else {
+		for i := range b.hashes {
+			if err := f.AppendAncient(b.number+uint64(i), b.hashes[i], b.headers[i], b.bodies[i], b.receipts[i], b.tds[i]); err != nil {
+				return err
+			}
+		}
+	}
+	*b = ancientBatch{number: b.number + uint64(len(b.hashes))}
+	return nil
+}
@@ -2,9 +2,25 @@ package rawdb
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/ancient-store-mem/lib"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -96,3 +112,254 @@ func TestClient1(t *testing.T) {
 		t.Fatalf("got: %d, want: 670", n)
 	}
 }
+
+// legacyFreezerServer is a remote freezer server predating the batch methods
+// and protocol versioning.
+type legacyFreezerServer struct {
+	mem *lib.MemFreezerRemoteServerAPI
+}
+
+func (s *legacyFreezerServer) Ancient(kind string, number uint64) ([]byte, error) {
+	return s.mem.Ancient(kind, number)
+}
+
+func (s *legacyFreezerServer) Ancients() (uint64, error) {
+	return s.mem.Ancients()
+}
+
+func (s *legacyFreezerServer) AppendAncient(number uint64, hash, header, body, receipt, td []byte) error {
+	return s.mem.AppendAncient(number, hash, header, body, receipt, td)
+}
+
+func TestClientBatch(t *testing.T) {
+	legacy := rpc.NewServer()
+	if err := legacy.RegisterName("freezer", &legacyFreezerServer{lib.NewMemFreezerRemoteServerAPI()}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		server  *rpc.Server
+		version uint64
+	}{
+		{newTestServer(t), FreezerRemoteProtocolVersion},
+		{legacy, 1},
+	} {
+		frClient := &FreezerRemoteClient{
+			client: rpc.DialInProc(c.server),
+			quit:   make(chan struct{}),
+		}
+		if err := frClient.negotiate(); err != nil {
+			t.Fatal(err)
+		}
+		if frClient.version != c.version {
+			t.Fatalf("protocol version mismatch: have %d, want %d", frClient.version, c.version)
+		}
+
+		// Append blocks large enough to be split over several batches
+		var hashes, headers, bodies, receipts, tds [][]byte
+		for i := 0; i < 8; i++ {
+			hashes = append(hashes, []byte{byte(i)})
+			headers = append(headers, []byte{byte(i)})
+			bodies = append(bodies, bytes.Repeat([]byte{byte(i)}, freezerRemoteBatchBytes/3))
+			receipts = append(receipts, []byte{byte(i)})
+			tds = append(tds, []byte{byte(i)})
+		}
+		if err := frClient.AppendAncients(0, hashes, headers, bodies, receipts, tds); err != nil {
+			t.Fatalf("version %d: append ancients: %v", c.version, err)
+		}
+		if err := frClient.AppendAncients(0, hashes[:1], headers[:1], bodies[:1], receipts[:1], tds[:1]); err == nil {
+			t.Fatalf("version %d: out of order append succeeded", c.version)
+		}
+		if n, err := frClient.Ancients(); err != nil || n != 8 {
+			t.Fatalf("version %d: ancients: have %d %v, want 8", c.version, n, err)
+		}
+
+		// Read ranges spanning several batches, and running past the frozen items
+		have, err := frClient.AncientRange(FreezerRemoteBodiesTable, 2, 10)
+		if err != nil {
+			t.Fatalf("version %d: ancient range: %v", c.version, err)
+		}
+		if len(have) != 6 {
+			t.Fatalf("version %d: ancient range length mismatch: have %d, want 6", c.version, len(have))
+		}
+		for i, v := range have {
+			if !bytes.Equal(v, bodies[i+2]) {
+				t.Fatalf("version %d: ancient range item %d mismatch", c.version, i)
+			}
+		}
+		if _, err := frClient.AncientRange(FreezerRemoteBodiesTable, 8, 1); err == nil {
+			t.Fatalf("version %d: out of bounds range succeeded", c.version)
+		}
+	}
+}
+
+// Tests that the database is reinitialized from remote freezers reading the
+// frozen hashes in ranges, and from legacy ones reading them one by one.
+func TestClientInitDatabase(t *testing.T) {
+	legacy := rpc.NewServer()
+	if err := legacy.RegisterName("freezer", &legacyFreezerServer{lib.NewMemFreezerRemoteServerAPI()}); err != nil {
+		t.Fatal(err)
+	}
+	for _, server := range []*rpc.Server{newTestServer(t), legacy} {
+		frClient := &FreezerRemoteClient{
+			client: rpc.DialInProc(server),
+			quit:   make(chan struct{}),
+		}
+		if err := frClient.negotiate(); err != nil {
+			t.Fatal(err)
+		}
+		// Freeze more blocks than read in one range
+		frozen := 2*freezerInitRange + 10
+		var hashes, blobs [][]byte
+		for i := 0; i < frozen; i++ {
+			hashes = append(hashes, common.BigToHash(big.NewInt(int64(i+1))).Bytes())
+			blobs = append(blobs, []byte{byte(i)})
+		}
+		if err := frClient.AppendAncients(0, hashes, blobs, blobs, blobs, blobs); err != nil {
+			t.Fatalf("version %d: append ancients: %v", frClient.version, err)
+		}
+		db := &freezerdb{KeyValueStore: memorydb.New(), AncientStore: frClient}
+		InitDatabaseFromFreezer(db)
+
+		for i, hash := range hashes {
+			if number := ReadHeaderNumber(db, common.BytesToHash(hash)); number == nil || *number != uint64(i) {
+				t.Fatalf("version %d: block %d number mismatch: have %v", frClient.version, i, number)
+			}
+		}
+		if head := ReadHeadHeaderHash(db); head != common.BytesToHash(hashes[frozen-1]) {
+			t.Fatalf("version %d: head header mismatch: have %x, want %x", frClient.version, head, hashes[frozen-1])
+		}
+	}
+}
+
+func TestClientAuthorization(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x42}, 32)
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secretFile := filepath.Join(dir, "jwtsecret")
+	if err := ioutil.WriteFile(secretFile, []byte(hex.EncodeToString(secret)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		config *FreezerRemoteConfig
+		check  func(auth string) error
+	}{
+		{
+			config: &FreezerRemoteConfig{BearerToken: "secret"},
+			check: func(auth string) error {
+				if auth != "Bearer secret" {
+					return fmt.Errorf("unexpected authorization %q", auth)
+				}
+				return nil
+			},
+		},
+		{
+			config: &FreezerRemoteConfig{JWTSecret: secretFile},
+			check: func(auth string) error {
+				parts := strings.Split(strings.TrimPrefix(auth, "Bearer "), ".")
+				if len(parts) != 3 {
+					return fmt.Errorf("malformed token %q", auth)
+				}
+				mac := hmac.New(sha256.New, secret)
+				mac.Write([]byte(parts[0] + "." + parts[1]))
+				if sig, _ := base64.RawURLEncoding.DecodeString(parts[2]); !hmac.Equal(sig, mac.Sum(nil)) {
+					return fmt.Errorf("invalid token signature %q", auth)
+				}
+				return nil
+			},
+		},
+	}
+	for i, tt := range tests {
+		var checkErr error
+		server := newTestServer(t)
+		httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := tt.check(r.Header.Get("Authorization")); err != nil {
+				checkErr = err
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			server.ServeHTTP(w, r)
+		}))
+		frClient, err := newFreezerRemoteClient(httpsrv.URL, tt.config)
+		if err != nil {
+			t.Fatalf("test %d: dial: %v", i, err)
+		}
+		if frClient.version != FreezerRemoteProtocolVersion {
+			t.Fatalf("test %d: protocol version mismatch: have %d, want %d", i, frClient.version, FreezerRemoteProtocolVersion)
+		}
+		if _, err := frClient.Ancients(); err != nil {
+			t.Fatalf("test %d: ancients: %v", i, err)
+		}
+		if checkErr != nil {
+			t.Fatalf("test %d: %v", i, checkErr)
+		}
+		httpsrv.Close()
+	}
+	// Authorization must not be sent over IPC
+	if _, err := newFreezerRemoteClient("/tmp/freezer.ipc", &FreezerRemoteConfig{BearerToken: "secret"}); err != errFreezerRemoteInsecure {
+		t.Fatalf("authorized IPC connection error mismatch: have %v, want %v", err, errFreezerRemoteInsecure)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	var (
+		server   = newTestServer(t)
+		failures = int32(2)
+	)
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Drop the connections while the freezer is "restarting"
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer httpsrv.Close()
+
+	frClient, err := newFreezerRemoteClient(httpsrv.URL, &FreezerRemoteConfig{RetryTimeout: time.Minute})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	if frClient.version != FreezerRemoteProtocolVersion {
+		t.Fatalf("protocol version mismatch: have %d, want %d", frClient.version, FreezerRemoteProtocolVersion)
+	}
+	// Errors of the freezer itself must not be retried
+	atomic.StoreInt32(&failures, 0)
+	if _, err := frClient.Ancient(FreezerRemoteHashTable, 0); err == nil {
+		t.Fatal("missing ancient retrieved")
+	}
+	// Calls fail straight away without a retry timeout
+	frClient.retry = 0
+	atomic.StoreInt32(&failures, 1)
+	if _, err := frClient.Ancients(); err == nil {
+		t.Fatal("unreachable freezer call succeeded")
+	}
+}
+
+// Tests that calls rejected by the freezer endpoint, rather than failing to reach
+// it, are not retried.
+func TestClientNoRetry(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound} {
+		var requests int32
+		httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			http.Error(w, http.StatusText(status), status)
+		}))
+		start := time.Now()
+		if _, err := newFreezerRemoteClient(httpsrv.URL, &FreezerRemoteConfig{RetryTimeout: time.Minute}); err == nil {
+			t.Errorf("status %d: dial succeeded", status)
+		}
+		if elapsed := time.Since(start); elapsed >= freezerRemoteRetryMinDelay {
+			t.Errorf("status %d: dial failure took %v, retried", status, elapsed)
+		}
+		if n := atomic.LoadInt32(&requests); n != 1 {
+			t.Errorf("status %d: request count mismatch: have %d, want 1", status, n)
+		}
+		httpsrv.Close()
+	}
+}
@@ -0,0 +1,164 @@
+package rawdb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
+)
+
+// FreezerRemoteConfig contains the connection settings of a remote freezer.
+type FreezerRemoteConfig struct {
+	TLSCert      string        `toml:",omitempty"` // PEM encoded client certificate file
+	TLSKey       string        `toml:",omitempty"` // PEM encoded client certificate key file
+	TLSCA        string        `toml:",omitempty"` // PEM encoded CA certificates verifying the server, system CAs if empty
+	BearerToken  string        `toml:",omitempty"` // Static token sent as the bearer authorization
+	JWTSecret    string        `toml:",omitempty"` // File containing a hex encoded HS256 secret to sign bearer tokens with
+	RetryTimeout time.Duration `toml:",omitempty"` // Time to keep retrying calls while the freezer is unreachable, 0 to fail immediately
+}
+
+// DefaultFreezerRemoteConfig contains the default remote freezer connection settings.
+var DefaultFreezerRemoteConfig = FreezerRemoteConfig{
+	RetryTimeout: 5 * time.Minute,
+}
+
+const (
+	freezerRemoteRetryMinDelay = 500 * time.Millisecond // Delay before the first retry of a failed call
+	freezerRemoteRetryMaxDelay = 30 * time.Second       // Maximum delay between retries of a failed call
+	freezerRemoteDialTimeout   = 10 * time.Second       // Timeout of connection attempts
+)
+
+var errFreezerRemoteInsecure = errors.New("remote freezer TLS and authorization require an http(s) or ws(s) endpoint")
+
+// freezerRemoteDialer connects to a remote freezer with the configured
+// transport security and authorization.
+type freezerRemoteDialer struct {
+	endpoint  string
+	tls       *tls.Config // Client TLS configuration, nil if not configured
+	token     string      // Static bearer token
+	jwtSecret []byte      // Secret to sign bearer tokens with, nil if not configured
+}
+
+// newFreezerRemoteDialer validates the connection settings and loads the
+// certificates and secrets they reference.
+func newFreezerRemoteDialer(endpoint string, config *FreezerRemoteConfig) (*freezerRemoteDialer, error) {
+	d := &freezerRemoteDialer{endpoint: endpoint, token: config.BearerToken}
+	if config.TLSCert != "" || config.TLSKey != "" || config.TLSCA != "" {
+		d.tls = new(tls.Config)
+		if config.TLSCert != "" || config.TLSKey != "" {
+			cert, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load remote freezer client certificate: %v", err)
+			}
+			d.tls.Certificates = []tls.Certificate{cert}
+		}
+		if config.TLSCA != "" {
+			pem, err := ioutil.ReadFile(config.TLSCA)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read remote freezer CA certificates: %v", err)
+			}
+			d.tls.RootCAs = x509.NewCertPool()
+			if !d.tls.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", config.TLSCA)
+			}
+		}
+	}
+	if config.JWTSecret != "" {
+		if config.BearerToken != "" {
+			return nil, errors.New("remote freezer bearer token and JWT secret are mutually exclusive")
+		}
+		data, err := ioutil.ReadFile(config.JWTSecret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read remote freezer JWT secret: %v", err)
+		}
+		secret := common.FromHex(strings.TrimSpace(string(data)))
+		if len(secret) != 32 {
+			return nil, fmt.Errorf("invalid remote freezer JWT secret in %s, want 32 hex encoded bytes", config.JWTSecret)
+		}
+		d.jwtSecret = secret
+	}
+	if d.tls != nil || d.token != "" || d.jwtSecret != nil {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "ws" && u.Scheme != "wss") {
+			return nil, errFreezerRemoteInsecure
+		}
+	}
+	return d, nil
+}
+
+// authorization returns the value of the Authorization header, signing a fresh
+// token if a JWT secret is configured.
+func (d *freezerRemoteDialer) authorization() (string, error) {
+	switch {
+	case d.jwtSecret != nil:
+		token, err := rpc.NewHS256JWT(d.jwtSecret, nil)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	case d.token != "":
+		return "Bearer " + d.token, nil
+	}
+	return "", nil
+}
+
+// dial connects to the remote freezer.
+func (d *freezerRemoteDialer) dial() (*rpc.Client, error) {
+	if d.tls == nil && d.token == "" && d.jwtSecret == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), freezerRemoteDialTimeout)
+		defer cancel()
+		return rpc.DialContext(ctx, d.endpoint)
+	}
+	auth, err := d.authorization()
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(d.endpoint, "ws") {
+		header := make(http.Header)
+		if auth != "" {
+			header.Set("Authorization", auth)
+		}
+		dialer := websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: freezerRemoteDialTimeout,
+			TLSClientConfig:  d.tls,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), freezerRemoteDialTimeout)
+		defer cancel()
+		return rpc.DialWebsocketWithHeader(ctx, d.endpoint, "", dialer, header)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = d.tls
+	client, err := rpc.DialHTTPWithClient(d.endpoint, &http.Client{Transport: transport})
+	if err != nil {
+		return nil, err
+	}
+	if auth != "" {
+		client.SetHeader("Authorization", auth)
+	}
+	return client, nil
+}
+
+// refresh re-signs the authorization of HTTP connections, which is sent with
+// every request. Websocket connections are only authorized when established.
+func (d *freezerRemoteDialer) refresh(client *rpc.Client) error {
+	if d.jwtSecret == nil || strings.HasPrefix(d.endpoint, "ws") {
+		return nil
+	}
+	auth, err := d.authorization()
+	if err != nil {
+		return err
+	}
+	client.SetHeader("Authorization", auth)
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 
@@ -97,7 +98,7 @@ type freezerTable struct {
 	headBytes  uint32        // Number of bytes written to the head file
 	readMeter  metrics.Meter // Meter for measuring the effective amount of data read
 	writeMeter metrics.Meter // Meter for measuring the effective amount of data written
-	sizeGauge  metrics.Gauge // Gauge for tracking the combined size of all freezer tables
+	sizeGauge  metrics.Gauge // Gauge for tracking the size of the table
 
 	logger log.Logger   // Logger with database path and table name ambedded
 	lock   sync.RWMutex // Mutex protecting the data file descriptors
@@ -653,3 +654,88 @@ func (t *freezerTable) printIndex() {
 	}
 	fmt.Printf("|-----------------|\n")
 }
+
+// dumpIndex writes the index entries of the items in [start, stop) to w. A
+// negative stop dumps the index up to the last item.
+func (t *freezerTable) dumpIndex(w io.Writer, start, stop int64) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	stat, err := t.index.Stat()
+	if err != nil {
+		fmt.Fprintf(w, "failed to stat index: %v\n", err)
+		return
+	}
+	dumpFreezerIndex(w, t.name, t.index, stat.Size(), start, stop)
+}
+
+// dumpFreezerIndex writes the entries of the items in [start, stop) of the
+// freezer table index of the given size to w, decoding them directly without
+// checking them against the data files. A negative stop dumps the index up to
+// the last item.
+func dumpFreezerIndex(w io.Writer, name string, index io.ReaderAt, size int64, start, stop int64) {
+	// Index zero holds the earliest file and the number of items deleted before it
+	buf := make([]byte, indexEntrySize)
+	if _, err := index.ReadAt(buf, 0); err != nil {
+		fmt.Fprintf(w, "failed to read index tail: %v\n", err)
+		return
+	}
+	var tail indexEntry
+	tail.unmarshalBinary(buf)
+
+	items := int64(tail.offset) + size/indexEntrySize - 1
+	if stop < 0 || stop > items {
+		stop = items
+	}
+	fmt.Fprintf(w, "table: %s, items: %d, tail file: %d, item offset: %d\n", name, items, tail.filenum, tail.offset)
+	fmt.Fprintf(w, "| number | fileno | offset |\n")
+	fmt.Fprintf(w, "|--------+--------+--------|\n")
+
+	for i := start; i < stop; i++ {
+		// Entry n of the index holds the end of item n-1, entry zero the tail marker
+		pos := (i - int64(tail.offset) + 1) * indexEntrySize
+		if pos <= 0 {
+			continue
+		}
+		if _, err := index.ReadAt(buf, pos); err != nil {
+			fmt.Fprintf(w, "| %6d | failed to read index: %v\n", i, err)
+			return
+		}
+		var entry indexEntry
+		entry.unmarshalBinary(buf)
+		fmt.Fprintf(w, "| %6d | %6d | %6d |\n", i, entry.filenum, entry.offset)
+	}
+}
+
+// InspectFreezerTable dumps the index entries of the items in [start, end) of
+// the given table of the freezer at ancient to stdout, without modifying it. A
+// negative end dumps the index up to the last item.
+func InspectFreezerTable(ancient string, table string, start, end int64) error {
+	noSnappy, ok := freezerNoSnappy[table]
+	if !ok {
+		var tables []string
+		for name := range freezerNoSnappy {
+			tables = append(tables, name)
+		}
+		sort.Strings(tables)
+		return fmt.Errorf("unknown freezer table %q, valid ones are %v", table, tables)
+	}
+	// Open the index read-only, opening the table would repair it, truncating
+	// the files of a freezer possibly in use by a running node.
+	idxName := fmt.Sprintf("%s.cidx", table)
+	if noSnappy {
+		idxName = fmt.Sprintf("%s.ridx", table)
+	}
+	index, err := os.Open(filepath.Join(ancient, idxName))
+	if err != nil {
+		return err
+	}
+	defer index.Close()
+
+	stat, err := index.Stat()
+	if err != nil {
+		return err
+	}
+	dumpFreezerIndex(os.Stdout, table, index, stat.Size(), start, end)
+	return nil
+}
@@ -630,6 +630,74 @@ func TestOffset(t *testing.T) {
 	checkPresent(1000000)
 }
 
+func TestFreezerDumpIndex(t *testing.T) {
+	t.Parallel()
+	fname := fmt.Sprintf("dumpindex-%d", rand.Uint64())
+
+	// Write 3 items of 10 bytes, in files of 20 bytes
+	f, err := newCustomTable(os.TempDir(), fname, metrics.NilMeter{}, metrics.NilMeter{}, metrics.NilGauge{}, 20, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for x := 0; x < 3; x++ {
+		f.Append(uint64(x), getChunk(10, x))
+	}
+	buf := new(bytes.Buffer)
+	f.dumpIndex(buf, 1, -1)
+
+	want := fmt.Sprintf(`table: %s, items: 3, tail file: 0, item offset: 0
+| number | fileno | offset |
+|--------+--------+--------|
+|      1 |      0 |     20 |
+|      2 |      1 |     10 |
+`, fname)
+	if buf.String() != want {
+		t.Fatalf("index dump mismatch:\nhave\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+// Tests that inspecting the index of a table doesn't repair it, leaving the
+// files of a table in use by another process intact.
+func TestFreezerInspectReadOnly(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("inspect-%d", rand.Uint64()))
+	defer os.RemoveAll(dir)
+
+	// Write 3 items of 10 bytes, then cut the data file short of the last one
+	f, err := newTable(dir, freezerHashTable, metrics.NilMeter{}, metrics.NilMeter{}, metrics.NilGauge{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 3; x++ {
+		f.Append(uint64(x), getChunk(10, x))
+	}
+	f.Close()
+
+	data := filepath.Join(dir, fmt.Sprintf("%s.0000.rdat", freezerHashTable))
+	if err := os.Truncate(data, 25); err != nil {
+		t.Fatal(err)
+	}
+	index := filepath.Join(dir, fmt.Sprintf("%s.ridx", freezerHashTable))
+	before, err := os.Stat(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := InspectFreezerTable(dir, freezerHashTable, 0, -1); err != nil {
+		t.Fatal(err)
+	}
+	if after, err := os.Stat(index); err != nil {
+		t.Fatal(err)
+	} else if after.Size() != before.Size() {
+		t.Errorf("index modified: size %d, want %d", after.Size(), before.Size())
+	}
+	if stat, err := os.Stat(data); err != nil {
+		t.Fatal(err)
+	} else if stat.Size() != 25 {
+		t.Errorf("data modified: size %d, want %d", stat.Size(), 25)
+	}
+}
+
 // TODO (?)
 // - test that if we remove several head-files, aswell as data last data-file,
 //   the index is truncated accordingly
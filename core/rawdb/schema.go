@@ -78,8 +78,9 @@ var (
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	codePrefix            = []byte("c") // codePrefix + code hash -> account code
 
-	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
-	ConfigPrefix   = []byte("ethereum-config-") // config prefix for the db
+	preimagePrefix      = []byte("secure-key-")        // preimagePrefix + hash -> preimage
+	rejectedReorgPrefix = []byte("finality-rejected-") // rejectedReorgPrefix + time (uint64 big endian) + num (uint64 big endian) + hash -> rejected reorg
+	ConfigPrefix        = []byte("ethereum-config-")   // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
@@ -196,6 +197,11 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// rejectedReorgKey = rejectedReorgPrefix + time (uint64 big endian) + num (uint64 big endian) + hash
+func rejectedReorgKey(time uint64, number uint64, hash common.Hash) []byte {
+	return append(append(append(rejectedReorgPrefix, encodeBlockNumber(time)...), encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // txLookupKey = txLookupPrefix + hash
 func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
@@ -0,0 +1,146 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// newPrunableChain creates an archive chain with snapshots enabled, changing the
+// state of a few accounts in every block, and returns its database and blocks.
+func newPrunableChain(t *testing.T, blocks int) (ethdb.Database, []*types.Block) {
+	alloc := make(genesisT.GenesisAlloc)
+	for i := 0; i < 100; i++ {
+		alloc[common.BigToAddress(big.NewInt(int64(i+1)))] = genesisT.GenesisAccount{Balance: big.NewInt(int64(i + 1))}
+	}
+	var (
+		gspec   = &genesisT.Genesis{Config: params.AllEthashProtocolChanges, Alloc: alloc}
+		db      = rawdb.NewMemoryDatabase()
+		genesis = core.MustCommitGenesis(db, gspec)
+		config  = &core.CacheConfig{
+			TrieCleanLimit:    256,
+			TrieDirtyLimit:    256,
+			TrieDirtyDisabled: true,
+			TrieTimeLimit:     5 * time.Minute,
+			SnapshotLimit:     256,
+			SnapshotWait:      true,
+		}
+	)
+	chain, err := core.NewBlockChain(db, config, params.AllEthashProtocolChanges, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	genDb := rawdb.NewMemoryDatabase()
+	core.MustCommitGenesis(genDb, gspec)
+	chainBlocks, _ := core.GenerateChain(params.AllEthashProtocolChanges, genesis, ethash.NewFaker(), genDb, blocks, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.BigToAddress(big.NewInt(int64(0x10000 + i))))
+	})
+	if _, err := chain.InsertChain(chainBlocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+
+	return db, append([]*types.Block{genesis}, chainBlocks...)
+}
+
+// hasState reports whether the entire state trie of a root is present.
+func hasState(db ethdb.Database, root common.Hash) bool {
+	tr, err := trie.New(root, trie.NewDatabase(db))
+	if err != nil {
+		return false
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+	}
+	return it.Error() == nil
+}
+
+func TestPruneState(t *testing.T) {
+	db, blocks := newPrunableChain(t, 10)
+
+	datadir, err := ioutil.TempDir("", "pruner-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary datadir: %v", err)
+	}
+	defer os.RemoveAll(datadir)
+
+	pruner, err := NewPruner(db, datadir, filepath.Join(datadir, "triecache"), 256)
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	target := blocks[len(blocks)-3].Root()
+	if err := pruner.Prune(target); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	// The target and the genesis state must be retained, the rest deleted
+	for i, block := range blocks {
+		want := i == 0 || block.Root() == target
+		if have := hasState(db, block.Root()); have != want {
+			t.Errorf("block %d: state presence mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	if _, err := state.New(target, state.NewDatabase(db), nil); err != nil {
+		t.Fatalf("failed to open pruned state: %v", err)
+	}
+	// The snapshot must be flattened into the target and the bloom removed
+	if root := rawdb.ReadSnapshotRoot(db); root != target {
+		t.Errorf("snapshot root mismatch: have %x, want %x", root, target)
+	}
+	if path, _, _ := findBloomFilter(datadir); path != "" {
+		t.Errorf("state bloom left behind: %s", path)
+	}
+	snaptree := snapshot.New(db, trie.NewDatabase(db), 256, target, false, false)
+	if err := snapshot.VerifyState(snaptree, target); err != nil {
+		t.Errorf("snapshot verification failed: %v", err)
+	}
+}
+
+func TestPruneStateDefaultTargetTooRecent(t *testing.T) {
+	db, _ := newPrunableChain(t, 10)
+
+	datadir, err := ioutil.TempDir("", "pruner-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary datadir: %v", err)
+	}
+	defer os.RemoveAll(datadir)
+
+	pruner, err := NewPruner(db, datadir, filepath.Join(datadir, "triecache"), 256)
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	if err := pruner.Prune(common.Hash{}); err == nil {
+		t.Fatalf("pruning succeeded without enough snapshot layers")
+	}
+}
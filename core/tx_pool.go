@@ -247,6 +247,8 @@ type TxPool struct {
 
 	eip2f    bool
 	eip2028f bool
+	eip2718f bool
+	eip2930f bool
 }
 
 type txpoolResetRequest struct {
@@ -264,7 +266,7 @@ func NewTxPool(config TxPoolConfig, chainconfig ctypes.ChainConfigurator, chain
 		config:          config,
 		chainconfig:     chainconfig,
 		chain:           chain,
-		signer:          types.NewEIP155Signer(chainconfig.GetChainID()),
+		signer:          types.LatestSigner(chainconfig),
 		pending:         make(map[common.Address]*txList),
 		queue:           make(map[common.Address]*txList),
 		beats:           make(map[common.Address]time.Time),
@@ -524,6 +526,14 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
+	// Accept only legacy transactions until the EIP-2718 typed envelope activates,
+	// and access list transactions only once EIP-2930 does too.
+	if !pool.eip2718f && tx.Type() != types.LegacyTxType {
+		return ErrTxTypeNotSupported
+	}
+	if !pool.eip2930f && tx.Type() == types.AccessListTxType {
+		return ErrTxTypeNotSupported
+	}
 	// Reject transactions over defined size to prevent DOS attacks
 	if uint64(tx.Size()) > txMaxSize {
 		return ErrOversizedData
@@ -557,7 +567,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrInsufficientFunds
 	}
 	// Ensure the transaction has more gas than the basic tx fee.
-	intrGas, err := IntrinsicGas(tx.Data(), tx.To() == nil, pool.eip2f, pool.eip2028f)
+	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, pool.eip2f, pool.eip2028f)
 	if err != nil {
 		return err
 	}
@@ -1196,6 +1206,8 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// Update all fork indicator by next pending block number.
 	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
 	pool.eip2028f = pool.chainconfig.IsEnabled(pool.chainconfig.GetEIP2028Transition, next)
+	pool.eip2718f = pool.chainconfig.IsEnabled(pool.chainconfig.GetEIP2718Transition, next)
+	pool.eip2930f = pool.chainconfig.IsEnabled(pool.chainconfig.GetEIP2930Transition, next)
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/coregeth"
 	"github.com/ethereum/go-ethereum/params/vars"
 	"github.com/ethereum/go-ethereum/trie"
 )
@@ -341,6 +342,50 @@ func TestTransactionNegativeValue(t *testing.T) {
 	}
 }
 
+// Tests that access list transactions are only accepted once both the EIP-2718
+// typed envelope and EIP-2930 are active.
+func TestTransactionTypedAcceptance(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	signer := types.NewEIP2930Signer(params.TestChainConfig.GetChainID())
+	tx, _ := types.SignNewTx(key, signer, &types.AccessListTx{
+		ChainID:  params.TestChainConfig.GetChainID(),
+		Gas:      100000,
+		GasPrice: big.NewInt(1),
+		To:       &common.Address{},
+		Value:    big.NewInt(100),
+	})
+	from, _ := types.Sender(signer, tx)
+
+	for i, tt := range []struct {
+		eip2718 *big.Int
+		eip2930 *big.Int
+		want    error
+	}{
+		{eip2718: big.NewInt(100), eip2930: big.NewInt(100), want: ErrTxTypeNotSupported},
+		{eip2718: big.NewInt(0), eip2930: big.NewInt(100), want: ErrTxTypeNotSupported},
+		{eip2718: big.NewInt(100), eip2930: big.NewInt(0), want: ErrTxTypeNotSupported},
+		{eip2718: big.NewInt(0), eip2930: big.NewInt(0), want: nil},
+	} {
+		config := &coregeth.CoreGethChainConfig{
+			ChainID:       params.TestChainConfig.GetChainID(),
+			EIP155Block:   big.NewInt(0),
+			EIP2718FBlock: tt.eip2718,
+			EIP2930FBlock: tt.eip2930,
+		}
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.AddBalance(from, big.NewInt(1000000))
+		blockchain := &testBlockChain{statedb, 10000000, new(event.Feed)}
+
+		pool := NewTxPool(testTxPoolConfig, config, blockchain)
+		if err := pool.AddRemote(tx); err != tt.want {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.want)
+		}
+		pool.Stop()
+	}
+}
+
 func TestTransactionChainFork(t *testing.T) {
 	t.Parallel()
 
@@ -108,6 +108,65 @@ func TestLegacyReceiptDecoding(t *testing.T) {
 	}
 }
 
+// Tests that typed receipts are encoded as EIP-2718 envelopes, both in their
+// binary form and wrapped into an RLP string, and that they decode back.
+func TestTypedReceiptEncodingDecoding(t *testing.T) {
+	receipt := &Receipt{
+		Type:              AccessListTxType,
+		Status:            ReceiptStatusSuccessful,
+		CumulativeGasUsed: 1,
+		Logs: []*Log{
+			{Address: common.BytesToAddress([]byte{0x11}), Topics: []common.Hash{{0x22}}, Data: []byte{0x33}},
+		},
+	}
+	receipt.Bloom = CreateBloom(Receipts{receipt})
+
+	payload, err := rlp.EncodeToBytes(&receiptRLP{receipt.statusEncoding(), receipt.CumulativeGasUsed, receipt.Bloom, receipt.Logs})
+	if err != nil {
+		t.Fatalf("failed to encode receipt payload: %v", err)
+	}
+	want := append([]byte{AccessListTxType}, payload...)
+
+	// Check the binary encoding
+	have, err := receipt.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal receipt: %v", err)
+	}
+	if !bytes.Equal(have, want) {
+		t.Fatalf("binary encoding mismatch: have %x, want %x", have, want)
+	}
+	if got := (Receipts{receipt}).GetRlp(0); !bytes.Equal(got, want) {
+		t.Fatalf("trie encoding mismatch: have %x, want %x", got, want)
+	}
+	var dec Receipt
+	if err := dec.UnmarshalBinary(have); err != nil {
+		t.Fatalf("failed to unmarshal receipt: %v", err)
+	}
+	if dec.Type != AccessListTxType || dec.Status != receipt.Status || dec.CumulativeGasUsed != receipt.CumulativeGasUsed || dec.Bloom != receipt.Bloom {
+		t.Fatalf("binary roundtrip mismatch: have %+v, want %+v", dec, receipt)
+	}
+	// Check the RLP encoding wraps the envelope into a string
+	enc, err := rlp.EncodeToBytes(receipt)
+	if err != nil {
+		t.Fatalf("failed to encode receipt: %v", err)
+	}
+	wrapped, _ := rlp.EncodeToBytes(want)
+	if !bytes.Equal(enc, wrapped) {
+		t.Fatalf("rlp encoding mismatch: have %x, want %x", enc, wrapped)
+	}
+	dec = Receipt{}
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("failed to decode receipt: %v", err)
+	}
+	if dec.Type != AccessListTxType || !reflect.DeepEqual(dec.Logs, receipt.Logs) {
+		t.Fatalf("rlp roundtrip mismatch: have %+v, want %+v", dec, receipt)
+	}
+	// Unknown receipt types are rejected
+	if err := dec.UnmarshalBinary(append([]byte{0x7f}, payload...)); err != ErrTxTypeNotSupported {
+		t.Fatalf("unexpected error for unknown receipt type: %v", err)
+	}
+}
+
 func encodeAsStoredReceiptRLP(want *Receipt) ([]byte, error) {
 	stored := &storedReceiptRLP{
 		PostStateOrStatus: want.statusEncoding(),
@@ -154,9 +213,17 @@ func encodeAsV3StoredReceiptRLP(want *Receipt) ([]byte, error) {
 // Tests that receipt data can be correctly derived from the contextual infos
 func TestDeriveFields(t *testing.T) {
 	// Create a few transactions to have receipts for
+	to3 := common.HexToAddress("0x3")
 	txs := Transactions{
 		NewContractCreation(1, big.NewInt(1), 1, big.NewInt(1), nil),
 		NewTransaction(2, common.HexToAddress("0x2"), big.NewInt(2), 2, big.NewInt(2), nil),
+		NewTx(&AccessListTx{
+			Nonce:    3,
+			To:       &to3,
+			Value:    big.NewInt(3),
+			Gas:      3,
+			GasPrice: big.NewInt(3),
+		}),
 	}
 	// Create the corresponding receipts
 	receipts := Receipts{
@@ -182,6 +249,18 @@ func TestDeriveFields(t *testing.T) {
 			ContractAddress: common.BytesToAddress([]byte{0x02, 0x22, 0x22}),
 			GasUsed:         2,
 		},
+		&Receipt{
+			Type:              AccessListTxType,
+			PostState:         common.Hash{3}.Bytes(),
+			CumulativeGasUsed: 6,
+			Logs: []*Log{
+				{Address: common.BytesToAddress([]byte{0x33})},
+				{Address: common.BytesToAddress([]byte{0x03, 0x33})},
+			},
+			TxHash:          txs[2].Hash(),
+			ContractAddress: common.BytesToAddress([]byte{0x03, 0x33, 0x33}),
+			GasUsed:         3,
+		},
 	}
 	// Clear all the computed fields and re-derive them
 	number := big.NewInt(1)
@@ -196,6 +275,9 @@ func TestDeriveFields(t *testing.T) {
 
 	logIndex := uint(0)
 	for i := range receipts {
+		if receipts[i].Type != txs[i].Type() {
+			t.Errorf("receipts[%d].Type = %d, want %d", i, receipts[i].Type, txs[i].Type())
+		}
 		if receipts[i].TxHash != txs[i].Hash() {
 			t.Errorf("receipts[%d].TxHash = %s, want %s", i, receipts[i].TxHash.String(), txs[i].Hash().String())
 		}
@@ -254,6 +336,7 @@ func clearComputedFieldsOnReceipts(t *testing.T, receipts Receipts) {
 func clearComputedFieldsOnReceipt(t *testing.T, receipt *Receipt) {
 	t.Helper()
 
+	receipt.Type = LegacyTxType
 	receipt.TxHash = common.Hash{}
 	receipt.BlockHash = common.Hash{}
 	receipt.BlockNumber = big.NewInt(math.MaxUint32)
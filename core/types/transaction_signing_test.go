@@ -22,6 +22,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params/types/coregeth"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -136,3 +137,38 @@ func TestChainId(t *testing.T) {
 		t.Error("expected no error")
 	}
 }
+
+// Tests that access list transactions are only accepted by the signer of a
+// block once both the EIP-2718 typed envelope and EIP-2930 are active.
+func TestMakeSignerAccessList(t *testing.T) {
+	key, _ := defaultTestKey()
+	tx, err := SignNewTx(key, NewEIP2930Signer(big.NewInt(1)), &AccessListTx{
+		ChainID:  big.NewInt(1),
+		Gas:      21000,
+		GasPrice: big.NewInt(1),
+		To:       &common.Address{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range []struct {
+		eip2718 *big.Int
+		eip2930 *big.Int
+		want    error
+	}{
+		{eip2718: big.NewInt(10), eip2930: big.NewInt(10), want: ErrTxTypeNotSupported},
+		{eip2718: big.NewInt(0), eip2930: big.NewInt(10), want: ErrTxTypeNotSupported},
+		{eip2718: big.NewInt(10), eip2930: big.NewInt(0), want: ErrTxTypeNotSupported},
+		{eip2718: big.NewInt(0), eip2930: big.NewInt(0), want: nil},
+	} {
+		config := &coregeth.CoreGethChainConfig{
+			ChainID:       big.NewInt(1),
+			EIP155Block:   big.NewInt(0),
+			EIP2718FBlock: tt.eip2718,
+			EIP2930FBlock: tt.eip2930,
+		}
+		if _, err := Sender(MakeSigner(config, big.NewInt(5)), tx); err != tt.want {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.want)
+		}
+	}
+}
@@ -96,6 +96,17 @@ func PrecompiledContractsForConfig(config ctypes.ChainConfigurator, bn *big.Int)
 	return precompileds
 }
 
+// ActivePrecompiles returns the addresses of the precompiled contracts enabled
+// at the given point in a chain config.
+func ActivePrecompiles(config ctypes.ChainConfigurator, bn *big.Int) []common.Address {
+	precompileds := PrecompiledContractsForConfig(config, bn)
+	addrs := make([]common.Address, 0, len(precompileds))
+	for addr := range precompileds {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 // It returns
 // - the returned bytes,
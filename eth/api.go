@@ -437,13 +437,13 @@ type storageEntry struct {
 }
 
 // StorageRangeAt returns the storage at the given block height and transaction index.
-func (api *PrivateDebugAPI) StorageRangeAt(blockHash common.Hash, txIndex int, contractAddress common.Address, keyStart hexutil.Bytes, maxResult int) (StorageRangeResult, error) {
+func (api *PrivateDebugAPI) StorageRangeAt(ctx context.Context, blockHash common.Hash, txIndex int, contractAddress common.Address, keyStart hexutil.Bytes, maxResult int) (StorageRangeResult, error) {
 	// Retrieve the block
 	block := api.eth.blockchain.GetBlockByHash(blockHash)
 	if block == nil {
 		return StorageRangeResult{}, fmt.Errorf("block %#x not found", blockHash)
 	}
-	_, _, statedb, err := api.computeTxEnv(block, txIndex, 0)
+	_, _, statedb, err := api.computeTxEnv(ctx, block, txIndex, 0)
 	if err != nil {
 		return StorageRangeResult{}, err
 	}
@@ -566,6 +566,22 @@ func (api *PrivateDebugAPI) RemovePendingTransaction(hash common.Hash) (*types.T
 	return api.eth.txPool.RemoveTx(hash), nil
 }
 
+// GetRejectedReorgs returns the most recent chain reorganizations rejected by
+// artificial finality, oldest first.
+func (api *PrivateDebugAPI) GetRejectedReorgs() ([]*rawdb.RejectedReorg, error) {
+	return rawdb.ReadRejectedReorgs(api.eth.ChainDb()), nil
+}
+
+// Ecbp1100Evaluate returns the ECBP1100 (MESS) arbitration of a candidate header
+// against the current chain, including the total difficulty ratio, the required
+// antigravity and the decision, without importing the header.
+func (api *PrivateDebugAPI) Ecbp1100Evaluate(header *types.Header) (*core.ECBP1100Result, error) {
+	if header == nil {
+		return nil, errors.New("missing header")
+	}
+	return api.eth.blockchain.EvaluateECBP1100(header)
+}
+
 // PrivateTraceAPI is the collection of Ethereum full node APIs exposed over
 // the private debugging endpoint.
 type PrivateTraceAPI struct {
@@ -42,6 +42,7 @@ import (
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/tracing"
 	"github.com/ethereum/go-ethereum/trie"
 )
 
@@ -469,7 +470,7 @@ func traceBlock(ctx context.Context, eth *Ethereum, block *types.Block, config *
 	if config != nil && config.Reexec != nil {
 		reexec = *config.Reexec
 	}
-	statedb, err := computeStateDB(eth, parent, reexec)
+	statedb, err := computeStateDB(ctx, eth, parent, reexec)
 	if err != nil {
 		return nil, err
 	}
@@ -568,7 +569,7 @@ func (api *PrivateDebugAPI) standardTraceBlockToFile(ctx context.Context, block
 	if config != nil && config.Reexec != nil {
 		reexec = *config.Reexec
 	}
-	statedb, err := computeStateDB(api.eth, parent, reexec)
+	statedb, err := computeStateDB(ctx, api.eth, parent, reexec)
 	if err != nil {
 		return nil, err
 	}
@@ -693,7 +694,13 @@ func containsTx(block *types.Block, hash common.Hash) bool {
 // computeStateDB retrieves the state database associated with a certain block.
 // If no state is locally available for the given block, a number of blocks are
 // attempted to be reexecuted to generate the desired state.
-func computeStateDB(eth *Ethereum, block *types.Block, reexec uint64) (*state.StateDB, error) {
+func computeStateDB(ctx context.Context, eth *Ethereum, block *types.Block, reexec uint64) (*state.StateDB, error) {
+	ctx, span := tracing.StartSpan(ctx, "eth.computeStateDB",
+		tracing.Uint64("block.number", block.NumberU64()),
+		tracing.Uint64("reexec", reexec),
+	)
+	defer span.End()
+
 	// If we have the state fully available, use that
 	statedb, err := eth.blockchain.StateAt(block.Root())
 	if err == nil {
@@ -722,9 +729,10 @@ func computeStateDB(eth *Ethereum, block *types.Block, reexec uint64) (*state.St
 	}
 	// State was available at historical point, regenerate
 	var (
-		start  = time.Now()
-		logged time.Time
-		proot  common.Hash
+		start     = time.Now()
+		logged    time.Time
+		proot     common.Hash
+		regenFrom = block.NumberU64()
 	)
 	for block.NumberU64() < origin {
 		// Print progress logs if long enough time elapsed
@@ -736,7 +744,10 @@ func computeStateDB(eth *Ethereum, block *types.Block, reexec uint64) (*state.St
 		if block = eth.blockchain.GetBlockByNumber(block.NumberU64() + 1); block == nil {
 			return nil, fmt.Errorf("block #%d not found", block.NumberU64()+1)
 		}
+		_, blockSpan := tracing.StartSpan(ctx, "eth.regenerateBlock", tracing.Uint64("block.number", block.NumberU64()))
 		_, _, _, err := eth.blockchain.Processor().Process(block, statedb, vm.Config{})
+		blockSpan.RecordError(err)
+		blockSpan.End()
 		if err != nil {
 			return nil, fmt.Errorf("processing block %d failed: %v", block.NumberU64(), err)
 		}
@@ -755,12 +766,13 @@ func computeStateDB(eth *Ethereum, block *types.Block, reexec uint64) (*state.St
 		proot = root
 	}
 	nodes, imgs := database.TrieDB().Size()
+	span.SetAttributes(tracing.Uint64("regenerated", origin-regenFrom))
 	log.Info("Historical state regenerated", "block", block.NumberU64(), "elapsed", time.Since(start), "nodes", nodes, "preimages", imgs)
 	return statedb, nil
 }
 
-func (api *PrivateDebugAPI) computeStateDB(block *types.Block, reexec uint64) (*state.StateDB, error) {
-	return computeStateDB(api.eth, block, reexec)
+func (api *PrivateDebugAPI) computeStateDB(ctx context.Context, block *types.Block, reexec uint64) (*state.StateDB, error) {
+	return computeStateDB(ctx, api.eth, block, reexec)
 }
 
 // traceTransaction returns the structured logs created during the execution of EVM
@@ -780,7 +792,7 @@ func traceTransaction(ctx context.Context, eth *Ethereum, hash common.Hash, conf
 	if block == nil {
 		return nil, fmt.Errorf("block %#x not found", blockHash)
 	}
-	msg, vmctx, statedb, err := computeTxEnv(eth, block, int(index), reexec)
+	msg, vmctx, statedb, err := computeTxEnv(ctx, eth, block, int(index), reexec)
 	if err != nil {
 		return nil, err
 	}
@@ -806,36 +818,46 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Ha
 // if the given transaction was added on top of the provided block and returns them as a JSON object.
 // You can provide -2 as a block number to trace on top of the pending block.
 func (api *PrivateDebugAPI) TraceCall(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceConfig) (interface{}, error) {
-	// First try to retrieve the state
-	statedb, header, err := api.eth.APIBackend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	statedb, header, err := stateAndHeaderAt(ctx, api.eth, blockNrOrHash, reexec)
 	if err != nil {
-		// Try to retrieve the specified block
-		var block *types.Block
-		if hash, ok := blockNrOrHash.Hash(); ok {
-			block = api.eth.blockchain.GetBlockByHash(hash)
-		} else if number, ok := blockNrOrHash.Number(); ok {
-			block = api.eth.blockchain.GetBlockByNumber(uint64(number))
-		}
-		if block == nil {
-			return nil, fmt.Errorf("block %v not found: %v", blockNrOrHash, err)
-		}
-		// try to recompute the state
-		reexec := defaultTraceReexec
-		if config != nil && config.Reexec != nil {
-			reexec = *config.Reexec
-		}
-		_, _, statedb, err = api.computeTxEnv(block, 0, reexec)
-		if err != nil {
-			return nil, err
-		}
+		return nil, err
 	}
-
 	// Execute the trace
 	msg := args.ToMessage(api.eth.APIBackend.RPCGasCap())
 	vmctx := core.NewEVMContext(msg, header, api.eth.blockchain, nil)
 	return api.traceTx(ctx, msg, vmctx, statedb, config)
 }
 
+// stateAndHeaderAt retrieves the state and header of the given block. If no state
+// is locally available for it, a number of blocks are attempted to be reexecuted
+// to generate it.
+func stateAndHeaderAt(ctx context.Context, eth *Ethereum, blockNrOrHash rpc.BlockNumberOrHash, reexec uint64) (*state.StateDB, *types.Header, error) {
+	// First try to retrieve the state
+	statedb, header, err := eth.APIBackend.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err == nil {
+		return statedb, header, nil
+	}
+	// Try to retrieve the specified block
+	var block *types.Block
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block = eth.blockchain.GetBlockByHash(hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		block = eth.blockchain.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, nil, fmt.Errorf("block %v not found: %v", blockNrOrHash, err)
+	}
+	// try to recompute the state
+	if statedb, err = computeStateDB(ctx, eth, block, reexec); err != nil {
+		return nil, nil, err
+	}
+	return statedb, block.Header(), nil
+}
+
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
@@ -854,20 +876,20 @@ func traceTx(ctx context.Context, eth *Ethereum, message core.Message, vmctx vm.
 				return nil, err
 			}
 		}
-		// Constuct the JavaScript tracer to execute with
-		if tracer, err = tracers.New(*config.Tracer); err != nil {
+		// Constuct the native or JavaScript tracer to execute with
+		if tracer, err = tracers.NewTxTracer(*config.Tracer); err != nil {
 			return nil, err
 		}
 		// Handle timeouts and RPC cancellations
 		deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 		go func() {
 			<-deadlineCtx.Done()
-			tracer.(*tracers.Tracer).Stop(errors.New("execution timeout"))
+			tracer.(tracers.TxTracer).Stop(errors.New("execution timeout"))
 		}()
 		defer cancel()
 
 		if extraContext != nil {
-			tracer.(*tracers.Tracer).CaptureExtraContext(extraContext)
+			tracer.(tracers.TxTracer).CaptureExtraContext(extraContext)
 		}
 
 	case config == nil:
@@ -898,7 +920,7 @@ func traceTx(ctx context.Context, eth *Ethereum, message core.Message, vmctx vm.
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
 		}, nil
 
-	case *tracers.Tracer:
+	case tracers.TxTracer:
 		return tracer.GetResult()
 
 	default:
@@ -914,13 +936,13 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 }
 
 // computeTxEnv returns the execution environment of a certain transaction.
-func computeTxEnv(eth *Ethereum, block *types.Block, txIndex int, reexec uint64) (core.Message, vm.Context, *state.StateDB, error) {
+func computeTxEnv(ctx context.Context, eth *Ethereum, block *types.Block, txIndex int, reexec uint64) (core.Message, vm.Context, *state.StateDB, error) {
 	// Create the parent state database
 	parent := eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
 	if parent == nil {
 		return nil, vm.Context{}, nil, fmt.Errorf("parent %#x not found", block.ParentHash())
 	}
-	statedb, err := computeStateDB(eth, parent, reexec)
+	statedb, err := computeStateDB(ctx, eth, parent, reexec)
 	if err != nil {
 		return nil, vm.Context{}, nil, err
 	}
@@ -951,6 +973,6 @@ func computeTxEnv(eth *Ethereum, block *types.Block, txIndex int, reexec uint64)
 	return nil, vm.Context{}, nil, fmt.Errorf("transaction index %d out of range for block %#x", txIndex, block.Hash())
 }
 
-func (api *PrivateDebugAPI) computeTxEnv(block *types.Block, txIndex int, reexec uint64) (core.Message, vm.Context, *state.StateDB, error) {
-	return computeTxEnv(api.eth, block, txIndex, reexec)
+func (api *PrivateDebugAPI) computeTxEnv(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (core.Message, vm.Context, *state.StateDB, error) {
+	return computeTxEnv(ctx, api.eth, block, txIndex, reexec)
 }
@@ -19,16 +19,40 @@ package eth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/native"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+const (
+	// traceTypeTrace selects the Parity call traces of a replayed transaction.
+	traceTypeTrace = "trace"
+
+	// traceTypeStateDiff selects the Parity state differences of a replayed transaction.
+	traceTypeStateDiff = "stateDiff"
+
+	// traceTypeVMTrace selects the Parity virtual machine trace of a replayed transaction.
+	traceTypeVMTrace = "vmTrace"
+)
+
+// replayOmittedFields are the fields of the callTracerParity output locating
+// a trace within the chain, which Parity leaves out of replayed traces.
+var replayOmittedFields = []string{"blockHash", "blockNumber", "transactionHash", "transactionPosition", "time"}
+
 // ParityTrace A trace in the desired format (Parity/OpenEtherum) See: https://Parity.github.io/wiki/JSONRPC-trace-module
 type ParityTrace struct {
 	Action              TraceRewardAction `json:"action"`
@@ -43,6 +67,16 @@ type ParityTrace struct {
 	Type                string            `json:"type"`
 }
 
+// TraceReplayResult is the Parity formatted result of replaying a transaction,
+// holding the output of the requested trace types.
+type TraceReplayResult struct {
+	Output          hexutil.Bytes    `json:"output"`
+	StateDiff       native.StateDiff `json:"stateDiff"`
+	Trace           []interface{}    `json:"trace"`
+	VMTrace         *native.VMTrace  `json:"vmTrace"`
+	TransactionHash *common.Hash     `json:"transactionHash,omitempty"`
+}
+
 // TraceRewardAction An Parity formatted trace reward action
 type TraceRewardAction struct {
 	Value      *hexutil.Big    `json:"value,omitempty"`
@@ -175,3 +209,260 @@ func (api *PrivateTraceAPI) Filter(ctx context.Context, args ethapi.CallArgs, co
 	// config = setConfigTracerToParity(config)
 	return nil, nil
 }
+
+// ReplayTransaction replays a transaction on top of the state it was originally
+// executed on, returning the requested Parity trace types ("trace", "stateDiff"
+// and/or "vmTrace").
+func (api *PrivateTraceAPI) ReplayTransaction(ctx context.Context, hash common.Hash, traceTypes []string, config *TraceConfig) (*TraceReplayResult, error) {
+	tx, blockHash, _, index := rawdb.ReadTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	block := api.eth.blockchain.GetBlockByHash(blockHash)
+	if block == nil {
+		return nil, fmt.Errorf("block %#x not found", blockHash)
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	msg, vmctx, statedb, err := computeTxEnv(ctx, api.eth, block, int(index), reexec)
+	if err != nil {
+		return nil, err
+	}
+	statedb.Prepare(hash, blockHash, int(index))
+
+	return replayTx(ctx, api.eth, msg, vmctx, statedb, traceTypes, config)
+}
+
+// ReplayBlockTransactions replays all the transactions of a block, returning the
+// requested Parity trace types for each of them.
+func (api *PrivateTraceAPI) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, traceTypes []string, config *TraceConfig) ([]*TraceReplayResult, error) {
+	var block *types.Block
+
+	switch number {
+	case rpc.PendingBlockNumber:
+		block = api.eth.miner.PendingBlock()
+	case rpc.LatestBlockNumber:
+		block = api.eth.blockchain.CurrentBlock()
+	default:
+		block = api.eth.blockchain.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	parent := api.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	statedb, err := computeStateDB(ctx, api.eth, parent, reexec)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		signer  = types.MakeSigner(api.eth.blockchain.Config(), block.Number())
+		txs     = block.Transactions()
+		results = make([]*TraceReplayResult, len(txs))
+	)
+	// Transactions are replayed sequentially, as every state diff is relative
+	// to the state left behind by the previous transaction
+	for i, tx := range txs {
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %#x failed: %v", tx.Hash(), err)
+		}
+		vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		res, err := replayTx(ctx, api.eth, msg, vmctx, statedb, traceTypes, config)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %#x failed: %v", tx.Hash(), err)
+		}
+		hash := tx.Hash()
+		res.TransactionHash = &hash
+		results[i] = res
+	}
+	return results, nil
+}
+
+// RawTransaction executes a signed, RLP encoded transaction on top of the latest
+// block without importing it, returning the requested Parity trace types.
+func (api *PrivateTraceAPI) RawTransaction(ctx context.Context, input hexutil.Bytes, traceTypes []string, config *TraceConfig) (*TraceReplayResult, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(input, tx); err != nil {
+		return nil, fmt.Errorf("could not decode transaction: %v", err)
+	}
+	statedb, header, err := api.eth.APIBackend.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := tx.AsMessage(types.MakeSigner(api.eth.blockchain.Config(), header.Number))
+	if err != nil {
+		return nil, err
+	}
+	vmctx := core.NewEVMContext(msg, header, api.eth.blockchain, nil)
+
+	return replayTx(ctx, api.eth, msg, vmctx, statedb, traceTypes, config)
+}
+
+// replayTx executes the given message in the provided environment, collecting the
+// requested Parity trace types. The state is finalised afterwards, so that
+// consecutive transactions can be replayed on top of each other.
+func replayTx(ctx context.Context, eth *Ethereum, message core.Message, vmctx vm.Context, statedb *state.StateDB, traceTypes []string, config *TraceConfig) (*TraceReplayResult, error) {
+	var (
+		callTracer      tracers.TxTracer
+		stateDiffTracer *native.StateDiffTracer
+		vmTracer        *native.VMTracer
+		mux             []vm.Tracer
+		err             error
+	)
+	for _, traceType := range traceTypes {
+		switch traceType {
+		case traceTypeTrace:
+			if callTracer == nil {
+				if callTracer, err = tracers.NewTxTracer("callTracerParity"); err != nil {
+					return nil, err
+				}
+				mux = append(mux, callTracer)
+			}
+		case traceTypeStateDiff:
+			if stateDiffTracer == nil {
+				stateDiffTracer = native.NewStateDiffTracer(statedb.Copy())
+				stateDiffTracer.TouchAccount(vmctx.Coinbase)
+				mux = append(mux, stateDiffTracer)
+			}
+		case traceTypeVMTrace:
+			if vmTracer == nil {
+				vmTracer = native.NewVMTracer()
+				mux = append(mux, vmTracer)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported trace type %q", traceType)
+		}
+	}
+	// Define a meaningful timeout of a single transaction replay
+	timeout := defaultTraceTimeout
+	if config != nil && config.Timeout != nil {
+		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
+			return nil, err
+		}
+	}
+	chainConfig := eth.blockchain.Config()
+	vmenv := vm.NewEVM(vmctx, statedb, chainConfig, vm.Config{Debug: len(mux) > 0, Tracer: native.NewMuxTracer(mux...)})
+
+	// Handle timeouts and RPC cancellations
+	deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
+	go func() {
+		<-deadlineCtx.Done()
+		vmenv.Cancel()
+	}()
+	defer cancel()
+
+	result, err := core.ApplyMessage(vmenv, message, new(core.GasPool).AddGas(message.Gas()))
+	if err != nil {
+		return nil, fmt.Errorf("tracing failed: %v", err)
+	}
+	if vmenv.Cancelled() {
+		return nil, errors.New("execution timeout")
+	}
+	statedb.Finalise(chainConfig.IsEnabled(chainConfig.GetEIP161dTransition, vmctx.BlockNumber))
+
+	res := &TraceReplayResult{
+		Output: result.ReturnData,
+		Trace:  []interface{}{},
+	}
+	if callTracer != nil {
+		raw, err := callTracer.GetResult()
+		if err != nil {
+			return nil, err
+		}
+		var traces []map[string]interface{}
+		if err := json.Unmarshal(raw, &traces); err != nil {
+			return nil, err
+		}
+		for _, trace := range traces {
+			for _, field := range replayOmittedFields {
+				delete(trace, field)
+			}
+			res.Trace = append(res.Trace, trace)
+		}
+	}
+	if stateDiffTracer != nil {
+		res.StateDiff = stateDiffTracer.GetResult(statedb)
+	}
+	if vmTracer != nil {
+		res.VMTrace = vmTracer.GetResult()
+	}
+	return res, nil
+}
+
+// TraceCallParam is a single call of a trace_callMany bundle, encoded in JSON as
+// a [callObject, traceTypes] tuple.
+type TraceCallParam struct {
+	Args       ethapi.CallArgs
+	TraceTypes []string
+}
+
+// UnmarshalJSON decodes the [callObject, traceTypes] tuple.
+func (p *TraceCallParam) UnmarshalJSON(input []byte) error {
+	var tuple []json.RawMessage
+	if err := json.Unmarshal(input, &tuple); err != nil {
+		return err
+	}
+	if len(tuple) != 2 {
+		return fmt.Errorf("expected [call, traceTypes] tuple, got %d elements", len(tuple))
+	}
+	if err := json.Unmarshal(tuple[0], &p.Args); err != nil {
+		return err
+	}
+	return json.Unmarshal(tuple[1], &p.TraceTypes)
+}
+
+// MarshalJSON encodes the call as a [callObject, traceTypes] tuple.
+func (p TraceCallParam) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{p.Args, p.TraceTypes})
+}
+
+// Call executes a call on top of the given block (latest by default) without
+// creating a transaction, returning the requested Parity trace types.
+func (api *PrivateTraceAPI) Call(ctx context.Context, args ethapi.CallArgs, traceTypes []string, blockNrOrHash *rpc.BlockNumberOrHash, config *TraceConfig) (*TraceReplayResult, error) {
+	results, err := api.CallMany(ctx, []TraceCallParam{{Args: args, TraceTypes: traceTypes}}, blockNrOrHash, config)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// CallMany executes an ordered bundle of calls on top of the given block (latest
+// by default), each call seeing the state changes of the previous ones, returning
+// the requested Parity trace types of every call.
+func (api *PrivateTraceAPI) CallMany(ctx context.Context, calls []TraceCallParam, blockNrOrHash *rpc.BlockNumberOrHash, config *TraceConfig) ([]*TraceReplayResult, error) {
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	statedb, header, err := stateAndHeaderAt(ctx, api.eth, *blockNrOrHash, reexec)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*TraceReplayResult, len(calls))
+	for i, call := range calls {
+		msg := call.Args.ToMessage(api.eth.APIBackend.RPCGasCap())
+		vmctx := core.NewEVMContext(msg, header, api.eth.blockchain, nil)
+
+		res, err := replayTx(ctx, api.eth, msg, vmctx, statedb, call.TraceTypes, config)
+		if err != nil {
+			return nil, fmt.Errorf("call %d failed: %v", i, err)
+		}
+		results[i] = res
+	}
+	return results, nil
+}
@@ -1,7 +1,20 @@
 package eth
 
 import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // BenchmarkTraceResultsAppend1 compares performance against BenchmarkTraceResultsAppend2,
@@ -39,3 +52,75 @@ func BenchmarkTraceResultsAppend2(b *testing.B) {
 		results = append(results, traceResults...) // nolint:ineffassign
 	}
 }
+
+func TestTraceCallParamJSON(t *testing.T) {
+	input := `[{"from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002","value":"0x1"},["trace","stateDiff"]]`
+
+	var param TraceCallParam
+	if err := json.Unmarshal([]byte(input), &param); err != nil {
+		t.Fatalf("failed to unmarshal call: %v", err)
+	}
+	if param.Args.To == nil || *param.Args.To != common.HexToAddress("0x2") {
+		t.Errorf("recipient mismatch: have %v", param.Args.To)
+	}
+	if param.Args.Value == nil || param.Args.Value.ToInt().Uint64() != 1 {
+		t.Errorf("value mismatch: have %v", param.Args.Value)
+	}
+	if !reflect.DeepEqual(param.TraceTypes, []string{"trace", "stateDiff"}) {
+		t.Errorf("trace types mismatch: have %v", param.TraceTypes)
+	}
+	if err := json.Unmarshal([]byte(`[{"to":"0x0000000000000000000000000000000000000002"}]`), &param); err == nil {
+		t.Errorf("expected error for incomplete tuple")
+	}
+}
+
+func TestTraceCallManySequentialState(t *testing.T) {
+	var (
+		from    = common.HexToAddress("0x0000000000000000000000000000000000000001")
+		counter = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	)
+	stack, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("could not create node: %v", err)
+	}
+	defer stack.Close()
+
+	// The counter increments storage slot 0 and returns the new value
+	config := &Config{Genesis: &genesisT.Genesis{
+		Config: params.AllEthashProtocolChanges,
+		Alloc: genesisT.GenesisAlloc{
+			from: {Balance: big.NewInt(1e18)},
+			counter: {Balance: common.Big0, Code: []byte{
+				0x60, 0x00, 0x54, 0x60, 0x01, 0x01, 0x80, 0x60, 0x00, 0x55, // sstore(0, sload(0)+1)
+				0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3, // return the new value
+			}},
+		},
+	}}
+	config.Ethash.PowMode = ethash.ModeFake
+	backend, err := New(stack, config)
+	if err != nil {
+		t.Fatalf("could not create eth backend: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+
+	call := TraceCallParam{
+		Args:       ethapi.CallArgs{From: &from, To: &counter},
+		TraceTypes: []string{"trace"},
+	}
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	results, err := NewPrivateTraceAPI(backend).CallMany(context.Background(), []TraceCallParam{call, call}, &latest, nil)
+	if err != nil {
+		t.Fatalf("failed to trace calls: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("result count mismatch: have %d, want 2", len(results))
+	}
+	// The second call must see the storage written by the first one
+	for i, want := range []uint64{1, 2} {
+		if have := new(big.Int).SetBytes(results[i].Output); have.Uint64() != want {
+			t.Errorf("call %d: output mismatch: have %v, want %d", i, hexutil.Bytes(results[i].Output), want)
+		}
+	}
+}
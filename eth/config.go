@@ -27,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/miner"
@@ -53,24 +54,27 @@ var DefaultLightGPOConfig = gasprice.Config{
 var DefaultConfig = Config{
 	SyncMode: downloader.FastSync,
 	Ethash: ethash.Config{
-		CacheDir:         "ethash",
-		CachesInMem:      2,
-		CachesOnDisk:     3,
-		CachesLockMmap:   false,
-		DatasetsInMem:    1,
-		DatasetsOnDisk:   2,
-		DatasetsLockMmap: false,
+		CacheDir:          "ethash",
+		CachesInMem:       2,
+		CachesOnDisk:      3,
+		CachesLockMmap:    false,
+		DatasetsInMem:     1,
+		DatasetsOnDisk:    2,
+		DatasetsLockMmap:  false,
+		StratumDifficulty: 1 << 32,
+		StratumShareTime:  10 * time.Second,
 	},
-	NetworkId:               vars.DefaultNetworkID,
-	LightPeers:              100,
-	UltraLightFraction:      75,
-	DatabaseCache:           512,
-	TrieCleanCache:          154,
-	TrieCleanCacheJournal:   "triecache",
-	TrieCleanCacheRejournal: 60 * time.Minute,
-	TrieDirtyCache:          256,
-	TrieTimeout:             60 * time.Minute,
-	SnapshotCache:           102,
+	NetworkId:                   vars.DefaultNetworkID,
+	LightPeers:                  100,
+	UltraLightFraction:          75,
+	DatabaseCache:               512,
+	DatabaseFreezerRemoteConfig: rawdb.DefaultFreezerRemoteConfig,
+	TrieCleanCache:              154,
+	TrieCleanCacheJournal:       "triecache",
+	TrieCleanCacheRejournal:     60 * time.Minute,
+	TrieDirtyCache:              256,
+	TrieTimeout:                 60 * time.Minute,
+	SnapshotCache:               102,
 	Miner: miner.Config{
 		GasFloor: 8000000,
 		GasCeil:  8000000,
@@ -141,11 +145,12 @@ type Config struct {
 	UltraLightOnlyAnnounce bool     `toml:",omitempty"` // Whether to only announce headers, or also serve them
 
 	// Database options
-	SkipBcVersionCheck    bool `toml:"-"`
-	DatabaseHandles       int  `toml:"-"`
-	DatabaseCache         int
-	DatabaseFreezer       string
-	DatabaseFreezerRemote string
+	SkipBcVersionCheck          bool `toml:"-"`
+	DatabaseHandles             int  `toml:"-"`
+	DatabaseCache               int
+	DatabaseFreezer             string
+	DatabaseFreezerRemote       string
+	DatabaseFreezerRemoteConfig rawdb.FreezerRemoteConfig
 
 	TrieCleanCache          int
 	TrieCleanCacheJournal   string        `toml:",omitempty"` // Disk journal directory for trie cache to survive node restarts
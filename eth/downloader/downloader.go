@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
@@ -113,6 +114,10 @@ type Downloader struct {
 	stateDB    ethdb.Database  // Database to state sync into (and deduplicate via)
 	stateBloom *trie.SyncBloom // Bloom filter for fast trie node and contract code existence checks
 
+	// Snap sync
+	SnapSyncer *snap.Syncer // Syncer retrieving the state over the snap protocol
+	snapSync   bool         // Whether to run state sync over the snap protocol
+
 	// Statistics
 	syncStatsChainOrigin uint64 // Origin block number where syncing started at
 	syncStatsChainHeight uint64 // Highest block number known when syncing started
@@ -244,6 +249,7 @@ func New(checkpoint uint64, stateDb ethdb.Database, stateBloom *trie.SyncBloom,
 			processed: rawdb.ReadFastTrieProgress(stateDb),
 		},
 		trackStateReq: make(chan *stateReq),
+		SnapSyncer:    snap.NewSyncer(stateDb, stateBloom),
 	}
 	go dl.qosTuner()
 	go dl.stateFetcher()
@@ -417,6 +423,18 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 
 	defer d.Cancel() // No matter what, we can't leave the cancel channel open
 
+	// If snap sync was requested, retrieve the state over the snap protocol, but
+	// otherwise proceed as with fast sync. Any other mode turns it back off.
+	if mode == SnapSync {
+		if !d.snapSync {
+			log.Info("Enabling snapshot sync")
+			d.snapSync = true
+		}
+		mode = FastSync
+	} else if d.snapSync {
+		log.Info("Disabling snapshot sync")
+		d.snapSync = false
+	}
 	// Atomically set the requested sync mode
 	atomic.StoreUint32(&d.mode, uint32(mode))
 
@@ -1970,6 +1988,17 @@ func (d *Downloader) DeliverReceipts(id string, receipts [][]*types.Receipt) (er
 	return d.deliver(id, d.receiptCh, &receiptPack{id, receipts}, receiptInMeter, receiptDropMeter)
 }
 
+// DeliverSnapPacket is invoked from a peer's message handler when it transmits a
+// data packet for the local node to consume.
+func (d *Downloader) DeliverSnapPacket(peer *snap.Peer, packet snap.Packet) error {
+	switch packet.(type) {
+	case *snap.AccountRangePacket, *snap.StorageRangesPacket, *snap.ByteCodesPacket, *snap.TrieNodesPacket:
+		return d.SnapSyncer.Deliver(peer, packet)
+	default:
+		return fmt.Errorf("unexpected snap packet type: %T", packet)
+	}
+}
+
 // DeliverNodeData injects a new batch of node state data received from a remote node.
 func (d *Downloader) DeliverNodeData(id string, data [][]byte) (err error) {
 	return d.deliver(id, d.stateCh, &statePack{id, data}, stateInMeter, stateDropMeter)
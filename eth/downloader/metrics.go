@@ -23,23 +23,30 @@ import (
 )
 
 var (
-	headerInMeter      = metrics.NewRegisteredMeter("eth/downloader/headers/in", nil)
-	headerReqTimer     = metrics.NewRegisteredTimer("eth/downloader/headers/req", nil)
-	headerDropMeter    = metrics.NewRegisteredMeter("eth/downloader/headers/drop", nil)
-	headerTimeoutMeter = metrics.NewRegisteredMeter("eth/downloader/headers/timeout", nil)
-
-	bodyInMeter      = metrics.NewRegisteredMeter("eth/downloader/bodies/in", nil)
-	bodyReqTimer     = metrics.NewRegisteredTimer("eth/downloader/bodies/req", nil)
-	bodyDropMeter    = metrics.NewRegisteredMeter("eth/downloader/bodies/drop", nil)
-	bodyTimeoutMeter = metrics.NewRegisteredMeter("eth/downloader/bodies/timeout", nil)
-
-	receiptInMeter      = metrics.NewRegisteredMeter("eth/downloader/receipts/in", nil)
-	receiptReqTimer     = metrics.NewRegisteredTimer("eth/downloader/receipts/req", nil)
-	receiptDropMeter    = metrics.NewRegisteredMeter("eth/downloader/receipts/drop", nil)
-	receiptTimeoutMeter = metrics.NewRegisteredMeter("eth/downloader/receipts/timeout", nil)
-
-	stateInMeter   = metrics.NewRegisteredMeter("eth/downloader/states/in", nil)
-	stateDropMeter = metrics.NewRegisteredMeter("eth/downloader/states/drop", nil)
+	// Delivery, request, drop and timeout metrics, labeled by the kind of data
+	// retrieved (headers, bodies, receipts or states).
+	inMeter      = metrics.NewRegisteredMeterVec("eth/downloader/in", []string{"kind"}, nil)
+	reqTimer     = metrics.NewRegisteredTimerVec("eth/downloader/req", []string{"kind"}, nil)
+	dropMeter    = metrics.NewRegisteredMeterVec("eth/downloader/drop", []string{"kind"}, nil)
+	timeoutMeter = metrics.NewRegisteredMeterVec("eth/downloader/timeout", []string{"kind"}, nil)
+
+	headerInMeter      = inMeter.With("headers")
+	headerReqTimer     = reqTimer.With("headers")
+	headerDropMeter    = dropMeter.With("headers")
+	headerTimeoutMeter = timeoutMeter.With("headers")
+
+	bodyInMeter      = inMeter.With("bodies")
+	bodyReqTimer     = reqTimer.With("bodies")
+	bodyDropMeter    = dropMeter.With("bodies")
+	bodyTimeoutMeter = timeoutMeter.With("bodies")
+
+	receiptInMeter      = inMeter.With("receipts")
+	receiptReqTimer     = reqTimer.With("receipts")
+	receiptDropMeter    = dropMeter.With("receipts")
+	receiptTimeoutMeter = timeoutMeter.With("receipts")
+
+	stateInMeter   = inMeter.With("states")
+	stateDropMeter = dropMeter.With("states")
 
 	throttleCounter = metrics.NewRegisteredCounter("eth/downloader/throttle", nil)
 )
@@ -8,6 +8,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/miner"
@@ -18,44 +19,46 @@ import (
 // MarshalTOML marshals as TOML.
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
-		Genesis                 *genesisT.Genesis `toml:",omitempty"`
-		NetworkId               uint64
-		SyncMode                downloader.SyncMode
-		DiscoveryURLs           []string
-		NoPruning               bool
-		NoPrefetch              bool
-		TxLookupLimit           uint64                 `toml:",omitempty"`
-		Whitelist               map[uint64]common.Hash `toml:"-"`
-		LightServ               int                    `toml:",omitempty"`
-		LightIngress            int                    `toml:",omitempty"`
-		LightEgress             int                    `toml:",omitempty"`
-		LightPeers              int                    `toml:",omitempty"`
-		LightNoPrune            bool                   `toml:",omitempty"`
-		UltraLightServers       []string               `toml:",omitempty"`
-		UltraLightFraction      int                    `toml:",omitempty"`
-		UltraLightOnlyAnnounce  bool                   `toml:",omitempty"`
-		SkipBcVersionCheck      bool                   `toml:"-"`
-		DatabaseHandles         int                    `toml:"-"`
-		DatabaseCache           int
-		DatabaseFreezer         string
-		TrieCleanCache          int
-		TrieCleanCacheJournal   string        `toml:",omitempty"`
-		TrieCleanCacheRejournal time.Duration `toml:",omitempty"`
-		TrieDirtyCache          int
-		TrieTimeout             time.Duration
-		SnapshotCache           int
-		Miner                   miner.Config
-		Ethash                  ethash.Config
-		TxPool                  core.TxPoolConfig
-		GPO                     gasprice.Config
-		EnablePreimageRecording bool
-		DocRoot                 string `toml:"-"`
-		EWASMInterpreter        string
-		EVMInterpreter          string
-		RPCGasCap               uint64                         `toml:",omitempty"`
-		RPCTxFeeCap             float64                        `toml:",omitempty"`
-		Checkpoint              *ctypes.TrustedCheckpoint      `toml:",omitempty"`
-		CheckpointOracle        *ctypes.CheckpointOracleConfig `toml:",omitempty"`
+		Genesis                     *genesisT.Genesis `toml:",omitempty"`
+		NetworkId                   uint64
+		SyncMode                    downloader.SyncMode
+		DiscoveryURLs               []string
+		NoPruning                   bool
+		NoPrefetch                  bool
+		TxLookupLimit               uint64                 `toml:",omitempty"`
+		Whitelist                   map[uint64]common.Hash `toml:"-"`
+		LightServ                   int                    `toml:",omitempty"`
+		LightIngress                int                    `toml:",omitempty"`
+		LightEgress                 int                    `toml:",omitempty"`
+		LightPeers                  int                    `toml:",omitempty"`
+		LightNoPrune                bool                   `toml:",omitempty"`
+		UltraLightServers           []string               `toml:",omitempty"`
+		UltraLightFraction          int                    `toml:",omitempty"`
+		UltraLightOnlyAnnounce      bool                   `toml:",omitempty"`
+		SkipBcVersionCheck          bool                   `toml:"-"`
+		DatabaseHandles             int                    `toml:"-"`
+		DatabaseCache               int
+		DatabaseFreezer             string
+		DatabaseFreezerRemote       string
+		DatabaseFreezerRemoteConfig rawdb.FreezerRemoteConfig
+		TrieCleanCache              int
+		TrieCleanCacheJournal       string        `toml:",omitempty"`
+		TrieCleanCacheRejournal     time.Duration `toml:",omitempty"`
+		TrieDirtyCache              int
+		TrieTimeout                 time.Duration
+		SnapshotCache               int
+		Miner                       miner.Config
+		Ethash                      ethash.Config
+		TxPool                      core.TxPoolConfig
+		GPO                         gasprice.Config
+		EnablePreimageRecording     bool
+		DocRoot                     string `toml:"-"`
+		EWASMInterpreter            string
+		EVMInterpreter              string
+		RPCGasCap                   uint64                         `toml:",omitempty"`
+		RPCTxFeeCap                 float64                        `toml:",omitempty"`
+		Checkpoint                  *ctypes.TrustedCheckpoint      `toml:",omitempty"`
+		CheckpointOracle            *ctypes.CheckpointOracleConfig `toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -78,6 +81,8 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
 	enc.DatabaseFreezer = c.DatabaseFreezer
+	enc.DatabaseFreezerRemote = c.DatabaseFreezerRemote
+	enc.DatabaseFreezerRemoteConfig = c.DatabaseFreezerRemoteConfig
 	enc.TrieCleanCache = c.TrieCleanCache
 	enc.TrieCleanCacheJournal = c.TrieCleanCacheJournal
 	enc.TrieCleanCacheRejournal = c.TrieCleanCacheRejournal
@@ -102,44 +107,46 @@ func (c Config) MarshalTOML() (interface{}, error) {
 // UnmarshalTOML unmarshals from TOML.
 func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
-		Genesis                 *genesisT.Genesis `toml:",omitempty"`
-		NetworkId               *uint64
-		SyncMode                *downloader.SyncMode
-		DiscoveryURLs           []string
-		NoPruning               *bool
-		NoPrefetch              *bool
-		TxLookupLimit           *uint64                `toml:",omitempty"`
-		Whitelist               map[uint64]common.Hash `toml:"-"`
-		LightServ               *int                   `toml:",omitempty"`
-		LightIngress            *int                   `toml:",omitempty"`
-		LightEgress             *int                   `toml:",omitempty"`
-		LightPeers              *int                   `toml:",omitempty"`
-		LightNoPrune            *bool                  `toml:",omitempty"`
-		UltraLightServers       []string               `toml:",omitempty"`
-		UltraLightFraction      *int                   `toml:",omitempty"`
-		UltraLightOnlyAnnounce  *bool                  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool                  `toml:"-"`
-		DatabaseHandles         *int                   `toml:"-"`
-		DatabaseCache           *int
-		DatabaseFreezer         *string
-		TrieCleanCache          *int
-		TrieCleanCacheJournal   *string        `toml:",omitempty"`
-		TrieCleanCacheRejournal *time.Duration `toml:",omitempty"`
-		TrieDirtyCache          *int
-		TrieTimeout             *time.Duration
-		SnapshotCache           *int
-		Miner                   *miner.Config
-		Ethash                  *ethash.Config
-		TxPool                  *core.TxPoolConfig
-		GPO                     *gasprice.Config
-		EnablePreimageRecording *bool
-		DocRoot                 *string `toml:"-"`
-		EWASMInterpreter        *string
-		EVMInterpreter          *string
-		RPCGasCap               *uint64                        `toml:",omitempty"`
-		RPCTxFeeCap             *float64                       `toml:",omitempty"`
-		Checkpoint              *ctypes.TrustedCheckpoint      `toml:",omitempty"`
-		CheckpointOracle        *ctypes.CheckpointOracleConfig `toml:",omitempty"`
+		Genesis                     *genesisT.Genesis `toml:",omitempty"`
+		NetworkId                   *uint64
+		SyncMode                    *downloader.SyncMode
+		DiscoveryURLs               []string
+		NoPruning                   *bool
+		NoPrefetch                  *bool
+		TxLookupLimit               *uint64                `toml:",omitempty"`
+		Whitelist                   map[uint64]common.Hash `toml:"-"`
+		LightServ                   *int                   `toml:",omitempty"`
+		LightIngress                *int                   `toml:",omitempty"`
+		LightEgress                 *int                   `toml:",omitempty"`
+		LightPeers                  *int                   `toml:",omitempty"`
+		LightNoPrune                *bool                  `toml:",omitempty"`
+		UltraLightServers           []string               `toml:",omitempty"`
+		UltraLightFraction          *int                   `toml:",omitempty"`
+		UltraLightOnlyAnnounce      *bool                  `toml:",omitempty"`
+		SkipBcVersionCheck          *bool                  `toml:"-"`
+		DatabaseHandles             *int                   `toml:"-"`
+		DatabaseCache               *int
+		DatabaseFreezer             *string
+		DatabaseFreezerRemote       *string
+		DatabaseFreezerRemoteConfig *rawdb.FreezerRemoteConfig
+		TrieCleanCache              *int
+		TrieCleanCacheJournal       *string        `toml:",omitempty"`
+		TrieCleanCacheRejournal     *time.Duration `toml:",omitempty"`
+		TrieDirtyCache              *int
+		TrieTimeout                 *time.Duration
+		SnapshotCache               *int
+		Miner                       *miner.Config
+		Ethash                      *ethash.Config
+		TxPool                      *core.TxPoolConfig
+		GPO                         *gasprice.Config
+		EnablePreimageRecording     *bool
+		DocRoot                     *string `toml:"-"`
+		EWASMInterpreter            *string
+		EVMInterpreter              *string
+		RPCGasCap                   *uint64                        `toml:",omitempty"`
+		RPCTxFeeCap                 *float64                       `toml:",omitempty"`
+		Checkpoint                  *ctypes.TrustedCheckpoint      `toml:",omitempty"`
+		CheckpointOracle            *ctypes.CheckpointOracleConfig `toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -205,6 +212,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseFreezer != nil {
 		c.DatabaseFreezer = *dec.DatabaseFreezer
 	}
+	if dec.DatabaseFreezerRemote != nil {
+		c.DatabaseFreezerRemote = *dec.DatabaseFreezerRemote
+	}
+	if dec.DatabaseFreezerRemoteConfig != nil {
+		c.DatabaseFreezerRemoteConfig = *dec.DatabaseFreezerRemoteConfig
+	}
 	if dec.TrieCleanCache != nil {
 		c.TrieCleanCache = *dec.TrieCleanCache
 	}
@@ -0,0 +1,78 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestServiceAccountRange(t *testing.T) {
+	chain, blocks := newTestChain(t, 100, 0)
+	defer chain.Stop()
+
+	root := blocks[0].Root()
+	last := common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+	// A capped response proves a partial range
+	accounts, proof := ServiceGetAccountRangeQuery(chain, &GetAccountRangePacket{Root: root, Limit: last, Bytes: 1000})
+	if len(accounts) == 0 || len(accounts) >= 101 {
+		t.Fatalf("capped account count mismatch: have %d", len(accounts))
+	}
+	keys, values := make([][]byte, len(accounts)), make([][]byte, len(accounts))
+	for i, account := range accounts {
+		keys[i] = account.Hash[:]
+		full, err := snapshot.FullAccountRLP(account.Body)
+		if err != nil {
+			t.Fatalf("invalid account %d: %v", i, err)
+		}
+		values[i] = full
+	}
+	err, more := trie.VerifyRangeProof(root, common.Hash{}.Bytes(), keys[len(keys)-1], keys, values, proofSet(proof))
+	if err != nil || !more {
+		t.Fatalf("range proof mismatch: err %v, more %v", err, more)
+	}
+	// The range stops at the first account beyond the limit
+	limit := accounts[1].Hash
+	limited, _ := ServiceGetAccountRangeQuery(chain, &GetAccountRangePacket{Root: root, Limit: limit, Bytes: softResponseLimit})
+	if len(limited) != 2 || limited[1].Hash != limit {
+		t.Fatalf("limited account count mismatch: have %d", len(limited))
+	}
+	// Unknown roots are rejected without proofs
+	if accounts, proof := ServiceGetAccountRangeQuery(chain, &GetAccountRangePacket{Root: common.Hash{1}, Limit: last, Bytes: 1000}); len(accounts) != 0 || len(proof) != 0 {
+		t.Fatalf("unknown root served: %d accounts, %d proof nodes", len(accounts), len(proof))
+	}
+}
+
+func TestServiceTrieNodes(t *testing.T) {
+	chain, blocks := newTestChain(t, 10, 0)
+	defer chain.Stop()
+
+	root := blocks[0].Root()
+	nodes, err := ServiceGetTrieNodesQuery(chain, &GetTrieNodesPacket{Root: root, Paths: []TrieNodePathSet{{nil}}, Bytes: softResponseLimit})
+	if err != nil || len(nodes) != 1 || crypto.Keccak256Hash(nodes[0]) != root {
+		t.Fatalf("root node mismatch: err %v, nodes %d", err, len(nodes))
+	}
+	if _, err := ServiceGetTrieNodesQuery(chain, &GetTrieNodesPacket{Root: root, Paths: []TrieNodePathSet{{}}, Bytes: softResponseLimit}); !errors.Is(err, errBadRequest) {
+		t.Fatalf("empty path set error mismatch: have %v", err)
+	}
+}
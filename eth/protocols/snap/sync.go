@@ -0,0 +1,1247 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
+const (
+	// maxRequestSize is the maximum number of bytes to request from a remote peer.
+	maxRequestSize = 512 * 1024
+
+	// maxStorageSetRequestCount is the maximum number of contracts to request the
+	// storage of in a single query. If this number is too low, we're not filling
+	// responses fully and waste round trip times. If it's too high, we're capping
+	// responses and waste bandwidth.
+	maxStorageSetRequestCount = maxRequestSize / 1024
+
+	// maxCodeRequestCount is the maximum number of bytecode blobs to request in a
+	// single query. If this number is too low, we're not filling responses fully
+	// and waste round trip times. If it's too high, we're capping responses and
+	// waste bandwidth.
+	maxCodeRequestCount = maxRequestSize / (24 * 1024) * 4
+
+	// maxTrieRequestCount is the maximum number of trie node blobs to request in
+	// a single query. If this number is too low, we're not filling responses fully
+	// and waste round trip times. If it's too high, we're capping responses and
+	// waste bandwidth.
+	maxTrieRequestCount = 512
+
+	// maxHealTasks is the maximum number of trie nodes and bytecodes retrieved
+	// from the healing scheduler at a time.
+	maxHealTasks = 4 * maxTrieRequestCount
+
+	// accountConcurrency is the number of chunks to split the account trie into
+	// to allow concurrent retrievals.
+	accountConcurrency = 16
+
+	// requestTimeout is the maximum time a peer is allowed to spend on serving
+	// a single network request.
+	requestTimeout = 10 * time.Second
+
+	// logInterval is the time between sync progress reports.
+	logInterval = 8 * time.Second
+)
+
+// ErrCancelled is returned from snap syncing if the operation was prematurely
+// terminated.
+var ErrCancelled = errors.New("sync cancelled")
+
+// SyncPeer abstracts out the methods required for a peer to be synced against
+// with the goal of allowing the construction of mock peers without the full
+// blown networking.
+type SyncPeer interface {
+	// ID retrieves the peer's unique identifier.
+	ID() string
+
+	// RequestAccountRange fetches a batch of accounts rooted in a specific account
+	// trie, starting with the origin.
+	RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error
+
+	// RequestStorageRanges fetches a batch of storage slots belonging to one or
+	// more accounts. If slots from only one account is requested, an origin marker
+	// may also be used to retrieve from there.
+	RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error
+
+	// RequestByteCodes fetches a batch of bytecodes by hash.
+	RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error
+
+	// RequestTrieNodes fetches a batch of account or storage trie nodes rooted in
+	// a specific state trie.
+	RequestTrieNodes(id uint64, root common.Hash, paths []TrieNodePathSet, bytes uint64) error
+
+	// Log retrieves the peer's own contextual logger.
+	Log() log.Logger
+}
+
+// accountTask represents the sync task for a chunk of the account snapshot.
+type accountTask struct {
+	next common.Hash // Next account to sync in this interval
+	last common.Hash // Last account to sync in this interval
+	req  *request    // Pending request to fill this task, nil if idle
+	done bool        // Flag whether the interval has been fully retrieved
+}
+
+// storageTask represents the sync task for the storage trie of an account.
+type storageTask struct {
+	account common.Hash // Hash of the account owning the storage trie
+	root    common.Hash // Root hash of the storage trie to retrieve
+	state   common.Hash // State root the storage trie belongs to
+	origin  common.Hash // Next storage slot to retrieve, non-zero for large tries
+}
+
+// healTask represents the sync task for healing the state trie after the
+// ranges were retrieved, filling the gaps left by the chunking and the moves
+// of the synced root.
+type healTask struct {
+	scheduler *trie.Sync                    // State trie sync scheduler defining the tasks
+	trieTasks map[common.Hash]trie.SyncPath // Set of trie node tasks currently queued for retrieval
+	codeTasks map[common.Hash]struct{}      // Set of byte code tasks currently queued for retrieval
+	batch     ethdb.Batch                   // Batch accumulating the healed data until committed
+	pending   map[common.Hash]*request      // Trie nodes and codes being retrieved, to avoid duplicates
+}
+
+// request tracks a pending network request to a peer.
+type request struct {
+	id   uint64      // Request ID of the query
+	peer string      // Peer to which this request is assigned
+	kind byte        // Message code of the expected response
+	root common.Hash // State root the request was issued against
+
+	task    *accountTask   // Account range task being filled (account requests)
+	origin  common.Hash    // First account or storage slot requested
+	storage []*storageTask // Storage tries being retrieved (storage requests)
+	codes   []common.Hash  // Bytecodes being retrieved (bytecode and heal requests)
+	nodes   []common.Hash  // Trie nodes being retrieved, in the order of the response (heal requests)
+	paths   []trie.SyncPath
+	heal    bool // Whether the request belongs to the healing phase
+
+	timeout *time.Timer // Timer to fail the request if the peer doesn't deliver
+}
+
+// response is a delivered reply to a request, or a failure notification if
+// the packet is nil.
+type response struct {
+	req    *request
+	packet Packet
+}
+
+// Syncer is an Ethereum account and storage trie syncer based on snapshots and
+// the  snap protocol. It's purpose is to download all the accounts and storage
+// slots from remote peers and reassemble chunks of the state trie, on top of
+// which a state sync can be run to fix any gaps / overlaps.
+//
+// Every network request has a variety of failure events:
+//   - The peer disconnects after task assignment, failing to send the request
+//   - The peer disconnects after sending the request, before delivering on it
+//   - The peer remains connected, but does not deliver a response in time
+//   - The peer delivers a stale response after a previous timeout
+//   - The peer delivers a refusal to serve the requested state
+//
+// The progress of the range retrievals is kept in memory across roots, so the
+// ranges don't need to be retrieved again when the sync target moves. It is
+// not persisted though, a restarted sync starts afresh.
+type Syncer struct {
+	db    ethdb.KeyValueStore // Database to store the trie nodes into (and dedup)
+	bloom *trie.SyncBloom     // Bloom filter to deduplicate nodes for state fixup
+
+	root    common.Hash              // Current state trie root being synced
+	tasks   []*accountTask           // Current account task set being synced
+	storage []*storageTask           // Storage tries queued for retrieval
+	codes   map[common.Hash]struct{} // Bytecodes queued for retrieval
+	built   bool                     // Whether the account ranges were retrieved and the trie assembled
+	healer  *healTask                // Current state healing task being executed
+
+	peers     map[string]SyncPeer // Currently active peers to download from
+	stateless map[string]struct{} // Peers that failed to deliver state data for the current root
+	requests  map[uint64]*request // Requests currently in flight, by ID
+	reqID     uint64              // Last assigned request ID
+
+	update     chan struct{}  // Notification channel for possible sync progression
+	deliveries chan *response // Delivery channel of responses and failures
+	quit       chan struct{}  // Closed when the current sync cycle terminates
+	lock       sync.RWMutex   // Protects the peers and requests
+
+	accountSynced  uint64             // Number of accounts downloaded
+	accountBytes   common.StorageSize // Number of account trie bytes persisted to disk
+	bytecodeSynced uint64             // Number of bytecodes downloaded
+	bytecodeBytes  common.StorageSize // Number of bytecode bytes downloaded
+	storageSynced  uint64             // Number of storage slots downloaded
+	storageBytes   common.StorageSize // Number of storage trie bytes persisted to disk
+	nodesHealed    uint64             // Number of state trie nodes downloaded while healing
+	nodesBytes     common.StorageSize // Number of state trie bytes downloaded while healing
+	startTime      time.Time          // Time instance when snapshot sync started
+	logTime        time.Time          // Time instance when status was last reported
+}
+
+// NewSyncer creates a new snapshot syncer to download the Ethereum state over
+// the snap protocol. The bloom filter is required for the healing of the state.
+func NewSyncer(db ethdb.KeyValueStore, bloom *trie.SyncBloom) *Syncer {
+	return &Syncer{
+		db:         db,
+		bloom:      bloom,
+		codes:      make(map[common.Hash]struct{}),
+		peers:      make(map[string]SyncPeer),
+		stateless:  make(map[string]struct{}),
+		requests:   make(map[uint64]*request),
+		update:     make(chan struct{}, 1),
+		deliveries: make(chan *response),
+	}
+}
+
+// Register injects a new data source into the syncer's peerset.
+func (s *Syncer) Register(peer SyncPeer) error {
+	id := peer.ID()
+
+	s.lock.Lock()
+	if _, ok := s.peers[id]; ok {
+		s.lock.Unlock()
+		log.Error("Snap peer already registered", "id", id)
+		return errors.New("already registered")
+	}
+	s.peers[id] = peer
+	s.lock.Unlock()
+
+	// Notify any active syncs that a new peer can be assigned data
+	s.notify()
+	return nil
+}
+
+// Unregister removes a data source from the syncer's peerset, failing any
+// requests in flight to it.
+func (s *Syncer) Unregister(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.peers[id]; !ok {
+		log.Error("Snap peer not registered", "id", id)
+		return errors.New("not registered")
+	}
+	delete(s.peers, id)
+
+	for _, req := range s.requests {
+		if req.peer == id && req.timeout.Stop() {
+			go s.fail(req, s.quit)
+		}
+	}
+	return nil
+}
+
+// notify signals the running sync cycle that new tasks might be assignable.
+func (s *Syncer) notify() {
+	select {
+	case s.update <- struct{}{}:
+	default:
+	}
+}
+
+// fail delivers the failure of a request to the running sync cycle.
+func (s *Syncer) fail(req *request, quit chan struct{}) {
+	select {
+	case s.deliveries <- &response{req: req}:
+	case <-quit:
+	}
+}
+
+// Deliver is invoked when a response packet is received from a remote peer,
+// passing it to the running sync cycle. Packets not matching any request in
+// flight are discarded.
+func (s *Syncer) Deliver(peer SyncPeer, packet Packet) error {
+	var id uint64
+	switch packet := packet.(type) {
+	case *AccountRangePacket:
+		id = packet.ID
+	case *StorageRangesPacket:
+		id = packet.ID
+	case *ByteCodesPacket:
+		id = packet.ID
+	case *TrieNodesPacket:
+		id = packet.ID
+	default:
+		return errInvalidMsgCode
+	}
+	s.lock.RLock()
+	req, quit := s.requests[id], s.quit
+	s.lock.RUnlock()
+
+	if req == nil || req.peer != peer.ID() {
+		peer.Log().Debug("Unrequested snap response", "type", packet.Name(), "reqid", id)
+		return nil
+	}
+	if req.kind != packet.Kind() {
+		return errBadRequest
+	}
+	if !req.timeout.Stop() {
+		// The request timed out or the peer dropped, failure already delivered
+		return nil
+	}
+	select {
+	case s.deliveries <- &response{req: req, packet: packet}:
+	case <-quit:
+	}
+	return nil
+}
+
+// Sync starts (or resumes a previous) sync cycle to iterate over an state trie
+// with the given root and reconstruct the nodes based on the snapshot leaves.
+// Previously downloaded segments will not be redownloaded of fixed, rather any
+// errors will be healed after the leaves are fully accumulated.
+func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
+	// Nothing to do if the state is already present, tries are written bottom up
+	if root == emptyRoot || len(rawdb.ReadTrieNode(s.db, root)) > 0 {
+		return nil
+	}
+	s.lock.Lock()
+	s.root = root
+	s.stateless = make(map[string]struct{})
+	s.quit = make(chan struct{})
+	s.lock.Unlock()
+
+	defer s.cleanup()
+
+	// Storage retrievals of a previous root would fail the range proofs of the
+	// new one, leave them to healing
+	var storage []*storageTask
+	for _, task := range s.storage {
+		if task.state == root {
+			storage = append(storage, task)
+		}
+	}
+	if dropped := len(s.storage) - len(storage); dropped > 0 {
+		log.Debug("Dropping stale storage retrievals", "count", dropped)
+	}
+	s.storage = storage
+
+	if s.tasks == nil {
+		s.tasks = splitAccountRange(accountConcurrency)
+		s.startTime = time.Now()
+	}
+	log.Debug("Starting snapshot sync cycle", "root", root)
+
+	// Retrieve the account and storage ranges, assembling the tries from them
+	for !s.built {
+		if s.rangesDone() {
+			if err := s.commitAccounts(); err != nil {
+				return err
+			}
+			s.built = true
+			break
+		}
+		s.assignCodeTasks()
+		s.assignStorageTasks()
+		s.assignAccountTasks()
+
+		if err := s.wait(cancel); err != nil {
+			return err
+		}
+	}
+	// Fill any gaps left in the state trie by the chunking and root moves
+	return s.heal(cancel)
+}
+
+// cleanup terminates the current sync cycle, requeueing the tasks of all
+// requests in flight.
+func (s *Syncer) cleanup() {
+	s.lock.Lock()
+	close(s.quit)
+	requests := s.requests
+	s.requests = make(map[uint64]*request)
+	s.lock.Unlock()
+
+	for _, req := range requests {
+		req.timeout.Stop()
+		s.revert(req)
+	}
+}
+
+// wait blocks until a response is delivered or peers join, processing the
+// former.
+func (s *Syncer) wait(cancel chan struct{}) error {
+	select {
+	case <-s.update:
+	case res := <-s.deliveries:
+		s.lock.Lock()
+		current := s.requests[res.req.id] == res.req
+		delete(s.requests, res.req.id)
+		s.lock.Unlock()
+
+		if current {
+			if err := s.process(res); err != nil {
+				return err
+			}
+		}
+	case <-cancel:
+		return ErrCancelled
+	}
+	s.report(false)
+	return nil
+}
+
+// rangesDone returns whether all account ranges and the storage and codes
+// they reference have been retrieved.
+func (s *Syncer) rangesDone() bool {
+	for _, task := range s.tasks {
+		if !task.done {
+			return false
+		}
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return len(s.storage) == 0 && len(s.codes) == 0 && len(s.requests) == 0
+}
+
+// idlePeers returns the peers able to serve the current root without any
+// requests in flight.
+func (s *Syncer) idlePeers() []SyncPeer {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	busy := make(map[string]struct{})
+	for _, req := range s.requests {
+		busy[req.peer] = struct{}{}
+	}
+	var idlers []SyncPeer
+	for id, peer := range s.peers {
+		if _, ok := busy[id]; ok {
+			continue
+		}
+		if _, ok := s.stateless[id]; ok {
+			continue
+		}
+		idlers = append(idlers, peer)
+	}
+	return idlers
+}
+
+// track registers a request as in flight and arms its timeout.
+func (s *Syncer) track(req *request) {
+	s.lock.Lock()
+	s.reqID++
+	req.id = s.reqID
+	req.root = s.root
+	s.requests[req.id] = req
+	quit := s.quit
+	req.timeout = time.AfterFunc(requestTimeout, func() { s.fail(req, quit) })
+	s.lock.Unlock()
+}
+
+// untrack removes a request which failed to be sent.
+func (s *Syncer) untrack(req *request) {
+	s.lock.Lock()
+	delete(s.requests, req.id)
+	s.lock.Unlock()
+
+	req.timeout.Stop()
+	s.revert(req)
+}
+
+// markStateless flags a peer as unable to serve the current root.
+func (s *Syncer) markStateless(peer string) {
+	s.lock.Lock()
+	s.stateless[peer] = struct{}{}
+	s.lock.Unlock()
+}
+
+// assignAccountTasks attempts to match idle peers to pending account range
+// retrievals.
+func (s *Syncer) assignAccountTasks() {
+	for _, peer := range s.idlePeers() {
+		var task *accountTask
+		for _, t := range s.tasks {
+			if !t.done && t.req == nil {
+				task = t
+				break
+			}
+		}
+		if task == nil {
+			return
+		}
+		req := &request{
+			peer:   peer.ID(),
+			kind:   AccountRangeMsg,
+			task:   task,
+			origin: task.next,
+		}
+		s.track(req)
+		task.req = req
+
+		if err := peer.RequestAccountRange(req.id, req.root, task.next, task.last, maxRequestSize); err != nil {
+			peer.Log().Debug("Failed to request account range", "err", err)
+			s.untrack(req)
+		}
+	}
+}
+
+// assignStorageTasks attempts to match idle peers to pending storage range
+// retrievals.
+func (s *Syncer) assignStorageTasks() {
+	for _, peer := range s.idlePeers() {
+		if len(s.storage) == 0 {
+			return
+		}
+		// Large storage tries are continued one by one, small ones are batched
+		var tasks []*storageTask
+		if s.storage[0].origin != (common.Hash{}) {
+			tasks = append(tasks, s.storage[0])
+		} else {
+			for _, task := range s.storage {
+				if task.origin != (common.Hash{}) || len(tasks) == maxStorageSetRequestCount {
+					break
+				}
+				tasks = append(tasks, task)
+			}
+		}
+		s.storage = s.storage[len(tasks):]
+
+		req := &request{
+			peer:    peer.ID(),
+			kind:    StorageRangesMsg,
+			storage: tasks,
+			origin:  tasks[0].origin,
+		}
+		s.track(req)
+
+		accounts := make([]common.Hash, len(tasks))
+		for i, task := range tasks {
+			accounts[i] = task.account
+		}
+		var origin []byte
+		if req.origin != (common.Hash{}) {
+			origin = req.origin[:]
+		}
+		if err := peer.RequestStorageRanges(req.id, req.root, accounts, origin, nil, maxRequestSize); err != nil {
+			peer.Log().Debug("Failed to request storage ranges", "err", err)
+			s.untrack(req)
+		}
+	}
+}
+
+// assignCodeTasks attempts to match idle peers to pending bytecode retrievals.
+func (s *Syncer) assignCodeTasks() {
+	for _, peer := range s.idlePeers() {
+		if len(s.codes) == 0 {
+			return
+		}
+		codes := make([]common.Hash, 0, maxCodeRequestCount)
+		for hash := range s.codes {
+			delete(s.codes, hash)
+			if codes = append(codes, hash); len(codes) == maxCodeRequestCount {
+				break
+			}
+		}
+		req := &request{
+			peer:  peer.ID(),
+			kind:  ByteCodesMsg,
+			codes: codes,
+		}
+		s.track(req)
+
+		if err := peer.RequestByteCodes(req.id, codes, maxRequestSize); err != nil {
+			peer.Log().Debug("Failed to request bytecodes", "err", err)
+			s.untrack(req)
+		}
+	}
+}
+
+// revert requeues the tasks of a failed request.
+func (s *Syncer) revert(req *request) {
+	switch {
+	case req.heal:
+		if s.healer == nil {
+			return
+		}
+		for i, hash := range req.nodes {
+			delete(s.healer.pending, hash)
+			s.healer.trieTasks[hash] = req.paths[i]
+		}
+		for _, hash := range req.codes {
+			delete(s.healer.pending, hash)
+			s.healer.codeTasks[hash] = struct{}{}
+		}
+	case req.task != nil:
+		req.task.req = nil
+	case req.storage != nil:
+		s.requeueStorage(req.storage...)
+	default:
+		for _, hash := range req.codes {
+			s.codes[hash] = struct{}{}
+		}
+	}
+}
+
+// requeueStorage schedules storage tasks for retrieval ahead of the queued
+// ones, continuing partially retrieved storage tries first.
+func (s *Syncer) requeueStorage(tasks ...*storageTask) {
+	queue := make([]*storageTask, 0, len(tasks)+len(s.storage))
+	queue = append(queue, tasks...)
+	s.storage = append(queue, s.storage...)
+}
+
+// process handles a response or failure of a request.
+func (s *Syncer) process(res *response) error {
+	req := res.req
+	if res.packet == nil {
+		log.Trace("Snap request failed", "peer", req.peer, "reqid", req.id)
+		s.revert(req)
+		return nil
+	}
+	switch packet := res.packet.(type) {
+	case *AccountRangePacket:
+		return s.processAccountResponse(req, packet)
+	case *StorageRangesPacket:
+		return s.processStorageResponse(req, packet)
+	case *ByteCodesPacket:
+		if req.heal {
+			return s.processHealByteCodes(req, packet)
+		}
+		return s.processByteCodes(req, packet)
+	case *TrieNodesPacket:
+		return s.processHealTrieNodes(req, packet)
+	}
+	return nil
+}
+
+// processAccountResponse verifies and stores a retrieved account range,
+// queueing the storage tries and bytecodes it references.
+func (s *Syncer) processAccountResponse(req *request, res *AccountRangePacket) error {
+	task := req.task
+	task.req = nil
+
+	// An empty response without proofs means the peer doesn't have the state
+	if len(res.Accounts) == 0 && len(res.Proof) == 0 {
+		log.Debug("Peer rejected account range request", "peer", req.peer, "root", req.root)
+		s.markStateless(req.peer)
+		return nil
+	}
+	// Ensure the range is valid and proven
+	var (
+		keys     = make([][]byte, len(res.Accounts))
+		values   = make([][]byte, len(res.Accounts))
+		accounts = make([]snapshot.Account, len(res.Accounts))
+		last     []byte
+	)
+	for i, account := range res.Accounts {
+		full, err := snapshot.FullAccount(account.Body)
+		if err != nil {
+			log.Debug("Peer delivered invalid account", "peer", req.peer, "err", err)
+			s.markStateless(req.peer)
+			return nil
+		}
+		if values[i], err = snapshot.FullAccountRLP(account.Body); err != nil {
+			s.markStateless(req.peer)
+			return nil
+		}
+		keys[i], accounts[i] = common.CopyBytes(account.Hash[:]), full
+	}
+	if len(keys) > 0 {
+		last = keys[len(keys)-1]
+	}
+	err, more := trie.VerifyRangeProof(req.root, req.origin[:], last, keys, values, proofSet(res.Proof))
+	if err != nil {
+		log.Debug("Peer delivered invalid account range", "peer", req.peer, "err", err)
+		s.markStateless(req.peer)
+		return nil
+	}
+	// Move the interval forward, or mark it done if all accounts arrived
+	end := task.last
+	if more && bytes.Compare(last, task.last[:]) < 0 {
+		end = common.BytesToHash(last)
+	}
+	// Persist the accounts of the interval and queue their dependencies, wiping
+	// any local leftovers (genesis or earlier roots) from the delivered range
+	batch := s.db.NewBatch()
+	if err := s.deleteAccountSnapshots(batch, req.origin, end); err != nil {
+		return err
+	}
+	for i, account := range res.Accounts {
+		if bytes.Compare(account.Hash[:], task.last[:]) > 0 {
+			break // Belongs to the next interval
+		}
+		rawdb.WriteAccountSnapshot(batch, account.Hash, account.Body)
+
+		if codeHash := common.BytesToHash(accounts[i].CodeHash); codeHash != emptyCode {
+			if len(rawdb.ReadCodeWithPrefix(s.db, codeHash)) == 0 {
+				s.codes[codeHash] = struct{}{}
+			}
+		}
+		if root := common.BytesToHash(accounts[i].Root); root != emptyRoot {
+			if len(rawdb.ReadTrieNode(s.db, root)) == 0 {
+				s.storage = append(s.storage, &storageTask{
+					account: account.Hash,
+					root:    root,
+					state:   req.root,
+				})
+			}
+		}
+		s.accountSynced++
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	if end == task.last {
+		task.done = true
+	} else {
+		task.next = incHash(end)
+	}
+	return nil
+}
+
+// processStorageResponse verifies and stores retrieved storage ranges,
+// assembling the storage tries which were fully retrieved.
+func (s *Syncer) processStorageResponse(req *request, res *StorageRangesPacket) error {
+	// Requeue any tasks not served by the peer
+	if len(res.Slots) > len(req.storage) {
+		log.Debug("Peer delivered excess storage ranges", "peer", req.peer, "have", len(res.Slots), "want", len(req.storage))
+		s.markStateless(req.peer)
+		s.revert(req)
+		return nil
+	}
+	if len(res.Slots) < len(req.storage) {
+		s.storage = append(s.storage, req.storage[len(res.Slots):]...)
+	}
+	if len(res.Slots) == 0 {
+		log.Debug("Peer rejected storage range request", "peer", req.peer, "root", req.root)
+		s.markStateless(req.peer)
+		return nil
+	}
+	batch := s.db.NewBatch()
+	var completed []*storageTask
+	for i, slots := range res.Slots {
+		task := req.storage[i]
+
+		keys := make([][]byte, len(slots))
+		values := make([][]byte, len(slots))
+		for j, slot := range slots {
+			keys[j], values[j] = common.CopyBytes(slot.Hash[:]), slot.Body
+		}
+		// Only the last range may be partial, proven by the attached proofs
+		var (
+			proof       ethdb.KeyValueReader
+			first, last []byte
+		)
+		if i == len(res.Slots)-1 && len(res.Proof) > 0 {
+			proof = proofSet(res.Proof)
+			first = task.origin[:]
+			if len(keys) > 0 {
+				last = keys[len(keys)-1]
+			}
+		}
+		err, more := trie.VerifyRangeProof(task.root, first, last, keys, values, proof)
+		if err != nil {
+			log.Debug("Peer delivered invalid storage range", "peer", req.peer, "account", task.account, "err", err)
+			s.markStateless(req.peer)
+			s.requeueStorage(req.storage[i:len(res.Slots)]...)
+			break
+		}
+		end := common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+		if more {
+			end = common.BytesToHash(last)
+		}
+		if err := s.deleteStorageSnapshots(batch, task.account, task.origin, end); err != nil {
+			return err
+		}
+		for j, slot := range slots {
+			rawdb.WriteStorageSnapshot(batch, task.account, slot.Hash, values[j])
+		}
+		s.storageSynced += uint64(len(slots))
+
+		if more {
+			task.origin = incHash(common.BytesToHash(last))
+			s.requeueStorage(task)
+		} else {
+			completed = append(completed, task)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	// Assemble the storage tries of the completed accounts
+	for _, task := range completed {
+		if err := s.commitStorage(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processByteCodes verifies and stores retrieved bytecodes.
+func (s *Syncer) processByteCodes(req *request, res *ByteCodesPacket) error {
+	requested := make(map[common.Hash]struct{}, len(req.codes))
+	for _, hash := range req.codes {
+		requested[hash] = struct{}{}
+	}
+	batch := s.db.NewBatch()
+	for _, code := range res.Codes {
+		hash := crypto.Keccak256Hash(code)
+		if _, ok := requested[hash]; !ok {
+			log.Debug("Peer delivered unrequested bytecode", "peer", req.peer, "hash", hash)
+			s.markStateless(req.peer)
+			break
+		}
+		delete(requested, hash)
+		rawdb.WriteCode(batch, hash, code)
+		if s.bloom != nil {
+			s.bloom.Add(hash[:])
+		}
+		s.bytecodeSynced++
+		s.bytecodeBytes += common.StorageSize(len(code))
+	}
+	if len(res.Codes) == 0 {
+		s.markStateless(req.peer)
+	}
+	for hash := range requested {
+		s.codes[hash] = struct{}{}
+	}
+	return batch.Write()
+}
+
+// deleteAccountSnapshots removes the account snapshot entries within the range
+// [origin, last] from the database, so the retrieved range fully replaces them.
+func (s *Syncer) deleteAccountSnapshots(batch ethdb.KeyValueWriter, origin, last common.Hash) error {
+	it := s.db.NewIterator(rawdb.SnapshotAccountPrefix, origin[:])
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(rawdb.SnapshotAccountPrefix)+common.HashLength {
+			continue
+		}
+		if bytes.Compare(key[len(rawdb.SnapshotAccountPrefix):], last[:]) > 0 {
+			break
+		}
+		if err := batch.Delete(common.CopyBytes(key)); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// deleteStorageSnapshots removes the storage snapshot entries of an account
+// within the range [origin, last] from the database, so the retrieved range
+// fully replaces them.
+func (s *Syncer) deleteStorageSnapshots(batch ethdb.KeyValueWriter, account, origin, last common.Hash) error {
+	prefix := append(common.CopyBytes(rawdb.SnapshotStoragePrefix), account[:]...)
+	it := s.db.NewIterator(prefix, origin[:])
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+common.HashLength {
+			continue
+		}
+		if bytes.Compare(key[len(prefix):], last[:]) > 0 {
+			break
+		}
+		if err := batch.Delete(common.CopyBytes(key)); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// commitStorage assembles the storage trie of an account from its retrieved
+// slots. If the trie doesn't match the expected root, healing will fix it.
+func (s *Syncer) commitStorage(task *storageTask) error {
+	writer := newTrieWriter(s.db, s.bloom)
+	st := trie.NewStackTrie(writer)
+
+	it := rawdb.IterateStorageSnapshots(s.db, task.account)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(rawdb.SnapshotStoragePrefix)+2*common.HashLength {
+			continue
+		}
+		if err := st.TryUpdate(key[len(rawdb.SnapshotStoragePrefix)+common.HashLength:], common.CopyBytes(it.Value())); err != nil {
+			it.Release()
+			return err
+		}
+	}
+	it.Release()
+
+	root, err := st.Commit()
+	if err != nil {
+		return err
+	}
+	if err := writer.flush(); err != nil {
+		return err
+	}
+	s.storageBytes += writer.size
+	if root != task.root {
+		log.Debug("Storage trie root mismatch, leaving to healing", "account", task.account, "have", root, "want", task.root)
+	}
+	return nil
+}
+
+// commitAccounts assembles the account trie from the retrieved accounts. If
+// the trie doesn't match the synced root, healing will fix it.
+func (s *Syncer) commitAccounts() error {
+	writer := newTrieWriter(s.db, s.bloom)
+	st := trie.NewStackTrie(writer)
+
+	it := s.db.NewIterator(rawdb.SnapshotAccountPrefix, nil)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(rawdb.SnapshotAccountPrefix)+common.HashLength {
+			continue
+		}
+		full, err := snapshot.FullAccountRLP(it.Value())
+		if err != nil {
+			it.Release()
+			return err
+		}
+		if err := st.TryUpdate(common.CopyBytes(key[len(rawdb.SnapshotAccountPrefix):]), full); err != nil {
+			it.Release()
+			return err
+		}
+	}
+	it.Release()
+
+	root, err := st.Commit()
+	if err != nil {
+		return err
+	}
+	if err := writer.flush(); err != nil {
+		return err
+	}
+	s.accountBytes += writer.size
+	if root != s.root {
+		log.Debug("Account trie root mismatch, leaving to healing", "have", root, "want", s.root)
+	}
+	return nil
+}
+
+// heal runs a state sync against the current root, retrieving any trie nodes
+// and bytecodes missing after the range retrievals.
+func (s *Syncer) heal(cancel chan struct{}) error {
+	s.healer = &healTask{
+		scheduler: state.NewStateSync(s.root, s.db, s.bloom),
+		trieTasks: make(map[common.Hash]trie.SyncPath),
+		codeTasks: make(map[common.Hash]struct{}),
+		batch:     s.db.NewBatch(),
+		pending:   make(map[common.Hash]*request),
+	}
+	defer func() { s.healer = nil }()
+
+	for s.healer.scheduler.Pending() > 0 {
+		// Refill the queued tasks from the scheduler
+		if fill := maxHealTasks - len(s.healer.trieTasks) - len(s.healer.codeTasks) - len(s.healer.pending); fill > 0 {
+			nodes, paths, codes := s.healer.scheduler.Missing(fill)
+			for i, hash := range nodes {
+				s.healer.trieTasks[hash] = paths[i]
+			}
+			for _, hash := range codes {
+				s.healer.codeTasks[hash] = struct{}{}
+			}
+		}
+		s.assignHealTasks()
+
+		if err := s.wait(cancel); err != nil {
+			s.commitHeal(true)
+			return err
+		}
+		if err := s.commitHeal(false); err != nil {
+			return err
+		}
+	}
+	if err := s.commitHeal(true); err != nil {
+		return err
+	}
+	s.report(true)
+	return nil
+}
+
+// commitHeal flushes the healed trie nodes and bytecodes to disk.
+func (s *Syncer) commitHeal(force bool) error {
+	if err := s.healer.scheduler.Commit(s.healer.batch); err != nil {
+		return err
+	}
+	if !force && s.healer.batch.ValueSize() < ethdb.IdealBatchSize {
+		return nil
+	}
+	if err := s.healer.batch.Write(); err != nil {
+		return err
+	}
+	s.healer.batch.Reset()
+	return nil
+}
+
+// assignHealTasks attempts to match idle peers to pending trie node and
+// bytecode retrievals.
+func (s *Syncer) assignHealTasks() {
+	for _, peer := range s.idlePeers() {
+		switch {
+		case len(s.healer.codeTasks) > 0:
+			codes := make([]common.Hash, 0, maxCodeRequestCount)
+			for hash := range s.healer.codeTasks {
+				delete(s.healer.codeTasks, hash)
+				if codes = append(codes, hash); len(codes) == maxCodeRequestCount {
+					break
+				}
+			}
+			req := &request{
+				peer:  peer.ID(),
+				kind:  ByteCodesMsg,
+				codes: codes,
+				heal:  true,
+			}
+			s.track(req)
+			for _, hash := range codes {
+				s.healer.pending[hash] = req
+			}
+			if err := peer.RequestByteCodes(req.id, codes, maxRequestSize); err != nil {
+				peer.Log().Debug("Failed to request bytecodes", "err", err)
+				s.untrack(req)
+			}
+
+		case len(s.healer.trieTasks) > 0:
+			// Group the storage trie nodes of the same account into path sets
+			var (
+				sets    []TrieNodePathSet
+				members [][]common.Hash
+				owners  = make(map[string]int)
+				count   int
+			)
+			for hash, path := range s.healer.trieTasks {
+				delete(s.healer.trieTasks, hash)
+				if len(path) == 1 {
+					sets = append(sets, TrieNodePathSet{path[0]})
+					members = append(members, []common.Hash{hash})
+				} else if idx, ok := owners[string(path[0])]; ok {
+					sets[idx] = append(sets[idx], path[1])
+					members[idx] = append(members[idx], hash)
+				} else {
+					owners[string(path[0])] = len(sets)
+					sets = append(sets, TrieNodePathSet{path[0], path[1]})
+					members = append(members, []common.Hash{hash})
+				}
+				if count++; count == maxTrieRequestCount {
+					break
+				}
+			}
+			req := &request{
+				peer: peer.ID(),
+				kind: TrieNodesMsg,
+				heal: true,
+			}
+			// Nodes are delivered in the order of the path sets
+			for i, set := range sets {
+				for j, hash := range members[i] {
+					path := trie.SyncPath{set[0]}
+					if len(set) > 1 {
+						path = trie.SyncPath{set[0], set[j+1]}
+					}
+					req.nodes = append(req.nodes, hash)
+					req.paths = append(req.paths, path)
+					s.healer.pending[hash] = req
+				}
+			}
+			s.track(req)
+			if err := peer.RequestTrieNodes(req.id, req.root, sets, maxRequestSize); err != nil {
+				peer.Log().Debug("Failed to request trie nodes", "err", err)
+				s.untrack(req)
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+// processHealTrieNodes feeds retrieved trie nodes into the healing scheduler.
+func (s *Syncer) processHealTrieNodes(req *request, res *TrieNodesPacket) error {
+	if s.healer == nil {
+		return nil
+	}
+	if len(res.Nodes) == 0 {
+		log.Debug("Peer rejected trie node request", "peer", req.peer, "root", req.root)
+		s.markStateless(req.peer)
+	}
+	for i, blob := range res.Nodes {
+		if i >= len(req.nodes) {
+			break
+		}
+		hash := req.nodes[i]
+		if len(blob) == 0 {
+			continue // Not served, requeued below
+		}
+		if crypto.Keccak256Hash(blob) != hash {
+			log.Debug("Peer delivered invalid trie node", "peer", req.peer, "hash", hash)
+			s.markStateless(req.peer)
+			break
+		}
+		delete(s.healer.pending, hash)
+		if err := s.healer.scheduler.Process(trie.SyncResult{Hash: hash, Data: blob}); err != nil && err != trie.ErrNotRequested && err != trie.ErrAlreadyProcessed {
+			return err
+		}
+		s.nodesHealed++
+		s.nodesBytes += common.StorageSize(len(blob))
+	}
+	// Requeue any nodes not delivered
+	for i, hash := range req.nodes {
+		if s.healer.pending[hash] == req {
+			delete(s.healer.pending, hash)
+			s.healer.trieTasks[hash] = req.paths[i]
+		}
+	}
+	return nil
+}
+
+// processHealByteCodes feeds retrieved bytecodes into the healing scheduler.
+func (s *Syncer) processHealByteCodes(req *request, res *ByteCodesPacket) error {
+	if s.healer == nil {
+		return nil
+	}
+	if len(res.Codes) == 0 {
+		s.markStateless(req.peer)
+	}
+	for _, code := range res.Codes {
+		hash := crypto.Keccak256Hash(code)
+		if s.healer.pending[hash] != req {
+			log.Debug("Peer delivered unrequested bytecode", "peer", req.peer, "hash", hash)
+			s.markStateless(req.peer)
+			break
+		}
+		delete(s.healer.pending, hash)
+		if err := s.healer.scheduler.Process(trie.SyncResult{Hash: hash, Data: code}); err != nil && err != trie.ErrNotRequested && err != trie.ErrAlreadyProcessed {
+			return err
+		}
+		s.bytecodeSynced++
+		s.bytecodeBytes += common.StorageSize(len(code))
+	}
+	// Requeue any codes not delivered
+	for _, hash := range req.codes {
+		if s.healer.pending[hash] == req {
+			delete(s.healer.pending, hash)
+			s.healer.codeTasks[hash] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// report calculates various status reports and provides it to the user.
+func (s *Syncer) report(force bool) {
+	if !force && time.Since(s.logTime) < logInterval {
+		return
+	}
+	s.logTime = time.Now()
+
+	done := 0
+	for _, task := range s.tasks {
+		if task.done {
+			done++
+		}
+	}
+	log.Info("State sync in progress", "chunks", done, "of", len(s.tasks),
+		"accounts", s.accountSynced, "slots", s.storageSynced, "codes", s.bytecodeSynced,
+		"healed", s.nodesHealed, "trie", s.accountBytes+s.storageBytes+s.nodesBytes,
+		"elapsed", common.PrettyDuration(time.Since(s.startTime)))
+}
+
+// splitAccountRange splits the account hash space into the given number of
+// equally sized intervals.
+func splitAccountRange(chunks int) []*accountTask {
+	var (
+		tasks []*accountTask
+		next  common.Hash
+		step  = new(big.Int).Sub(new(big.Int).Div(new(big.Int).Exp(common.Big2, common.Big256, nil), big.NewInt(int64(chunks))), common.Big1)
+	)
+	for i := 0; i < chunks; i++ {
+		last := common.BigToHash(new(big.Int).Add(next.Big(), step))
+		if i == chunks-1 {
+			// Make sure we don't overflow if the step is not a proper divisor
+			last = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+		}
+		tasks = append(tasks, &accountTask{next: next, last: last})
+		next = incHash(last)
+	}
+	return tasks
+}
+
+// proofSet assembles the trie nodes of a proof into a database to verify it.
+func proofSet(proof [][]byte) *light.NodeSet {
+	set := light.NewNodeSet()
+	for _, node := range proof {
+		set.Put(crypto.Keccak256(node), node)
+	}
+	return set
+}
+
+// incHash returns the next hash, in lexicographical order (a.k.a plus one).
+func incHash(h common.Hash) common.Hash {
+	return common.BigToHash(new(big.Int).Add(h.Big(), common.Big1))
+}
+
+// trieWriter batches the trie nodes written by a stack trie into the database,
+// tracking them in the sync bloom. Only writes are supported.
+type trieWriter struct {
+	ethdb.KeyValueStore
+	batch ethdb.Batch
+	bloom *trie.SyncBloom
+	size  common.StorageSize
+}
+
+func newTrieWriter(db ethdb.KeyValueStore, bloom *trie.SyncBloom) *trieWriter {
+	return &trieWriter{KeyValueStore: db, batch: db.NewBatch(), bloom: bloom}
+}
+
+// Put implements ethdb.KeyValueWriter, adding a trie node to the batch.
+func (w *trieWriter) Put(key []byte, value []byte) error {
+	if w.bloom != nil {
+		w.bloom.Add(key)
+	}
+	w.size += common.StorageSize(len(key) + len(value))
+	if err := w.batch.Put(key, value); err != nil {
+		return err
+	}
+	if w.batch.ValueSize() >= ethdb.IdealBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush writes the batched trie nodes to the database.
+func (w *trieWriter) flush() error {
+	if err := w.batch.Write(); err != nil {
+		return err
+	}
+	w.batch.Reset()
+	return nil
+}
@@ -0,0 +1,332 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// newTestChain creates a blockchain with snapshots enabled, whose genesis state
+// contains the given number of accounts, every tenth being a contract with some
+// storage, and one contract having a large storage trie.
+func newTestChain(t *testing.T, accounts int, blocks int) (*core.BlockChain, []*types.Block) {
+	alloc := make(genesisT.GenesisAlloc)
+	for i := 0; i < accounts; i++ {
+		account := genesisT.GenesisAccount{Balance: big.NewInt(int64(i + 1))}
+		if i%10 == 0 {
+			account.Code = []byte{byte(vm.PUSH1), byte(i), byte(vm.STOP)}
+			account.Storage = make(map[common.Hash]common.Hash)
+			for j := 0; j < 20; j++ {
+				account.Storage[common.BigToHash(big.NewInt(int64(j)))] = common.BigToHash(big.NewInt(int64(i*j + 1)))
+			}
+		}
+		alloc[common.BigToAddress(big.NewInt(int64(i+1)))] = account
+	}
+	large := genesisT.GenesisAccount{Balance: big.NewInt(1), Code: []byte{byte(vm.STOP)}, Storage: make(map[common.Hash]common.Hash)}
+	for j := 0; j < 2000; j++ {
+		large.Storage[common.BigToHash(big.NewInt(int64(j)))] = common.BigToHash(big.NewInt(int64(j + 1)))
+	}
+	alloc[common.HexToAddress("0xffff")] = large
+
+	var (
+		gspec   = &genesisT.Genesis{Config: params.AllEthashProtocolChanges, Alloc: alloc}
+		db      = rawdb.NewMemoryDatabase()
+		genesis = core.MustCommitGenesis(db, gspec)
+		config  = &core.CacheConfig{
+			TrieCleanLimit: 256,
+			TrieDirtyLimit: 256,
+			TrieTimeLimit:  5 * time.Minute,
+			SnapshotLimit:  256,
+			SnapshotWait:   true,
+		}
+	)
+	chain, err := core.NewBlockChain(db, config, params.AllEthashProtocolChanges, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	genDb := rawdb.NewMemoryDatabase()
+	core.MustCommitGenesis(genDb, gspec)
+	chainBlocks, _ := core.GenerateChain(params.AllEthashProtocolChanges, genesis, ethash.NewFaker(), genDb, blocks, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.BigToAddress(big.NewInt(int64(0x10000 + i))))
+	})
+	if _, err := chain.InsertChain(chainBlocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return chain, append([]*types.Block{genesis}, chainBlocks...)
+}
+
+// testPeer is a snap peer serving requests from a local chain.
+type testPeer struct {
+	id        string
+	chain     *core.BlockChain
+	syncer    *Syncer
+	bytes     uint64 // Cap on the response sizes, forcing partial responses
+	stateless bool   // Whether to refuse serving state
+
+	accountRequests uint32
+	storageRequests uint32
+	codeRequests    uint32
+	nodeRequests    uint32
+}
+
+func (p *testPeer) ID() string      { return p.id }
+func (p *testPeer) Log() log.Logger { return log.New("peer", p.id) }
+
+func (p *testPeer) limit(bytes uint64) uint64 {
+	if p.bytes > 0 && bytes > p.bytes {
+		return p.bytes
+	}
+	return bytes
+}
+
+func (p *testPeer) RequestAccountRange(id uint64, root, origin, limit common.Hash, bytes uint64) error {
+	atomic.AddUint32(&p.accountRequests, 1)
+	go func() {
+		res := &AccountRangePacket{ID: id}
+		if !p.stateless {
+			res.Accounts, res.Proof = ServiceGetAccountRangeQuery(p.chain, &GetAccountRangePacket{ID: id, Root: root, Origin: origin, Limit: limit, Bytes: p.limit(bytes)})
+		}
+		p.syncer.Deliver(p, res)
+	}()
+	return nil
+}
+
+func (p *testPeer) RequestStorageRanges(id uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, bytes uint64) error {
+	atomic.AddUint32(&p.storageRequests, 1)
+	go func() {
+		res := &StorageRangesPacket{ID: id}
+		if !p.stateless {
+			res.Slots, res.Proof = ServiceGetStorageRangesQuery(p.chain, &GetStorageRangesPacket{ID: id, Root: root, Accounts: accounts, Origin: origin, Limit: limit, Bytes: p.limit(bytes)})
+		}
+		p.syncer.Deliver(p, res)
+	}()
+	return nil
+}
+
+func (p *testPeer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint64) error {
+	atomic.AddUint32(&p.codeRequests, 1)
+	go func() {
+		res := &ByteCodesPacket{ID: id}
+		if !p.stateless {
+			res.Codes = ServiceGetByteCodesQuery(p.chain, &GetByteCodesPacket{ID: id, Hashes: hashes, Bytes: p.limit(bytes)})
+		}
+		p.syncer.Deliver(p, res)
+	}()
+	return nil
+}
+
+func (p *testPeer) RequestTrieNodes(id uint64, root common.Hash, paths []TrieNodePathSet, bytes uint64) error {
+	atomic.AddUint32(&p.nodeRequests, 1)
+	go func() {
+		res := &TrieNodesPacket{ID: id}
+		if !p.stateless {
+			res.Nodes, _ = ServiceGetTrieNodesQuery(p.chain, &GetTrieNodesPacket{ID: id, Root: root, Paths: paths, Bytes: p.limit(bytes)})
+		}
+		p.syncer.Deliver(p, res)
+	}()
+	return nil
+}
+
+// newTestSyncer creates a syncer over an empty database with the given peers.
+func newTestSyncer(t *testing.T, peers ...*testPeer) (*Syncer, ethdb.Database) {
+	db := rawdb.NewMemoryDatabase()
+	syncer := NewSyncer(db, trie.NewSyncBloom(1, db))
+	for _, peer := range peers {
+		peer.syncer = syncer
+		if err := syncer.Register(peer); err != nil {
+			t.Fatalf("failed to register peer: %v", err)
+		}
+	}
+	return syncer, db
+}
+
+// syncState runs a sync cycle to the given root, failing on timeout.
+func syncState(t *testing.T, syncer *Syncer, root common.Hash) {
+	cancel := make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- syncer.Sync(root, cancel) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("sync failed: %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		close(cancel)
+		t.Fatalf("sync timed out")
+	}
+}
+
+// checkState verifies that the entire state of a root is present in a database,
+// returning the number of accounts in it.
+func checkState(t *testing.T, db ethdb.Database, root common.Hash) int {
+	triedb := trie.NewDatabase(db)
+	accTrie, err := trie.New(root, triedb)
+	if err != nil {
+		t.Fatalf("state root missing: %v", err)
+	}
+	accounts := 0
+	it := trie.NewIterator(accTrie.NodeIterator(nil))
+	for it.Next() {
+		accounts++
+
+		var acc state.Account
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			t.Fatalf("invalid account: %v", err)
+		}
+		if acc.Root != emptyRoot {
+			stTrie, err := trie.New(acc.Root, triedb)
+			if err != nil {
+				t.Fatalf("storage root missing: %v", err)
+			}
+			sit := trie.NewIterator(stTrie.NodeIterator(nil))
+			for sit.Next() {
+			}
+			if sit.Err != nil {
+				t.Fatalf("storage trie incomplete: %v", sit.Err)
+			}
+		}
+		if hash := common.BytesToHash(acc.CodeHash); hash != emptyCode && len(rawdb.ReadCode(db, hash)) == 0 {
+			t.Fatalf("code %x missing", hash)
+		}
+	}
+	if it.Err != nil {
+		t.Fatalf("account trie incomplete: %v", it.Err)
+	}
+	return accounts
+}
+
+func TestSync(t *testing.T) {
+	chain, blocks := newTestChain(t, 500, 0)
+	defer chain.Stop()
+
+	var (
+		root  = blocks[0].Root()
+		peerA = &testPeer{id: "a", chain: chain, bytes: 4096}
+		peerB = &testPeer{id: "b", chain: chain, bytes: 4096}
+	)
+	syncer, db := newTestSyncer(t, peerA, peerB)
+	syncState(t, syncer, root)
+
+	if have := checkState(t, db, root); have != 501 {
+		t.Fatalf("account count mismatch: have %d, want %d", have, 501)
+	}
+	// Chunked ranges are assembled into the full trie, leaving nothing to heal
+	if nodes := atomic.LoadUint32(&peerA.nodeRequests) + atomic.LoadUint32(&peerB.nodeRequests); nodes != 0 {
+		t.Errorf("healing requests made: %d", nodes)
+	}
+	if storage := atomic.LoadUint32(&peerA.storageRequests) + atomic.LoadUint32(&peerB.storageRequests); storage < 2 {
+		t.Errorf("large storage trie not continued: %d storage requests", storage)
+	}
+}
+
+func TestSyncStaleSnapshot(t *testing.T) {
+	chain, blocks := newTestChain(t, 100, 0)
+	defer chain.Stop()
+
+	var (
+		root  = blocks[0].Root()
+		peer  = &testPeer{id: "peer", chain: chain, bytes: 4096}
+		stale = common.HexToHash("0x01")
+		large = crypto.Keccak256Hash(common.HexToAddress("0xffff").Bytes())
+	)
+	syncer, db := newTestSyncer(t, peer)
+
+	// Leave snapshot entries in the database which are not part of the state
+	rawdb.WriteAccountSnapshot(db, stale, snapshot.SlimAccountRLP(1, big.NewInt(1), emptyRoot, emptyCode[:]))
+	rawdb.WriteStorageSnapshot(db, large, stale, []byte{0x01})
+
+	syncState(t, syncer, root)
+
+	if have := checkState(t, db, root); have != 101 {
+		t.Fatalf("account count mismatch: have %d, want %d", have, 101)
+	}
+	// The delivered ranges replace the stale entries, assembling the exact tries
+	if blob := rawdb.ReadAccountSnapshot(db, stale); len(blob) != 0 {
+		t.Errorf("stale account snapshot retained: %x", blob)
+	}
+	if blob := rawdb.ReadStorageSnapshot(db, large, stale); len(blob) != 0 {
+		t.Errorf("stale storage snapshot retained: %x", blob)
+	}
+	if nodes := atomic.LoadUint32(&peer.nodeRequests); nodes != 0 {
+		t.Errorf("healing requests made: %d", nodes)
+	}
+}
+
+func TestSyncStatelessPeer(t *testing.T) {
+	chain, blocks := newTestChain(t, 100, 0)
+	defer chain.Stop()
+
+	var (
+		root      = blocks[0].Root()
+		stateless = &testPeer{id: "stateless", chain: chain, stateless: true}
+		full      = &testPeer{id: "full", chain: chain}
+	)
+	syncer, db := newTestSyncer(t, stateless, full)
+	syncState(t, syncer, root)
+
+	if have := checkState(t, db, root); have != 101 {
+		t.Fatalf("account count mismatch: have %d, want %d", have, 101)
+	}
+	if requests := atomic.LoadUint32(&stateless.accountRequests); requests > 1 {
+		t.Errorf("stateless peer requested %d times", requests)
+	}
+}
+
+func TestSyncMovingRoot(t *testing.T) {
+	chain, blocks := newTestChain(t, 100, 3)
+	defer chain.Stop()
+
+	peer := &testPeer{id: "peer", chain: chain}
+	syncer, db := newTestSyncer(t, peer)
+
+	syncState(t, syncer, blocks[0].Root())
+	requests := atomic.LoadUint32(&peer.accountRequests)
+
+	// Moving the root only heals the state, without retrieving the ranges again
+	root := blocks[len(blocks)-1].Root()
+	syncState(t, syncer, root)
+
+	if have := checkState(t, db, root); have != 101+3 {
+		t.Fatalf("account count mismatch: have %d, want %d", have, 101+3)
+	}
+	if have := atomic.LoadUint32(&peer.accountRequests); have != requests {
+		t.Errorf("account ranges retrieved again: %d requests", have-requests)
+	}
+	if atomic.LoadUint32(&peer.nodeRequests) == 0 {
+		t.Errorf("state not healed")
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// callFrame is a single call reported by the call tracer. The exported fields
+// follow the JSON layout of the JavaScript callTracer, the unexported ones are
+// the bookkeeping needed while the call is still executing.
+type callFrame struct {
+	Type    string       `json:"type,omitempty"`
+	From    string       `json:"from,omitempty"`
+	To      string       `json:"to,omitempty"`
+	Value   string       `json:"value,omitempty"`
+	Gas     string       `json:"gas,omitempty"`
+	GasUsed string       `json:"gasUsed,omitempty"`
+	Input   string       `json:"input,omitempty"`
+	Output  string       `json:"output,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Time    string       `json:"time,omitempty"`
+	Calls   []*callFrame `json:"calls,omitempty"`
+
+	gas     *big.Int // Gas allowance of the call, known once it starts executing
+	gasIn   uint64   // Gas available before the calling opcode
+	gasCost uint64   // Cost of the calling opcode
+	outOff  *big.Int // Memory offset of the return data
+	outLen  *big.Int // Memory length of the return data
+}
+
+// format replaces the gas allowance with its hex encoded form, as done when the
+// frame is finished.
+func (f *callFrame) format() {
+	if f.gas != nil {
+		f.Gas = hexutil.EncodeBig(f.gas)
+	}
+}
+
+// callstack is the recursive call stack of the EVM execution.
+type callstack struct {
+	frames    []*callFrame
+	descended bool // Whether we've just descended into an inner call
+}
+
+// top returns the innermost call being executed.
+func (s *callstack) top() *callFrame {
+	return s.frames[len(s.frames)-1]
+}
+
+// push adds a new inner call.
+func (s *callstack) push(call *callFrame) {
+	s.frames = append(s.frames, call)
+	s.descended = true
+}
+
+// pop removes the innermost call.
+func (s *callstack) pop() *callFrame {
+	call := s.top()
+	s.frames = s.frames[:len(s.frames)-1]
+	return call
+}
+
+// nest appends a finished call to the one which made it.
+func (s *callstack) nest(call *callFrame) {
+	parent := s.top()
+	parent.Calls = append(parent.Calls, call)
+}
+
+// stepFilter decides which opcodes the call tracers need to inspect, skipping
+// all the ones which cannot affect the call stack.
+type stepFilter struct {
+	handleNext bool // Whether the next opcode needs to be inspected
+}
+
+// relevant reports whether an opcode needs to be inspected given the number of
+// calls on the stack: the ones returning to the innermost call's caller, the
+// system opcodes and the ones right after them.
+func (f *stepFilter) relevant(op vm.OpCode, depth int, calls int) bool {
+	switch {
+	case calls-1 == depth:
+	case f.handleNext:
+		f.handleNext = false
+	case op&0xf0 == 0xf0:
+		f.handleNext = true
+	default:
+		return false
+	}
+	return true
+}
+
+// CallTracer is a native implementation of the callTracer, which extracts and
+// reports all the internal calls made by a transaction.
+type CallTracer struct {
+	txContext
+	callstack
+	stepFilter
+}
+
+// NewCallTracer creates a new native callTracer.
+func NewCallTracer() *CallTracer {
+	return &CallTracer{callstack: callstack{frames: []*callFrame{{}}}}
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *CallTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	if !t.capture(env) || !t.relevant(op, depth, len(t.frames)) {
+		return nil
+	}
+	// Capture any errors immediately
+	if err != nil {
+		t.fault(err)
+		return nil
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		// If a new contract is being created, add to the call stack
+		t.push(&callFrame{
+			Type:    op.String(),
+			From:    hexutil.Encode(contract.Address().Bytes()),
+			Input:   hexutil.Encode(memorySlice(memory, peek(stack, 1), peek(stack, 2))),
+			Value:   hexutil.EncodeBig(peek(stack, 0)),
+			gas:     new(big.Int).SetUint64(env.CallGasTemp),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		return nil
+
+	case vm.SELFDESTRUCT:
+		// If a contract is being self destructed, gather that as a subcall too
+		t.nest(&callFrame{
+			Type:  op.String(),
+			From:  hexutil.Encode(contract.Address().Bytes()),
+			To:    hexutil.Encode(peekAddress(stack, 0).Bytes()),
+			Value: hexutil.EncodeBig(env.StateDB.GetBalance(contract.Address())),
+		})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Skip any pre-compile invocations, those are just fancy opcodes
+		to := peekAddress(stack, 1)
+		if isPrecompiled(to) {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		call := &callFrame{
+			Type:    op.String(),
+			From:    hexutil.Encode(contract.Address().Bytes()),
+			To:      hexutil.Encode(to.Bytes()),
+			Input:   hexutil.Encode(memorySlice(memory, peek(stack, 2+off), peek(stack, 3+off))),
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  peek(stack, 4+off),
+			outLen:  peek(stack, 5+off),
+		}
+		if off == 1 {
+			call.Value = hexutil.EncodeBig(peek(stack, 2))
+		}
+		t.push(call)
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve it's true allowance. We
+	// need to extract if from within the call as there may be funky gas dynamics
+	// with regard to requested and actually given gas (2300 stipend, 63/64 rule).
+	if t.descended {
+		if depth >= len(t.frames) {
+			t.top().gas = new(big.Int).SetUint64(gas)
+		}
+		t.descended = false
+	}
+	// If an existing call is returning, pop off the call stack
+	if op == vm.REVERT {
+		t.top().Error = "execution reverted"
+		return nil
+	}
+	if depth == len(t.frames)-1 {
+		// Pop off the last call and get the execution results
+		call := t.pop()
+		ret := peek(stack, 0)
+
+		if call.Type == vm.CREATE.String() || call.Type == vm.CREATE2.String() {
+			// If the call was a CREATE, retrieve the contract address and output code
+			used := new(big.Int).Sub(call.gas, new(big.Int).SetUint64(gas))
+			used.Add(used, new(big.Int).SetUint64(call.gasIn-call.gasCost))
+			call.GasUsed = hexutil.EncodeBig(used)
+
+			if ret.Sign() != 0 {
+				addr := common.BigToAddress(ret)
+				call.To = hexutil.Encode(addr.Bytes())
+				call.Output = hexutil.Encode(env.StateDB.GetCode(addr))
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		} else {
+			// If the call was a contract call, retrieve the gas usage and output
+			if call.gas != nil {
+				used := new(big.Int).SetUint64(call.gasIn - call.gasCost)
+				used.Add(used, call.gas)
+				used.Sub(used, new(big.Int).SetUint64(gas))
+				call.GasUsed = hexutil.EncodeBig(used)
+			}
+			if ret.Sign() != 0 {
+				call.Output = hexutil.Encode(memorySlice(memory, call.outOff, call.outLen))
+			} else if call.Error == "" {
+				call.Error = "internal failure"
+			}
+		}
+		call.format()
+		t.nest(call)
+	}
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *CallTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	if t.failure == nil {
+		t.fault(err)
+	}
+	return nil
+}
+
+// fault handles the failure of the innermost call.
+func (t *CallTracer) fault(err error) {
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.top().Error != "" {
+		return
+	}
+	// Pop off the just failed call, consuming all available gas
+	call := t.pop()
+	call.Error = err.Error()
+	if call.gas != nil {
+		call.format()
+		call.GasUsed = call.Gas
+	}
+	// Flatten the failed call into its parent, unless it's the last one
+	if len(t.frames) > 0 {
+		t.nest(call)
+		return
+	}
+	t.frames = append(t.frames, call)
+}
+
+// GetResult returns the outermost call along with all its internal calls in
+// the format of the JavaScript callTracer.
+func (t *CallTracer) GetResult() (json.RawMessage, error) {
+	if t.failure != nil {
+		return nil, t.failure
+	}
+	result := &callFrame{
+		Type:    t.typ,
+		From:    hexutil.Encode(t.from.Bytes()),
+		To:      hexutil.Encode(t.to.Bytes()),
+		Value:   hexutil.EncodeBig(t.value),
+		Gas:     hexutil.EncodeUint64(t.gas),
+		GasUsed: hexutil.EncodeUint64(t.gasUsed),
+		Input:   hexutil.Encode(t.input),
+		Output:  hexutil.Encode(t.output),
+		Time:    t.time,
+		Calls:   t.frames[0].Calls,
+		Error:   t.frames[0].Error,
+	}
+	if result.Error == "" && t.err != nil {
+		result.Error = t.err.Error()
+	}
+	if result.Error != "" && (result.Error != "execution reverted" || result.Output == "0x") {
+		result.Output = ""
+	}
+	return json.Marshal(result)
+}
@@ -0,0 +1,502 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// errEmptyCallstack is returned if the traced execution unwound more calls than
+// were recorded, which can only happen if the call opcodes were misreported.
+var errEmptyCallstack = errors.New("call stack exhausted")
+
+var (
+	// parityErrorMapping translates EVM errors into their Parity equivalent.
+	parityErrorMapping = map[string]string{
+		"contract creation code storage out of gas": "Out of gas",
+		"out of gas":                      "Out of gas",
+		"gas uint64 overflow":             "Out of gas",
+		"max code size exceeded":          "Out of gas",
+		"invalid jump destination":        "Bad jump destination",
+		"execution reverted":              "Reverted",
+		"return data out of bounds":       "Out of bounds",
+		"stack limit reached 1024 (1023)": "Out of stack",
+		"precompiled failed":              "Built-in failed",
+	}
+
+	// parityErrorMappingContaining translates EVM errors with variable details
+	// into their Parity equivalent.
+	parityErrorMappingContaining = []struct{ search, replace string }{
+		{"invalid opcode:", "Bad instruction"},
+		{"stack underflow", "Stack underflow"},
+	}
+
+	// paritySkipTracesForErrors lists the call errors whose calls are not reported.
+	paritySkipTracesForErrors = map[string]bool{
+		"insufficient balance for transfer": true,
+	}
+)
+
+// parityFrame is a single call gathered by the Parity call tracer, before being
+// flattened into the reported traces.
+type parityFrame struct {
+	typ     string
+	from    string
+	to      string
+	value   string
+	input   string
+	output  *string
+	err     string
+	gasUsed string
+	calls   []*parityFrame
+
+	gas     *big.Int // Gas allowance of the call, until it's finished
+	gasHex  string   // Gas allowance of the call, once finished
+	gasIn   uint64   // Gas available before the calling opcode
+	gasCost uint64   // Cost of the calling opcode
+
+	block *uint64 // Number of the block, outermost call only
+	time  string  // Execution time, outermost call only
+}
+
+// empty reports whether no details at all have been gathered for the frame.
+func (f *parityFrame) empty() bool {
+	return f.typ == "" && f.err == "" && f.output == nil && f.gas == nil && f.gasHex == "" && f.gasUsed == "" && f.calls == nil
+}
+
+// format replaces the gas allowance with its hex encoded form.
+func (f *parityFrame) format() {
+	if f.gas != nil {
+		f.gasHex = hexutil.EncodeBig(f.gas)
+		f.gas = nil
+	}
+}
+
+// parityTrace is a single flattened call in the format of Parity's trace module.
+type parityTrace struct {
+	Type                string      `json:"type"`
+	Action              interface{} `json:"action"`
+	Result              interface{} `json:"result,omitempty"`
+	Error               string      `json:"error,omitempty"`
+	TraceAddress        []int       `json:"traceAddress"`
+	Subtraces           int         `json:"subtraces"`
+	TransactionPosition *uint64     `json:"transactionPosition,omitempty"`
+	TransactionHash     *string     `json:"transactionHash,omitempty"`
+	BlockNumber         *uint64     `json:"blockNumber,omitempty"`
+	BlockHash           *string     `json:"blockHash,omitempty"`
+	Time                string      `json:"time,omitempty"`
+}
+
+type parityCallAction struct {
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Gas      string `json:"gas,omitempty"`
+	Input    string `json:"input,omitempty"`
+	CallType string `json:"callType,omitempty"`
+}
+
+type parityCallResult struct {
+	GasUsed string `json:"gasUsed,omitempty"`
+	Output  string `json:"output,omitempty"`
+}
+
+type parityCreateAction struct {
+	From           string `json:"from,omitempty"`
+	Value          string `json:"value,omitempty"`
+	Gas            string `json:"gas,omitempty"`
+	Init           string `json:"init,omitempty"`
+	CreationMethod string `json:"creationMethod,omitempty"`
+}
+
+type parityCreateResult struct {
+	GasUsed string `json:"gasUsed,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+type paritySuicideAction struct {
+	Address       string `json:"address,omitempty"`
+	RefundAddress string `json:"refundAddress,omitempty"`
+	Balance       string `json:"balance,omitempty"`
+}
+
+// CallTracerParity is a native implementation of the callTracerParity, which
+// reports all the internal calls made by a transaction as Parity style traces.
+type CallTracerParity struct {
+	txContext
+	stepFilter
+
+	frames    []*parityFrame
+	descended bool    // Whether we've just descended into an inner call
+	callErr   *string // Error of the last inner call, if it failed
+}
+
+// NewCallTracerParity creates a new native callTracerParity.
+func NewCallTracerParity() *CallTracerParity {
+	return &CallTracerParity{frames: []*parityFrame{{}}}
+}
+
+// top returns the innermost call being executed.
+func (t *CallTracerParity) top() *parityFrame {
+	return t.frames[len(t.frames)-1]
+}
+
+// pop removes the innermost call.
+func (t *CallTracerParity) pop() *parityFrame {
+	call := t.top()
+	t.frames = t.frames[:len(t.frames)-1]
+	return call
+}
+
+// nest appends a finished call to the one which made it.
+func (t *CallTracerParity) nest(call *parityFrame) {
+	parent := t.top()
+	parent.calls = append(parent.calls, call)
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *CallTracerParity) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	if !t.capture(env) {
+		return nil
+	}
+	t.callErr = nil
+	if env.CallErrorTemp != nil {
+		msg := env.CallErrorTemp.Error()
+		t.callErr = &msg
+		env.CallErrorTemp = nil
+	}
+	if !t.relevant(op, depth, len(t.frames)) {
+		return nil
+	}
+	// Capture any errors immediately
+	if err != nil {
+		t.fault(gas, err)
+		return nil
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		// If a new contract is being created, add to the call stack
+		t.frames = append(t.frames, &parityFrame{
+			typ:     op.String(),
+			from:    hexutil.Encode(contract.Address().Bytes()),
+			input:   hexutil.Encode(memorySlice(memory, peek(stack, 1), peek(stack, 2))),
+			value:   hexutil.EncodeBig(peek(stack, 0)),
+			gas:     new(big.Int).SetUint64(env.CallGasTemp),
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return nil
+
+	case vm.SELFDESTRUCT:
+		// If a contract is being self destructed, gather that as a subcall too
+		t.nest(&parityFrame{
+			typ:   op.String(),
+			from:  hexutil.Encode(contract.Address().Bytes()),
+			to:    hexutil.Encode(peekAddress(stack, 0).Bytes()),
+			value: hexutil.EncodeBig(env.StateDB.GetBalance(contract.Address())),
+		})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		// Skip any pre-compile invocations, those are just fancy opcodes
+		to := peekAddress(stack, 1)
+		if isPrecompiled(to) && (op == vm.CALL || op == vm.STATICCALL) {
+			return nil
+		}
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		call := &parityFrame{
+			typ:     op.String(),
+			from:    hexutil.Encode(contract.Address().Bytes()),
+			to:      hexutil.Encode(to.Bytes()),
+			input:   hexutil.Encode(memorySlice(memory, peek(stack, 2+off), peek(stack, 3+off))),
+			gas:     new(big.Int).SetUint64(env.CallGasTemp),
+			gasIn:   gas,
+			gasCost: cost,
+		}
+		switch op {
+		case vm.CALL, vm.CALLCODE:
+			value := peek(stack, 2)
+			call.value = hexutil.EncodeBig(value)
+
+			// Add the stipend granted to value transfers
+			if value.Sign() > 0 {
+				call.gas.Add(call.gas, new(big.Int).SetUint64(2300))
+			}
+		case vm.STATICCALL:
+			call.value = "0x0"
+		}
+		t.frames = append(t.frames, call)
+		t.descended = true
+		return nil
+	}
+	// If we've just descended into an inner call, retrieve it's true allowance. We
+	// need to extract if from within the call as there may be funky gas dynamics
+	// with regard to requested and actually given gas (2300 stipend, 63/64 rule).
+	if t.descended {
+		if call := t.top(); depth >= len(t.frames) && call.gas == nil && call.gasHex == "" {
+			call.gas = new(big.Int).SetUint64(gas)
+		}
+		t.descended = false
+	}
+	switch op {
+	case vm.REVERT:
+		t.top().err = "execution reverted"
+		return nil
+
+	case vm.RETURN:
+		if depth == len(t.frames) {
+			output := hexutil.Encode(memorySlice(memory, peek(stack, 0), peek(stack, 1)))
+			t.top().output = &output
+		}
+		return nil
+	}
+	if depth != len(t.frames)-1 {
+		return nil
+	}
+	// Pop off the last call and get the execution results
+	call := t.pop()
+	ret := peek(stack, 0)
+
+	if call.typ == vm.CREATE.String() || call.typ == vm.CREATE2.String() {
+		// If the call was a CREATE, retrieve the contract address and output code
+		used := new(big.Int).Sub(call.gas, new(big.Int).SetUint64(gas))
+		used.Add(used, new(big.Int).SetUint64(call.gasIn-call.gasCost))
+		call.gasUsed = hexutil.EncodeBig(used)
+
+		if ret.Sign() != 0 {
+			addr := common.BigToAddress(ret)
+			output := hexutil.Encode(env.StateDB.GetCode(addr))
+			call.to = hexutil.Encode(addr.Bytes())
+			call.output = &output
+		} else if call.err == "" {
+			if t.callErr == nil || paritySkipTracesForErrors[*t.callErr] {
+				return nil
+			}
+			call.err = *t.callErr
+		}
+	} else {
+		// If the call was a contract call, retrieve the gas usage and output
+		if call.gas != nil {
+			used := new(big.Int).SetUint64(call.gasIn - call.gasCost)
+			used.Add(used, call.gas)
+			used.Sub(used, new(big.Int).SetUint64(gas))
+			call.gasUsed = hexutil.EncodeBig(used)
+		}
+		if ret.Sign() != 0 {
+			if call.output == nil || *call.output == "0x" {
+				output := hexutil.Encode(rData)
+				call.output = &output
+			}
+		} else if call.err == "" {
+			switch {
+			case t.callErr == nil:
+				call.err = "internal failure"
+			case paritySkipTracesForErrors[*t.callErr]:
+				return nil
+			case isPrecompiled(common.HexToAddress(call.to)) && *t.callErr != "out of gas":
+				call.err = "precompiled failed"
+			default:
+				call.err = *t.callErr
+			}
+		}
+	}
+	call.format()
+	t.nest(call)
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *CallTracerParity) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	if t.failure == nil {
+		t.callErr = nil
+		env.CallErrorTemp = nil
+		t.fault(gas, err)
+	}
+	return nil
+}
+
+// fault handles the failure of the innermost call.
+func (t *CallTracerParity) fault(gas uint64, err error) {
+	if len(t.frames) == 0 {
+		t.failure = errEmptyCallstack
+		return
+	}
+	// If the topmost call already reverted, don't handle the additional fault again
+	if t.top().err != "" {
+		return
+	}
+	// Pop off the just failed call
+	call := t.pop()
+	call.err = err.Error()
+	if t.callErr != nil {
+		if paritySkipTracesForErrors[*t.callErr] {
+			return
+		}
+		call.err = *t.callErr
+	}
+	// Consume all available gas, or retrieve the true allowance of the inner call
+	if call.gas != nil {
+		call.format()
+		call.gasUsed = call.gasHex
+	} else {
+		call.gasHex = hexutil.EncodeUint64(gas)
+	}
+	// Flatten the failed call into its parent, unless it's the last one
+	if len(t.frames) > 0 {
+		t.nest(call)
+		return
+	}
+	t.frames = append(t.frames, call)
+}
+
+// GetResult returns the calls made by the transaction flattened into a list of
+// Parity style traces.
+func (t *CallTracerParity) GetResult() (json.RawMessage, error) {
+	if t.failure == nil && len(t.frames) == 0 {
+		t.failure = errEmptyCallstack
+	}
+	if t.failure != nil {
+		return nil, t.failure
+	}
+	output := hexutil.Encode(t.output)
+	result := &parityFrame{
+		typ:     t.typ,
+		from:    hexutil.Encode(t.from.Bytes()),
+		to:      hexutil.Encode(t.to.Bytes()),
+		value:   hexutil.EncodeBig(t.value),
+		gasHex:  hexutil.EncodeUint64(t.gas),
+		gasUsed: hexutil.EncodeUint64(t.gasUsed),
+		input:   hexutil.Encode(t.input),
+		output:  &output,
+		time:    t.time,
+		block:   t.block,
+	}
+	// When the outermost call was never filled in while the tracer descended into
+	// an inner one, e.g. init code creating a contract and self destructing
+	// before it gets to run, the inner call is the relevant one.
+	if t.descended && len(t.frames) > 1 && t.frames[0].empty() {
+		t.frames = t.frames[1:]
+	}
+	result.calls = t.frames[0].calls
+	result.err = t.frames[0].err
+	if result.err == "" && t.err != nil {
+		result.err = t.err.Error()
+	}
+	if result.err != "" && (result.err != "execution reverted" || output == "0x") {
+		result.output = nil
+	}
+	return json.Marshal(t.finalize(result, []int{}))
+}
+
+// finalize flattens the call and all its internal ones into Parity traces.
+func (t *CallTracerParity) finalize(call *parityFrame, traceAddress []int) []*parityTrace {
+	var output string
+	if call.output != nil {
+		output = *call.output
+	}
+	trace := &parityTrace{
+		Error:               call.err,
+		TraceAddress:        traceAddress,
+		TransactionPosition: t.transactionPosition,
+		TransactionHash:     t.transactionHash,
+		BlockNumber:         t.blockNumber,
+		BlockHash:           t.blockHash,
+		Time:                call.time,
+	}
+	if call.block != nil && *call.block != 0 {
+		trace.BlockNumber = call.block
+	}
+	switch call.typ {
+	case vm.CREATE.String(), vm.CREATE2.String():
+		trace.Type = "create"
+		trace.Action = &parityCreateAction{
+			From:           call.from,
+			Value:          call.value,
+			Gas:            call.gasHex,
+			Init:           call.input,
+			CreationMethod: strings.ToLower(call.typ),
+		}
+		trace.Result = &parityCreateResult{
+			GasUsed: call.gasUsed,
+			Code:    output,
+			Address: call.to,
+		}
+	case vm.SELFDESTRUCT.String():
+		trace.Type = "suicide"
+		trace.Action = &paritySuicideAction{
+			Address:       call.from,
+			RefundAddress: call.to,
+			Balance:       call.value,
+		}
+		trace.Result = json.RawMessage("null")
+	default:
+		trace.Type = "call"
+		trace.Action = &parityCallAction{
+			From:     call.from,
+			To:       call.to,
+			Value:    call.value,
+			Gas:      call.gasHex,
+			Input:    call.input,
+			CallType: strings.ToLower(call.typ),
+		}
+		trace.Result = &parityCallResult{
+			GasUsed: call.gasUsed,
+			Output:  output,
+		}
+	}
+	if trace.Error != "" {
+		if mapped, ok := parityErrorMapping[trace.Error]; ok {
+			trace.Error = mapped
+			trace.Result = nil
+		} else {
+			for _, mapping := range parityErrorMappingContaining {
+				if strings.Contains(trace.Error, mapping.search) {
+					trace.Error = mapping.replace
+					trace.Result = nil
+				}
+			}
+		}
+	}
+	trace.Subtraces = len(call.calls)
+
+	traces := []*parityTrace{trace}
+	for i, child := range call.calls {
+		// Delegatecall uses the value from parent
+		if (child.typ == vm.DELEGATECALL.String() || child.typ == vm.STATICCALL.String()) && child.value == "" {
+			child.value = call.value
+		}
+		childAddress := make([]int, len(traceAddress)+1)
+		copy(childAddress, traceAddress)
+		childAddress[len(traceAddress)] = i
+
+		traces = append(traces, t.finalize(child, childAddress)...)
+	}
+	return traces
+}
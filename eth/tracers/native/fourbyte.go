@@ -0,0 +1,90 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// FourByteTracer is a native implementation of the 4byteTracer, which searches
+// for 4byte-identifiers and collects them for post-processing. The identifiers
+// are collected along with the size of the supplied data, so a reversed
+// signature can be matched against the size of the data.
+type FourByteTracer struct {
+	txContext
+
+	ids map[string]int // ids aggregates the 4byte ids found
+}
+
+// NewFourByteTracer creates a new native 4byteTracer.
+func NewFourByteTracer() *FourByteTracer {
+	return &FourByteTracer{ids: make(map[string]int)}
+}
+
+// store saves the given identifier and data size.
+func (t *FourByteTracer) store(id []byte, size *big.Int) {
+	t.ids[hexutil.Encode(id)+"-"+size.String()]++
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *FourByteTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	if !t.capture(env) {
+		return nil
+	}
+	// Skip any opcodes that are not internal calls, finding the stack position
+	// of the input offset otherwise
+	var in int
+	switch op {
+	case vm.CALL, vm.CALLCODE:
+		in = 3
+	case vm.DELEGATECALL, vm.STATICCALL:
+		in = 2
+	default:
+		return nil
+	}
+	// Skip any pre-compile invocations, those are just fancy opcodes
+	if isPrecompiled(peekAddress(stack, 1)) {
+		return nil
+	}
+	// Gather internal call details
+	if size := peek(stack, in+1); size.Cmp(big.NewInt(4)) >= 0 {
+		t.store(memorySlice(memory, peek(stack, in), big.NewInt(4)), size.Sub(size, big.NewInt(4)))
+	}
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *FourByteTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// GetResult returns the number of occurrences of each identifier and data size
+// pair, including the ones of the outer call data.
+func (t *FourByteTracer) GetResult() (json.RawMessage, error) {
+	if t.failure != nil {
+		return nil, t.failure
+	}
+	if len(t.input) >= 4 {
+		t.store(t.input[:4], big.NewInt(int64(len(t.input)-4)))
+	}
+	return json.Marshal(t.ids)
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// init registers the Go implementations of the built in JavaScript tracers, so
+// that they are picked up instead of the interpreted ones.
+func init() {
+	tracers.RegisterNative("callTracer", func() tracers.TxTracer { return NewCallTracer() })
+	tracers.RegisterNative("callTracerParity", func() tracers.TxTracer { return NewCallTracerParity() })
+	tracers.RegisterNative("prestateTracer", func() tracers.TxTracer { return NewPrestateTracer() })
+	tracers.RegisterNative("4byteTracer", func() tracers.TxTracer { return NewFourByteTracer() })
+}
+
+// precompiles is the set of precompiled contracts the built in tracers treat as
+// plain opcodes, matching the isPrecompiled helper of the JavaScript tracers.
+var precompiles = vm.PrecompiledContractsForConfig(params.AllEthashProtocolChanges, big.NewInt(0))
+
+// isPrecompiled reports whether the given address is a precompiled contract.
+func isPrecompiled(addr common.Address) bool {
+	_, ok := precompiles[addr]
+	return ok
+}
+
+// txContext holds the transaction context gathered throughout execution, along
+// with the interruption handling shared by all the named native tracers. It
+// mirrors the 'ctx' object passed to the result function of JavaScript tracers.
+type txContext struct {
+	typ     string
+	from    common.Address
+	to      common.Address
+	input   []byte
+	gas     uint64
+	value   *big.Int
+	output  []byte
+	gasUsed uint64
+	time    string
+	err     error
+
+	block *uint64 // number of the block, known once the first opcode executes
+
+	// Extra context injected via CaptureExtraContext
+	blockHash           *string
+	blockNumber         *uint64
+	transactionHash     *string
+	transactionPosition *uint64
+
+	failure   error  // Error which occurred during tracing, if any
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// CaptureStart implements the vm.Tracer interface to initialize the tracing operation.
+func (c *txContext) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	c.typ = "CALL"
+	if create {
+		c.typ = "CREATE"
+	}
+	c.from, c.to = from, to
+	c.input = common.CopyBytes(input)
+	c.gas = gas
+	c.value = new(big.Int).Set(value)
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (c *txContext) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	c.output = common.CopyBytes(output)
+	c.gasUsed = gasUsed
+	c.time = t.String()
+	c.err = err
+	return nil
+}
+
+// CaptureExtraContext injects additional context about the traced transaction,
+// i.e. its position within the chain.
+func (c *txContext) CaptureExtraContext(inputs map[string]interface{}) error {
+	for key, val := range inputs {
+		switch val := val.(type) {
+		case string:
+			switch key {
+			case "blockHash":
+				c.blockHash = &val
+			case "transactionHash":
+				c.transactionHash = &val
+			}
+		case uint64:
+			switch key {
+			case "blockNumber":
+				c.blockNumber = &val
+			case "transactionPosition":
+				c.transactionPosition = &val
+			}
+		}
+	}
+	return nil
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (c *txContext) Stop(err error) {
+	c.reason = err
+	atomic.StoreUint32(&c.interrupt, 1)
+}
+
+// capture reports whether the current step should be processed, initializing
+// the block context on the first one and honouring interruptions.
+func (c *txContext) capture(env *vm.EVM) bool {
+	if c.failure != nil {
+		return false
+	}
+	if c.block == nil {
+		number := env.BlockNumber.Uint64()
+		c.block = &number
+	}
+	if atomic.LoadUint32(&c.interrupt) > 0 {
+		c.failure = c.reason
+		return false
+	}
+	return true
+}
+
+// peek returns the nth-from-the-top element of the stack, or zero if the stack
+// is not deep enough.
+func peek(stack *vm.Stack, n int) *big.Int {
+	if len(stack.Data()) <= n || n < 0 {
+		return new(big.Int)
+	}
+	return stack.Back(n).ToBig()
+}
+
+// peekAddress returns the nth-from-the-top element of the stack as an address.
+func peekAddress(stack *vm.Stack, n int) common.Address {
+	return common.BigToAddress(peek(stack, n))
+}
+
+// memorySlice returns a copy of the memory in the range [offset, offset+size),
+// or an empty slice if the range is out of bounds.
+func memorySlice(memory *vm.Memory, offset, size *big.Int) []byte {
+	if !offset.IsUint64() || !size.IsUint64() {
+		return []byte{}
+	}
+	off, n := offset.Uint64(), size.Uint64()
+	if n == 0 || off+n < off || off+n > uint64(memory.Len()) {
+		return []byte{}
+	}
+	return memory.GetCopy(int64(off), int64(n))
+}
@@ -0,0 +1,279 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/vars"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+var (
+	testKey, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testOrigin   = crypto.PubkeyToAddress(testKey.PublicKey)
+	testMiner    = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+	testContract = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+)
+
+// runTracedTx applies a transaction calling the test contract running the given
+// code, with the tracer attached to the EVM.
+func runTracedTx(t *testing.T, code []byte, newTracer func(statedb *state.StateDB) vm.Tracer) *state.StateDB {
+	alloc := genesisT.GenesisAlloc{
+		testContract: {Code: code, Balance: big.NewInt(0)},
+		testOrigin:   {Balance: big.NewInt(vars.Ether)},
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), alloc, false)
+
+	signer := types.NewEIP155Signer(params.TestChainConfig.GetChainID())
+	tx, err := types.SignTx(types.NewTransaction(0, testContract, big.NewInt(1), 100000, big.NewInt(1), nil), signer, testKey)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	msg, err := tx.AsMessage(signer)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      testOrigin,
+		Coinbase:    testMiner,
+		BlockNumber: big.NewInt(8000000),
+		Time:        big.NewInt(5),
+		Difficulty:  big.NewInt(0x30000),
+		GasLimit:    uint64(6000000),
+		GasPrice:    big.NewInt(1),
+	}
+	tracer := newTracer(statedb)
+	evm := vm.NewEVM(context, statedb, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+	if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	statedb.Finalise(true)
+	return statedb
+}
+
+func TestVMTracer(t *testing.T) {
+	tracer := NewVMTracer()
+	// PUSH1 1, PUSH1 0, SSTORE, PUSH1 0x20, PUSH1 0, MSTORE, STOP
+	code := hexutil.MustDecode("0x600160005560206000520000")
+	runTracedTx(t, code, func(*state.StateDB) vm.Tracer { return tracer })
+
+	trace := tracer.GetResult()
+	if have, want := len(trace.Ops), 7; have != want {
+		t.Fatalf("op count mismatch: have %d, want %d", have, want)
+	}
+	if store := trace.Ops[2].Ex.Store; store == nil || store.Key.ToInt().Uint64() != 0 || store.Val.ToInt().Uint64() != 1 {
+		t.Errorf("sstore mismatch: have %+v", store)
+	}
+	if push := trace.Ops[0].Ex.Push; len(push) != 1 || push[0].ToInt().Uint64() != 1 {
+		t.Errorf("push mismatch: have %v", push)
+	}
+	if mem := trace.Ops[5].Ex.Mem; mem == nil || mem.Off != 0 || len(mem.Data) != 32 || mem.Data[31] != 0x20 {
+		t.Errorf("memory mismatch: have %+v", mem)
+	}
+	for i, op := range trace.Ops {
+		if op.Ex == nil {
+			t.Fatalf("op %d: missing execution result", i)
+		}
+		if i > 0 && op.Ex.Used > trace.Ops[i-1].Ex.Used {
+			t.Errorf("op %d: gas used increased", i)
+		}
+	}
+}
+
+func TestStateDiffTracer(t *testing.T) {
+	var tracer *StateDiffTracer
+	// PUSH1 1, PUSH1 0, SSTORE, STOP
+	code := hexutil.MustDecode("0x600160005500")
+	statedb := runTracedTx(t, code, func(statedb *state.StateDB) vm.Tracer {
+		tracer = NewStateDiffTracer(statedb.Copy())
+		tracer.TouchAccount(testMiner)
+		return tracer
+	})
+	diff := tracer.GetResult(statedb)
+
+	blob, err := json.Marshal(diff)
+	if err != nil {
+		t.Fatalf("failed to marshal state diff: %v", err)
+	}
+	var res map[common.Address]map[string]interface{}
+	if err := json.Unmarshal(blob, &res); err != nil {
+		t.Fatalf("failed to unmarshal state diff: %v", err)
+	}
+	if len(res) != 3 {
+		t.Fatalf("account count mismatch: have %d, want 3: %s", len(res), blob)
+	}
+	if _, ok := res[testMiner]["balance"].(map[string]interface{})["+"]; !ok {
+		t.Errorf("miner not reported as created: %s", blob)
+	}
+	if res[testOrigin]["nonce"].(map[string]interface{})["*"] == nil {
+		t.Errorf("sender nonce not reported as changed: %s", blob)
+	}
+	if res[testContract]["code"] != "=" {
+		t.Errorf("contract code reported as changed: %s", blob)
+	}
+	storage := res[testContract]["storage"].(map[string]interface{})
+	slot := storage[common.Hash{}.Hex()].(map[string]interface{})["*"].(map[string]interface{})
+	if slot["to"] != common.BigToHash(big.NewInt(1)).Hex() {
+		t.Errorf("storage change mismatch: %s", blob)
+	}
+}
+
+// tracerTest is the subset of the JavaScript tracer test fixtures needed to
+// replay their transactions.
+type tracerTest struct {
+	Genesis *genesisT.Genesis `json:"genesis"`
+	Context struct {
+		Number     math.HexOrDecimal64   `json:"number"`
+		Difficulty *math.HexOrDecimal256 `json:"difficulty"`
+		Time       math.HexOrDecimal64   `json:"timestamp"`
+		GasLimit   math.HexOrDecimal64   `json:"gasLimit"`
+		Miner      common.Address        `json:"miner"`
+	} `json:"context"`
+	Input string `json:"input"`
+}
+
+// runFixture replays the transaction of a JavaScript tracer test fixture with
+// the given tracer attached, returning the tracing result.
+func runFixture(t *testing.T, test *tracerTest, tracer tracers.TxTracer) (interface{}, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(test.Input), tx); err != nil {
+		t.Fatalf("failed to parse testcase input: %v", err)
+	}
+	signer := types.MakeSigner(test.Genesis.Config, new(big.Int).SetUint64(uint64(test.Context.Number)))
+	origin, _ := signer.Sender(tx)
+
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		Origin:      origin,
+		Coinbase:    test.Context.Miner,
+		BlockNumber: new(big.Int).SetUint64(uint64(test.Context.Number)),
+		Time:        new(big.Int).SetUint64(uint64(test.Context.Time)),
+		Difficulty:  (*big.Int)(test.Context.Difficulty),
+		GasLimit:    uint64(test.Context.GasLimit),
+		GasPrice:    tx.GasPrice(),
+	}
+	_, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(), test.Genesis.Alloc, false)
+
+	tracer.CaptureExtraContext(map[string]interface{}{
+		"blockNumber":         uint64(test.Context.Number),
+		"blockHash":           common.Hash{0x01}.Hex(),
+		"transactionHash":     tx.Hash().Hex(),
+		"transactionPosition": uint64(2),
+	})
+	evm := vm.NewEVM(context, statedb, test.Genesis.Config, vm.Config{Debug: true, Tracer: tracer})
+
+	msg, err := tx.AsMessage(signer)
+	if err != nil {
+		t.Fatalf("failed to prepare transaction for tracing: %v", err)
+	}
+	if _, err = core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(tx.Gas())).TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		return nil, err
+	}
+	var ret interface{}
+	if err := json.Unmarshal(res, &ret); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	dropTime(ret)
+	return ret, nil
+}
+
+// dropTime removes the execution times from a tracing result, as they differ
+// between any two runs.
+func dropTime(res interface{}) {
+	switch res := res.(type) {
+	case map[string]interface{}:
+		delete(res, "time")
+		for _, v := range res {
+			dropTime(v)
+		}
+	case []interface{}:
+		for _, v := range res {
+			dropTime(v)
+		}
+	}
+}
+
+// Tests that the native tracers produce the same output as the JavaScript ones
+// they replace, over all the call tracer test fixtures.
+func TestNativeTracersMatchJavaScript(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "testdata", "*call_tracer_*.json"))
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		blob, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read testcase: %v", err)
+		}
+		test := new(tracerTest)
+		if err := json.Unmarshal(blob, test); err != nil {
+			t.Fatalf("failed to parse testcase: %v", err)
+		}
+		for _, name := range []string{"callTracer", "callTracerParity", "prestateTracer", "4byteTracer"} {
+			t.Run(strings.TrimSuffix(filepath.Base(file), ".json")+"/"+name, func(t *testing.T) {
+				jsTracer, err := tracers.New(name)
+				if err != nil {
+					t.Fatalf("failed to create JavaScript tracer: %v", err)
+				}
+				nativeTracer, err := tracers.NewTxTracer(name)
+				if err != nil {
+					t.Fatalf("failed to create native tracer: %v", err)
+				}
+				if _, ok := nativeTracer.(*tracers.Tracer); ok {
+					t.Fatalf("native tracer not registered")
+				}
+				want, wantErr := runFixture(t, test, jsTracer)
+				have, haveErr := runFixture(t, test, nativeTracer)
+				if (wantErr != nil) != (haveErr != nil) {
+					t.Fatalf("error mismatch: have %v, want %v", haveErr, wantErr)
+				}
+				if !reflect.DeepEqual(have, want) {
+					haveJSON, _ := json.MarshalIndent(have, "", "  ")
+					wantJSON, _ := json.MarshalIndent(want, "", "  ")
+					t.Fatalf("trace mismatch:\nhave %s\nwant %s", haveJSON, wantJSON)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// errNoPrestate is returned if the transaction did not execute any code, so the
+// accounts it touched could not be gathered.
+var errNoPrestate = errors.New("no state accessed by the transaction")
+
+// prestateAccount is the genesis allocation of a single account, in the format
+// of the JavaScript prestateTracer.
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   int64                       `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// PrestateTracer is a native implementation of the prestateTracer, which outputs
+// sufficient information to create a local execution of the transaction from a
+// custom assembled genesis block.
+type PrestateTracer struct {
+	txContext
+
+	prestate map[common.Address]*prestateAccount
+	db       vm.StateDB // State database of the last executed opcode
+}
+
+// NewPrestateTracer creates a new native prestateTracer.
+func NewPrestateTracer() *PrestateTracer {
+	return &PrestateTracer{}
+}
+
+// lookupAccount injects the specified account into the prestate.
+func (t *PrestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; !ok {
+		t.prestate[addr] = &prestateAccount{
+			Balance: (*hexutil.Big)(new(big.Int).Set(t.db.GetBalance(addr))),
+			Nonce:   int64(t.db.GetNonce(addr)),
+			Code:    common.CopyBytes(t.db.GetCode(addr)),
+			Storage: make(map[common.Hash]common.Hash),
+		}
+	}
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate.
+func (t *PrestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+	if _, ok := t.prestate[addr].Storage[key]; !ok {
+		t.prestate[addr].Storage[key] = t.db.GetState(addr, key)
+	}
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *PrestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	if !t.capture(env) {
+		return nil
+	}
+	t.db = env.StateDB
+
+	// Add the current account if we just started tracing. Balance will potentially
+	// be wrong here, since this will include the value sent along with the message.
+	// We fix that in GetResult.
+	if t.prestate == nil {
+		t.prestate = make(map[common.Address]*prestateAccount)
+		t.lookupAccount(contract.Address())
+	}
+	// Whenever new state is accessed, add it to the prestate
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE:
+		t.lookupAccount(peekAddress(stack, 0))
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, env.StateDB.GetNonce(from)))
+	case vm.CREATE2:
+		// stack: salt, size, offset, endowment
+		code := memorySlice(memory, peek(stack, 1), peek(stack, 2))
+		t.lookupAccount(crypto.CreateAddress2(contract.Address(), common.BigToHash(peek(stack, 3)), crypto.Keccak256(code)))
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(peekAddress(stack, 1))
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.BigToHash(peek(stack, 0)))
+	}
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *PrestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// GetResult returns the assembled allocations of the accounts accessed by the
+// transaction, as they were before its execution.
+func (t *PrestateTracer) GetResult() (json.RawMessage, error) {
+	if t.failure != nil {
+		return nil, t.failure
+	}
+	// Transactions executing no code at all have no prestate gathered yet
+	if t.prestate == nil {
+		return nil, errNoPrestate
+	}
+	// At this point, we need to deduct the 'value' from the outer transaction,
+	// and move it back to the origin
+	t.lookupAccount(t.from)
+
+	from, to := t.prestate[t.from], t.prestate[t.to]
+	if to == nil {
+		return nil, errNoPrestate
+	}
+	fromBal, toBal := from.Balance.ToInt(), to.Balance.ToInt()
+	to.Balance = (*hexutil.Big)(new(big.Int).Sub(toBal, t.value))
+	from.Balance = (*hexutil.Big)(new(big.Int).Add(fromBal, t.value))
+
+	// Decrement the caller's nonce, and remove empty create targets
+	from.Nonce--
+	if t.typ == "CREATE" {
+		// We can blindly delete the contract prestate, as any existing state would
+		// have caused the transaction to be rejected as invalid in the first place.
+		delete(t.prestate, t.to)
+	}
+	return json.Marshal(t.prestate)
+}
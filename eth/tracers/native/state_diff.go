@@ -0,0 +1,221 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// diffSame is the Parity marker of a field which was left unchanged.
+const diffSame = "="
+
+// diffBorn is the Parity marker of a field of a newly created account.
+type diffBorn struct {
+	Value interface{} `json:"+"`
+}
+
+// diffDied is the Parity marker of a field of a deleted account.
+type diffDied struct {
+	Value interface{} `json:"-"`
+}
+
+// diffChanged is the Parity marker of a modified field.
+type diffChanged struct {
+	Changed diffFromTo `json:"*"`
+}
+
+type diffFromTo struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AccountDiff is the Parity formatted state difference of a single account.
+// Every field is either the "=" marker or an object keyed by one of the "+",
+// "-" or "*" markers.
+type AccountDiff struct {
+	Balance interface{}                 `json:"balance"`
+	Code    interface{}                 `json:"code"`
+	Nonce   interface{}                 `json:"nonce"`
+	Storage map[common.Hash]interface{} `json:"storage"`
+}
+
+// StateDiff is the Parity formatted state difference of a transaction.
+type StateDiff map[common.Address]*AccountDiff
+
+// StateDiffTracer is a native vm.Tracer collecting the accounts and storage
+// slots a transaction may have modified, which are compared against the state
+// prior to the transaction to produce Parity formatted stateDiff output.
+type StateDiffTracer struct {
+	prestate vm.StateDB
+
+	accounts map[common.Address]map[common.Hash]struct{}
+	depth    int
+	creates  []int // depths of the CREATE instructions awaiting their outcome
+}
+
+// NewStateDiffTracer creates a new Parity stateDiff tracer. The prestate must
+// be a copy of the state before the transaction is applied, it is not modified.
+func NewStateDiffTracer(prestate vm.StateDB) *StateDiffTracer {
+	return &StateDiffTracer{
+		prestate: prestate,
+		accounts: make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// TouchAccount marks an account as possibly modified outside of the EVM
+// execution, e.g. the block's coinbase receiving the transaction fees.
+func (t *StateDiffTracer) TouchAccount(addr common.Address) {
+	if _, ok := t.accounts[addr]; !ok {
+		t.accounts[addr] = make(map[common.Hash]struct{})
+	}
+}
+
+// touchSlot marks a storage slot as possibly modified.
+func (t *StateDiffTracer) touchSlot(addr common.Address, slot common.Hash) {
+	t.TouchAccount(addr)
+	t.accounts[addr][slot] = struct{}{}
+}
+
+// CaptureStart implements the vm.Tracer interface to initialize the tracing operation.
+func (t *StateDiffTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.TouchAccount(from)
+	t.TouchAccount(to)
+	return nil
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *StateDiffTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	if depth != t.depth {
+		t.TouchAccount(contract.Address())
+		t.depth = depth
+	}
+	// Contracts created without init code never run, pick them up from the stack
+	if n := len(t.creates); n > 0 && t.creates[n-1] == depth {
+		t.creates = t.creates[:n-1]
+		if len(stack.Data()) > 0 {
+			t.TouchAccount(common.Address(stack.Back(0).Bytes20()))
+		}
+	}
+	if err != nil {
+		return nil
+	}
+	switch op {
+	case vm.SSTORE:
+		t.touchSlot(contract.Address(), common.Hash(stack.Back(0).Bytes32()))
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.TouchAccount(common.Address(stack.Back(1).Bytes20()))
+	case vm.CREATE, vm.CREATE2:
+		t.creates = append(t.creates, depth)
+	case vm.SELFDESTRUCT:
+		t.TouchAccount(common.Address(stack.Back(0).Bytes20()))
+	}
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *StateDiffTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *StateDiffTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult compares the touched accounts between the prestate and the given
+// poststate, which should be finalised already so that destructed accounts are
+// removed.
+func (t *StateDiffTracer) GetResult(poststate vm.StateDB) StateDiff {
+	diff := make(StateDiff)
+	for addr, slots := range t.accounts {
+		var (
+			existed = t.prestate.Exist(addr)
+			exists  = poststate.Exist(addr)
+		)
+		switch {
+		case !existed && !exists:
+			continue
+
+		case !existed:
+			account := &AccountDiff{
+				Balance: diffBorn{(*hexutil.Big)(poststate.GetBalance(addr))},
+				Code:    diffBorn{hexutil.Bytes(poststate.GetCode(addr))},
+				Nonce:   diffBorn{hexutil.Uint64(poststate.GetNonce(addr))},
+				Storage: make(map[common.Hash]interface{}),
+			}
+			for slot := range slots {
+				if value := poststate.GetState(addr, slot); value != (common.Hash{}) {
+					account.Storage[slot] = diffBorn{value}
+				}
+			}
+			diff[addr] = account
+
+		case !exists:
+			account := &AccountDiff{
+				Balance: diffDied{(*hexutil.Big)(t.prestate.GetBalance(addr))},
+				Code:    diffDied{hexutil.Bytes(t.prestate.GetCode(addr))},
+				Nonce:   diffDied{hexutil.Uint64(t.prestate.GetNonce(addr))},
+				Storage: make(map[common.Hash]interface{}),
+			}
+			t.prestate.ForEachStorage(addr, func(slot, value common.Hash) bool {
+				if value != (common.Hash{}) {
+					account.Storage[slot] = diffDied{value}
+				}
+				return true
+			})
+			diff[addr] = account
+
+		default:
+			account := &AccountDiff{
+				Balance: diffSame,
+				Code:    diffSame,
+				Nonce:   diffSame,
+				Storage: make(map[common.Hash]interface{}),
+			}
+			changed := false
+			if from, to := t.prestate.GetBalance(addr), poststate.GetBalance(addr); from.Cmp(to) != 0 {
+				account.Balance = diffChanged{diffFromTo{(*hexutil.Big)(from), (*hexutil.Big)(to)}}
+				changed = true
+			}
+			if from, to := t.prestate.GetCode(addr), poststate.GetCode(addr); !bytes.Equal(from, to) {
+				account.Code = diffChanged{diffFromTo{hexutil.Bytes(from), hexutil.Bytes(to)}}
+				changed = true
+			}
+			if from, to := t.prestate.GetNonce(addr), poststate.GetNonce(addr); from != to {
+				account.Nonce = diffChanged{diffFromTo{hexutil.Uint64(from), hexutil.Uint64(to)}}
+				changed = true
+			}
+			for slot := range slots {
+				if from, to := t.prestate.GetState(addr, slot), poststate.GetState(addr, slot); from != to {
+					account.Storage[slot] = diffChanged{diffFromTo{from, to}}
+					changed = true
+				}
+			}
+			if changed {
+				diff[addr] = account
+			}
+		}
+	}
+	return diff
+}
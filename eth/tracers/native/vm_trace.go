@@ -0,0 +1,297 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// VMTrace is a Parity (OpenEthereum) formatted virtual machine trace, listing
+// every executed instruction of a call frame along with the frames spawned by
+// its CALL and CREATE instructions.
+type VMTrace struct {
+	Code hexutil.Bytes `json:"code"`
+	Ops  []*VMTraceOp  `json:"ops"`
+}
+
+// VMTraceOp is a single executed instruction within a VMTrace.
+type VMTraceOp struct {
+	Cost uint64     `json:"cost"`
+	Ex   *VMTraceEx `json:"ex"`
+	PC   uint64     `json:"pc"`
+	Sub  *VMTrace   `json:"sub"`
+}
+
+// VMTraceEx holds the effects of an executed instruction. It is nil for
+// instructions which failed.
+type VMTraceEx struct {
+	Mem   *VMTraceMem    `json:"mem"`
+	Push  []*hexutil.Big `json:"push"`
+	Store *VMTraceStore  `json:"store"`
+	Used  uint64         `json:"used"`
+}
+
+// VMTraceMem is the memory region written by an instruction.
+type VMTraceMem struct {
+	Data hexutil.Bytes `json:"data"`
+	Off  uint64        `json:"off"`
+}
+
+// VMTraceStore is the storage slot written by an instruction.
+type VMTraceStore struct {
+	Key *hexutil.Big `json:"key"`
+	Val *hexutil.Big `json:"val"`
+}
+
+// vmTraceStep is an instruction which has been captured before its execution,
+// and whose effects still need to be filled in from the next observed state.
+type vmTraceStep struct {
+	op     vm.OpCode
+	entry  *VMTraceOp
+	pushes int // number of stack items the instruction leaves behind
+
+	memOff  uint64 // memory region the instruction is expected to write
+	memSize uint64
+
+	store *VMTraceStore
+	gas   uint64 // remaining gas after the instruction, if nothing better is known
+}
+
+// vmTraceFrame is an active call frame of the traced execution.
+type vmTraceFrame struct {
+	depth int
+	trace *VMTrace
+	step  *vmTraceStep
+}
+
+// VMTracer is a native vm.Tracer producing Parity formatted vmTrace output.
+//
+// The vm.Tracer interface only reports the state before each instruction, so
+// the effects of an instruction (pushed stack items, memory and storage
+// writes, remaining gas) are collected on the following step of the same
+// call frame.
+type VMTracer struct {
+	root   *VMTrace
+	frames []*vmTraceFrame
+}
+
+// NewVMTracer creates a new Parity vmTrace tracer.
+func NewVMTracer() *VMTracer {
+	return &VMTracer{}
+}
+
+// CaptureStart implements the vm.Tracer interface to initialize the tracing operation.
+func (t *VMTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	return nil
+}
+
+// CaptureState implements the vm.Tracer interface to trace a single step of VM execution.
+func (t *VMTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	frame := t.enter(contract, depth)
+
+	// Whatever happened since the previous step of this frame is the outcome
+	// of the previous instruction
+	t.complete(frame, gas, memory, stack)
+
+	entry := &VMTraceOp{Cost: cost, PC: pc}
+	frame.trace.Ops = append(frame.trace.Ops, entry)
+
+	// Instructions reported alongside an error never executed, there's nothing
+	// more to collect about them
+	if err != nil {
+		return nil
+	}
+	step := &vmTraceStep{
+		op:     op,
+		entry:  entry,
+		pushes: vmTracePushes(op),
+	}
+	if gas > cost {
+		step.gas = gas - cost
+	}
+	step.memOff, step.memSize = vmTraceMemoryWrite(op, stack)
+	if op == vm.SSTORE {
+		step.store = &VMTraceStore{
+			Key: (*hexutil.Big)(stack.Back(0).ToBig()),
+			Val: (*hexutil.Big)(stack.Back(1).ToBig()),
+		}
+	}
+	frame.step = step
+	return nil
+}
+
+// CaptureFault implements the vm.Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *VMTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, rStack *vm.ReturnStack, contract *vm.Contract, depth int, err error) error {
+	// Reverts are reported as faults too, but the instruction itself succeeded
+	if len(t.frames) == 0 || err == vm.ErrExecutionReverted {
+		return nil
+	}
+	// The last captured instruction failed, it has no effects to report
+	frame := t.frames[len(t.frames)-1]
+	frame.step = nil
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *VMTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	for len(t.frames) > 0 {
+		t.leave()
+	}
+	return nil
+}
+
+// GetResult returns the collected vmTrace.
+func (t *VMTracer) GetResult() *VMTrace {
+	if t.root == nil {
+		return &VMTrace{Code: []byte{}, Ops: []*VMTraceOp{}}
+	}
+	return t.root
+}
+
+// enter aligns the tracked call frames with the depth of the step being
+// executed, opening a new frame or closing the finished ones.
+func (t *VMTracer) enter(contract *vm.Contract, depth int) *vmTraceFrame {
+	if len(t.frames) == 0 {
+		t.root = &VMTrace{Code: common.CopyBytes(contract.Code), Ops: []*VMTraceOp{}}
+		t.frames = append(t.frames, &vmTraceFrame{depth: depth, trace: t.root})
+		return t.frames[0]
+	}
+	frame := t.frames[len(t.frames)-1]
+	if depth > frame.depth {
+		sub := &VMTrace{Code: common.CopyBytes(contract.Code), Ops: []*VMTraceOp{}}
+		if frame.step != nil {
+			frame.step.entry.Sub = sub
+		}
+		frame = &vmTraceFrame{depth: depth, trace: sub}
+		t.frames = append(t.frames, frame)
+		return frame
+	}
+	for depth < frame.depth && len(t.frames) > 1 {
+		t.leave()
+		frame = t.frames[len(t.frames)-1]
+	}
+	return frame
+}
+
+// leave closes the innermost call frame. The effects of its last instruction
+// can't be observed anymore, so only the remaining gas is reported for it.
+func (t *VMTracer) leave() {
+	frame := t.frames[len(t.frames)-1]
+	if step := frame.step; step != nil {
+		step.entry.Ex = &VMTraceEx{Push: []*hexutil.Big{}, Used: step.gas}
+		frame.step = nil
+	}
+	t.frames = t.frames[:len(t.frames)-1]
+}
+
+// complete fills in the effects of the pending instruction of a frame, based
+// on the machine state observed right after it executed.
+func (t *VMTracer) complete(frame *vmTraceFrame, gas uint64, memory *vm.Memory, stack *vm.Stack) {
+	step := frame.step
+	if step == nil {
+		return
+	}
+	frame.step = nil
+
+	ex := &VMTraceEx{
+		Push:  make([]*hexutil.Big, 0, step.pushes),
+		Store: step.store,
+		Used:  gas,
+	}
+	data := stack.Data()
+	for i := step.pushes; i > 0; i-- {
+		if i > len(data) {
+			continue
+		}
+		ex.Push = append(ex.Push, (*hexutil.Big)(data[len(data)-i].ToBig()))
+	}
+	if step.memSize > 0 && step.memOff+step.memSize <= uint64(memory.Len()) {
+		ex.Mem = &VMTraceMem{
+			Data: memory.GetCopy(int64(step.memOff), int64(step.memSize)),
+			Off:  step.memOff,
+		}
+	}
+	step.entry.Ex = ex
+
+	// Calls into accounts without code (or precompiles) never open a frame,
+	// report them with an empty sub-trace nonetheless
+	if step.entry.Sub == nil && vmTraceSpawns(step.op) {
+		step.entry.Sub = &VMTrace{Code: []byte{}, Ops: []*VMTraceOp{}}
+	}
+}
+
+// vmTraceSpawns reports whether the instruction starts a new call frame.
+func vmTraceSpawns(op vm.OpCode) bool {
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.CREATE, vm.CREATE2:
+		return true
+	}
+	return false
+}
+
+// vmTracePushes returns the number of stack items an instruction leaves
+// behind that are reported in the Parity trace.
+func vmTracePushes(op vm.OpCode) int {
+	switch {
+	case op >= vm.DUP1 && op <= vm.DUP16:
+		return int(op-vm.DUP1) + 2
+	case op >= vm.SWAP1 && op <= vm.SWAP16:
+		return int(op-vm.SWAP1) + 2
+	case op >= vm.LOG0 && op <= vm.LOG4:
+		return 0
+	}
+	switch op {
+	case vm.STOP, vm.POP, vm.MSTORE, vm.MSTORE8, vm.SSTORE, vm.JUMP, vm.JUMPI, vm.JUMPDEST,
+		vm.CALLDATACOPY, vm.CODECOPY, vm.EXTCODECOPY, vm.RETURNDATACOPY,
+		vm.RETURN, vm.REVERT, vm.SELFDESTRUCT, vm.BEGINSUB, vm.JUMPSUB, vm.RETURNSUB:
+		return 0
+	}
+	return 1
+}
+
+// vmTraceMemoryWrite returns the memory region an instruction is going to
+// write to, based on its stack arguments.
+func vmTraceMemoryWrite(op vm.OpCode, stack *vm.Stack) (uint64, uint64) {
+	var off, size int
+	switch op {
+	case vm.MSTORE:
+		return stack.Back(0).Uint64(), 32
+	case vm.MSTORE8:
+		return stack.Back(0).Uint64(), 1
+	case vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY:
+		off, size = 0, 2
+	case vm.EXTCODECOPY:
+		off, size = 1, 3
+	case vm.CALL, vm.CALLCODE:
+		off, size = 5, 6
+	case vm.DELEGATECALL, vm.STATICCALL:
+		off, size = 4, 5
+	default:
+		return 0, 0
+	}
+	o, s := stack.Back(off), stack.Back(size)
+	if !o.IsUint64() || !s.IsUint64() {
+		return 0, 0
+	}
+	return o.Uint64(), s.Uint64()
+}
@@ -18,15 +18,52 @@
 package tracers
 
 import (
+	"encoding/json"
 	"strings"
 	"unicode"
 
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/tracers/internal/tracers"
 )
 
+// TxTracer is a transaction tracer producing a JSON result, implemented either
+// by a JavaScript tracer or by a natively registered Go one.
+type TxTracer interface {
+	vm.Tracer
+
+	// CaptureExtraContext injects additional context about the traced transaction,
+	// e.g. its hash and position within the block.
+	CaptureExtraContext(map[string]interface{}) error
+
+	// GetResult returns the result of the tracing, or any accumulated error.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates the tracing at the first opportune moment.
+	Stop(err error)
+}
+
 // all contains all the built in JavaScript tracers by name.
 var all = make(map[string]string)
 
+// native contains the constructors of all the registered Go tracers by name.
+var native = make(map[string]func() TxTracer)
+
+// RegisterNative makes a Go tracer available by name, taking precedence over
+// any JavaScript tracer of the same name. It is meant to be called from init
+// functions and is not safe for concurrent use.
+func RegisterNative(name string, ctor func() TxTracer) {
+	native[name] = ctor
+}
+
+// NewTxTracer resolves a tracer by name, preferring the registered Go tracers,
+// and falls back to interpreting the code as a JavaScript tracer otherwise.
+func NewTxTracer(code string) (TxTracer, error) {
+	if ctor, ok := native[code]; ok {
+		return ctor(), nil
+	}
+	return New(code)
+}
+
 // camel converts a snake cased input string into a camel cased output.
 func camel(str string) string {
 	pieces := strings.Split(str, "_")
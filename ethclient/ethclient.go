@@ -28,7 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -285,6 +285,27 @@ func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*
 	return r, err
 }
 
+// BlockReceiptsByHash returns the receipts of all the transactions in the block
+// with the given hash.
+func (ec *Client) BlockReceiptsByHash(ctx context.Context, hash common.Hash) ([]*types.Receipt, error) {
+	return ec.getBlockReceipts(ctx, hash)
+}
+
+// BlockReceiptsByNumber returns the receipts of all the transactions in the
+// block with the given number. If number is nil, the latest known block is used.
+func (ec *Client) BlockReceiptsByNumber(ctx context.Context, number *big.Int) ([]*types.Receipt, error) {
+	return ec.getBlockReceipts(ctx, toBlockNumArg(number))
+}
+
+func (ec *Client) getBlockReceipts(ctx context.Context, block interface{}) ([]*types.Receipt, error) {
+	var r []*types.Receipt
+	err := ec.c.CallContext(ctx, &r, "eth_getBlockReceipts", block)
+	if err == nil && r == nil {
+		return nil, ethereum.NotFound
+	}
+	return r, err
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
@@ -341,6 +362,65 @@ func (ec *Client) SubscribeNewSideHead(ctx context.Context, ch chan<- *types.Hea
 	return ec.c.EthSubscribe(ctx, ch, "newSideHeads")
 }
 
+// BlockReceipts are the receipts of all the transactions in a block.
+type BlockReceipts struct {
+	BlockHash   common.Hash
+	BlockNumber uint64
+	Receipts    []*types.Receipt
+
+	err string // Reason the stream stopped at this block, if it failed
+}
+
+// UnmarshalJSON decodes a blockReceipts subscription notification.
+func (r *BlockReceipts) UnmarshalJSON(input []byte) error {
+	var dec struct {
+		BlockHash   common.Hash      `json:"blockHash"`
+		BlockNumber hexutil.Uint64   `json:"blockNumber"`
+		Receipts    []*types.Receipt `json:"receipts"`
+		Error       string           `json:"error"`
+	}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	r.BlockHash, r.BlockNumber, r.Receipts, r.err = dec.BlockHash, uint64(dec.BlockNumber), dec.Receipts, dec.Error
+	return nil
+}
+
+// SubscribeBlockReceipts streams the receipts of the blocks from..to on the given
+// channel, one block at a time in ascending order. A nil block number stands for
+// the latest known block. The subscription should be unsubscribed once the
+// receipts of the last block have been received. If the node fails to serve a
+// block of the range, the error is delivered on the subscription's error channel.
+func (ec *Client) SubscribeBlockReceipts(ctx context.Context, from, to *big.Int, ch chan<- *BlockReceipts) (ethereum.Subscription, error) {
+	results := make(chan *BlockReceipts)
+	sub, err := ec.c.EthSubscribe(ctx, results, "blockReceipts", toBlockNumArg(from), toBlockNumArg(to))
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case result := <-results:
+				if result.err != "" {
+					return errors.New(result.err)
+				}
+				select {
+				case ch <- result:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
 // State Access
 
 // NetworkID returns the network ID (also known as the chain ID) for this chain.
@@ -522,12 +602,32 @@ func (ec *Client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64
 	return uint64(hex), nil
 }
 
+// CreateAccessList tries to create an EIP-2930 access list for the given message.
+// It returns the list of accounts and storage slots touched by the message, the
+// amount of gas it used with the access list applied and the execution error
+// message of the call, if any.
+//
+// blockNumber selects the block height at which the call runs. It can be nil, in which
+// case the state of the latest known block is used.
+func (ec *Client) CreateAccessList(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (*types.AccessList, uint64, string, error) {
+	type accessListResult struct {
+		Accesslist *types.AccessList `json:"accessList"`
+		Error      string            `json:"error,omitempty"`
+		GasUsed    hexutil.Uint64    `json:"gasUsed"`
+	}
+	var result accessListResult
+	if err := ec.c.CallContext(ctx, &result, "eth_createAccessList", toCallArg(msg), toBlockNumArg(blockNumber)); err != nil {
+		return nil, 0, "", err
+	}
+	return result.Accesslist, uint64(result.GasUsed), result.Error, nil
+}
+
 // SendTransaction injects a signed transaction into the pending pool for execution.
 //
 // If the transaction was a contract creation use the TransactionReceipt method to get the
 // contract address after the transaction has been mined.
 func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return err
 	}
@@ -551,6 +651,9 @@ func toCallArg(msg ethereum.CallMsg) interface{} {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
 	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
 	return arg
 }
 
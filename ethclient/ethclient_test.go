@@ -17,11 +17,13 @@
 package ethclient
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,6 +40,7 @@ import (
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/vars"
 	meta_schema "github.com/open-rpc/meta-schema"
 )
 
@@ -191,6 +194,10 @@ var (
 func newTestBackend(t *testing.T) (*node.Node, []*types.Block) {
 	// Generate test chain.
 	genesis, blocks := generateTestChain()
+	return newTestBackendWithChain(t, genesis, blocks)
+}
+
+func newTestBackendWithChain(t *testing.T, genesis *genesisT.Genesis, blocks []*types.Block) (*node.Node, []*types.Block) {
 	// Create node
 	n, err := node.New(&node.Config{})
 	if err != nil {
@@ -373,6 +380,328 @@ func TestBlockNumber(t *testing.T) {
 	}
 }
 
+func TestCreateAccessList(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Close()
+	defer client.Close()
+	ec := NewClient(client)
+
+	// Deploy code reading storage slot 1 of the new contract and the balance of
+	// an unrelated account.
+	other := common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+	code := append(append([]byte{0x60, 0x01, 0x54, 0x50, 0x73}, other.Bytes()...), 0x31, 0x50, 0x00)
+
+	acl, gasUsed, vmErr, err := ec.CreateAccessList(context.Background(), ethereum.CallMsg{
+		From:     testAddr,
+		GasPrice: big.NewInt(1),
+		Data:     code,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vmErr != "" {
+		t.Fatalf("unexpected execution error: %v", vmErr)
+	}
+	if gasUsed == 0 {
+		t.Fatalf("no gas used reported")
+	}
+	created := crypto.CreateAddress(testAddr, 0)
+	want := map[common.Address][]common.Hash{
+		created: {common.BigToHash(big.NewInt(1))},
+		other:   {},
+	}
+	if acl == nil || len(*acl) != len(want) {
+		t.Fatalf("access list length mismatch: have %v, want %v", acl, want)
+	}
+	for _, tuple := range *acl {
+		keys, ok := want[tuple.Address]
+		if !ok {
+			t.Fatalf("unexpected account in access list: %x", tuple.Address)
+		}
+		if !reflect.DeepEqual(tuple.StorageKeys, keys) {
+			t.Errorf("storage keys mismatch for %x: have %v, want %v", tuple.Address, tuple.StorageKeys, keys)
+		}
+	}
+}
+
+// simTestCode is contract code incrementing storage slot 0, logging and
+// returning its new value.
+var simTestCode = hexutil.Bytes{
+	0x60, 0x00, 0x54, 0x60, 0x01, 0x01, 0x80, 0x60, 0x00, 0x55, // slot0 = slot0 + 1
+	0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xa0, // log0(slot0)
+	0x60, 0x20, 0x60, 0x00, 0xf3, // return slot0
+}
+
+type simTestCall struct {
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	Logs       []*types.Log   `json:"logs"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Error      string         `json:"error"`
+}
+
+func TestCallBundle(t *testing.T) {
+	backend, _ := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Close()
+	defer client.Close()
+
+	var (
+		counter  = common.HexToAddress("0x000000000000000000000000000000000000c0de")
+		reverter = common.HexToAddress("0x00000000000000000000000000000000000000fd")
+	)
+	overrides := map[common.Address]interface{}{
+		counter:  map[string]interface{}{"code": simTestCode},
+		reverter: map[string]interface{}{"code": hexutil.Bytes{0x60, 0x00, 0x60, 0x00, 0xfd}},
+	}
+	calls := []interface{}{
+		map[string]interface{}{"from": testAddr, "to": counter},
+		map[string]interface{}{"from": testAddr, "to": counter},
+		map[string]interface{}{"from": testAddr, "to": reverter},
+	}
+	var results []simTestCall
+	if err := client.Call(&results, "eth_callBundle", calls, "latest", overrides, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(calls) {
+		t.Fatalf("result count mismatch: have %d, want %d", len(results), len(calls))
+	}
+	for i, result := range results[:2] {
+		want := common.BigToHash(big.NewInt(int64(i + 1)))
+		if common.BytesToHash(result.ReturnData) != want {
+			t.Errorf("call %d: return data mismatch: have %x, want %x", i, result.ReturnData, want)
+		}
+		if len(result.Logs) != 1 || common.BytesToHash(result.Logs[0].Data) != want {
+			t.Errorf("call %d: logs mismatch: %v", i, result.Logs)
+		}
+		if result.GasUsed == 0 || result.Error != "" {
+			t.Errorf("call %d: unexpected gas used %d or error %q", i, result.GasUsed, result.Error)
+		}
+	}
+	if results[2].Error != "execution reverted" || len(results[2].Logs) != 0 {
+		t.Errorf("reverted call mismatch: %+v", results[2])
+	}
+}
+
+func TestSimulateBlocks(t *testing.T) {
+	backend, chain := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Close()
+	defer client.Close()
+
+	var (
+		counter = common.HexToAddress("0x000000000000000000000000000000000000c0de")
+		info    = common.HexToAddress("0x00000000000000000000000000000000000001f0")
+		miner   = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	)
+	// Code returning the number, timestamp and coinbase of its block
+	infoCode := hexutil.Bytes{0x43, 0x60, 0x00, 0x52, 0x42, 0x60, 0x20, 0x52, 0x41, 0x60, 0x40, 0x52, 0x60, 0x60, 0x60, 0x00, 0xf3}
+	blocks := []interface{}{
+		map[string]interface{}{
+			"blockOverrides": map[string]interface{}{"number": "0x64", "timestamp": "0x3e8", "coinbase": miner},
+			"stateOverrides": map[common.Address]interface{}{
+				counter: map[string]interface{}{"code": simTestCode},
+				info:    map[string]interface{}{"code": infoCode},
+			},
+			"calls": []interface{}{
+				map[string]interface{}{"to": counter},
+				map[string]interface{}{"to": info},
+			},
+		},
+		map[string]interface{}{
+			"calls": []interface{}{
+				map[string]interface{}{"to": counter},
+				map[string]interface{}{"to": info},
+			},
+		},
+	}
+	var results []struct {
+		Number    *hexutil.Big   `json:"number"`
+		Timestamp hexutil.Uint64 `json:"timestamp"`
+		GasUsed   hexutil.Uint64 `json:"gasUsed"`
+		Calls     []simTestCall  `json:"calls"`
+	}
+	if err := client.Call(&results, "eth_simulateBlocks", blocks, "latest"); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("result count mismatch: have %d, want 2", len(results))
+	}
+	for i, result := range results {
+		number, time := uint64(100+i), uint64(1000+i)
+		if result.Number.ToInt().Uint64() != number || uint64(result.Timestamp) != time {
+			t.Errorf("block %d: context mismatch: number %v, timestamp %d", i, result.Number, result.Timestamp)
+		}
+		if len(result.Calls) != 2 || uint64(result.GasUsed) != uint64(result.Calls[0].GasUsed+result.Calls[1].GasUsed) {
+			t.Fatalf("block %d: calls mismatch: %+v", i, result)
+		}
+		if have := common.BytesToHash(result.Calls[0].ReturnData); have != common.BigToHash(big.NewInt(int64(i+1))) {
+			t.Errorf("block %d: counter mismatch: %x", i, have)
+		}
+		if len(result.Calls[0].Logs) != 1 || result.Calls[0].Logs[0].BlockNumber != number {
+			t.Errorf("block %d: logs mismatch: %v", i, result.Calls[0].Logs)
+		}
+		want := append(append(common.BigToHash(new(big.Int).SetUint64(number)).Bytes(), common.BigToHash(new(big.Int).SetUint64(time)).Bytes()...), common.BytesToHash(miner.Bytes()).Bytes()...)
+		if !bytes.Equal(result.Calls[1].ReturnData, want) {
+			t.Errorf("block %d: block context mismatch: have %x, want %x", i, result.Calls[1].ReturnData, want)
+		}
+	}
+	// Simulated blocks must follow their parents
+	blocks[1].(map[string]interface{})["blockOverrides"] = map[string]interface{}{"number": hexutil.EncodeUint64(chain[1].NumberU64())}
+	if err := client.Call(&results, "eth_simulateBlocks", blocks, "latest"); err == nil {
+		t.Fatal("expected error for block number below its parent")
+	}
+}
+
+// generateReceiptsTestChain creates a chain of three blocks holding two, zero
+// and one transactions.
+func generateReceiptsTestChain() (*genesisT.Genesis, []*types.Block) {
+	db := rawdb.NewMemoryDatabase()
+	config := params.AllEthashProtocolChanges
+	genesis := &genesisT.Genesis{
+		Config: config,
+		Alloc:  genesisT.GenesisAlloc{testAddr: {Balance: testBalance}},
+	}
+	var (
+		signer = types.LatestSigner(config)
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		nonce  uint64
+	)
+	generate := func(i int, g *core.BlockGen) {
+		txs := []int{2, 0, 1}[i]
+		for j := 0; j < txs; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(1), vars.TxGas, big.NewInt(1), nil), signer, testKey)
+			g.AddTx(tx)
+			nonce++
+		}
+	}
+	gblock := core.GenesisToBlock(genesis, db)
+	blocks, _ := core.GenerateChain(config, gblock, ethash.NewFaker(), db, 3, generate)
+	return genesis, append([]*types.Block{gblock}, blocks...)
+}
+
+func TestBlockReceipts(t *testing.T) {
+	genesis, blocks := generateReceiptsTestChain()
+	backend, chain := newTestBackendWithChain(t, genesis, blocks)
+	client, _ := backend.Attach()
+	defer backend.Close()
+	defer client.Close()
+	ec := NewClient(client)
+
+	checkReceipts := func(block *types.Block, receipts []*types.Receipt) {
+		t.Helper()
+		if len(receipts) != len(block.Transactions()) {
+			t.Fatalf("block %d: receipt count mismatch: have %d, want %d", block.NumberU64(), len(receipts), len(block.Transactions()))
+		}
+		for i, receipt := range receipts {
+			if receipt.TxHash != block.Transactions()[i].Hash() || receipt.BlockHash != block.Hash() ||
+				receipt.BlockNumber.Uint64() != block.NumberU64() || receipt.TransactionIndex != uint(i) {
+				t.Errorf("block %d: receipt %d fields mismatch: %+v", block.NumberU64(), i, receipt)
+			}
+			if receipt.GasUsed != vars.TxGas || receipt.CumulativeGasUsed != uint64(i+1)*vars.TxGas {
+				t.Errorf("block %d: receipt %d gas mismatch: used %d, cumulative %d", block.NumberU64(), i, receipt.GasUsed, receipt.CumulativeGasUsed)
+			}
+		}
+	}
+	for _, block := range chain[1:] {
+		receipts, err := ec.BlockReceiptsByNumber(context.Background(), block.Number())
+		if err != nil {
+			t.Fatalf("block %d: %v", block.NumberU64(), err)
+		}
+		checkReceipts(block, receipts)
+
+		receipts, err = ec.BlockReceiptsByHash(context.Background(), block.Hash())
+		if err != nil {
+			t.Fatalf("block %d: %v", block.NumberU64(), err)
+		}
+		checkReceipts(block, receipts)
+	}
+	if _, err := ec.BlockReceiptsByNumber(context.Background(), big.NewInt(10)); err != ethereum.NotFound {
+		t.Errorf("unknown block: have error %v, want %v", err, ethereum.NotFound)
+	}
+
+	// Stream the receipts of the whole chain
+	ch := make(chan *BlockReceipts)
+	sub, err := ec.SubscribeBlockReceipts(context.Background(), big.NewInt(1), nil, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	for _, block := range chain[1:] {
+		select {
+		case result := <-ch:
+			if result.BlockHash != block.Hash() || result.BlockNumber != block.NumberU64() {
+				t.Fatalf("streamed block mismatch: have %d (%x), want %d", result.BlockNumber, result.BlockHash, block.NumberU64())
+			}
+			checkReceipts(block, result.Receipts)
+		case err := <-sub.Err():
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for the receipts of block %d", block.NumberU64())
+		}
+	}
+	if _, err := ec.SubscribeBlockReceipts(context.Background(), big.NewInt(3), big.NewInt(1), ch); err == nil {
+		t.Error("expected error for inverted block range")
+	}
+}
+
+func TestBlockReceiptsStreamError(t *testing.T) {
+	genesis, blocks := generateReceiptsTestChain()
+
+	n, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("can't create new node: %v", err)
+	}
+	config := &eth.Config{Genesis: genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethservice, err := eth.New(n, config)
+	if err != nil {
+		t.Fatalf("can't create new ethereum service: %v", err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatalf("can't start test node: %v", err)
+	}
+	defer n.Close()
+	if _, err := ethservice.BlockChain().InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("can't import test blocks: %v", err)
+	}
+	client, _ := n.Attach()
+	defer client.Close()
+	ec := NewClient(client)
+
+	// Lose the receipts of the last block of the streamed range
+	rawdb.DeleteReceipts(ethservice.ChainDb(), blocks[3].Hash(), blocks[3].NumberU64())
+
+	ch := make(chan *BlockReceipts)
+	sub, err := ec.SubscribeBlockReceipts(context.Background(), big.NewInt(1), nil, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	for _, block := range blocks[1:3] {
+		select {
+		case result := <-ch:
+			if result.BlockHash != block.Hash() {
+				t.Fatalf("streamed block mismatch: have %x, want %x", result.BlockHash, block.Hash())
+			}
+		case err := <-sub.Err():
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for the receipts of block %d", block.NumberU64())
+		}
+	}
+	select {
+	case result := <-ch:
+		t.Fatalf("receipts of block %d streamed after their loss", result.BlockNumber)
+	case err := <-sub.Err():
+		if err == nil || !strings.Contains(err.Error(), "block 3") {
+			t.Fatalf("stream error mismatch: have %v, want the failure of block 3", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the stream error")
+	}
+}
+
 func TestRPCDiscover(t *testing.T) {
 	backend, _ := newTestBackend(t)
 	client, _ := backend.Attach()
@@ -647,12 +976,14 @@ var allRPCMethods = []string{
 	"debug_chaindbProperty",
 	"debug_cpuProfile",
 	"debug_dumpBlock",
+	"debug_ecbp1100Evaluate",
 	"debug_freeOSMemory",
 	"debug_gcStats",
 	"debug_getBadBlocks",
 	"debug_getBlockRlp",
 	"debug_getModifiedAccountsByHash",
 	"debug_getModifiedAccountsByNumber",
+	"debug_getRejectedReorgs",
 	"debug_goTrace",
 	"debug_memStats",
 	"debug_mutexProfile",
@@ -688,9 +1019,11 @@ var allRPCMethods = []string{
 	"eth_accounts",
 	"eth_blockNumber",
 	"eth_call",
+	"eth_callBundle",
 	"eth_chainId",
 	"eth_chainId",
 	"eth_coinbase",
+	"eth_createAccessList",
 	"eth_estimateGas",
 	"eth_etherbase",
 	"eth_fillTransaction",
@@ -698,6 +1031,7 @@ var allRPCMethods = []string{
 	"eth_getBalance",
 	"eth_getBlockByHash",
 	"eth_getBlockByNumber",
+	"eth_getBlockReceipts",
 	"eth_getBlockTransactionCountByHash",
 	"eth_getBlockTransactionCountByNumber",
 	"eth_getCode",
@@ -712,6 +1046,7 @@ var allRPCMethods = []string{
 	"eth_getRawTransactionByBlockNumberAndIndex",
 	"eth_getRawTransactionByHash",
 	"eth_getStorageAt",
+	"eth_getStratumWorkers",
 	"eth_getTransactionByBlockHashAndIndex",
 	"eth_getTransactionByBlockNumberAndIndex",
 	"eth_getTransactionByHash",
@@ -735,6 +1070,7 @@ var allRPCMethods = []string{
 	"eth_sendTransaction",
 	"eth_sign",
 	"eth_signTransaction",
+	"eth_simulateBlocks",
 	"eth_submitHashRate",
 	"eth_submitWork",
 	"eth_subscribe",
@@ -742,6 +1078,7 @@ var allRPCMethods = []string{
 	"eth_uninstallFilter",
 	"eth_unsubscribe",
 	"ethash_getHashrate",
+	"ethash_getStratumWorkers",
 	"ethash_getWork",
 	"ethash_submitHashRate",
 	"ethash_submitWork",
@@ -771,7 +1108,12 @@ var allRPCMethods = []string{
 	"personal_unlockAccount",
 	"personal_unpair",
 	"trace_block",
+	"trace_call",
+	"trace_callMany",
 	"trace_filter",
+	"trace_rawTransaction",
+	"trace_replayBlockTransactions",
+	"trace_replayTransaction",
 	"trace_transaction",
 	"txpool_content",
 	"txpool_inspect",
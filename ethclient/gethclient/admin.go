@@ -0,0 +1,198 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package gethclient
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// TxPool
+
+// TxPoolContent holds the transactions of the pool, grouped by sender and
+// keyed by nonce.
+type TxPoolContent struct {
+	Pending map[common.Address]map[uint64]*types.Transaction `json:"pending"`
+	Queued  map[common.Address]map[uint64]*types.Transaction `json:"queued"`
+}
+
+// TxPoolInspect holds a textual summary of every transaction of the pool,
+// grouped by sender and keyed by nonce.
+type TxPoolInspect struct {
+	Pending map[common.Address]map[uint64]string `json:"pending"`
+	Queued  map[common.Address]map[uint64]string `json:"queued"`
+}
+
+// TxPoolContent returns the pending and queued transactions of the pool.
+func (ec *Client) TxPoolContent(ctx context.Context) (*TxPoolContent, error) {
+	var result TxPoolContent
+	if err := ec.c.CallContext(ctx, &result, "txpool_content"); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// TxPoolInspect returns a textual summary of the pending and queued transactions
+// of the pool.
+func (ec *Client) TxPoolInspect(ctx context.Context) (*TxPoolInspect, error) {
+	var result TxPoolInspect
+	if err := ec.c.CallContext(ctx, &result, "txpool_inspect"); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// TxPoolStatus returns the number of pending and queued transactions of the pool.
+func (ec *Client) TxPoolStatus(ctx context.Context) (pending, queued uint, err error) {
+	var result map[string]hexutil.Uint
+	if err := ec.c.CallContext(ctx, &result, "txpool_status"); err != nil {
+		return 0, 0, err
+	}
+	return uint(result["pending"]), uint(result["queued"]), nil
+}
+
+// Admin
+
+// NodeInfo returns the p2p details of the node.
+func (ec *Client) NodeInfo(ctx context.Context) (*p2p.NodeInfo, error) {
+	var result p2p.NodeInfo
+	if err := ec.c.CallContext(ctx, &result, "admin_nodeInfo"); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Peers returns the details of the peers connected to the node.
+func (ec *Client) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
+	var result []*p2p.PeerInfo
+	err := ec.c.CallContext(ctx, &result, "admin_peers")
+	return result, err
+}
+
+// AddPeer requests the node to connect to a remote node, given by its enode URL,
+// and to keep reconnecting to it.
+func (ec *Client) AddPeer(ctx context.Context, url string) error {
+	return ec.c.CallContext(ctx, nil, "admin_addPeer", url)
+}
+
+// RemovePeer disconnects the node from a remote node, given by its enode URL.
+func (ec *Client) RemovePeer(ctx context.Context, url string) error {
+	return ec.c.CallContext(ctx, nil, "admin_removePeer", url)
+}
+
+// AddTrustedPeer allows a remote node to always connect, even above the peer
+// limit of the node.
+func (ec *Client) AddTrustedPeer(ctx context.Context, url string) error {
+	return ec.c.CallContext(ctx, nil, "admin_addTrustedPeer", url)
+}
+
+// RemoveTrustedPeer removes a remote node from the trusted peer set, without
+// disconnecting it.
+func (ec *Client) RemoveTrustedPeer(ctx context.Context, url string) error {
+	return ec.c.CallContext(ctx, nil, "admin_removeTrustedPeer", url)
+}
+
+// SubscribePeerEvents subscribes to peer connections, disconnections and
+// message transfers of the node.
+func (ec *Client) SubscribePeerEvents(ctx context.Context, ch chan<- *p2p.PeerEvent) (*rpc.ClientSubscription, error) {
+	return ec.c.Subscribe(ctx, "admin", ch, "peerEvents")
+}
+
+// SetMaxPeers sets the maximum peer count of the node, disconnecting the worst
+// peers above the limit.
+func (ec *Client) SetMaxPeers(ctx context.Context, n int) error {
+	return ec.c.CallContext(ctx, nil, "admin_maxPeers", n)
+}
+
+// ExportChain exports the blocks between first and last, or the whole chain if
+// they are nil, into a file on the machine of the node.
+func (ec *Client) ExportChain(ctx context.Context, file string, first, last *uint64) error {
+	return ec.c.CallContext(ctx, nil, "admin_exportChain", file, first, last)
+}
+
+// ImportChain imports the blocks of a file on the machine of the node.
+func (ec *Client) ImportChain(ctx context.Context, file string) error {
+	return ec.c.CallContext(ctx, nil, "admin_importChain", file)
+}
+
+// SetECBP1100 schedules the activation of ECBP1100 (MESS) artificial finality
+// at the given block, reporting whether it is in effect for the current head.
+func (ec *Client) SetECBP1100(ctx context.Context, number *big.Int) (bool, error) {
+	var result bool
+	err := ec.c.CallContext(ctx, &result, "admin_ecbp1100", hexutil.EncodeBig(number))
+	return result, err
+}
+
+// Miner
+
+// Mining reports whether the node is mining.
+func (ec *Client) Mining(ctx context.Context) (bool, error) {
+	var result bool
+	err := ec.c.CallContext(ctx, &result, "eth_mining")
+	return result, err
+}
+
+// StartMining starts the miner of the node with the given number of threads, or
+// as many as there are usable CPUs if threads is zero.
+func (ec *Client) StartMining(ctx context.Context, threads int) error {
+	var arg *int
+	if threads > 0 {
+		arg = &threads
+	}
+	return ec.c.CallContext(ctx, nil, "miner_start", arg)
+}
+
+// StopMining stops the miner of the node.
+func (ec *Client) StopMining(ctx context.Context) error {
+	return ec.c.CallContext(ctx, nil, "miner_stop")
+}
+
+// Hashrate returns the current hashrate of the miner.
+func (ec *Client) Hashrate(ctx context.Context) (uint64, error) {
+	var result uint64
+	err := ec.c.CallContext(ctx, &result, "miner_getHashrate")
+	return result, err
+}
+
+// SetEtherbase sets the address credited with the rewards of mined blocks.
+func (ec *Client) SetEtherbase(ctx context.Context, etherbase common.Address) error {
+	return ec.c.CallContext(ctx, nil, "miner_setEtherbase", etherbase)
+}
+
+// SetExtra sets the extra data of mined blocks.
+func (ec *Client) SetExtra(ctx context.Context, extra string) error {
+	return ec.c.CallContext(ctx, nil, "miner_setExtra", extra)
+}
+
+// SetGasPrice sets the minimum gas price of transactions accepted by the miner
+// and the pool.
+func (ec *Client) SetGasPrice(ctx context.Context, gasPrice *big.Int) error {
+	return ec.c.CallContext(ctx, nil, "miner_setGasPrice", (*hexutil.Big)(gasPrice))
+}
+
+// SetRecommitInterval sets the interval at which the miner recreates the block
+// being sealed.
+func (ec *Client) SetRecommitInterval(ctx context.Context, interval time.Duration) error {
+	return ec.c.CallContext(ctx, nil, "miner_setRecommitInterval", int(interval/time.Millisecond))
+}
@@ -0,0 +1,324 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package gethclient provides a client for the node specific RPC APIs, the
+// debug, trace, txpool, admin and miner namespaces, complementing ethclient.
+package gethclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Client is a wrapper around rpc.Client that implements the node specific
+// RPC APIs.
+type Client struct {
+	c *rpc.Client
+}
+
+// New creates a client that uses the given RPC client.
+func New(c *rpc.Client) *Client {
+	return &Client{c}
+}
+
+// AccountResult is the result of a GetProof operation.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []string        `json:"accountProof"`
+	Balance      *big.Int        `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        uint64          `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult provides a proof for a key-value pair.
+type StorageResult struct {
+	Key   string   `json:"key"`
+	Value *big.Int `json:"value"`
+	Proof []string `json:"proof"`
+}
+
+// GetProof returns the account and storage values of the specified account
+// including the Merkle-proof. The block number can be nil, in which case the
+// value is taken from the latest known block.
+func (ec *Client) GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*AccountResult, error) {
+	type storageResult struct {
+		Key   string       `json:"key"`
+		Value *hexutil.Big `json:"value"`
+		Proof []string     `json:"proof"`
+	}
+	type accountResult struct {
+		Address      common.Address  `json:"address"`
+		AccountProof []string        `json:"accountProof"`
+		Balance      *hexutil.Big    `json:"balance"`
+		CodeHash     common.Hash     `json:"codeHash"`
+		Nonce        hexutil.Uint64  `json:"nonce"`
+		StorageHash  common.Hash     `json:"storageHash"`
+		StorageProof []storageResult `json:"storageProof"`
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	var res accountResult
+	if err := ec.c.CallContext(ctx, &res, "eth_getProof", account, keys, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	// Turn hexutils back to normal datatypes
+	storageResults := make([]StorageResult, 0, len(res.StorageProof))
+	for _, st := range res.StorageProof {
+		storageResults = append(storageResults, StorageResult{
+			Key:   st.Key,
+			Value: st.Value.ToInt(),
+			Proof: st.Proof,
+		})
+	}
+	result := AccountResult{
+		Address:      res.Address,
+		AccountProof: res.AccountProof,
+		Balance:      res.Balance.ToInt(),
+		Nonce:        uint64(res.Nonce),
+		CodeHash:     res.CodeHash,
+		StorageHash:  res.StorageHash,
+		StorageProof: storageResults,
+	}
+	return &result, nil
+}
+
+// TraceConfig holds the optional parameters of the debug and trace methods.
+// Nil fields are left for the node to default.
+type TraceConfig struct {
+	DisableMemory     bool    `json:"DisableMemory,omitempty"`     // Disable memory capture of the struct logger
+	DisableStack      bool    `json:"DisableStack,omitempty"`      // Disable stack capture of the struct logger
+	DisableStorage    bool    `json:"DisableStorage,omitempty"`    // Disable storage capture of the struct logger
+	DisableReturnData bool    `json:"DisableReturnData,omitempty"` // Disable return data capture of the struct logger
+	Limit             int     `json:"Limit,omitempty"`             // Maximum number of struct logs, zero means unlimited
+	Tracer            *string `json:"Tracer,omitempty"`            // Name of a native tracer, or JavaScript tracer code
+	Timeout           *string `json:"Timeout,omitempty"`           // Execution timeout of a JavaScript tracer, e.g. "10s"
+	Reexec            *uint64 `json:"Reexec,omitempty"`            // Number of blocks to reexecute for missing state
+}
+
+// ExecutionResult is the trace produced by the default struct logger of the
+// debug tracing methods.
+type ExecutionResult struct {
+	Gas         uint64         `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue string         `json:"returnValue"`
+	StructLogs  []StructLogRes `json:"structLogs"`
+}
+
+// StructLogRes is a single instruction of an ExecutionResult.
+type StructLogRes struct {
+	Pc      uint64             `json:"pc"`
+	Op      string             `json:"op"`
+	Gas     uint64             `json:"gas"`
+	GasCost uint64             `json:"gasCost"`
+	Depth   int                `json:"depth"`
+	Error   json.RawMessage    `json:"error,omitempty"`
+	Stack   *[]string          `json:"stack,omitempty"`
+	Memory  *[]string          `json:"memory,omitempty"`
+	Storage *map[string]string `json:"storage,omitempty"`
+}
+
+// TxTraceResult is the trace of a single transaction within a traced block.
+// The format of Result depends on the tracer used.
+type TxTraceResult struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// BlockTraceResult is the trace of a single block delivered by a chain tracing
+// subscription.
+type BlockTraceResult struct {
+	Block  hexutil.Uint64   `json:"block"`
+	Hash   common.Hash      `json:"hash"`
+	Traces []*TxTraceResult `json:"traces"`
+}
+
+// TraceTransaction returns the trace of a mined transaction, replayed on top of
+// the state it was originally executed on. With the default struct logger the
+// result decodes into an ExecutionResult, otherwise its format is determined by
+// the tracer.
+func (ec *Client) TraceTransaction(ctx context.Context, hash common.Hash, config *TraceConfig) (json.RawMessage, error) {
+	var result json.RawMessage
+	err := ec.c.CallContext(ctx, &result, "debug_traceTransaction", hash, config)
+	return result, err
+}
+
+// TraceCall returns the trace of a call executed on top of the given block.
+// The block number can be nil, in which case the latest known block is used.
+func (ec *Client) TraceCall(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int, config *TraceConfig) (json.RawMessage, error) {
+	var result json.RawMessage
+	err := ec.c.CallContext(ctx, &result, "debug_traceCall", toCallArg(msg), toBlockNumArg(blockNumber), config)
+	return result, err
+}
+
+// TraceBlockByNumber returns the traces of all the transactions in a block of
+// the canonical chain. The block number can be nil, in which case the latest
+// known block is traced.
+func (ec *Client) TraceBlockByNumber(ctx context.Context, number *big.Int, config *TraceConfig) ([]*TxTraceResult, error) {
+	var result []*TxTraceResult
+	err := ec.c.CallContext(ctx, &result, "debug_traceBlockByNumber", toBlockNumArg(number), config)
+	return result, err
+}
+
+// TraceBlockByHash returns the traces of all the transactions in a block.
+func (ec *Client) TraceBlockByHash(ctx context.Context, hash common.Hash, config *TraceConfig) ([]*TxTraceResult, error) {
+	var result []*TxTraceResult
+	err := ec.c.CallContext(ctx, &result, "debug_traceBlockByHash", hash, config)
+	return result, err
+}
+
+// TraceBadBlock returns the traces of all the transactions in a block which was
+// rejected by the node.
+func (ec *Client) TraceBadBlock(ctx context.Context, hash common.Hash, config *TraceConfig) ([]*TxTraceResult, error) {
+	var result []*TxTraceResult
+	err := ec.c.CallContext(ctx, &result, "debug_traceBadBlock", hash, config)
+	return result, err
+}
+
+// SubscribeTraceChain subscribes to the traces of the blocks between start
+// (exclusive) and end (inclusive), delivered in order as they are produced.
+func (ec *Client) SubscribeTraceChain(ctx context.Context, start, end *big.Int, config *TraceConfig, ch chan<- *BlockTraceResult) (*rpc.ClientSubscription, error) {
+	return ec.c.Subscribe(ctx, "debug", ch, "traceChain", toBlockNumArg(start), toBlockNumArg(end), config)
+}
+
+// StorageRangeResult is a page of the storage of a contract.
+type StorageRangeResult struct {
+	Storage map[common.Hash]StorageEntry `json:"storage"`
+	NextKey *common.Hash                 `json:"nextKey"` // nil if the range reached the last key
+}
+
+// StorageEntry is a single slot of a StorageRangeResult, keyed by the hash of
+// its key. Key is nil if the preimage of the hash is unknown.
+type StorageEntry struct {
+	Key   *common.Hash `json:"key"`
+	Value common.Hash  `json:"value"`
+}
+
+// StorageRangeAt returns up to maxResult storage slots of a contract, starting
+// at the hashed key keyStart, as they were after executing the first txIndex
+// transactions of the given block.
+func (ec *Client) StorageRangeAt(ctx context.Context, blockHash common.Hash, txIndex int, contract common.Address, keyStart []byte, maxResult int) (*StorageRangeResult, error) {
+	var result StorageRangeResult
+	if err := ec.c.CallContext(ctx, &result, "debug_storageRangeAt", blockHash, txIndex, contract, hexutil.Bytes(keyStart), maxResult); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Preimage returns the preimage of a hash stored by the node, if known.
+func (ec *Client) Preimage(ctx context.Context, hash common.Hash) ([]byte, error) {
+	var result hexutil.Bytes
+	err := ec.c.CallContext(ctx, &result, "debug_preimage", hash)
+	return result, err
+}
+
+// ModifiedAccountsByNumber returns the accounts modified between two blocks,
+// excluding the start block. If end is nil, only the changes of the start block
+// are returned.
+func (ec *Client) ModifiedAccountsByNumber(ctx context.Context, start uint64, end *uint64) ([]common.Address, error) {
+	var result []common.Address
+	err := ec.c.CallContext(ctx, &result, "debug_getModifiedAccountsByNumber", start, end)
+	return result, err
+}
+
+// ModifiedAccountsByHash returns the accounts modified between two blocks,
+// excluding the start block. If end is nil, only the changes of the start block
+// are returned.
+func (ec *Client) ModifiedAccountsByHash(ctx context.Context, start common.Hash, end *common.Hash) ([]common.Address, error) {
+	var result []common.Address
+	err := ec.c.CallContext(ctx, &result, "debug_getModifiedAccountsByHash", start, end)
+	return result, err
+}
+
+// RejectedReorgs returns the most recent chain reorganizations rejected by
+// artificial finality, oldest first.
+func (ec *Client) RejectedReorgs(ctx context.Context) ([]*rawdb.RejectedReorg, error) {
+	var result []*rawdb.RejectedReorg
+	err := ec.c.CallContext(ctx, &result, "debug_getRejectedReorgs")
+	return result, err
+}
+
+// EvaluateECBP1100 returns the ECBP1100 (MESS) arbitration of a candidate header
+// against the current chain of the node, without importing the header.
+func (ec *Client) EvaluateECBP1100(ctx context.Context, header *types.Header) (*core.ECBP1100Result, error) {
+	var result core.ECBP1100Result
+	if err := ec.c.CallContext(ctx, &result, "debug_ecbp1100Evaluate", header); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SetHead rewinds the local chain of the node to the given block.
+func (ec *Client) SetHead(ctx context.Context, number *big.Int) error {
+	return ec.c.CallContext(ctx, nil, "debug_setHead", hexutil.Uint64(number.Uint64()))
+}
+
+// SubscribeNewSideHeads subscribes to notifications about the headers of blocks
+// written to the database outside of the canonical chain.
+func (ec *Client) SubscribeNewSideHeads(ctx context.Context, ch chan<- *types.Header) (*rpc.ClientSubscription, error) {
+	return ec.c.EthSubscribe(ctx, ch, "newSideHeads")
+}
+
+// SubscribePendingTransactions subscribes to the hashes of the transactions
+// entering the transaction pool.
+func (ec *Client) SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (*rpc.ClientSubscription, error) {
+	return ec.c.EthSubscribe(ctx, ch, "newPendingTransactions")
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	pending := big.NewInt(-1)
+	if number.Cmp(pending) == 0 {
+		return "pending"
+	}
+	return hexutil.EncodeBig(number)
+}
+
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
+	return arg
+}
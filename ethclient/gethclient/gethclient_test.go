@@ -0,0 +1,400 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package gethclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+	"github.com/ethereum/go-ethereum/params/vars"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = big.NewInt(2e15)
+	testRecv    = common.Address{0x01, 0x02}
+	testSigner  = types.HomesteadSigner{}
+)
+
+func newTestBackend(t *testing.T) (*node.Node, []*types.Block) {
+	// Generate test chain.
+	genesis, blocks := generateTestChain()
+	// Create node
+	n, err := node.New(&node.Config{})
+	if err != nil {
+		t.Fatalf("can't create new node: %v", err)
+	}
+	// Create Ethereum Service
+	config := &eth.Config{Genesis: genesis}
+	config.Ethash.PowMode = ethash.ModeFake
+	ethservice, err := eth.New(n, config)
+	if err != nil {
+		t.Fatalf("can't create new ethereum service: %v", err)
+	}
+	// Import the test chain.
+	if err := n.Start(); err != nil {
+		t.Fatalf("can't start test node: %v", err)
+	}
+	if _, err := ethservice.BlockChain().InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("can't import test blocks: %v", err)
+	}
+	return n, blocks
+}
+
+// generateTestChain creates a chain of two blocks, the first one transferring
+// funds from the test account.
+func generateTestChain() (*genesisT.Genesis, []*types.Block) {
+	db := rawdb.NewMemoryDatabase()
+	config := params.AllEthashProtocolChanges
+	genesis := &genesisT.Genesis{
+		Config:    config,
+		Alloc:     genesisT.GenesisAlloc{testAddr: {Balance: testBalance}},
+		ExtraData: []byte("test genesis"),
+		Timestamp: 9000,
+	}
+	generate := func(i int, g *core.BlockGen) {
+		g.OffsetTime(5)
+		g.SetExtra([]byte("test"))
+		if i == 0 {
+			tx, _ := types.SignTx(types.NewTransaction(0, testRecv, big.NewInt(1000), vars.TxGas, big.NewInt(1), nil), testSigner, testKey)
+			g.AddTx(tx)
+		}
+	}
+	gblock := core.GenesisToBlock(genesis, db)
+	engine := ethash.NewFaker()
+	blocks, _ := core.GenerateChain(config, gblock, engine, db, 2, generate)
+	blocks = append([]*types.Block{gblock}, blocks...)
+	return genesis, blocks
+}
+
+func TestGethClient(t *testing.T) {
+	backend, chain := newTestBackend(t)
+	client, _ := backend.Attach()
+	defer backend.Close()
+	defer client.Close()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, client *rpc.Client, chain []*types.Block)
+	}{
+		{"GetProof", testGetProof},
+		{"TraceTransaction", testTraceTransaction},
+		{"TraceBlock", testTraceBlock},
+		{"StorageRangeAt", testStorageRangeAt},
+		{"ParityTraceBlock", testParityTraceBlock},
+		{"ReplayTransaction", testReplayTransaction},
+		{"ParityTraceCallMany", testParityTraceCallMany},
+		{"NodeInfo", testNodeInfo},
+		{"Miner", testMiner},
+		{"SubscribeTraceChainFail", testSubscribeTraceChainFail},
+		{"TxPool", testTxPool}, // last, leaves a pending transaction behind
+	}
+	for _, tt := range tests {
+		test := tt.test
+		t.Run(tt.name, func(t *testing.T) { test(t, client, chain) })
+	}
+}
+
+func testGetProof(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+	result, err := ec.GetProof(context.Background(), testAddr, []string{"0x00"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Address != testAddr {
+		t.Errorf("address mismatch: have %x, want %x", result.Address, testAddr)
+	}
+	if result.Nonce != 1 {
+		t.Errorf("nonce mismatch: have %d, want 1", result.Nonce)
+	}
+	if len(result.AccountProof) == 0 || len(result.StorageProof) != 1 {
+		t.Errorf("proofs missing: %+v", result)
+	}
+	if result.StorageProof[0].Value.Sign() != 0 {
+		t.Errorf("storage value mismatch: have %v, want 0", result.StorageProof[0].Value)
+	}
+}
+
+func testTraceTransaction(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+	tx := chain[1].Transactions()[0]
+
+	raw, err := ec.TraceTransaction(context.Background(), tx.Hash(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result ExecutionResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Gas != vars.TxGas || result.Failed {
+		t.Errorf("execution result mismatch: %+v", result)
+	}
+	tracer := "callTracer"
+	raw, err = ec.TraceCall(context.Background(), ethereum.CallMsg{From: testAddr, To: &testRecv, Value: big.NewInt(1)}, nil, &TraceConfig{Tracer: &tracer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var call struct {
+		Type string `json:"type"`
+		To   string `json:"to"`
+	}
+	if err := json.Unmarshal(raw, &call); err != nil {
+		t.Fatal(err)
+	}
+	if call.Type != "CALL" || common.HexToAddress(call.To) != testRecv {
+		t.Errorf("call trace mismatch: %s", raw)
+	}
+}
+
+func testTraceBlock(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+	byNumber, err := ec.TraceBlockByNumber(context.Background(), big.NewInt(1), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byHash, err := ec.TraceBlockByHash(context.Background(), chain[1].Hash(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byNumber) != 1 || len(byHash) != 1 {
+		t.Fatalf("trace count mismatch: have %d and %d, want 1", len(byNumber), len(byHash))
+	}
+	if string(byNumber[0].Result) != string(byHash[0].Result) {
+		t.Errorf("traces by number and hash differ")
+	}
+}
+
+func testStorageRangeAt(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+	result, err := ec.StorageRangeAt(context.Background(), chain[2].Hash(), 0, testRecv, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Storage) != 0 || result.NextKey != nil {
+		t.Errorf("unexpected storage: %+v", result)
+	}
+}
+
+func testParityTraceBlock(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+	traces, err := ec.ParityTraceBlock(context.Background(), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 2 {
+		t.Fatalf("trace count mismatch: have %d, want 2", len(traces))
+	}
+	call, reward := traces[0], traces[1]
+	if call.Type != "call" || *call.Action.From != testAddr || *call.Action.To != testRecv || call.Action.Value.ToInt().Int64() != 1000 {
+		t.Errorf("call trace mismatch: %+v", call.Action)
+	}
+	if call.TransactionHash == nil || *call.TransactionHash != chain[1].Transactions()[0].Hash() {
+		t.Errorf("call trace not located: %+v", call)
+	}
+	if reward.Type != "reward" || reward.Action.RewardType != "block" || *reward.Action.Author != chain[1].Coinbase() {
+		t.Errorf("reward trace mismatch: %+v", reward.Action)
+	}
+	txTraces, err := ec.ParityTraceTransaction(context.Background(), chain[1].Transactions()[0].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txTraces) != 1 || txTraces[0].Result == nil || txTraces[0].Result.GasUsed != 0 {
+		t.Errorf("transaction trace mismatch: %+v", txTraces)
+	}
+}
+
+func testReplayTransaction(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+	tx := chain[1].Transactions()[0]
+
+	result, err := ec.ReplayTransaction(context.Background(), tx.Hash(), []string{TraceTypeTrace, TraceTypeStateDiff, TraceTypeVMTrace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Trace) != 1 || result.Trace[0].BlockNumber != nil {
+		t.Errorf("replayed trace mismatch: %+v", result.Trace)
+	}
+	if result.VMTrace == nil || len(result.VMTrace.Ops) != 0 {
+		t.Errorf("vm trace mismatch: %+v", result.VMTrace)
+	}
+	recv := result.StateDiff[testRecv]
+	if recv == nil || recv.Balance.Kind != DiffBorn || recv.Nonce.Kind != DiffBorn {
+		t.Fatalf("receiver diff mismatch: %+v", recv)
+	}
+	if string(recv.Balance.To) != `"0x3e8"` {
+		t.Errorf("receiver balance mismatch: have %s", recv.Balance.To)
+	}
+	sender := result.StateDiff[testAddr]
+	if sender == nil || sender.Code.Kind != DiffSame || sender.Nonce.Kind != DiffChanged {
+		t.Fatalf("sender diff mismatch: %+v", sender)
+	}
+	if string(sender.Nonce.From) != `"0x0"` || string(sender.Nonce.To) != `"0x1"` {
+		t.Errorf("sender nonce mismatch: have %s -> %s", sender.Nonce.From, sender.Nonce.To)
+	}
+	results, err := ec.ReplayBlockTransactions(context.Background(), big.NewInt(1), []string{TraceTypeTrace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].TransactionHash == nil || *results[0].TransactionHash != tx.Hash() {
+		t.Errorf("replayed block mismatch: %+v", results)
+	}
+}
+
+func testParityTraceCallMany(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+	msg := ethereum.CallMsg{From: testAddr, To: &testRecv, Value: big.NewInt(7)}
+
+	single, err := ec.ParityTraceCall(context.Background(), msg, []string{TraceTypeStateDiff}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := single.StateDiff[testRecv]; diff == nil || diff.Balance.Kind != DiffChanged {
+		t.Errorf("call diff mismatch: %+v", diff)
+	}
+	calls := []TraceCallRequest{
+		{Msg: msg, TraceTypes: []string{TraceTypeTrace}},
+		{Msg: msg, TraceTypes: []string{TraceTypeStateDiff}},
+	}
+	results, err := ec.ParityTraceCallMany(context.Background(), calls, big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || len(results[0].Trace) != 1 || results[0].StateDiff != nil {
+		t.Fatalf("call bundle mismatch: %+v", results)
+	}
+	// The second call sees the balance credited by the first
+	if have := string(results[1].StateDiff[testRecv].Balance.From); have != `"0x3ef"` {
+		t.Errorf("second call balance mismatch: have %s", have)
+	}
+}
+
+func testNodeInfo(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+	info, err := ec.NodeInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Enode == "" || info.ID == "" {
+		t.Errorf("node info mismatch: %+v", info)
+	}
+	peers, err := ec.Peers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 0 {
+		t.Errorf("unexpected peers: %v", peers)
+	}
+}
+
+func testMiner(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+	if err := ec.SetEtherbase(context.Background(), testAddr); err != nil {
+		t.Fatal(err)
+	}
+	if err := ec.SetExtra(context.Background(), "gethclient"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ec.SetGasPrice(context.Background(), big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ec.SetRecommitInterval(context.Background(), 3*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	mining, err := ec.Mining(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mining {
+		t.Error("node unexpectedly mining")
+	}
+}
+
+func testSubscribeTraceChainFail(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+	ch := make(chan *BlockTraceResult)
+	if _, err := ec.SubscribeTraceChain(context.Background(), big.NewInt(2), big.NewInt(1), nil, ch); err == nil {
+		t.Error("inverted chain range accepted")
+	}
+}
+
+func testTxPool(t *testing.T, client *rpc.Client, chain []*types.Block) {
+	ec := New(client)
+
+	hashes := make(chan common.Hash, 1)
+	sub, err := ec.SubscribePendingTransactions(context.Background(), hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	// The node installs the subscription in the background, give it some time
+	time.Sleep(100 * time.Millisecond)
+
+	tx, _ := types.SignTx(types.NewTransaction(1, testRecv, big.NewInt(1), vars.TxGas, big.NewInt(1), nil), testSigner, testKey)
+	if err := ethclient.NewClient(client).SendTransaction(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case hash := <-hashes:
+		if hash != tx.Hash() {
+			t.Errorf("pending transaction mismatch: have %x, want %x", hash, tx.Hash())
+		}
+	case err := <-sub.Err():
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending transaction not announced")
+	}
+	pending, queued, err := ec.TxPoolStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending != 1 || queued != 0 {
+		t.Errorf("pool status mismatch: have %d/%d, want 1/0", pending, queued)
+	}
+	content, err := ec.TxPoolContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := content.Pending[testAddr][1]; have == nil || have.Hash() != tx.Hash() {
+		t.Errorf("pool content mismatch: %+v", content)
+	}
+	inspect, err := ec.TxPoolInspect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inspect.Pending[testAddr][1] == "" {
+		t.Errorf("pool summary mismatch: %+v", inspect)
+	}
+}
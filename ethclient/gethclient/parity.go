@@ -0,0 +1,297 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package gethclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Trace types selectable when replaying transactions with the trace namespace.
+const (
+	TraceTypeTrace     = "trace"     // Call traces, see ParityTrace
+	TraceTypeStateDiff = "stateDiff" // State differences, see StateDiff
+	TraceTypeVMTrace   = "vmTrace"   // Executed instructions, see VMTrace
+)
+
+// ParityTrace is a single call, contract creation, self-destruct or reward in
+// the format of the Parity (OpenEthereum) trace module. The block and
+// transaction locators are nil for replayed transactions and calls.
+type ParityTrace struct {
+	Type                string             `json:"type"` // call, create, suicide or reward
+	Action              ParityTraceAction  `json:"action"`
+	Result              *ParityTraceResult `json:"result"` // nil for failed calls, self-destructs and rewards
+	Error               string             `json:"error,omitempty"`
+	Subtraces           int                `json:"subtraces"`
+	TraceAddress        []int              `json:"traceAddress"`
+	TransactionHash     *common.Hash       `json:"transactionHash"`
+	TransactionPosition *uint64            `json:"transactionPosition"`
+	BlockHash           *common.Hash       `json:"blockHash"`
+	BlockNumber         *uint64            `json:"blockNumber"`
+}
+
+// ParityTraceAction holds the fields of every action type of a ParityTrace,
+// only those of the actual type are set.
+type ParityTraceAction struct {
+	// Calls and contract creations
+	From     *common.Address `json:"from,omitempty"`
+	Value    *hexutil.Big    `json:"value,omitempty"`
+	Gas      *hexutil.Uint64 `json:"gas,omitempty"`
+	To       *common.Address `json:"to,omitempty"`
+	Input    hexutil.Bytes   `json:"input,omitempty"`
+	CallType string          `json:"callType,omitempty"`
+
+	// Contract creations
+	Init           hexutil.Bytes `json:"init,omitempty"`
+	CreationMethod string        `json:"creationMethod,omitempty"`
+
+	// Self-destructs
+	Address       *common.Address `json:"address,omitempty"`
+	RefundAddress *common.Address `json:"refundAddress,omitempty"`
+	Balance       *hexutil.Big    `json:"balance,omitempty"`
+
+	// Rewards
+	Author     *common.Address `json:"author,omitempty"`
+	RewardType string          `json:"rewardType,omitempty"` // block or uncle
+}
+
+// ParityTraceResult is the outcome of a successful call or contract creation.
+type ParityTraceResult struct {
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Output  hexutil.Bytes   `json:"output,omitempty"`  // Calls only
+	Address *common.Address `json:"address,omitempty"` // Contract creations only
+	Code    hexutil.Bytes   `json:"code,omitempty"`    // Contract creations only
+}
+
+// TraceReplayResult is the result of replaying a transaction or call, holding
+// the output of the requested trace types.
+type TraceReplayResult struct {
+	Output          hexutil.Bytes  `json:"output"`
+	Trace           []*ParityTrace `json:"trace"`
+	StateDiff       StateDiff      `json:"stateDiff"`
+	VMTrace         *VMTrace       `json:"vmTrace"`
+	TransactionHash *common.Hash   `json:"transactionHash,omitempty"` // Set by ReplayBlockTransactions only
+}
+
+// StateDiff is the Parity formatted state difference of a transaction, keyed by
+// the touched accounts.
+type StateDiff map[common.Address]*AccountDiff
+
+// AccountDiff is the state difference of a single account.
+type AccountDiff struct {
+	Balance *Diff                 `json:"balance"`
+	Code    *Diff                 `json:"code"`
+	Nonce   *Diff                 `json:"nonce"`
+	Storage map[common.Hash]*Diff `json:"storage"`
+}
+
+// DiffKind is the kind of change recorded by a Diff.
+type DiffKind string
+
+const (
+	DiffSame    DiffKind = "=" // Unchanged field
+	DiffBorn    DiffKind = "+" // Field of a newly created account
+	DiffDied    DiffKind = "-" // Field of a deleted account
+	DiffChanged DiffKind = "*" // Modified field
+)
+
+// Diff is the change of a single field of an account. From is unset for born
+// fields and To for died ones, neither are set for unchanged fields. The values
+// are left JSON encoded, they are hex quantities for balances and nonces, hex
+// data for code and hashes for storage slots.
+type Diff struct {
+	Kind DiffKind
+	From json.RawMessage
+	To   json.RawMessage
+}
+
+// UnmarshalJSON decodes the "=" marker or an object keyed by the "+", "-" or
+// "*" marker.
+func (d *Diff) UnmarshalJSON(input []byte) error {
+	var same string
+	if err := json.Unmarshal(input, &same); err == nil {
+		if DiffKind(same) != DiffSame {
+			return fmt.Errorf("invalid diff marker %q", same)
+		}
+		*d = Diff{Kind: DiffSame}
+		return nil
+	}
+	var dec map[DiffKind]json.RawMessage
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if len(dec) != 1 {
+		return fmt.Errorf("expected a single diff marker, got %d", len(dec))
+	}
+	for kind, value := range dec {
+		switch kind {
+		case DiffBorn:
+			*d = Diff{Kind: kind, To: value}
+		case DiffDied:
+			*d = Diff{Kind: kind, From: value}
+		case DiffChanged:
+			var change struct {
+				From json.RawMessage `json:"from"`
+				To   json.RawMessage `json:"to"`
+			}
+			if err := json.Unmarshal(value, &change); err != nil {
+				return err
+			}
+			*d = Diff{Kind: kind, From: change.From, To: change.To}
+		default:
+			return fmt.Errorf("invalid diff marker %q", kind)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the diff in the Parity format.
+func (d Diff) MarshalJSON() ([]byte, error) {
+	switch d.Kind {
+	case DiffSame:
+		return json.Marshal(DiffSame)
+	case DiffBorn:
+		return json.Marshal(map[DiffKind]json.RawMessage{DiffBorn: d.To})
+	case DiffDied:
+		return json.Marshal(map[DiffKind]json.RawMessage{DiffDied: d.From})
+	case DiffChanged:
+		return json.Marshal(map[DiffKind]map[string]json.RawMessage{DiffChanged: {"from": d.From, "to": d.To}})
+	}
+	return nil, fmt.Errorf("invalid diff kind %q", d.Kind)
+}
+
+// VMTrace is the Parity formatted trace of the instructions executed by a call
+// frame, along with the frames spawned by its CALL and CREATE instructions.
+type VMTrace struct {
+	Code hexutil.Bytes `json:"code"`
+	Ops  []*VMTraceOp  `json:"ops"`
+}
+
+// VMTraceOp is a single executed instruction within a VMTrace.
+type VMTraceOp struct {
+	Cost uint64     `json:"cost"`
+	Ex   *VMTraceEx `json:"ex"` // nil for failed instructions
+	PC   uint64     `json:"pc"`
+	Sub  *VMTrace   `json:"sub"`
+}
+
+// VMTraceEx holds the effects of an executed instruction.
+type VMTraceEx struct {
+	Mem   *VMTraceMem    `json:"mem"`
+	Push  []*hexutil.Big `json:"push"`
+	Store *VMTraceStore  `json:"store"`
+	Used  uint64         `json:"used"`
+}
+
+// VMTraceMem is the memory region written by an instruction.
+type VMTraceMem struct {
+	Data hexutil.Bytes `json:"data"`
+	Off  uint64        `json:"off"`
+}
+
+// VMTraceStore is the storage slot written by an instruction.
+type VMTraceStore struct {
+	Key *hexutil.Big `json:"key"`
+	Val *hexutil.Big `json:"val"`
+}
+
+// TraceCallRequest is a single call of a ParityTraceCallMany bundle.
+type TraceCallRequest struct {
+	Msg        ethereum.CallMsg
+	TraceTypes []string
+}
+
+// ParityTraceBlock returns the call traces of all the transactions in a block of
+// the canonical chain, followed by its block and uncle reward traces. The block
+// number can be nil, in which case the latest known block is traced.
+func (ec *Client) ParityTraceBlock(ctx context.Context, number *big.Int) ([]*ParityTrace, error) {
+	var result []*ParityTrace
+	err := ec.c.CallContext(ctx, &result, "trace_block", toBlockNumArg(number))
+	return result, err
+}
+
+// ParityTraceTransaction returns the call traces of a mined transaction.
+func (ec *Client) ParityTraceTransaction(ctx context.Context, hash common.Hash) ([]*ParityTrace, error) {
+	var result []*ParityTrace
+	err := ec.c.CallContext(ctx, &result, "trace_transaction", hash)
+	return result, err
+}
+
+// ReplayTransaction replays a mined transaction on top of the state it was
+// originally executed on, returning the requested trace types.
+func (ec *Client) ReplayTransaction(ctx context.Context, hash common.Hash, traceTypes []string) (*TraceReplayResult, error) {
+	var result TraceReplayResult
+	if err := ec.c.CallContext(ctx, &result, "trace_replayTransaction", hash, traceTypes); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ReplayBlockTransactions replays all the transactions of a block of the
+// canonical chain, returning the requested trace types for each of them. The
+// block number can be nil, in which case the latest known block is replayed.
+func (ec *Client) ReplayBlockTransactions(ctx context.Context, number *big.Int, traceTypes []string) ([]*TraceReplayResult, error) {
+	var result []*TraceReplayResult
+	err := ec.c.CallContext(ctx, &result, "trace_replayBlockTransactions", toBlockNumArg(number), traceTypes)
+	return result, err
+}
+
+// ParityTraceRawTransaction executes a signed transaction on top of the latest
+// block without submitting it, returning the requested trace types.
+func (ec *Client) ParityTraceRawTransaction(ctx context.Context, tx *types.Transaction, traceTypes []string) (*TraceReplayResult, error) {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var result TraceReplayResult
+	if err := ec.c.CallContext(ctx, &result, "trace_rawTransaction", hexutil.Bytes(data), traceTypes); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ParityTraceCall executes a call on top of the given block without creating a
+// transaction, returning the requested trace types. The block number can be
+// nil, in which case the latest known block is used.
+func (ec *Client) ParityTraceCall(ctx context.Context, msg ethereum.CallMsg, traceTypes []string, blockNumber *big.Int) (*TraceReplayResult, error) {
+	var result TraceReplayResult
+	if err := ec.c.CallContext(ctx, &result, "trace_call", toCallArg(msg), traceTypes, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ParityTraceCallMany executes an ordered bundle of calls on top of the given
+// block, each call seeing the state changes of the previous ones, returning the
+// requested trace types of every call. The block number can be nil, in which
+// case the latest known block is used.
+func (ec *Client) ParityTraceCallMany(ctx context.Context, calls []TraceCallRequest, blockNumber *big.Int) ([]*TraceReplayResult, error) {
+	args := make([][]interface{}, len(calls))
+	for i, call := range calls {
+		args[i] = []interface{}{toCallArg(call.Msg), call.TraceTypes}
+	}
+	var result []*TraceReplayResult
+	err := ec.c.CallContext(ctx, &result, "trace_callMany", args, toBlockNumArg(blockNumber))
+	return result, err
+}
@@ -12,6 +12,7 @@ require (
 	github.com/btcsuite/btcd v0.21.0-beta
 	github.com/cespare/cp v1.1.1
 	github.com/cloudflare/cloudflare-go v0.13.2
+	github.com/cockroachdb/pebble v0.0.0-20210525181856-e45797baeb78
 	github.com/davecgh/go-spew v1.1.1
 	github.com/deckarep/golang-set v0.0.0-20180603214616-504e848d77ea
 	github.com/dlclark/regexp2 v1.4.0 // indirect
@@ -67,7 +68,7 @@ require (
 	github.com/xeipuuv/gojsonschema v1.2.0
 	github.com/xordataexchange/crypt v0.0.3-0.20170626215501-b2862e3d0a77 // indirect
 	golang.org/x/crypto v0.0.0-20200820211705-5c72a883971a
-	golang.org/x/exp v0.0.0-20200331195152-e8c3332aa8e5
+	golang.org/x/exp v0.0.0-20200513190911-00229845015e
 	golang.org/x/sys v0.0.0-20201214210602-f9fddec55a1e
 	golang.org/x/text v0.3.3
 	golang.org/x/time v0.0.0-20200630173020-3af7569d3a1e
@@ -19,26 +19,39 @@ package graphql
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native" // register the native tracers
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 var (
-	errBlockInvariant = errors.New("block objects must be instantiated with at least one of num or hash")
+	errBlockInvariant  = errors.New("block objects must be instantiated with at least one of num or hash")
+	errReceiptsLengths = errors.New("block receipts and transactions count mismatch")
 )
 
+// maxStorageRange is the maximum number of storage slots returned by a
+// storageRange query.
+const maxStorageRange = 1024
+
 // Account represents an Ethereum account at a particular block.
 type Account struct {
 	backend       ethapi.Backend
@@ -88,6 +101,87 @@ func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash })
 	return state.GetState(a.address, args.Slot), nil
 }
 
+func (a *Account) StorageRange(ctx context.Context, args struct {
+	Start *common.Hash
+	Limit int32
+}) (*StorageRange, error) {
+	if args.Limit < 0 {
+		return nil, errors.New("negative storage range limit")
+	}
+	limit := int(args.Limit)
+	if limit > maxStorageRange {
+		limit = maxStorageRange
+	}
+	state, err := a.getState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &StorageRange{entries: []*StorageEntry{}}
+	st := state.StorageTrie(a.address)
+	if st == nil {
+		return result, nil
+	}
+	var start []byte
+	if args.Start != nil {
+		start = args.Start.Bytes()
+	}
+	it := trie.NewIterator(st.NodeIterator(start))
+	for i := 0; i < limit && it.Next(); i++ {
+		_, content, _, err := rlp.Split(it.Value)
+		if err != nil {
+			return nil, err
+		}
+		entry := &StorageEntry{
+			hashedKey: common.BytesToHash(it.Key),
+			value:     common.BytesToHash(content),
+		}
+		if preimage := st.GetKey(it.Key); preimage != nil {
+			key := common.BytesToHash(preimage)
+			entry.key = &key
+		}
+		result.entries = append(result.entries, entry)
+	}
+	// Add the 'next key' so clients can continue downloading.
+	if it.Next() {
+		next := common.BytesToHash(it.Key)
+		result.nextKey = &next
+	}
+	return result, nil
+}
+
+// StorageRange represents a range of the storage slots of a contract account.
+type StorageRange struct {
+	entries []*StorageEntry
+	nextKey *common.Hash
+}
+
+func (r *StorageRange) Entries() []*StorageEntry {
+	return r.entries
+}
+
+func (r *StorageRange) NextKey() *common.Hash {
+	return r.nextKey
+}
+
+// StorageEntry represents a single storage slot of a contract account.
+type StorageEntry struct {
+	hashedKey common.Hash
+	key       *common.Hash
+	value     common.Hash
+}
+
+func (e *StorageEntry) HashedKey() common.Hash {
+	return e.hashedKey
+}
+
+func (e *StorageEntry) Key() *common.Hash {
+	return e.key
+}
+
+func (e *StorageEntry) Value() common.Hash {
+	return e.value
+}
+
 // Log represents an individual log message. All arguments are mandatory.
 type Log struct {
 	backend     ethapi.Backend
@@ -215,7 +309,7 @@ func (t *Transaction) From(ctx context.Context, args BlockNumberArgs) (*Account,
 	}
 	var signer types.Signer = types.HomesteadSigner{}
 	if tx.Protected() {
-		signer = types.NewEIP155Signer(tx.ChainId())
+		signer = types.LatestSignerForChainID(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 
@@ -314,6 +408,29 @@ func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
 	return &ret, nil
 }
 
+func (t *Transaction) Traces(ctx context.Context) (*[]*CallTrace, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || t.block == nil {
+		return nil, err
+	}
+	block, err := t.block.resolve(ctx)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	traces, err := traceTransaction(ctx, t.backend, block, int(t.index))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*CallTrace, 0, len(traces))
+	for _, trace := range traces {
+		ret = append(ret, &CallTrace{
+			backend: t.backend,
+			trace:   trace,
+		})
+	}
+	return &ret, nil
+}
+
 func (t *Transaction) R(ctx context.Context) (hexutil.Big, error) {
 	tx, err := t.resolve(ctx)
 	if err != nil || tx == nil {
@@ -341,6 +458,254 @@ func (t *Transaction) V(ctx context.Context) (hexutil.Big, error) {
 	return hexutil.Big(*v), nil
 }
 
+// parityTrace is a single call reported by the callTracerParity tracer.
+type parityTrace struct {
+	Type   string `json:"type"`
+	Action struct {
+		CallType       string          `json:"callType"`
+		CreationMethod string          `json:"creationMethod"`
+		From           *common.Address `json:"from"`
+		To             *common.Address `json:"to"`
+		Value          *hexutil.Big    `json:"value"`
+		Gas            *hexutil.Uint64 `json:"gas"`
+		Input          *hexutil.Bytes  `json:"input"`
+		Init           *hexutil.Bytes  `json:"init"`
+		Address        *common.Address `json:"address"`
+		RefundAddress  *common.Address `json:"refundAddress"`
+		Balance        *hexutil.Big    `json:"balance"`
+	} `json:"action"`
+	Result *struct {
+		GasUsed *hexutil.Uint64 `json:"gasUsed"`
+		Output  *hexutil.Bytes  `json:"output"`
+		Code    *hexutil.Bytes  `json:"code"`
+		Address *common.Address `json:"address"`
+	} `json:"result"`
+	Error        string `json:"error"`
+	TraceAddress []int  `json:"traceAddress"`
+	Subtraces    int    `json:"subtraces"`
+}
+
+// traceTransaction replays the transaction at the given index of block on top
+// of the state of its parent, returning the calls it made.
+func traceTransaction(ctx context.Context, backend ethapi.Backend, block *types.Block, index int) ([]*parityTrace, error) {
+	txs := block.Transactions()
+	if index >= len(txs) {
+		return nil, fmt.Errorf("transaction index %d out of range for block %#x", index, block.Hash())
+	}
+	statedb, _, err := backend.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithHash(block.ParentHash(), false))
+	if err != nil {
+		return nil, err
+	}
+	if statedb == nil {
+		return nil, fmt.Errorf("state of parent %#x not available", block.ParentHash())
+	}
+	config := backend.ChainConfig()
+	signer := types.MakeSigner(config, block.Number())
+	for i, tx := range txs[:index+1] {
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			return nil, err
+		}
+		var (
+			tracer   tracers.TxTracer
+			vmConfig vm.Config
+		)
+		if i == index {
+			if tracer, err = tracers.NewTxTracer("callTracerParity"); err != nil {
+				return nil, err
+			}
+			vmConfig = vm.Config{Debug: true, Tracer: tracer}
+		}
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		evm, vmError, err := backend.GetEVM(ctx, msg, statedb, block.Header(), &vmConfig)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas())); err != nil {
+			return nil, fmt.Errorf("transaction %#x failed: %v", tx.Hash(), err)
+		}
+		if err := vmError(); err != nil {
+			return nil, err
+		}
+		if tracer != nil {
+			result, err := tracer.GetResult()
+			if err != nil {
+				return nil, err
+			}
+			var traces []*parityTrace
+			if err := json.Unmarshal(result, &traces); err != nil {
+				return nil, err
+			}
+			return traces, nil
+		}
+		statedb.Finalise(config.IsEnabled(config.GetEIP161dTransition, block.Number()))
+	}
+	return nil, nil
+}
+
+// CallTrace represents a call made by a transaction, as reported by the
+// callTracerParity tracer.
+type CallTrace struct {
+	backend ethapi.Backend
+	trace   *parityTrace
+}
+
+func (c *CallTrace) Type(ctx context.Context) string {
+	return c.trace.Type
+}
+
+func (c *CallTrace) CallType(ctx context.Context) *string {
+	callType := c.trace.Action.CallType
+	if callType == "" {
+		callType = c.trace.Action.CreationMethod
+	}
+	if callType == "" {
+		return nil
+	}
+	return &callType
+}
+
+// account returns the first of the given addresses set, as an account.
+func (c *CallTrace) account(args BlockNumberArgs, addrs ...*common.Address) *Account {
+	for _, addr := range addrs {
+		if addr != nil {
+			return &Account{
+				backend:       c.backend,
+				address:       *addr,
+				blockNrOrHash: args.NumberOrLatest(),
+			}
+		}
+	}
+	return nil
+}
+
+func (c *CallTrace) From(ctx context.Context, args BlockNumberArgs) *Account {
+	return c.account(args, c.trace.Action.From, c.trace.Action.Address)
+}
+
+func (c *CallTrace) To(ctx context.Context, args BlockNumberArgs) *Account {
+	var created *common.Address
+	if c.trace.Result != nil {
+		created = c.trace.Result.Address
+	}
+	return c.account(args, c.trace.Action.To, created, c.trace.Action.RefundAddress)
+}
+
+func (c *CallTrace) Value(ctx context.Context) *hexutil.Big {
+	if c.trace.Action.Value != nil {
+		return c.trace.Action.Value
+	}
+	return c.trace.Action.Balance
+}
+
+func (c *CallTrace) Gas(ctx context.Context) *hexutil.Uint64 {
+	return c.trace.Action.Gas
+}
+
+func (c *CallTrace) GasUsed(ctx context.Context) *hexutil.Uint64 {
+	if c.trace.Result == nil {
+		return nil
+	}
+	return c.trace.Result.GasUsed
+}
+
+func (c *CallTrace) Input(ctx context.Context) *hexutil.Bytes {
+	if c.trace.Action.Input != nil {
+		return c.trace.Action.Input
+	}
+	return c.trace.Action.Init
+}
+
+func (c *CallTrace) Output(ctx context.Context) *hexutil.Bytes {
+	if c.trace.Result == nil {
+		return nil
+	}
+	if c.trace.Result.Output != nil {
+		return c.trace.Result.Output
+	}
+	return c.trace.Result.Code
+}
+
+func (c *CallTrace) Error(ctx context.Context) *string {
+	if c.trace.Error == "" {
+		return nil
+	}
+	return &c.trace.Error
+}
+
+func (c *CallTrace) TraceAddress(ctx context.Context) []int32 {
+	ret := make([]int32, len(c.trace.TraceAddress))
+	for i, index := range c.trace.TraceAddress {
+		ret[i] = int32(index)
+	}
+	return ret
+}
+
+func (c *CallTrace) Subtraces(ctx context.Context) int32 {
+	return int32(c.trace.Subtraces)
+}
+
+// Receipt represents the receipt of a transaction included in a block.
+type Receipt struct {
+	transaction *Transaction
+	receipt     *types.Receipt
+}
+
+func (r *Receipt) Transaction(ctx context.Context) *Transaction {
+	return r.transaction
+}
+
+func (r *Receipt) Status(ctx context.Context) *hexutil.Uint64 {
+	if len(r.receipt.PostState) > 0 {
+		return nil
+	}
+	ret := hexutil.Uint64(r.receipt.Status)
+	return &ret
+}
+
+func (r *Receipt) Root(ctx context.Context) *common.Hash {
+	if len(r.receipt.PostState) == 0 {
+		return nil
+	}
+	ret := common.BytesToHash(r.receipt.PostState)
+	return &ret
+}
+
+func (r *Receipt) GasUsed(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(r.receipt.GasUsed)
+}
+
+func (r *Receipt) CumulativeGasUsed(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(r.receipt.CumulativeGasUsed)
+}
+
+func (r *Receipt) CreatedContract(ctx context.Context, args BlockNumberArgs) *Account {
+	if r.receipt.ContractAddress == (common.Address{}) {
+		return nil
+	}
+	return &Account{
+		backend:       r.transaction.backend,
+		address:       r.receipt.ContractAddress,
+		blockNrOrHash: args.NumberOrLatest(),
+	}
+}
+
+func (r *Receipt) Logs(ctx context.Context) []*Log {
+	ret := make([]*Log, 0, len(r.receipt.Logs))
+	for _, log := range r.receipt.Logs {
+		ret = append(ret, &Log{
+			backend:     r.transaction.backend,
+			transaction: r.transaction,
+			log:         log,
+		})
+	}
+	return ret
+}
+
+func (r *Receipt) LogsBloom(ctx context.Context) hexutil.Bytes {
+	return r.receipt.Bloom.Bytes()
+}
+
 type BlockType int
 
 // Block represents an Ethereum block.
@@ -650,6 +1015,28 @@ func (b *Block) Transactions(ctx context.Context) (*[]*Transaction, error) {
 	return &ret, nil
 }
 
+func (b *Block) Receipts(ctx context.Context) (*[]*Receipt, error) {
+	txs, err := b.Transactions(ctx)
+	if err != nil || txs == nil {
+		return nil, err
+	}
+	receipts, err := b.resolveReceipts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) != len(*txs) {
+		return nil, errReceiptsLengths
+	}
+	ret := make([]*Receipt, 0, len(receipts))
+	for i, receipt := range receipts {
+		ret = append(ret, &Receipt{
+			transaction: (*txs)[i],
+			receipt:     receipt,
+		})
+	}
+	return &ret, nil
+}
+
 func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) (*Transaction, error) {
 	block, err := b.resolve(ctx)
 	if err != nil || block == nil {
@@ -794,6 +1181,73 @@ func (c *CallResult) Status() hexutil.Uint64 {
 	return c.status
 }
 
+// AccessTuple encapsulates an account and the storage slots of it accessed
+// by a call.
+type AccessTuple struct {
+	address     common.Address
+	storageKeys []common.Hash
+}
+
+func (at *AccessTuple) Address(ctx context.Context) common.Address {
+	return at.address
+}
+
+func (at *AccessTuple) StorageKeys(ctx context.Context) []common.Hash {
+	return at.storageKeys
+}
+
+// AccessListResult encapsulates the result of an invocation of the
+// `createAccessList` accessor.
+type AccessListResult struct {
+	accessList []*AccessTuple // The accounts and storage slots touched by the call
+	gasUsed    hexutil.Uint64 // The amount of gas used with the access list applied
+	err        *string        // The execution error of the call, if any
+}
+
+func (r *AccessListResult) AccessList() []*AccessTuple {
+	return r.accessList
+}
+
+func (r *AccessListResult) GasUsed() hexutil.Uint64 {
+	return r.gasUsed
+}
+
+func (r *AccessListResult) Error() *string {
+	return r.err
+}
+
+// createAccessList generates the access list of a call on top of the given
+// block's state.
+func createAccessList(ctx context.Context, backend ethapi.Backend, blockNrOrHash rpc.BlockNumberOrHash, args ethapi.CallArgs) (*AccessListResult, error) {
+	sendArgs := ethapi.SendTxArgs{
+		To:         args.To,
+		Gas:        args.Gas,
+		GasPrice:   args.GasPrice,
+		Value:      args.Value,
+		Data:       args.Data,
+		AccessList: args.AccessList,
+	}
+	if args.From != nil {
+		sendArgs.From = *args.From
+	}
+	acl, gasUsed, vmerr, err := ethapi.AccessList(ctx, backend, blockNrOrHash, sendArgs)
+	if err != nil {
+		return nil, err
+	}
+	result := &AccessListResult{
+		accessList: make([]*AccessTuple, 0, len(acl)),
+		gasUsed:    hexutil.Uint64(gasUsed),
+	}
+	for _, tuple := range acl {
+		result.accessList = append(result.accessList, &AccessTuple{address: tuple.Address, storageKeys: tuple.StorageKeys})
+	}
+	if vmerr != nil {
+		msg := vmerr.Error()
+		result.err = &msg
+	}
+	return result, nil
+}
+
 func (b *Block) Call(ctx context.Context, args struct {
 	Data ethapi.CallArgs
 }) (*CallResult, error) {
@@ -819,6 +1273,18 @@ func (b *Block) Call(ctx context.Context, args struct {
 	}, nil
 }
 
+func (b *Block) CreateAccessList(ctx context.Context, args struct {
+	Data ethapi.CallArgs
+}) (*AccessListResult, error) {
+	if b.numberOrHash == nil {
+		_, err := b.resolveHeader(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return createAccessList(ctx, b.backend, *b.numberOrHash, args.Data)
+}
+
 func (b *Block) EstimateGas(ctx context.Context, args struct {
 	Data ethapi.CallArgs
 }) (hexutil.Uint64, error) {
@@ -832,6 +1298,81 @@ func (b *Block) EstimateGas(ctx context.Context, args struct {
 	return gas, err
 }
 
+func (b *Block) Rewards(ctx context.Context) (*BlockRewards, error) {
+	if _, ok := b.backend.Engine().(*ethash.Ethash); !ok {
+		return nil, nil
+	}
+	block, err := b.resolve(ctx)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	minerReward, ommerRewards := ethash.GetRewards(b.backend.ChainConfig(), block.Header(), block.Uncles())
+	rewards := &BlockRewards{
+		backend:     b.backend,
+		miner:       block.Coinbase(),
+		minerReward: minerReward,
+		ommers:      make([]*OmmerReward, 0, len(ommerRewards)),
+	}
+	for i, uncle := range block.Uncles() {
+		blockNumberOrHash := rpc.BlockNumberOrHashWithHash(uncle.Hash(), false)
+		rewards.ommers = append(rewards.ommers, &OmmerReward{
+			ommer: &Block{
+				backend:      b.backend,
+				numberOrHash: &blockNumberOrHash,
+				header:       uncle,
+			},
+			reward: ommerRewards[i],
+		})
+	}
+	return rewards, nil
+}
+
+// BlockRewards represents the breakdown of the mining rewards of a block.
+type BlockRewards struct {
+	backend     ethapi.Backend
+	miner       common.Address
+	minerReward *big.Int
+	ommers      []*OmmerReward
+}
+
+func (r *BlockRewards) Miner(ctx context.Context, args BlockNumberArgs) *Account {
+	return &Account{
+		backend:       r.backend,
+		address:       r.miner,
+		blockNrOrHash: args.NumberOrLatest(),
+	}
+}
+
+func (r *BlockRewards) MinerReward(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*r.minerReward)
+}
+
+func (r *BlockRewards) Ommers(ctx context.Context) []*OmmerReward {
+	return r.ommers
+}
+
+// OmmerReward represents the reward of an ommer included in a block.
+type OmmerReward struct {
+	ommer  *Block
+	reward *big.Int
+}
+
+func (r *OmmerReward) Ommer(ctx context.Context) *Block {
+	return r.ommer
+}
+
+func (r *OmmerReward) Miner(ctx context.Context, args BlockNumberArgs) *Account {
+	return &Account{
+		backend:       r.ommer.backend,
+		address:       r.ommer.header.Coinbase,
+		blockNrOrHash: args.NumberOrLatest(),
+	}
+}
+
+func (r *OmmerReward) Reward(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*r.reward)
+}
+
 type Pending struct {
 	backend ethapi.Backend
 }
@@ -889,6 +1430,13 @@ func (p *Pending) Call(ctx context.Context, args struct {
 	}, nil
 }
 
+func (p *Pending) CreateAccessList(ctx context.Context, args struct {
+	Data ethapi.CallArgs
+}) (*AccessListResult, error) {
+	pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	return createAccessList(ctx, p.backend, pendingBlockNr, args.Data)
+}
+
 func (p *Pending) EstimateGas(ctx context.Context, args struct {
 	Data ethapi.CallArgs
 }) (hexutil.Uint64, error) {
@@ -1048,6 +1596,34 @@ func (r *Resolver) ChainID(ctx context.Context) (hexutil.Big, error) {
 	return hexutil.Big(*r.backend.ChainConfig().GetChainID()), nil
 }
 
+func (r *Resolver) ArtificialFinality(ctx context.Context) *ArtificialFinality {
+	return &ArtificialFinality{backend: r.backend}
+}
+
+// ArtificialFinality represents the status of the artificial finality features
+// returned from the `artificialFinality` accessor.
+type ArtificialFinality struct {
+	backend ethapi.Backend
+}
+
+func (f *ArtificialFinality) Enabled() bool {
+	return f.backend.IsArtificialFinalityEnabled()
+}
+
+func (f *ArtificialFinality) Ecbp1100Transition() *hexutil.Uint64 {
+	transition := f.backend.ChainConfig().GetECBP1100Transition()
+	if transition == nil {
+		return nil
+	}
+	ret := hexutil.Uint64(*transition)
+	return &ret
+}
+
+func (f *ArtificialFinality) Active() bool {
+	config := f.backend.ChainConfig()
+	return f.Enabled() && config.IsEnabled(config.GetECBP1100Transition, f.backend.CurrentHeader().Number)
+}
+
 // SyncState represents the synchronisation status returned from the `syncing` accessor.
 type SyncState struct {
 	progress ethereum.SyncProgress
@@ -17,14 +17,27 @@
 package graphql
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -132,3 +145,167 @@ func doHTTPRequest(t *testing.T, req *http.Request) *http.Response {
 	}
 	return resp
 }
+
+func TestGraphQLTracesRewardsAndState(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		miner   = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+		ommerer = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+		callee  = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+		caller  = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+	)
+	// The caller stores 0x2a in slot 1 and calls the callee
+	code := append([]byte{0x60, 0x2a, 0x60, 0x01, 0x55, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x73}, callee.Bytes()...)
+	code = append(code, 0x5a, 0xf1, 0x00)
+
+	config := params.AllEthashProtocolChanges
+	genesis := &genesisT.Genesis{
+		Config: config,
+		Alloc: genesisT.GenesisAlloc{
+			sender: {Balance: big.NewInt(1e18)},
+			callee: {Code: []byte{0x00}, Balance: common.Big0},
+			caller: {Code: code, Balance: common.Big0, Storage: map[common.Hash]common.Hash{
+				common.HexToHash("0x02"): common.HexToHash("0x07"),
+			}},
+		},
+	}
+	db, sidedb := rawdb.NewMemoryDatabase(), rawdb.NewMemoryDatabase()
+	gblock := core.MustCommitGenesis(db, genesis)
+	core.MustCommitGenesis(sidedb, genesis)
+	engine := ethash.NewFaker()
+
+	ommers, _ := core.GenerateChain(config, gblock, engine, sidedb, 1, func(i int, g *core.BlockGen) {
+		g.SetCoinbase(ommerer)
+	})
+	tx, _ := types.SignTx(types.NewTransaction(0, caller, common.Big0, 100000, common.Big1, nil), types.LatestSigner(config), key)
+	blocks, _ := core.GenerateChain(config, gblock, engine, db, 2, func(i int, g *core.BlockGen) {
+		g.SetCoinbase(miner)
+		switch i {
+		case 0:
+			g.AddTx(tx)
+		case 1:
+			g.AddUncle(ommers[0].Header())
+		}
+	})
+
+	stack, err := node.New(&node.Config{HTTPHost: "127.0.0.1", HTTPPort: 9394})
+	if err != nil {
+		t.Fatalf("could not create node: %v", err)
+	}
+	defer stack.Close()
+	ethConfig := &eth.Config{Genesis: genesis}
+	ethConfig.Ethash.PowMode = ethash.ModeFake
+	ethBackend, err := eth.New(stack, ethConfig)
+	if err != nil {
+		t.Fatalf("could not create eth backend: %v", err)
+	}
+	if err := New(stack, ethBackend.APIBackend, []string{}, []string{}); err != nil {
+		t.Fatalf("could not create graphql service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	if _, err := ethBackend.BlockChain().InsertChain(blocks); err != nil {
+		t.Fatalf("could not import blocks: %v", err)
+	}
+
+	query := fmt.Sprintf(`{
+		transaction(hash: "%s") { traces { type callType from { address } to { address } error traceAddress subtraces } }
+		block(number: 2) { rewards { miner { address } minerReward ommers { ommer { number } miner { address } reward } } }
+		state: block(number: 1) { account(address: "%s") { storageRange(limit: 1) { entries { value } nextKey } } }
+		artificialFinality { enabled ecbp1100Transition active }
+	}`, tx.Hash().Hex(), caller.Hex())
+	body, _ := json.Marshal(map[string]interface{}{"query": query})
+	req, err := http.NewRequest(http.MethodPost, "http://127.0.0.1:9394/graphql", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp := doHTTPRequest(t, req)
+	defer resp.Body.Close()
+
+	type account struct{ Address common.Address }
+	var result struct {
+		Errors []interface{}
+		Data   struct {
+			Transaction struct {
+				Traces []struct {
+					Type, CallType string
+					From, To       account
+					Error          *string
+					TraceAddress   []int
+					Subtraces      int
+				}
+			}
+			Block struct {
+				Rewards struct {
+					Miner       account
+					MinerReward hexutil.Big
+					Ommers      []struct {
+						Ommer  struct{ Number hexutil.Uint64 }
+						Miner  account
+						Reward hexutil.Big
+					}
+				}
+			}
+			State struct {
+				Account struct {
+					StorageRange struct {
+						Entries []struct{ Value common.Hash }
+						NextKey *common.Hash
+					}
+				}
+			}
+			ArtificialFinality struct {
+				Enabled            bool
+				Ecbp1100Transition *hexutil.Uint64
+				Active             bool
+			}
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("query failed: %v", result.Errors)
+	}
+
+	// The transaction calls the caller, which calls the callee
+	traces := result.Data.Transaction.Traces
+	if len(traces) != 2 {
+		t.Fatalf("trace count mismatch: have %d, want 2", len(traces))
+	}
+	if traces[0].Type != "call" || traces[0].CallType != "call" || traces[0].From.Address != sender ||
+		traces[0].To.Address != caller || traces[0].Error != nil || len(traces[0].TraceAddress) != 0 || traces[0].Subtraces != 1 {
+		t.Errorf("transaction trace mismatch: %+v", traces[0])
+	}
+	if traces[1].From.Address != caller || traces[1].To.Address != callee || !reflect.DeepEqual(traces[1].TraceAddress, []int{0}) {
+		t.Errorf("internal call trace mismatch: %+v", traces[1])
+	}
+
+	// The rewards match the ethash ones
+	minerReward, ommerRewards := ethash.GetRewards(config, blocks[1].Header(), blocks[1].Uncles())
+	rewards := result.Data.Block.Rewards
+	if rewards.Miner.Address != miner || rewards.MinerReward.ToInt().Cmp(minerReward) != 0 {
+		t.Errorf("miner reward mismatch: have %v to %x, want %v to %x", rewards.MinerReward.ToInt(), rewards.Miner.Address, minerReward, miner)
+	}
+	if len(rewards.Ommers) != 1 || rewards.Ommers[0].Ommer.Number != 1 || rewards.Ommers[0].Miner.Address != ommerer ||
+		rewards.Ommers[0].Reward.ToInt().Cmp(ommerRewards[0]) != 0 {
+		t.Errorf("ommer rewards mismatch: have %+v, want %v to %x", rewards.Ommers, ommerRewards[0], ommerer)
+	}
+
+	// The storage holds the genesis slot and the one set by the transaction
+	storage := result.Data.State.Account.StorageRange
+	if len(storage.Entries) != 1 || storage.NextKey == nil {
+		t.Fatalf("storage range mismatch: %+v", storage)
+	}
+	if value := storage.Entries[0].Value; value != common.HexToHash("0x2a") && value != common.HexToHash("0x07") {
+		t.Errorf("storage value mismatch: %x", value)
+	}
+
+	finality := result.Data.ArtificialFinality
+	if finality.Enabled || finality.Active || finality.Ecbp1100Transition != nil {
+		t.Errorf("artificial finality mismatch: %+v", finality)
+	}
+}
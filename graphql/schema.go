@@ -52,6 +52,30 @@ const schema string = `
         # Storage provides access to the storage of a contract account, indexed
         # by its 32 byte slot identifier.
         storage(slot: Bytes32!): Bytes32!
+        # StorageRange returns up to limit storage slots of a contract account in
+        # the order of their hashed slot identifiers, starting at the given hash.
+        # The limit is capped at 1024 slots.
+        storageRange(start: Bytes32, limit: Int!): StorageRange!
+    }
+
+    # StorageRange is a range of the storage slots of a contract account.
+    type StorageRange {
+        # Entries are the storage slots in the range.
+        entries: [StorageEntry!]!
+        # NextKey is the hashed slot identifier following the range. This will be
+        # null if the range reaches the end of the storage.
+        nextKey: Bytes32
+    }
+
+    # StorageEntry is a single storage slot of a contract account.
+    type StorageEntry {
+        # HashedKey is the keccak256 hash of the slot identifier.
+        hashedKey: Bytes32!
+        # Key is the slot identifier. This will be null if the node doesn't know
+        # the preimage of the hashed key.
+        key: Bytes32
+        # Value is the value stored in the slot.
+        value: Bytes32!
     }
 
     # Log is an Ethereum event log.
@@ -115,11 +139,73 @@ const schema string = `
         # Logs is a list of log entries emitted by this transaction. If the
         # transaction has not yet been mined, this field will be null.
         logs: [Log!]
+        # Traces is the list of calls made by this transaction, starting with the
+        # transaction itself, in depth-first order. This requires the state of
+        # the parent block. If the transaction has not yet been mined, this field
+        # will be null.
+        traces: [CallTrace!]
         r: BigInt!
         s: BigInt!
         v: BigInt!
     }
 
+    # Receipt is the outcome of a transaction included in a block.
+    type Receipt {
+        # Transaction is the transaction this receipt belongs to.
+        transaction: Transaction!
+        # Status is the return status of the transaction, 1 if it succeeded and
+        # 0 if it failed. This will be null for receipts holding a state root.
+        status: Long
+        # Root is the state root after the transaction, held by receipts from
+        # before the Byzantium fork instead of a status.
+        root: Bytes32
+        # GasUsed is the amount of gas that was used processing the transaction.
+        gasUsed: Long!
+        # CumulativeGasUsed is the total gas used in the block up to and including
+        # the transaction.
+        cumulativeGasUsed: Long!
+        # CreatedContract is the account that was created by a contract creation
+        # transaction. If the transaction was not a contract creation transaction,
+        # this field will be null.
+        createdContract(block: Long): Account
+        # Logs is a list of log entries emitted by the transaction.
+        logs: [Log!]!
+        # LogsBloom is a bloom filter of the logs emitted by the transaction.
+        logsBloom: Bytes!
+    }
+
+    # CallTrace is a call made by a transaction, either the transaction itself or
+    # an internal one, as reported by the callTracerParity tracer.
+    type CallTrace {
+        # Type is the kind of the call: call, create or suicide.
+        type: String!
+        # CallType is the opcode making the call for calls (call, callcode,
+        # delegatecall or staticcall) and creations (create or create2).
+        callType: String
+        # From is the account making the call, or self destructing.
+        from(block: Long): Account
+        # To is the account called, created, or receiving the balance of a self
+        # destructing account.
+        to(block: Long): Account
+        # Value is the value transferred by the call, in wei.
+        value: BigInt
+        # Gas is the amount of gas made available to the call.
+        gas: Long
+        # GasUsed is the amount of gas used by the call. This will be null if the
+        # call failed.
+        gasUsed: Long
+        # Input is the data supplied to the call, or the init code of creations.
+        input: Bytes
+        # Output is the data returned by the call, or the code of created contracts.
+        output: Bytes
+        # Error is the reason the call failed. This will be null if it succeeded.
+        error: String
+        # TraceAddress is the path of the call in the call tree of the transaction.
+        traceAddress: [Int!]!
+        # Subtraces is the number of calls made directly by this call.
+        subtraces: Int!
+    }
+
     # BlockFilterCriteria encapsulates log filter criteria for a filter applied
     # to a single block.
     input BlockFilterCriteria {
@@ -200,6 +286,9 @@ const schema string = `
         # transactions are unavailable for this block, or if the index is out of
         # bounds, this field will be null.
         transactionAt(index: Int!): Transaction
+        # Receipts is the list of receipts of the transactions in this block. If
+        # transactions are unavailable for this block, this field will be null.
+        receipts: [Receipt!]
         # Logs returns a filtered set of logs from this block.
         logs(filter: BlockFilterCriteria!): [Log!]!
         # Account fetches an Ethereum account at the current block's state.
@@ -209,6 +298,34 @@ const schema string = `
         # EstimateGas estimates the amount of gas that will be required for
         # successful execution of a transaction at the current block's state.
         estimateGas(data: CallData!): Long!
+        # CreateAccessList generates the EIP-2930 access list of the accounts
+        # and storage slots touched by a call at the current block's state.
+        createAccessList(data: CallData!): AccessListResult
+        # Rewards is the breakdown of the mining rewards of this block. This will
+        # be null if the chain doesn't use ethash consensus.
+        rewards: BlockRewards
+    }
+
+    # BlockRewards is the breakdown of the mining rewards of a block.
+    type BlockRewards {
+        # Miner is the account receiving the block reward.
+        miner(block: Long): Account!
+        # MinerReward is the reward of the miner, including the rewards for the
+        # included ommers, in wei. Transaction fees are not included.
+        minerReward: BigInt!
+        # Ommers is the list of rewards of the ommers (AKA uncles) included in
+        # the block.
+        ommers: [OmmerReward!]!
+    }
+
+    # OmmerReward is the reward of an ommer (AKA uncle) included in a block.
+    type OmmerReward {
+        # Ommer is the ommer block.
+        ommer: Block!
+        # Miner is the account receiving the ommer reward.
+        miner(block: Long): Account!
+        # Reward is the reward of the ommer miner, in wei.
+        reward: BigInt!
     }
 
     # CallData represents the data associated with a local contract call.
@@ -238,6 +355,24 @@ const schema string = `
         status: Long!
     }
 
+    # AccessTuple is an account and the storage slots of it accessed by a call.
+    type AccessTuple {
+        # Address is the address of the accessed account.
+        address: Address!
+        # StorageKeys are the accessed storage slots of the account.
+        storageKeys: [Bytes32!]!
+    }
+
+    # AccessListResult is the result of an access list creation.
+    type AccessListResult {
+        # AccessList is the list of accounts and storage slots touched by the call.
+        accessList: [AccessTuple!]!
+        # GasUsed is the amount of gas used by the call with the access list applied.
+        gasUsed: Long!
+        # Error is the execution error of the call with the access list applied, if any.
+        error: String
+    }
+
     # FilterCriteria encapsulates log filter criteria for searching log entries.
     input FilterCriteria {
         # FromBlock is the block at which to start searching, inclusive. Defaults
@@ -292,6 +427,9 @@ const schema string = `
       # EstimateGas estimates the amount of gas that will be required for
       # successful execution of a transaction for the pending state.
       estimateGas(data: CallData!): Long!
+      # CreateAccessList generates the EIP-2930 access list of the accounts
+      # and storage slots touched by a call for the pending state.
+      createAccessList(data: CallData!): AccessListResult
     }
 
     type Query {
@@ -316,6 +454,23 @@ const schema string = `
         syncing: SyncState
         # ChainID returns the current chain ID for transaction replay protection.
         chainID: BigInt!
+        # ArtificialFinality returns the status of the artificial finality
+        # features of the node.
+        artificialFinality: ArtificialFinality!
+    }
+
+    # ArtificialFinality is the status of the artificial finality features of
+    # the node, currently ECBP1100 (MESS).
+    type ArtificialFinality {
+        # Enabled is whether the node has enabled artificial finality, which it
+        # does once synced with enough peers.
+        enabled: Boolean!
+        # ECBP1100Transition is the block number from which the chain
+        # configuration activates ECBP1100. This will be null if it never does.
+        ecbp1100Transition: Long
+        # Active is whether artificial finality is enabled and activated at the
+        # current head block.
+        active: Boolean!
     }
 
     type Mutation {
@@ -0,0 +1,247 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/tracing"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks simulated by a request.
+	maxSimulateBlocks = 256
+
+	// simulateTimeout bounds the execution of all the calls of a request.
+	simulateTimeout = 5 * time.Second
+)
+
+// blockOverrides indicates the block context fields to override during the
+// execution of simulated calls.
+type blockOverrides struct {
+	Number     *hexutil.Big    `json:"number"`
+	Difficulty *hexutil.Big    `json:"difficulty"`
+	Time       *hexutil.Uint64 `json:"timestamp"`
+	GasLimit   *hexutil.Uint64 `json:"gasLimit"`
+	Coinbase   *common.Address `json:"coinbase"`
+}
+
+// simBlock is a synthetic block of calls to simulate, optionally overriding
+// its block context and the state it starts from.
+type simBlock struct {
+	BlockOverrides *blockOverrides             `json:"blockOverrides"`
+	StateOverrides *map[common.Address]account `json:"stateOverrides"`
+	Calls          []CallArgs                  `json:"calls"`
+}
+
+// simBlockResult is the outcome of a simulated block.
+type simBlockResult struct {
+	Number    *hexutil.Big     `json:"number"`
+	Timestamp hexutil.Uint64   `json:"timestamp"`
+	GasUsed   hexutil.Uint64   `json:"gasUsed"`
+	Calls     []*simCallResult `json:"calls"`
+}
+
+// simCallResult is the outcome of a simulated call. It contains an error if the
+// call failed, and the hex encoded revert data if it reverted.
+type simCallResult struct {
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	Logs       []*types.Log   `json:"logs"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Error      string         `json:"error,omitempty"`
+	RevertData string         `json:"revertData,omitempty"`
+}
+
+// CallBundle executes the given calls in order on top of the state of the
+// given block, each call seeing the state changes of the previous ones. The
+// calls run in a successor block of the given one, whose context may be
+// overridden, and the state they start from may be overridden too.
+//
+// Note, this function doesn't make any changes in the state/blockchain.
+func (s *PublicBlockChainAPI) CallBundle(ctx context.Context, calls []CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *map[common.Address]account, blockOverrides *blockOverrides) ([]*simCallResult, error) {
+	results, err := DoSimulate(ctx, s.b, []simBlock{{BlockOverrides: blockOverrides, StateOverrides: overrides, Calls: calls}}, blockNrOrHash, s.b.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
+	return results[0].Calls, nil
+}
+
+// SimulateBlocks executes the calls of a sequence of synthetic blocks built on
+// top of the given block. Each block defaults to the successor of the previous
+// one, one second later, and may override its context and state. The calls see
+// the state changes of all the calls before them.
+//
+// Note, this function doesn't make any changes in the state/blockchain.
+func (s *PublicBlockChainAPI) SimulateBlocks(ctx context.Context, blocks []simBlock, blockNrOrHash rpc.BlockNumberOrHash) ([]*simBlockResult, error) {
+	return DoSimulate(ctx, s.b, blocks, blockNrOrHash, s.b.RPCGasCap())
+}
+
+// DoSimulate executes the calls of the given synthetic blocks in order on top of
+// the state of the given block.
+func DoSimulate(ctx context.Context, b Backend, blocks []simBlock, blockNrOrHash rpc.BlockNumberOrHash, globalGasCap uint64) ([]*simBlockResult, error) {
+	if len(blocks) == 0 {
+		return nil, errors.New("no blocks to simulate")
+	}
+	if len(blocks) > maxSimulateBlocks {
+		return nil, fmt.Errorf("too many blocks to simulate, have %d, max %d", len(blocks), maxSimulateBlocks)
+	}
+	ctx, span := tracing.StartSpan(ctx, "ethapi.DoSimulate")
+	defer span.End()
+
+	state, base, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	span.SetAttributes(tracing.Uint64("block.number", base.Number.Uint64()))
+
+	ctx, cancel := context.WithTimeout(ctx, simulateTimeout)
+	defer cancel()
+
+	var (
+		parent  = base
+		hashes  = make(map[uint64]common.Hash) // Hashes of the simulated blocks
+		results = make([]*simBlockResult, 0, len(blocks))
+	)
+	getHash := func(n uint64) common.Hash {
+		if hash, ok := hashes[n]; ok {
+			return hash
+		}
+		if n == base.Number.Uint64() {
+			return base.Hash()
+		}
+		if n > base.Number.Uint64() {
+			return common.Hash{}
+		}
+		header, _ := b.HeaderByNumber(ctx, rpc.BlockNumber(n))
+		if header == nil {
+			return common.Hash{}
+		}
+		return header.Hash()
+	}
+	for i, block := range blocks {
+		header, err := simHeader(parent, block.BlockOverrides)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", i, err)
+		}
+		if block.StateOverrides != nil {
+			if err := applyStateOverrides(state, *block.StateOverrides); err != nil {
+				return nil, fmt.Errorf("block %d: %v", i, err)
+			}
+		}
+		blockCtx := func(c *vm.Context) {
+			c.Coinbase = header.Coinbase
+			c.GetHash = getHash
+		}
+		result := &simBlockResult{
+			Number:    (*hexutil.Big)(header.Number),
+			Timestamp: hexutil.Uint64(header.Time),
+			Calls:     make([]*simCallResult, 0, len(block.Calls)),
+		}
+		for j, args := range block.Calls {
+			call, err := simCall(ctx, b, state, header, blockCtx, args, j, globalGasCap)
+			if err != nil {
+				return nil, fmt.Errorf("block %d, call %d: %v", i, j, err)
+			}
+			result.GasUsed += call.GasUsed
+			result.Calls = append(result.Calls, call)
+		}
+		hashes[header.Number.Uint64()] = header.Hash()
+		results = append(results, result)
+		parent = header
+	}
+	return results, nil
+}
+
+// simHeader creates the header of a simulated block following parent, with the
+// given fields overridden.
+func simHeader(parent *types.Header, overrides *blockOverrides) (*types.Header, error) {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   parent.Coinbase,
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 1,
+	}
+	if overrides == nil {
+		return header, nil
+	}
+	if overrides.Number != nil {
+		if overrides.Number.ToInt().Cmp(parent.Number) <= 0 {
+			return nil, fmt.Errorf("block number %v not above parent number %v", overrides.Number.ToInt(), parent.Number)
+		}
+		header.Number = new(big.Int).Set(overrides.Number.ToInt())
+	}
+	if overrides.Difficulty != nil {
+		header.Difficulty = new(big.Int).Set(overrides.Difficulty.ToInt())
+	}
+	if overrides.Time != nil {
+		header.Time = uint64(*overrides.Time)
+	}
+	if overrides.GasLimit != nil {
+		header.GasLimit = uint64(*overrides.GasLimit)
+	}
+	if overrides.Coinbase != nil {
+		header.Coinbase = *overrides.Coinbase
+	}
+	return header, nil
+}
+
+// simCall executes a simulated call on top of state, keeping its state changes
+// for the following calls.
+func simCall(ctx context.Context, b Backend, state *state.StateDB, header *types.Header, blockCtx func(*vm.Context), args CallArgs, index int, globalGasCap uint64) (*simCallResult, error) {
+	// The calls aren't transactions, collect their logs under the zero hash
+	logged := len(state.GetLogs(common.Hash{}))
+	state.Prepare(common.Hash{}, common.Hash{}, index)
+
+	result, err := applyMessage(ctx, b, state, header, blockCtx, args.ToMessage(globalGasCap), simulateTimeout)
+	if err != nil {
+		return nil, err
+	}
+	config := b.ChainConfig()
+	state.Finalise(config.IsEnabled(config.GetEIP161dTransition, header.Number))
+
+	call := &simCallResult{
+		ReturnData: result.Return(),
+		Logs:       state.GetLogs(common.Hash{})[logged:],
+		GasUsed:    hexutil.Uint64(result.UsedGas),
+	}
+	if call.ReturnData == nil {
+		call.ReturnData = []byte{}
+	}
+	if call.Logs == nil {
+		call.Logs = []*types.Log{}
+	}
+	if len(result.Revert()) > 0 {
+		revert := newRevertError(result)
+		call.Error = revert.Error()
+		call.RevertData = revert.reason
+	} else if result.Err != nil {
+		call.Error = result.Err.Error()
+	}
+	return call, nil
+}
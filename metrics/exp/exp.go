@@ -163,24 +163,33 @@ func (exp *exp) publishResettingTimer(name string, metric metrics.ResettingTimer
 }
 
 func (exp *exp) syncToExpvar() {
-	exp.registry.Each(func(name string, i interface{}) {
-		switch i := i.(type) {
-		case metrics.Counter:
-			exp.publishCounter(name, i)
-		case metrics.Gauge:
-			exp.publishGauge(name, i)
-		case metrics.GaugeFloat64:
-			exp.publishGaugeFloat64(name, i)
-		case metrics.Histogram:
-			exp.publishHistogram(name, i)
-		case metrics.Meter:
-			exp.publishMeter(name, i)
-		case metrics.Timer:
-			exp.publishTimer(name, i)
-		case metrics.ResettingTimer:
-			exp.publishResettingTimer(name, i)
-		default:
-			panic(fmt.Sprintf("unsupported type for '%s': %T", name, i))
-		}
-	})
+	exp.registry.Each(exp.publish)
+}
+
+// publish loads a single metric into expvar, flattening labeled vectors into one
+// variable per series.
+func (exp *exp) publish(name string, i interface{}) {
+	switch i := i.(type) {
+	case metrics.Counter:
+		exp.publishCounter(name, i)
+	case metrics.Gauge:
+		exp.publishGauge(name, i)
+	case metrics.GaugeFloat64:
+		exp.publishGaugeFloat64(name, i)
+	case metrics.Histogram:
+		exp.publishHistogram(name, i)
+	case metrics.Meter:
+		exp.publishMeter(name, i)
+	case metrics.Timer:
+		exp.publishTimer(name, i)
+	case metrics.ResettingTimer:
+		exp.publishResettingTimer(name, i)
+	case metrics.Vector:
+		labels := i.LabelNames()
+		i.Each(func(values []string, metric interface{}) {
+			exp.publish(metrics.SeriesName(name, labels, values), metric)
+		})
+	default:
+		panic(fmt.Sprintf("unsupported type for '%s': %T", name, i))
+	}
 }
@@ -63,7 +63,8 @@ func graphite(c *GraphiteConfig) error {
 	}
 	defer conn.Close()
 	w := bufio.NewWriter(conn)
-	c.Registry.Each(func(name string, i interface{}) {
+	var report func(name string, i interface{})
+	report = func(name string, i interface{}) {
 		switch metric := i.(type) {
 		case Counter:
 			fmt.Fprintf(w, "%s.%s.count %d %d\n", c.Prefix, name, metric.Count(), now)
@@ -106,8 +107,14 @@ func graphite(c *GraphiteConfig) error {
 			fmt.Fprintf(w, "%s.%s.five-minute %.2f %d\n", c.Prefix, name, t.Rate5(), now)
 			fmt.Fprintf(w, "%s.%s.fifteen-minute %.2f %d\n", c.Prefix, name, t.Rate15(), now)
 			fmt.Fprintf(w, "%s.%s.mean-rate %.2f %d\n", c.Prefix, name, t.RateMean(), now)
+		case Vector:
+			labels := metric.LabelNames()
+			metric.Each(func(values []string, m interface{}) {
+				report(SeriesName(name, labels, values), m)
+			})
 		}
 		w.Flush()
-	})
+	}
+	c.Registry.Each(report)
 	return nil
 }
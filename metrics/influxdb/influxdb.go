@@ -125,125 +125,22 @@ func (r *reporter) send() error {
 
 	r.reg.Each(func(name string, i interface{}) {
 		now := time.Now()
-		namespace := r.namespace
 
-		switch metric := i.(type) {
-		case metrics.Counter:
-			v := metric.Count()
-			l := r.cache[name]
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s%s.count", namespace, name),
-				Tags:        r.tags,
-				Fields: map[string]interface{}{
-					"value": v - l,
-				},
-				Time: now,
-			})
-			r.cache[name] = v
-		case metrics.Gauge:
-			ms := metric.Snapshot()
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s%s.gauge", namespace, name),
-				Tags:        r.tags,
-				Fields: map[string]interface{}{
-					"value": ms.Value(),
-				},
-				Time: now,
-			})
-		case metrics.GaugeFloat64:
-			ms := metric.Snapshot()
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s%s.gauge", namespace, name),
-				Tags:        r.tags,
-				Fields: map[string]interface{}{
-					"value": ms.Value(),
-				},
-				Time: now,
-			})
-		case metrics.Histogram:
-			ms := metric.Snapshot()
-			ps := ms.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999})
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s%s.histogram", namespace, name),
-				Tags:        r.tags,
-				Fields: map[string]interface{}{
-					"count":    ms.Count(),
-					"max":      ms.Max(),
-					"mean":     ms.Mean(),
-					"min":      ms.Min(),
-					"stddev":   ms.StdDev(),
-					"variance": ms.Variance(),
-					"p50":      ps[0],
-					"p75":      ps[1],
-					"p95":      ps[2],
-					"p99":      ps[3],
-					"p999":     ps[4],
-					"p9999":    ps[5],
-				},
-				Time: now,
-			})
-		case metrics.Meter:
-			ms := metric.Snapshot()
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s%s.meter", namespace, name),
-				Tags:        r.tags,
-				Fields: map[string]interface{}{
-					"count": ms.Count(),
-					"m1":    ms.Rate1(),
-					"m5":    ms.Rate5(),
-					"m15":   ms.Rate15(),
-					"mean":  ms.RateMean(),
-				},
-				Time: now,
-			})
-		case metrics.Timer:
-			ms := metric.Snapshot()
-			ps := ms.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999})
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s%s.timer", namespace, name),
-				Tags:        r.tags,
-				Fields: map[string]interface{}{
-					"count":    ms.Count(),
-					"max":      ms.Max(),
-					"mean":     ms.Mean(),
-					"min":      ms.Min(),
-					"stddev":   ms.StdDev(),
-					"variance": ms.Variance(),
-					"p50":      ps[0],
-					"p75":      ps[1],
-					"p95":      ps[2],
-					"p99":      ps[3],
-					"p999":     ps[4],
-					"p9999":    ps[5],
-					"m1":       ms.Rate1(),
-					"m5":       ms.Rate5(),
-					"m15":      ms.Rate15(),
-					"meanrate": ms.RateMean(),
-				},
-				Time: now,
+		if v, ok := i.(metrics.Vector); ok {
+			labels := v.LabelNames()
+			v.Each(func(values []string, metric interface{}) {
+				tags := make(map[string]string, len(r.tags)+len(labels))
+				for k, tag := range r.tags {
+					tags[k] = tag
+				}
+				for i, label := range labels {
+					tags[label] = values[i]
+				}
+				pts = append(pts, r.points(name, metrics.SeriesName(name, labels, values), tags, metric, now)...)
 			})
-		case metrics.ResettingTimer:
-			t := metric.Snapshot()
-
-			if len(t.Values()) > 0 {
-				ps := t.Percentiles([]float64{50, 95, 99})
-				val := t.Values()
-				pts = append(pts, client.Point{
-					Measurement: fmt.Sprintf("%s%s.span", namespace, name),
-					Tags:        r.tags,
-					Fields: map[string]interface{}{
-						"count": len(val),
-						"max":   val[len(val)-1],
-						"mean":  t.Mean(),
-						"min":   val[0],
-						"p50":   ps[0],
-						"p95":   ps[1],
-						"p99":   ps[2],
-					},
-					Time: now,
-				})
-			}
+			return
 		}
+		pts = append(pts, r.points(name, name, r.tags, i, now)...)
 	})
 
 	bps := client.BatchPoints{
@@ -254,3 +151,128 @@ func (r *reporter) send() error {
 	_, err := r.client.Write(bps)
 	return err
 }
+
+// points converts a single metric into InfluxDB points. The key identifies the
+// series in the counter cache, tags are attached to every point.
+func (r *reporter) points(name, key string, tags map[string]string, i interface{}, now time.Time) []client.Point {
+	namespace := r.namespace
+
+	switch metric := i.(type) {
+	case metrics.Counter:
+		v := metric.Count()
+		l := r.cache[key]
+		r.cache[key] = v
+		return []client.Point{{
+			Measurement: fmt.Sprintf("%s%s.count", namespace, name),
+			Tags:        tags,
+			Fields: map[string]interface{}{
+				"value": v - l,
+			},
+			Time: now,
+		}}
+	case metrics.Gauge:
+		ms := metric.Snapshot()
+		return []client.Point{{
+			Measurement: fmt.Sprintf("%s%s.gauge", namespace, name),
+			Tags:        tags,
+			Fields: map[string]interface{}{
+				"value": ms.Value(),
+			},
+			Time: now,
+		}}
+	case metrics.GaugeFloat64:
+		ms := metric.Snapshot()
+		return []client.Point{{
+			Measurement: fmt.Sprintf("%s%s.gauge", namespace, name),
+			Tags:        tags,
+			Fields: map[string]interface{}{
+				"value": ms.Value(),
+			},
+			Time: now,
+		}}
+	case metrics.Histogram:
+		ms := metric.Snapshot()
+		ps := ms.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999})
+		return []client.Point{{
+			Measurement: fmt.Sprintf("%s%s.histogram", namespace, name),
+			Tags:        tags,
+			Fields: map[string]interface{}{
+				"count":    ms.Count(),
+				"max":      ms.Max(),
+				"mean":     ms.Mean(),
+				"min":      ms.Min(),
+				"stddev":   ms.StdDev(),
+				"variance": ms.Variance(),
+				"p50":      ps[0],
+				"p75":      ps[1],
+				"p95":      ps[2],
+				"p99":      ps[3],
+				"p999":     ps[4],
+				"p9999":    ps[5],
+			},
+			Time: now,
+		}}
+	case metrics.Meter:
+		ms := metric.Snapshot()
+		return []client.Point{{
+			Measurement: fmt.Sprintf("%s%s.meter", namespace, name),
+			Tags:        tags,
+			Fields: map[string]interface{}{
+				"count": ms.Count(),
+				"m1":    ms.Rate1(),
+				"m5":    ms.Rate5(),
+				"m15":   ms.Rate15(),
+				"mean":  ms.RateMean(),
+			},
+			Time: now,
+		}}
+	case metrics.Timer:
+		ms := metric.Snapshot()
+		ps := ms.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999})
+		return []client.Point{{
+			Measurement: fmt.Sprintf("%s%s.timer", namespace, name),
+			Tags:        tags,
+			Fields: map[string]interface{}{
+				"count":    ms.Count(),
+				"max":      ms.Max(),
+				"mean":     ms.Mean(),
+				"min":      ms.Min(),
+				"stddev":   ms.StdDev(),
+				"variance": ms.Variance(),
+				"p50":      ps[0],
+				"p75":      ps[1],
+				"p95":      ps[2],
+				"p99":      ps[3],
+				"p999":     ps[4],
+				"p9999":    ps[5],
+				"m1":       ms.Rate1(),
+				"m5":       ms.Rate5(),
+				"m15":      ms.Rate15(),
+				"meanrate": ms.RateMean(),
+			},
+			Time: now,
+		}}
+	case metrics.ResettingTimer:
+		t := metric.Snapshot()
+
+		if len(t.Values()) > 0 {
+			ps := t.Percentiles([]float64{50, 95, 99})
+			val := t.Values()
+			return []client.Point{{
+				Measurement: fmt.Sprintf("%s%s.span", namespace, name),
+				Tags:        tags,
+				Fields: map[string]interface{}{
+					"count": len(val),
+					"max":   val[len(val)-1],
+					"mean":  t.Mean(),
+					"min":   val[0],
+					"p50":   ps[0],
+					"p95":   ps[1],
+					"p99":   ps[2],
+				},
+				Time: now,
+			}}
+		}
+	}
+	return nil
+}
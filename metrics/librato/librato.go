@@ -89,10 +89,8 @@ func (rep *Reporter) BuildRequest(now time.Time, r metrics.Registry) (snapshot B
 	snapshot.Gauges = make([]Measurement, 0)
 	snapshot.Counters = make([]Measurement, 0)
 	histogramGaugeCount := 1 + len(rep.Percentiles)
-	r.Each(func(name string, metric interface{}) {
-		if rep.Namespace != "" {
-			name = fmt.Sprintf("%s.%s", rep.Namespace, name)
-		}
+	var measure func(name string, metric interface{})
+	measure = func(name string, metric interface{}) {
 		measurement := Measurement{}
 		measurement[Period] = rep.Interval.Seconds()
 		switch m := metric.(type) {
@@ -230,7 +228,18 @@ func (rep *Reporter) BuildRequest(now time.Time, r metrics.Registry) (snapshot B
 					},
 				)
 			}
+		case metrics.Vector:
+			labels := m.LabelNames()
+			m.Each(func(values []string, metric interface{}) {
+				measure(metrics.SeriesName(name, labels, values), metric)
+			})
+		}
+	}
+	r.Each(func(name string, metric interface{}) {
+		if rep.Namespace != "" {
+			name = fmt.Sprintf("%s.%s", rep.Namespace, name)
 		}
+		measure(name, metric)
 	})
 	return
 }
@@ -67,7 +67,8 @@ func openTSDB(c *OpenTSDBConfig) error {
 	}
 	defer conn.Close()
 	w := bufio.NewWriter(conn)
-	c.Registry.Each(func(name string, i interface{}) {
+	var report func(name string, i interface{})
+	report = func(name string, i interface{}) {
 		switch metric := i.(type) {
 		case Counter:
 			fmt.Fprintf(w, "put %s.%s.count %d %d host=%s\n", c.Prefix, name, now, metric.Count(), shortHostname)
@@ -112,8 +113,14 @@ func openTSDB(c *OpenTSDBConfig) error {
 			fmt.Fprintf(w, "put %s.%s.five-minute %d %.2f host=%s\n", c.Prefix, name, now, t.Rate5(), shortHostname)
 			fmt.Fprintf(w, "put %s.%s.fifteen-minute %d %.2f host=%s\n", c.Prefix, name, now, t.Rate15(), shortHostname)
 			fmt.Fprintf(w, "put %s.%s.mean-rate %d %.2f host=%s\n", c.Prefix, name, now, t.RateMean(), shortHostname)
+		case Vector:
+			labels := metric.LabelNames()
+			metric.Each(func(values []string, m interface{}) {
+				report(SeriesName(name, labels, values), m)
+			})
 		}
 		w.Flush()
-	})
+	}
+	c.Registry.Each(report)
 	return nil
 }
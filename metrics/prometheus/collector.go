@@ -31,6 +31,7 @@ var (
 	typeSummaryTpl         = "# TYPE %s summary\n"
 	keyValueTpl            = "%s %v\n\n"
 	keyQuantileTagValueTpl = "%s {quantile=\"%s\"} %v\n"
+	keyLabelsValueTpl      = "%s{%s} %v\n"
 )
 
 // collector is a collection of byte buffers that aggregate Prometheus reports
@@ -98,6 +99,65 @@ func (c *collector) addResettingTimer(name string, m metrics.ResettingTimer) {
 	c.buff.WriteRune('\n')
 }
 
+// addVector reports every series of a labeled metric vector as a single metric
+// family, with the vector's label values as Prometheus labels.
+func (c *collector) addVector(name string, v metrics.Vector) {
+	var (
+		key    = mutateKey(name)
+		labels = v.LabelNames()
+		typed  bool
+	)
+	writeType := func(tpl string) {
+		if !typed {
+			c.buff.WriteString(fmt.Sprintf(tpl, key))
+			typed = true
+		}
+	}
+	v.Each(func(values []string, i interface{}) {
+		pairs := labelPairs(labels, values)
+		switch m := i.(type) {
+		case metrics.Counter:
+			writeType(typeCounterTpl)
+			c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, key, pairs, m.Count()))
+		case metrics.Gauge:
+			writeType(typeGaugeTpl)
+			c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, key, pairs, m.Value()))
+		case metrics.GaugeFloat64:
+			writeType(typeGaugeTpl)
+			c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, key, pairs, m.Value()))
+		case metrics.Meter:
+			writeType(typeCounterTpl)
+			c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, key, pairs, m.Count()))
+		case metrics.Histogram:
+			writeType(typeSummaryTpl)
+			h := m.Snapshot()
+			c.writeLabeledSummary(key, pairs, h.Count(), h.Percentiles)
+		case metrics.Timer:
+			writeType(typeSummaryTpl)
+			t := m.Snapshot()
+			c.writeLabeledSummary(key, pairs, t.Count(), t.Percentiles)
+		}
+	})
+	if typed {
+		c.buff.WriteRune('\n')
+	}
+}
+
+// writeLabeledSummary writes the quantiles and the count of a single labeled
+// summary series.
+func (c *collector) writeLabeledSummary(key, pairs string, count int64, percentiles func([]float64) []float64) {
+	pv := []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
+	ps := percentiles(pv)
+	for i := range pv {
+		quantile := fmt.Sprintf("quantile=\"%s\"", strconv.FormatFloat(pv[i], 'f', -1, 64))
+		if pairs != "" {
+			quantile = pairs + "," + quantile
+		}
+		c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, key, quantile, ps[i]))
+	}
+	c.buff.WriteString(fmt.Sprintf(keyLabelsValueTpl, key+"_count", pairs, count))
+}
+
 func (c *collector) writeGaugeCounter(name string, value interface{}) {
 	name = mutateKey(name)
 	c.buff.WriteString(fmt.Sprintf(typeGaugeTpl, name))
@@ -118,3 +178,15 @@ func (c *collector) writeSummaryPercentile(name, p string, value interface{}) {
 func mutateKey(key string) string {
 	return strings.Replace(key, "/", "_", -1)
 }
+
+// labelPairs formats label names and values in the Prometheus exposition format.
+func labelPairs(labels, values []string) string {
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		pairs[i] = fmt.Sprintf("%s=\"%s\"", label, labelEscaper.Replace(values[i]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// labelEscaper escapes label values as required by the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
@@ -108,3 +108,45 @@ test_resetting_timer {quantile="0.99"} 120000000
 		t.Fatal("unexpected collector output")
 	}
 }
+
+func TestCollectorVector(t *testing.T) {
+	c := newCollector()
+
+	counters := metrics.NewCounterVec([]string{"method", "result"})
+	counters.With("eth_call", "success").Inc(3)
+	counters.With("eth_call", "failure").Inc(1)
+	c.addVector("test/counter_vec", counters)
+
+	gauges := metrics.NewGaugeVec([]string{"table"})
+	gauges.With(`weird"name`).Update(7)
+	c.addVector("test/gauge_vec", gauges)
+
+	timers := metrics.NewTimerVec([]string{"kind"})
+	defer timers.(metrics.Stoppable).Stop()
+	timers.With("headers").Update(20 * time.Millisecond)
+	c.addVector("test/timer_vec", timers)
+
+	const expectedOutput = `# TYPE test_counter_vec counter
+test_counter_vec{method="eth_call",result="failure"} 1
+test_counter_vec{method="eth_call",result="success"} 3
+
+# TYPE test_gauge_vec gauge
+test_gauge_vec{table="weird\"name"} 7
+
+# TYPE test_timer_vec summary
+test_timer_vec{kind="headers",quantile="0.5"} 2e+07
+test_timer_vec{kind="headers",quantile="0.75"} 2e+07
+test_timer_vec{kind="headers",quantile="0.95"} 2e+07
+test_timer_vec{kind="headers",quantile="0.99"} 2e+07
+test_timer_vec{kind="headers",quantile="0.999"} 2e+07
+test_timer_vec{kind="headers",quantile="0.9999"} 2e+07
+test_timer_vec_count{kind="headers"} 1
+
+`
+	exp := c.buff.String()
+	if exp != expectedOutput {
+		t.Log("Expected Output:\n", expectedOutput)
+		t.Log("Actual Output:\n", exp)
+		t.Fatal("unexpected collector output")
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MaxVectorSeries is the maximum number of distinct label value combinations
+// tracked by a single vector. Once reached, observations for any new combination
+// are folded into a single overflow series with every label set to
+// OverflowLabelValue, keeping the number of exported series bounded.
+var MaxVectorSeries = 256
+
+// OverflowLabelValue is the label value of the overflow series of a vector which
+// exceeded MaxVectorSeries.
+const OverflowLabelValue = "other"
+
+// labelSeparator joins label values into series keys. It can't appear in valid
+// UTF-8, so distinct value tuples never collide.
+const labelSeparator = "\xff"
+
+// Vector is a family of metrics of the same type, partitioned by the values of
+// a fixed set of labels.
+type Vector interface {
+	// LabelNames returns the names of the labels partitioning the vector.
+	LabelNames() []string
+
+	// Each calls the given function for every series of the vector, ordered
+	// by label values.
+	Each(func(values []string, metric interface{}))
+}
+
+// SeriesName returns the flat name of a single series of a vector, in the form
+// name{label="value",...}, for reporters without native label support.
+func SeriesName(name string, labels, values []string) string {
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		pairs[i] = fmt.Sprintf("%s=%q", label, values[i])
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// vectorSeries is a single metric of a vector along with its label values.
+type vectorSeries struct {
+	values []string
+	metric interface{}
+}
+
+// vector is the label bookkeeping shared by all the typed vectors.
+type vector struct {
+	labels []string
+	create func() interface{}
+	series map[string]*vectorSeries
+	lock   sync.RWMutex
+}
+
+func newVector(labels []string, create func() interface{}) *vector {
+	return &vector{
+		labels: append([]string(nil), labels...),
+		create: create,
+		series: make(map[string]*vectorSeries),
+	}
+}
+
+// LabelNames returns the names of the labels partitioning the vector.
+func (v *vector) LabelNames() []string {
+	return append([]string(nil), v.labels...)
+}
+
+// Each calls the given function for every series of the vector, ordered by
+// label values.
+func (v *vector) Each(fn func(values []string, metric interface{})) {
+	v.lock.RLock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	series := make([]*vectorSeries, len(keys))
+	for i, key := range keys {
+		series[i] = v.series[key]
+	}
+	v.lock.RUnlock()
+
+	for _, s := range series {
+		fn(append([]string(nil), s.values...), s.metric)
+	}
+}
+
+// Stop stops every series of the vector which needs stopping, e.g. meters.
+func (v *vector) Stop() {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+
+	for _, s := range v.series {
+		if stoppable, ok := s.metric.(Stoppable); ok {
+			stoppable.Stop()
+		}
+	}
+}
+
+// with returns the metric for the given label values, creating it if it does not
+// exist yet. It panics if the number of values doesn't match the labels.
+func (v *vector) with(values []string) interface{} {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %d label values given for %d labels", len(values), len(v.labels)))
+	}
+	key := strings.Join(values, labelSeparator)
+
+	v.lock.RLock()
+	s, ok := v.series[key]
+	v.lock.RUnlock()
+	if ok {
+		return s.metric
+	}
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if s, ok := v.series[key]; ok {
+		return s.metric
+	}
+	if len(v.series) >= MaxVectorSeries {
+		values = make([]string, len(v.labels))
+		for i := range values {
+			values[i] = OverflowLabelValue
+		}
+		key = strings.Join(values, labelSeparator)
+		if s, ok := v.series[key]; ok {
+			return s.metric
+		}
+	}
+	s = &vectorSeries{
+		values: append([]string(nil), values...),
+		metric: v.create(),
+	}
+	v.series[key] = s
+	return s.metric
+}
+
+// CounterVec is a family of Counters partitioned by labels.
+type CounterVec interface {
+	Vector
+	With(values ...string) Counter
+}
+
+// GetOrRegisterCounterVec returns an existing CounterVec or constructs and
+// registers a new StandardCounterVec.
+func GetOrRegisterCounterVec(name string, labels []string, r Registry) CounterVec {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() CounterVec { return NewCounterVec(labels) }).(CounterVec)
+}
+
+// NewCounterVec constructs a new StandardCounterVec.
+func NewCounterVec(labels []string) CounterVec {
+	return &StandardCounterVec{newVector(labels, func() interface{} { return NewCounter() })}
+}
+
+// NewRegisteredCounterVec constructs and registers a new StandardCounterVec.
+func NewRegisteredCounterVec(name string, labels []string, r Registry) CounterVec {
+	c := NewCounterVec(labels)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// StandardCounterVec is the standard implementation of a CounterVec.
+type StandardCounterVec struct {
+	*vector
+}
+
+// With returns the Counter for the given label values.
+func (v *StandardCounterVec) With(values ...string) Counter {
+	return v.with(values).(Counter)
+}
+
+// GaugeVec is a family of Gauges partitioned by labels.
+type GaugeVec interface {
+	Vector
+	With(values ...string) Gauge
+}
+
+// GetOrRegisterGaugeVec returns an existing GaugeVec or constructs and registers
+// a new StandardGaugeVec.
+func GetOrRegisterGaugeVec(name string, labels []string, r Registry) GaugeVec {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() GaugeVec { return NewGaugeVec(labels) }).(GaugeVec)
+}
+
+// NewGaugeVec constructs a new StandardGaugeVec.
+func NewGaugeVec(labels []string) GaugeVec {
+	return &StandardGaugeVec{newVector(labels, func() interface{} { return NewGauge() })}
+}
+
+// NewRegisteredGaugeVec constructs and registers a new StandardGaugeVec.
+func NewRegisteredGaugeVec(name string, labels []string, r Registry) GaugeVec {
+	c := NewGaugeVec(labels)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// StandardGaugeVec is the standard implementation of a GaugeVec.
+type StandardGaugeVec struct {
+	*vector
+}
+
+// With returns the Gauge for the given label values.
+func (v *StandardGaugeVec) With(values ...string) Gauge {
+	return v.with(values).(Gauge)
+}
+
+// MeterVec is a family of Meters partitioned by labels.
+type MeterVec interface {
+	Vector
+	With(values ...string) Meter
+}
+
+// GetOrRegisterMeterVec returns an existing MeterVec or constructs and registers
+// a new StandardMeterVec.
+func GetOrRegisterMeterVec(name string, labels []string, r Registry) MeterVec {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() MeterVec { return NewMeterVec(labels) }).(MeterVec)
+}
+
+// NewMeterVec constructs a new StandardMeterVec.
+// Be sure to call Stop() once the vector is of no use to allow for garbage collection.
+func NewMeterVec(labels []string) MeterVec {
+	return &StandardMeterVec{newVector(labels, func() interface{} { return NewMeter() })}
+}
+
+// NewRegisteredMeterVec constructs and registers a new StandardMeterVec.
+// Be sure to unregister the vector from the registry once it is of no use to
+// allow for garbage collection.
+func NewRegisteredMeterVec(name string, labels []string, r Registry) MeterVec {
+	c := NewMeterVec(labels)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// StandardMeterVec is the standard implementation of a MeterVec.
+type StandardMeterVec struct {
+	*vector
+}
+
+// With returns the Meter for the given label values.
+func (v *StandardMeterVec) With(values ...string) Meter {
+	return v.with(values).(Meter)
+}
+
+// TimerVec is a family of Timers partitioned by labels.
+type TimerVec interface {
+	Vector
+	With(values ...string) Timer
+}
+
+// GetOrRegisterTimerVec returns an existing TimerVec or constructs and registers
+// a new StandardTimerVec.
+func GetOrRegisterTimerVec(name string, labels []string, r Registry) TimerVec {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() TimerVec { return NewTimerVec(labels) }).(TimerVec)
+}
+
+// NewTimerVec constructs a new StandardTimerVec.
+// Be sure to call Stop() once the vector is of no use to allow for garbage collection.
+func NewTimerVec(labels []string) TimerVec {
+	return &StandardTimerVec{newVector(labels, func() interface{} { return NewTimer() })}
+}
+
+// NewRegisteredTimerVec constructs and registers a new StandardTimerVec.
+// Be sure to unregister the vector from the registry once it is of no use to
+// allow for garbage collection.
+func NewRegisteredTimerVec(name string, labels []string, r Registry) TimerVec {
+	c := NewTimerVec(labels)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// StandardTimerVec is the standard implementation of a TimerVec.
+type StandardTimerVec struct {
+	*vector
+}
+
+// With returns the Timer for the given label values.
+func (v *StandardTimerVec) With(values ...string) Timer {
+	return v.with(values).(Timer)
+}
+
+// HistogramVec is a family of Histograms partitioned by labels.
+type HistogramVec interface {
+	Vector
+	With(values ...string) Histogram
+}
+
+// GetOrRegisterHistogramVec returns an existing HistogramVec or constructs and
+// registers a new StandardHistogramVec. Every series gets its own Sample from
+// the given constructor.
+func GetOrRegisterHistogramVec(name string, labels []string, r Registry, s func() Sample) HistogramVec {
+	if nil == r {
+		r = DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() HistogramVec { return NewHistogramVec(labels, s) }).(HistogramVec)
+}
+
+// NewHistogramVec constructs a new StandardHistogramVec. Every series gets its
+// own Sample from the given constructor.
+func NewHistogramVec(labels []string, s func() Sample) HistogramVec {
+	return &StandardHistogramVec{newVector(labels, func() interface{} { return NewHistogram(s()) })}
+}
+
+// NewRegisteredHistogramVec constructs and registers a new StandardHistogramVec.
+func NewRegisteredHistogramVec(name string, labels []string, r Registry, s func() Sample) HistogramVec {
+	c := NewHistogramVec(labels, s)
+	if nil == r {
+		r = DefaultRegistry
+	}
+	r.Register(name, c)
+	return c
+}
+
+// StandardHistogramVec is the standard implementation of a HistogramVec.
+type StandardHistogramVec struct {
+	*vector
+}
+
+// With returns the Histogram for the given label values.
+func (v *StandardHistogramVec) With(values ...string) Histogram {
+	return v.with(values).(Histogram)
+}
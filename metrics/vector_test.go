@@ -0,0 +1,91 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCounterVec(t *testing.T) {
+	v := NewCounterVec([]string{"method", "result"})
+	v.With("eth_call", "success").Inc(2)
+	v.With("eth_call", "failure").Inc(1)
+	v.With("eth_call", "success").Inc(3)
+
+	var (
+		values [][]string
+		counts []int64
+	)
+	v.Each(func(labels []string, metric interface{}) {
+		values = append(values, labels)
+		counts = append(counts, metric.(Counter).Count())
+	})
+	if want := [][]string{{"eth_call", "failure"}, {"eth_call", "success"}}; !reflect.DeepEqual(values, want) {
+		t.Errorf("label values mismatch: have %v, want %v", values, want)
+	}
+	if want := []int64{1, 5}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts mismatch: have %v, want %v", counts, want)
+	}
+}
+
+func TestVectorOverflow(t *testing.T) {
+	defer func(limit int) { MaxVectorSeries = limit }(MaxVectorSeries)
+	MaxVectorSeries = 2
+
+	v := NewCounterVec([]string{"peer"})
+	v.With("a").Inc(1)
+	v.With("b").Inc(1)
+	v.With("c").Inc(1)
+	v.With("d").Inc(1)
+	v.With("a").Inc(1)
+
+	series := make(map[string]int64)
+	v.Each(func(labels []string, metric interface{}) {
+		series[labels[0]] = metric.(Counter).Count()
+	})
+	if want := map[string]int64{"a": 2, "b": 1, OverflowLabelValue: 2}; !reflect.DeepEqual(series, want) {
+		t.Errorf("series mismatch: have %v, want %v", series, want)
+	}
+}
+
+func TestVectorLabelMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("mismatching label values accepted")
+		}
+	}()
+	NewGaugeVec([]string{"table"}).With("headers", "bodies")
+}
+
+func TestRegistryVector(t *testing.T) {
+	r := NewRegistry()
+	v := GetOrRegisterTimerVec("rpc/duration", []string{"method"}, r)
+	if have := GetOrRegisterTimerVec("rpc/duration", []string{"method"}, r); have != v {
+		t.Fatal("registered vector not returned")
+	}
+	v.With("eth_call").Update(1)
+
+	all := r.GetAll()
+	if _, ok := all[`rpc/duration{method="eth_call"}`]; !ok || len(all) != 1 {
+		t.Errorf("unexpected flattened series: %v", all)
+	}
+	r.Unregister("rpc/duration")
+	if r.Get("rpc/duration") != nil {
+		t.Error("vector not unregistered")
+	}
+}
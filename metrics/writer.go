@@ -20,6 +20,13 @@ func Write(r Registry, d time.Duration, w io.Writer) {
 func WriteOnce(r Registry, w io.Writer) {
 	var namedMetrics namedMetricSlice
 	r.Each(func(name string, i interface{}) {
+		if v, ok := i.(Vector); ok {
+			labels := v.LabelNames()
+			v.Each(func(values []string, metric interface{}) {
+				namedMetrics = append(namedMetrics, namedMetric{SeriesName(name, labels, values), metric})
+			})
+			return
+		}
 		namedMetrics = append(namedMetrics, namedMetric{name, i})
 	})
 
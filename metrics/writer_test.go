@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"bytes"
 	"sort"
 	"testing"
 )
@@ -20,3 +21,21 @@ func TestMetricsSorting(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteOnceVector(t *testing.T) {
+	r := NewRegistry()
+	v := NewRegisteredCounterVec("requests", []string{"method"}, r)
+	v.With("eth_call").Inc(2)
+	v.With("eth_sendRawTransaction").Inc(1)
+
+	var buf bytes.Buffer
+	WriteOnce(r, &buf)
+
+	want := "counter requests{method=\"eth_call\"}\n" +
+		"  count:               2\n" +
+		"counter requests{method=\"eth_sendRawTransaction\"}\n" +
+		"  count:               1\n"
+	if have := buf.String(); have != want {
+		t.Errorf("output mismatch:\nhave:\n%s\nwant:\n%s", have, want)
+	}
+}
@@ -180,10 +180,16 @@ func (api *privateAdminAPI) StartRPC(host *string, port *int, cors *string, apis
 	}
 
 	// Determine config.
+	secret, err := api.node.obtainJWTSecret()
+	if err != nil {
+		return false, err
+	}
 	config := httpConfig{
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		jwtSecret:          secret,
+		limits:             api.node.config.RPCLimits,
 	}
 	if cors != nil {
 		config.CorsAllowedOrigins = nil
@@ -240,9 +246,15 @@ func (api *privateAdminAPI) StartWS(host *string, port *int, allowedOrigins *str
 	}
 
 	// Determine config.
+	secret, err := api.node.obtainJWTSecret()
+	if err != nil {
+		return false, err
+	}
 	config := wsConfig{
-		Modules: api.node.config.WSModules,
-		Origins: api.node.config.WSOrigins,
+		Modules:   api.node.config.WSModules,
+		Origins:   api.node.config.WSOrigins,
+		jwtSecret: secret,
+		limits:    api.node.config.RPCLimits,
 		// ExposeAll: api.node.config.WSExposeAll,
 	}
 	if apis != nil {
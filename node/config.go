@@ -72,6 +72,11 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the key-value database engine of the persistent databases,
+	// leveldb or pebble. If empty, existing databases are opened with the engine
+	// they were created with and new ones with leveldb.
+	DBEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -164,6 +169,16 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// JWTSecret is the path of a file holding a hex encoded 32 byte secret. If
+	// set, the HTTP and websocket RPC interfaces require HS256 JWT bearer tokens
+	// signed with it, whose "namespaces" and "methods" claims may restrict the
+	// callable API methods.
+	JWTSecret string `toml:",omitempty"`
+
+	// RPCLimits bounds the batch sizes, response sizes, request rates and
+	// concurrent calls the HTTP and websocket RPC interfaces grant to callers.
+	RPCLimits rpc.Limits `toml:",omitempty"`
+
 	// GraphQLCors is the Cross-Origin Resource Sharing header to send to requesting
 	// clients. Please be aware that CORS is a browser enforced security, it's fully
 	// useless for custom HTTP clients.
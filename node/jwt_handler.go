@@ -0,0 +1,177 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errCodeUnauthenticated is the JSON-RPC error code of requests rejected for a
+// missing or invalid bearer token.
+const errCodeUnauthenticated = -32001
+
+// jwtHandler is a handler which requires HS256 JWT bearer tokens, restricting
+// the RPC methods callable with a token to those granted by its claims:
+//
+//	"namespaces": ["eth", "net"]             // all methods of the namespaces
+//	"methods":    ["debug_traceTransaction"] // individual methods
+//
+// A token without either claim grants all the methods exposed by the endpoint.
+type jwtHandler struct {
+	secret []byte
+	next   http.Handler
+}
+
+// newJWTHandler wraps next with JWT authentication, or returns it unchanged if
+// no secret is configured.
+func newJWTHandler(secret []byte, next http.Handler) http.Handler {
+	if secret == nil {
+		return next
+	}
+	return &jwtHandler{secret: secret, next: next}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *jwtHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		writeUnauthenticated(w, errors.New("missing bearer token"))
+		return
+	}
+	token := strings.TrimPrefix(auth, "Bearer ")
+	claims, err := rpc.ParseHS256JWT(h.secret, token)
+	if err != nil {
+		writeUnauthenticated(w, err)
+		return
+	}
+	allow, err := jwtMethodFilter(claims)
+	if err != nil {
+		writeUnauthenticated(w, err)
+		return
+	}
+	ctx := rpc.ContextWithClientID(r.Context(), jwtClientID(claims, token))
+	if allow != nil {
+		ctx = rpc.ContextWithMethodFilter(ctx, allow)
+	}
+	h.next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// jwtClientID returns the identity the rate limits of a verified token are
+// tracked under: its "sub" claim, or the token itself if it has no subject.
+func jwtClientID(claims map[string]interface{}, token string) string {
+	if sub, ok := claims["sub"].(string); ok && sub != "" {
+		return "sub:" + sub
+	}
+	hash := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(hash[:16])
+}
+
+// jwtMethodFilter returns the filter accepting the RPC methods granted by the
+// "namespaces" and "methods" claims of a token, or nil if it has neither.
+func jwtMethodFilter(claims map[string]interface{}) (func(string) bool, error) {
+	namespaces, err := jwtStringSet(claims, "namespaces")
+	if err != nil {
+		return nil, err
+	}
+	methods, err := jwtStringSet(claims, "methods")
+	if err != nil {
+		return nil, err
+	}
+	if namespaces == nil && methods == nil {
+		return nil, nil
+	}
+	return func(method string) bool {
+		if _, ok := methods[method]; ok {
+			return true
+		}
+		namespace := method
+		if i := strings.Index(method, "_"); i >= 0 {
+			namespace = method[:i]
+		}
+		_, ok := namespaces[namespace]
+		return ok
+	}, nil
+}
+
+// jwtStringSet returns the elements of a string array claim, or nil if the
+// claim is absent.
+func jwtStringSet(claims map[string]interface{}, claim string) (map[string]struct{}, error) {
+	value, ok := claims[claim]
+	if !ok {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %q claim, want a string array", claim)
+	}
+	set := make(map[string]struct{}, len(list))
+	for _, elem := range list {
+		s, ok := elem.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %q claim, want a string array", claim)
+		}
+		set[s] = struct{}{}
+	}
+	return set, nil
+}
+
+// writeUnauthenticated rejects a request with a JSON-RPC error response.
+func writeUnauthenticated(w http.ResponseWriter, err error) {
+	type jsonError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	resp := struct {
+		Version string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Error   jsonError       `json:"error"`
+	}{
+		Version: "2.0",
+		ID:      json.RawMessage("null"),
+		Error:   jsonError{Code: errCodeUnauthenticated, Message: "unauthenticated: " + err.Error()},
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// obtainJWTSecret loads the JWT secret of the RPC endpoints, returning nil if
+// authentication isn't configured.
+func (n *Node) obtainJWTSecret() ([]byte, error) {
+	if n.config.JWTSecret == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(n.config.JWTSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT secret: %v", err)
+	}
+	secret := common.FromHex(strings.TrimSpace(string(data)))
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("invalid JWT secret in %s, want 32 hex encoded bytes", n.config.JWTSecret)
+	}
+	return secret, nil
+}
@@ -393,12 +393,19 @@ func (n *Node) startRPC() error {
 		}
 	}
 
+	secret, err := n.obtainJWTSecret()
+	if err != nil {
+		return err
+	}
+
 	// Configure HTTP.
 	if n.config.HTTPHost != "" {
 		config := httpConfig{
 			CorsAllowedOrigins: n.config.HTTPCors,
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
+			jwtSecret:          secret,
+			limits:             n.config.RPCLimits,
 		}
 		if err := n.http.setListenAddr(n.config.HTTPHost, n.config.HTTPPort); err != nil {
 			return err
@@ -412,8 +419,10 @@ func (n *Node) startRPC() error {
 	if n.config.WSHost != "" {
 		server := n.wsServerForPort(n.config.WSPort)
 		config := wsConfig{
-			Modules: n.config.WSModules,
-			Origins: n.config.WSOrigins,
+			Modules:   n.config.WSModules,
+			Origins:   n.config.WSOrigins,
+			jwtSecret: secret,
+			limits:    n.config.RPCLimits,
 		}
 		if err := server.setListenAddr(n.config.WSHost, n.config.WSPort); err != nil {
 			return err
@@ -600,7 +609,7 @@ func (n *Node) OpenDatabase(name string, cache, handles int, namespace string) (
 	if n.config.DataDir == "" {
 		db = rawdb.NewMemoryDatabase()
 	} else {
-		db, err = rawdb.NewLevelDBDatabase(n.ResolvePath(name), cache, handles, namespace)
+		db, err = rawdb.NewPersistentDatabase(n.config.DBEngine, n.ResolvePath(name), cache, handles, namespace)
 	}
 
 	if err == nil {
@@ -613,13 +622,14 @@ func (n *Node) OpenDatabase(name string, cache, handles int, namespace string) (
 // creates one if no previous can be found) from within the node's data directory,
 // also attaching a chain freezer to it that moves ancient chain data from the
 // database to immutable append-only files. If the node is an ephemeral one, a
-// memory database is returned.
-func (n *Node) OpenDatabaseWithFreezerRemote(name string, cache, handles int, freezerURL string) (ethdb.Database, error) {
+// memory database is returned. The connection to the freezer is configured by
+// config, which may be nil.
+func (n *Node) OpenDatabaseWithFreezerRemote(name string, cache, handles int, freezerURL string, config *rawdb.FreezerRemoteConfig) (ethdb.Database, error) {
 	if n.config.DataDir == "" {
 		return rawdb.NewMemoryDatabase(), nil
 	}
 	root := n.config.ResolvePath(name)
-	return rawdb.NewLevelDBDatabaseWithFreezerRemote(root, cache, handles, freezerURL)
+	return rawdb.NewPersistentDatabaseWithFreezerRemote(n.config.DBEngine, root, cache, handles, freezerURL, config)
 }
 
 // OpenDatabaseWithFreezer opens an existing database with the given name (or
@@ -646,7 +656,7 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer,
 		case !filepath.IsAbs(freezer):
 			freezer = n.ResolvePath(freezer)
 		}
-		db, err = rawdb.NewLevelDBDatabaseWithFreezer(root, cache, handles, freezer, namespace)
+		db, err = rawdb.NewPersistentDatabaseWithFreezer(n.config.DBEngine, root, cache, handles, freezer, namespace)
 	}
 
 	if err == nil {
@@ -18,8 +18,11 @@ package node
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/internal/testlog"
 	"github.com/ethereum/go-ethereum/log"
@@ -88,6 +91,86 @@ func TestIsWebsocket(t *testing.T) {
 	assert.True(t, isWebsocket(r))
 }
 
+// TestJWT makes sure JWT authentication and the method permissions of the
+// tokens are enforced on the http and websocket servers.
+func TestJWT(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x42}, 32)
+	srv := createAndStartServer(t, httpConfig{jwtSecret: secret}, true, wsConfig{jwtSecret: secret})
+	defer srv.stop()
+
+	issue := func(secret []byte, claims map[string]interface{}) string {
+		token, err := rpc.NewHS256JWT(secret, claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + token
+	}
+	// Requests without a valid token are rejected before reaching the server
+	rejected := map[string]string{
+		"missing token":   "",
+		"wrong scheme":    "Basic " + issue(secret, nil)[len("Bearer "):],
+		"wrong secret":    issue(bytes.Repeat([]byte{0x43}, 32), nil),
+		"expired token":   issue(secret, map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}),
+		"future token":    issue(secret, map[string]interface{}{"iat": time.Now().Add(time.Hour).Unix()}),
+		"malformed claim": issue(secret, map[string]interface{}{"namespaces": "rpc"}),
+	}
+	for name, auth := range rejected {
+		resp := testRequest(t, "Authorization", auth, "", srv)
+		var body struct {
+			Error struct {
+				Code int `json:"code"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("%s: invalid response body: %v", name, err)
+		}
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, name)
+		assert.Equal(t, errCodeUnauthenticated, body.Error.Code, name)
+	}
+	_, _, err := websocket.DefaultDialer.Dial("ws://"+srv.listenAddr(), nil)
+	assert.Error(t, err, "websocket without token")
+
+	// Valid tokens may call the methods their claims grant
+	tests := []struct {
+		claims  map[string]interface{}
+		allowed bool
+	}{
+		{nil, true},
+		{map[string]interface{}{"namespaces": []string{"rpc"}}, true},
+		{map[string]interface{}{"methods": []string{"rpc_modules"}}, true},
+		{map[string]interface{}{"namespaces": []string{"eth"}, "methods": []string{"debug_traceTransaction"}}, false},
+	}
+	for i, tt := range tests {
+		auth := issue(secret, tt.claims)
+
+		httpClient, err := rpc.DialHTTP("http://" + srv.listenAddr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		httpClient.SetHeader("Authorization", auth)
+		wsClient, err := rpc.DialWebsocketWithHeader(context.Background(), "ws://"+srv.listenAddr(), "", *websocket.DefaultDialer, http.Header{"Authorization": {auth}})
+		if err != nil {
+			t.Fatalf("test %d: websocket dial failed: %v", i, err)
+		}
+		for transport, client := range map[string]*rpc.Client{"http": httpClient, "ws": wsClient} {
+			var modules map[string]string
+			err := client.Call(&modules, "rpc_modules")
+			if tt.allowed {
+				assert.NoError(t, err, "test %d over %s", i, transport)
+				continue
+			}
+			rpcErr, ok := err.(rpc.Error)
+			if !ok {
+				t.Fatalf("test %d over %s: have error %v, want unauthorized error", i, transport, err)
+			}
+			assert.Equal(t, -32002, rpcErr.ErrorCode(), "test %d over %s", i, transport)
+		}
+		httpClient.Close()
+		wsClient.Close()
+	}
+}
+
 func createAndStartServer(t *testing.T, conf httpConfig, ws bool, wsConf wsConfig) *httpServer {
 	t.Helper()
 
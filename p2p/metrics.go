@@ -19,7 +19,9 @@
 package p2p
 
 import (
+	"fmt"
 	"net"
+	"strconv"
 
 	"github.com/ethereum/go-ethereum/metrics"
 )
@@ -35,8 +37,25 @@ var (
 	egressConnectMeter  = metrics.NewRegisteredMeter("p2p/dials", nil)
 	egressTrafficMeter  = metrics.NewRegisteredMeter(egressMeterName, nil)
 	activePeerGauge     = metrics.NewRegisteredGauge("p2p/peers", nil)
+
+	// Per message traffic, labeled by subprotocol, version and message code.
+	// Only messages of running subprotocols are metered, bounding the labels.
+	ingressMessageMeter = metrics.NewRegisteredMeterVec(ingressMeterName+"/msg", messageLabels, nil)
+	ingressPacketMeter  = metrics.NewRegisteredMeterVec(ingressMeterName+"/msg/packets", messageLabels, nil)
+	egressMessageMeter  = metrics.NewRegisteredMeterVec(egressMeterName+"/msg", messageLabels, nil)
+	egressPacketMeter   = metrics.NewRegisteredMeterVec(egressMeterName+"/msg/packets", messageLabels, nil)
 )
 
+// messageLabels are the labels partitioning the per message traffic meters.
+var messageLabels = []string{"protocol", "version", "code"}
+
+// markMessage meters a single subprotocol message of the given size.
+func markMessage(bytes, packets metrics.MeterVec, proto string, version uint, code uint64, size uint32) {
+	labels := []string{proto, strconv.FormatUint(uint64(version), 10), fmt.Sprintf("%#02x", code)}
+	bytes.With(labels...).Mark(int64(size))
+	packets.With(labels...).Mark(1)
+}
+
 // meteredConn is a wrapper around a net.Conn that meters both the
 // inbound and outbound network traffic.
 type meteredConn struct {
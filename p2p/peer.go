@@ -311,9 +311,7 @@ func (p *Peer) handle(msg Msg) error {
 			return fmt.Errorf("msg code out of range: %v", msg.Code)
 		}
 		if metrics.Enabled {
-			m := fmt.Sprintf("%s/%s/%d/%#02x", ingressMeterName, proto.Name, proto.Version, msg.Code-proto.offset)
-			metrics.GetOrRegisterMeter(m, nil).Mark(int64(msg.meterSize))
-			metrics.GetOrRegisterMeter(m+"/packets", nil).Mark(1)
+			markMessage(ingressMessageMeter, ingressPacketMeter, proto.Name, proto.Version, msg.Code-proto.offset, msg.meterSize)
 		}
 		select {
 		case proto.in <- msg:
@@ -17,10 +17,15 @@
 package confp
 
 import (
+	"bytes"
 	"fmt"
+	"math/big"
 	"reflect"
+	"sort"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 )
 
@@ -138,9 +143,9 @@ func convert(k reflect.Type, source, target interface{}) error {
 }
 
 type DiffT struct {
-	Field string
-	A     interface{}
-	B     interface{}
+	Field string      `json:"field"`
+	A     interface{} `json:"a"`
+	B     interface{} `json:"b"`
 }
 
 func (d DiffT) String() string {
@@ -200,6 +205,137 @@ func compare(k reflect.Type, source, target interface{}) (diffs []DiffT) {
 	return
 }
 
+// Diff compares the values returned by all the parameter getters of the
+// ChainConfigurator and GenesisBlocker interfaces implemented by both a and b,
+// along with their genesis accounts.
+func Diff(a, b interface{}) (diffs []DiffT) {
+	for _, k := range []reflect.Type{
+		reflect.TypeOf((*ctypes.ChainConfigurator)(nil)).Elem(),
+		reflect.TypeOf((*ctypes.GenesisBlocker)(nil)).Elem(),
+	} {
+		if !reflect.TypeOf(a).Implements(k) || !reflect.TypeOf(b).Implements(k) {
+			continue
+		}
+		for i := 0; i < k.NumMethod(); i++ {
+			method := k.Method(i)
+			if !strings.HasPrefix(method.Name, "Get") || method.Type.NumIn() > 0 {
+				continue
+			}
+			va := diffValue(reflect.ValueOf(a).MethodByName(method.Name).Call(nil)[0])
+			vb := diffValue(reflect.ValueOf(b).MethodByName(method.Name).Call(nil)[0])
+			if !diffEqual(va, vb) {
+				diffs = append(diffs, DiffT{
+					Field: strings.TrimPrefix(method.Name, "Get"),
+					A:     va,
+					B:     vb,
+				})
+			}
+		}
+	}
+	ga, okA := a.(ctypes.GenesisBlocker)
+	gb, okB := b.(ctypes.GenesisBlocker)
+	if !okA || !okB {
+		return diffs
+	}
+	accsA, accsB := diffAccounts(ga), diffAccounts(gb)
+	var addrs []common.Address
+	for addr := range accsA {
+		addrs = append(addrs, addr)
+	}
+	for addr := range accsB {
+		if _, ok := accsA[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	for _, addr := range addrs {
+		accA, okA := accsA[addr]
+		accB, okB := accsB[addr]
+		if !diffEqual(accA, accB) {
+			d := DiffT{Field: fmt.Sprintf("Account(%s)", addr.Hex())}
+			if okA {
+				d.A = accA
+			}
+			if okB {
+				d.B = accB
+			}
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// diffValue dereferences a getter response for comparison and display,
+// treating empty maps and slices as unset.
+func diffValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if _, ok := v.Interface().(*big.Int); ok {
+			return v.Interface()
+		}
+		return v.Elem().Interface()
+	case reflect.Map, reflect.Slice:
+		if v.Len() == 0 {
+			return nil
+		}
+	}
+	return v.Interface()
+}
+
+// diffEqual reports whether two values compared by Diff are equal. Other
+// pointers are already dereferenced by diffValue, but big integers are kept
+// as pointers and compared by value here, since reflect.DeepEqual would tell
+// apart equal numbers of different internal representations (e.g. the zeros
+// decoded from JSON and allocated with new).
+func diffEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case *big.Int:
+		b, ok := b.(*big.Int)
+		return ok && (a == nil) == (b == nil) && (a == nil || a.Cmp(b) == 0)
+	case *diffAccount:
+		b, ok := b.(*diffAccount)
+		if !ok || (a == nil) != (b == nil) {
+			return false
+		}
+		return a == nil || (diffEqual(a.Balance, b.Balance) && a.Nonce == b.Nonce &&
+			bytes.Equal(a.Code, b.Code) && reflect.DeepEqual(a.Storage, b.Storage))
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// diffAccount is a genesis account, as compared by Diff.
+type diffAccount struct {
+	Balance *big.Int                    `json:"balance"`
+	Nonce   uint64                      `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// diffAccounts collects the genesis accounts of a GenesisBlocker.
+func diffAccounts(g ctypes.GenesisBlocker) map[common.Address]*diffAccount {
+	accounts := make(map[common.Address]*diffAccount)
+	g.ForEachAccount(func(address common.Address, bal *big.Int, nonce uint64, code []byte, storage map[common.Hash]common.Hash) error {
+		acc := &diffAccount{Balance: new(big.Int), Nonce: nonce}
+		if bal != nil {
+			acc.Balance.Set(bal)
+		}
+		if len(code) > 0 {
+			acc.Code = code
+		}
+		if len(storage) > 0 {
+			acc.Storage = storage
+		}
+		accounts[address] = acc
+		return nil
+	})
+	return accounts
+}
+
 // Identical determines if chain fields are of the same identity; comparing equivalence
 // of only essential network and chain parameters. This allows for identity comparison
 // independent of potential or realized chain upgrades.
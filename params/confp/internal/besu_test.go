@@ -0,0 +1,66 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package convert_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/params/confp"
+	"github.com/ethereum/go-ethereum/params/confp/tconvert"
+	"github.com/ethereum/go-ethereum/params/types/besu"
+	"github.com/ethereum/go-ethereum/params/types/genesisT"
+)
+
+// TestBesuRoundTrip tests that the default networks survive a conversion to the
+// Besu genesis format, through JSON, and back.
+func TestBesuRoundTrip(t *testing.T) {
+	for name, genesis := range map[string]*genesisT.Genesis{
+		"foundation": params.DefaultGenesisBlock(),
+		"ropsten":    params.DefaultRopstenGenesisBlock(),
+		"rinkeby":    params.DefaultRinkebyGenesisBlock(),
+		"goerli":     params.DefaultGoerliGenesisBlock(),
+		"classic":    params.DefaultClassicGenesisBlock(),
+		"mordor":     params.DefaultMordorGenesisBlock(),
+		"kotti":      params.DefaultKottiGenesisBlock(),
+	} {
+		spec, err := tconvert.NewBesuGenesisSpec(genesis)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		b, err := json.MarshalIndent(spec, "", "    ")
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		read := besu.NewBesuGenesis()
+		if err := json.Unmarshal(b, read); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		back, err := tconvert.BesuConfigToCoreGethGenesis(read)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := confp.Equivalent(genesis.Config, back.Config); err != nil {
+			t.Errorf("%s: %v\n%s", name, err, b)
+		}
+		if got, want := core.GenesisToBlock(back, nil).Hash(), core.GenesisToBlock(genesis, nil).Hash(); got != want {
+			t.Errorf("%s: genesis hash mismatch: have %x, want %x", name, got, want)
+		}
+	}
+}
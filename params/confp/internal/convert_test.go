@@ -25,9 +25,11 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params/confp"
 	"github.com/ethereum/go-ethereum/params/confp/tconvert"
 	"github.com/ethereum/go-ethereum/params/types/aleth"
+	"github.com/ethereum/go-ethereum/params/types/besu"
 	"github.com/ethereum/go-ethereum/params/types/coregeth"
 	"github.com/ethereum/go-ethereum/params/types/ctypes"
 	"github.com/ethereum/go-ethereum/params/types/genesisT"
@@ -124,6 +126,7 @@ func TestIdentical(t *testing.T) {
 func TestConfiguratorImplementationsSatisfied(t *testing.T) {
 	for _, ty := range []interface{}{
 		&parity.ParityChainSpec{},
+		besu.NewBesuGenesis(),
 	} {
 		_ = ty.(ctypes.Configurator)
 	}
@@ -150,3 +153,56 @@ func TestCompatible(t *testing.T) {
 	}
 	t.Log(fns)
 }
+
+func TestDiff(t *testing.T) {
+	a := &genesisT.Genesis{
+		Config: &coregeth.CoreGethChainConfig{
+			ChainID:     big.NewInt(1),
+			Ethash:      new(ctypes.EthashConfig),
+			EIP150Block: big.NewInt(1),
+		},
+		GasLimit: 5000,
+		Alloc:    genesisT.GenesisAlloc{common.Address{1}: {Balance: big.NewInt(1)}},
+	}
+	b := &genesisT.Genesis{
+		Config: &coregeth.CoreGethChainConfig{
+			ChainID:     big.NewInt(1),
+			Ethash:      new(ctypes.EthashConfig),
+			EIP150Block: big.NewInt(2),
+		},
+		GasLimit: 5000,
+		Alloc:    genesisT.GenesisAlloc{common.Address{2}: {Balance: big.NewInt(1)}},
+	}
+	diffs := confp.Diff(a, b)
+	want := []string{"EIP150Transition", "Account(0x0100000000000000000000000000000000000000)", "Account(0x0200000000000000000000000000000000000000)"}
+	if len(diffs) != len(want) {
+		t.Fatalf("diff count mismatch: have %v, want %v", diffs, want)
+	}
+	for i, d := range diffs {
+		if d.Field != want[i] {
+			t.Errorf("diff %d field mismatch: have %s, want %s", i, d.Field, want[i])
+		}
+	}
+	if diffs[0].A != uint64(1) || diffs[0].B != uint64(2) {
+		t.Errorf("transition diff mismatch: have %v", diffs[0])
+	}
+	if diffs[1].B != nil || diffs[2].A != nil {
+		t.Errorf("missing accounts not unset: %v", diffs[1:])
+	}
+	if diffs := confp.Diff(a, a); len(diffs) != 0 {
+		t.Errorf("unexpected self diff: %v", diffs)
+	}
+
+	// Equal numbers differently represented, here zeros decoded from JSON and
+	// allocated with new, are not different
+	var c genesisT.Genesis
+	if err := json.Unmarshal([]byte(`{"config": {"chainId": 1, "eip150Block": 1, "ethash": {}}, "gasLimit": "0x1388", "difficulty": "0x0", "alloc": {"0x0100000000000000000000000000000000000000": {"balance": "0x1"}}}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	networkID := uint64(1) // Defaulted to the chain id when decoding
+	a.Config.SetNetworkID(&networkID)
+	a.Difficulty = new(big.Int)
+	if diffs := confp.Diff(a, &c); len(diffs) != 0 {
+		t.Errorf("unexpected diff of equal numbers: %v", diffs)
+	}
+}
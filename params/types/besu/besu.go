@@ -0,0 +1,156 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package besu
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/params/types/coregeth"
+)
+
+// BesuGenesis represents the genesis file format used by Hyperledger Besu.
+//
+// Besu configures protocol features as named forks, and uses a different set of
+// fork names for Ethereum Classic networks than for Ethereum ones. Since the
+// schedule can only be chosen once the whole configuration is known, the
+// features are kept in a feature-complete chain configuration, and are only
+// translated to and from Besu's fork names when encoding and decoding JSON.
+type BesuGenesis struct {
+	*coregeth.CoreGethChainConfig `json:"-"`
+
+	Nonce      math.HexOrDecimal64                              `json:"nonce"`
+	Timestamp  math.HexOrDecimal64                              `json:"timestamp"`
+	ExtraData  hexutil.Bytes                                    `json:"extraData"`
+	GasLimit   math.HexOrDecimal64                              `json:"gasLimit"`
+	Difficulty *math.HexOrDecimal256                            `json:"difficulty"`
+	MixHash    common.Hash                                      `json:"mixHash"`
+	Coinbase   common.Address                                   `json:"coinbase"`
+	ParentHash common.Hash                                      `json:"parentHash"`
+	Alloc      map[common.UnprefixedAddress]*BesuGenesisAccount `json:"alloc"`
+}
+
+// BesuGenesisAccount is a prefunded genesis account.
+type BesuGenesisAccount struct {
+	Balance *math.HexOrDecimal256       `json:"balance"`
+	Nonce   math.HexOrDecimal64         `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// BesuConfig is the 'config' object of a Besu genesis file.
+type BesuConfig struct {
+	ChainID *big.Int `json:"chainId"`
+
+	HomesteadBlock *uint64 `json:"homesteadBlock,omitempty"`
+
+	// Ethereum forks
+	DAOForkBlock        *uint64      `json:"daoForkBlock,omitempty"`
+	EIP150Block         *uint64      `json:"eip150Block,omitempty"`
+	EIP150Hash          *common.Hash `json:"eip150Hash,omitempty"`
+	EIP158Block         *uint64      `json:"eip158Block,omitempty"` // Spurious Dragon, includes EIP155, 160, 161 and 170
+	ByzantiumBlock      *uint64      `json:"byzantiumBlock,omitempty"`
+	ConstantinopleBlock *uint64      `json:"constantinopleBlock,omitempty"`
+	PetersburgBlock     *uint64      `json:"petersburgBlock,omitempty"`
+	IstanbulBlock       *uint64      `json:"istanbulBlock,omitempty"`
+	MuirGlacierBlock    *uint64      `json:"muirGlacierBlock,omitempty"`
+	BerlinBlock         *uint64      `json:"berlinBlock,omitempty"`
+
+	// Ethereum Classic forks
+	ClassicForkBlock  *uint64 `json:"classicForkBlock,omitempty"`
+	ECIP1015Block     *uint64 `json:"ecip1015Block,omitempty"` // Classic Tangerine Whistle
+	DieHardBlock      *uint64 `json:"diehardBlock,omitempty"`
+	GothamBlock       *uint64 `json:"gothamBlock,omitempty"`
+	ECIP1041Block     *uint64 `json:"ecip1041Block,omitempty"`
+	AtlantisBlock     *uint64 `json:"atlantisBlock,omitempty"`
+	AghartaBlock      *uint64 `json:"aghartaBlock,omitempty"`
+	PhoenixBlock      *uint64 `json:"phoenixBlock,omitempty"`
+	ThanosBlock       *uint64 `json:"thanosBlock,omitempty"`
+	ECIP1017EraRounds *uint64 `json:"ecip1017EraRounds,omitempty"`
+
+	// Consensus engines
+	Ethash *BesuEthashConfig `json:"ethash,omitempty"`
+	Clique *BesuCliqueConfig `json:"clique,omitempty"`
+}
+
+// BesuEthashConfig is the proof-of-work engine configuration.
+type BesuEthashConfig struct {
+	FixedDifficulty *uint64 `json:"fixeddifficulty,omitempty"`
+}
+
+// BesuCliqueConfig is the proof-of-authority engine configuration.
+type BesuCliqueConfig struct {
+	BlockPeriodSeconds uint64 `json:"blockperiodseconds"`
+	EpochLength        uint64 `json:"epochlength"`
+}
+
+// classic reports whether the configuration uses the Ethereum Classic fork names.
+func (c *BesuConfig) classic() bool {
+	for _, n := range []*uint64{
+		c.ClassicForkBlock,
+		c.ECIP1015Block,
+		c.DieHardBlock,
+		c.GothamBlock,
+		c.ECIP1041Block,
+		c.AtlantisBlock,
+		c.AghartaBlock,
+		c.PhoenixBlock,
+		c.ThanosBlock,
+		c.ECIP1017EraRounds,
+	} {
+		if n != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// besuGenesisJSON is the encoding type of BesuGenesis, adding the translated
+// configuration to the genesis fields.
+type besuGenesisJSON struct {
+	Config *BesuConfig `json:"config"`
+	*besuGenesisFields
+}
+
+// besuGenesisFields drops the methods of BesuGenesis, so it can be encoded
+// without recursing into its own marshaling methods.
+type besuGenesisFields BesuGenesis
+
+// MarshalJSON implements the json.Marshaler interface.
+func (g *BesuGenesis) MarshalJSON() ([]byte, error) {
+	config, err := encodeConfig(g)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(besuGenesisJSON{Config: config, besuGenesisFields: (*besuGenesisFields)(g)})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (g *BesuGenesis) UnmarshalJSON(input []byte) error {
+	dec := besuGenesisJSON{besuGenesisFields: (*besuGenesisFields)(g)}
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	g.CoreGethChainConfig = &coregeth.CoreGethChainConfig{}
+	if dec.Config == nil {
+		return errMissingConfig
+	}
+	return decodeConfig(dec.Config, g)
+}
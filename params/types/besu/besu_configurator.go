@@ -0,0 +1,139 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package besu
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/params/types/coregeth"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+)
+
+// File contains the Besu implementation of the GenesisBlocker interface.
+// The ChainConfigurator interface is implemented by the embedded chain configuration.
+
+// NewBesuGenesis returns an empty Besu genesis.
+func NewBesuGenesis() *BesuGenesis {
+	return &BesuGenesis{CoreGethChainConfig: &coregeth.CoreGethChainConfig{}}
+}
+
+func (g *BesuGenesis) GetSealingType() ctypes.BlockSealingT {
+	return ctypes.BlockSealing_Ethereum
+}
+
+func (g *BesuGenesis) SetSealingType(t ctypes.BlockSealingT) error {
+	if t != ctypes.BlockSealing_Ethereum {
+		return ctypes.ErrUnsupportedConfigFatal
+	}
+	return nil
+}
+
+func (g *BesuGenesis) GetGenesisSealerEthereumNonce() uint64 {
+	return uint64(g.Nonce)
+}
+
+func (g *BesuGenesis) SetGenesisSealerEthereumNonce(n uint64) error {
+	g.Nonce = math.HexOrDecimal64(n)
+	return nil
+}
+
+func (g *BesuGenesis) GetGenesisSealerEthereumMixHash() common.Hash {
+	return g.MixHash
+}
+
+func (g *BesuGenesis) SetGenesisSealerEthereumMixHash(h common.Hash) error {
+	g.MixHash = h
+	return nil
+}
+
+func (g *BesuGenesis) GetGenesisDifficulty() *big.Int {
+	return (*big.Int)(g.Difficulty)
+}
+
+func (g *BesuGenesis) SetGenesisDifficulty(i *big.Int) error {
+	g.Difficulty = (*math.HexOrDecimal256)(i)
+	return nil
+}
+
+func (g *BesuGenesis) GetGenesisAuthor() common.Address {
+	return g.Coinbase
+}
+
+func (g *BesuGenesis) SetGenesisAuthor(a common.Address) error {
+	g.Coinbase = a
+	return nil
+}
+
+func (g *BesuGenesis) GetGenesisTimestamp() uint64 {
+	return uint64(g.Timestamp)
+}
+
+func (g *BesuGenesis) SetGenesisTimestamp(u uint64) error {
+	g.Timestamp = math.HexOrDecimal64(u)
+	return nil
+}
+
+func (g *BesuGenesis) GetGenesisParentHash() common.Hash {
+	return g.ParentHash
+}
+
+func (g *BesuGenesis) SetGenesisParentHash(h common.Hash) error {
+	g.ParentHash = h
+	return nil
+}
+
+func (g *BesuGenesis) GetGenesisExtraData() []byte {
+	return g.ExtraData
+}
+
+func (g *BesuGenesis) SetGenesisExtraData(b []byte) error {
+	g.ExtraData = b
+	return nil
+}
+
+func (g *BesuGenesis) GetGenesisGasLimit() uint64 {
+	return uint64(g.GasLimit)
+}
+
+func (g *BesuGenesis) SetGenesisGasLimit(u uint64) error {
+	g.GasLimit = math.HexOrDecimal64(u)
+	return nil
+}
+
+func (g *BesuGenesis) ForEachAccount(fn func(address common.Address, bal *big.Int, nonce uint64, code []byte, storage map[common.Hash]common.Hash) error) error {
+	for k, v := range g.Alloc {
+		if err := fn(common.Address(k), (*big.Int)(v.Balance), uint64(v.Nonce), v.Code, v.Storage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *BesuGenesis) UpdateAccount(address common.Address, bal *big.Int, nonce uint64, code []byte, storage map[common.Hash]common.Hash) error {
+	if g.Alloc == nil {
+		g.Alloc = make(map[common.UnprefixedAddress]*BesuGenesisAccount)
+	}
+	g.Alloc[common.UnprefixedAddress(address)] = &BesuGenesisAccount{
+		Balance: (*math.HexOrDecimal256)(bal),
+		Nonce:   math.HexOrDecimal64(nonce),
+		Code:    code,
+		Storage: storage,
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package besu
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params/types/ctypes"
+)
+
+var (
+	errMissingConfig  = errors.New("missing chain configuration")
+	errMissingChainID = errors.New("missing chain id")
+	errMixedForks     = errors.New("both Ethereum and Ethereum Classic forks configured")
+)
+
+// feature is the activation of a single protocol feature.
+type feature struct {
+	name string
+	get  func() *uint64
+	set  func(*uint64) error
+}
+
+// fork is a Besu configuration field, along with the features which it
+// activates. All of the features of a fork must activate at the same block.
+type fork struct {
+	name     string
+	block    **uint64
+	features []feature
+}
+
+// isClassic reports whether a configuration needs to be described with the
+// Ethereum Classic fork names, either because it uses features specific to
+// Ethereum Classic, or because it separates EIP155 from the rest of Spurious
+// Dragon.
+func isClassic(c ctypes.ChainConfigurator) bool {
+	for _, n := range []*uint64{
+		c.GetEthashECIP1010PauseTransition(),
+		c.GetEthashECIP1017Transition(),
+		c.GetEthashECIP1041Transition(),
+		c.GetEthashECIP1099Transition(),
+	} {
+		if n != nil {
+			return true
+		}
+	}
+	return !equal(c.GetEIP155Transition(), c.GetEIP161abcTransition())
+}
+
+// forks returns the forks of the Ethereum or Ethereum Classic schedule of Besu,
+// binding the fields of bc to the features of c. The features which cannot be
+// configured by the chosen schedule are returned separately.
+func forks(bc *BesuConfig, c ctypes.ChainConfigurator, classic bool) (forks []fork, unsupported []feature) {
+	ethash := c.GetConsensusEngineType().IsEthash()
+
+	// withEthash appends the features which only exist for the Ethash engine.
+	withEthash := func(features []feature, ethashFeatures ...feature) []feature {
+		if ethash {
+			return append(features, ethashFeatures...)
+		}
+		return features
+	}
+	homestead := fork{"homesteadBlock", &bc.HomesteadBlock, withEthash([]feature{
+		{"EIP2", c.GetEIP2Transition, c.SetEIP2Transition},
+		{"EIP7", c.GetEIP7Transition, c.SetEIP7Transition},
+	}, feature{"EthashHomestead", c.GetEthashHomesteadTransition, c.SetEthashHomesteadTransition})}
+
+	unsupported = []feature{
+		{"EIP1706", c.GetEIP1706Transition, c.SetEIP1706Transition},
+		{"EIP2200Disable", c.GetEIP2200DisableTransition, c.SetEIP2200DisableTransition},
+		{"ECIP1080", c.GetECIP1080Transition, c.SetECIP1080Transition},
+	}
+	if !classic {
+		forks = []fork{
+			homestead,
+			{"daoForkBlock", &bc.DAOForkBlock, withEthash(nil,
+				feature{"EthashEIP779", c.GetEthashEIP779Transition, c.SetEthashEIP779Transition},
+			)},
+			{"eip150Block", &bc.EIP150Block, []feature{
+				{"EIP150", c.GetEIP150Transition, c.SetEIP150Transition},
+			}},
+			{"eip158Block", &bc.EIP158Block, []feature{
+				{"EIP155", c.GetEIP155Transition, c.SetEIP155Transition},
+				{"EIP160", c.GetEIP160Transition, c.SetEIP160Transition},
+				{"EIP161abc", c.GetEIP161abcTransition, c.SetEIP161abcTransition},
+				{"EIP161d", c.GetEIP161dTransition, c.SetEIP161dTransition},
+				{"EIP170", c.GetEIP170Transition, c.SetEIP170Transition},
+			}},
+			{"byzantiumBlock", &bc.ByzantiumBlock, withEthash([]feature{
+				{"EIP140", c.GetEIP140Transition, c.SetEIP140Transition},
+				{"EIP198", c.GetEIP198Transition, c.SetEIP198Transition},
+				{"EIP211", c.GetEIP211Transition, c.SetEIP211Transition},
+				{"EIP212", c.GetEIP212Transition, c.SetEIP212Transition},
+				{"EIP213", c.GetEIP213Transition, c.SetEIP213Transition},
+				{"EIP214", c.GetEIP214Transition, c.SetEIP214Transition},
+				{"EIP658", c.GetEIP658Transition, c.SetEIP658Transition},
+			},
+				feature{"EthashEIP100B", c.GetEthashEIP100BTransition, c.SetEthashEIP100BTransition},
+				feature{"EthashEIP649", c.GetEthashEIP649Transition, c.SetEthashEIP649Transition},
+			)},
+			{"constantinopleBlock", &bc.ConstantinopleBlock, withEthash([]feature{
+				{"EIP145", c.GetEIP145Transition, c.SetEIP145Transition},
+				{"EIP1014", c.GetEIP1014Transition, c.SetEIP1014Transition},
+				{"EIP1052", c.GetEIP1052Transition, c.SetEIP1052Transition},
+				{"EIP1283", c.GetEIP1283Transition, c.SetEIP1283Transition},
+			},
+				feature{"EthashEIP1234", c.GetEthashEIP1234Transition, c.SetEthashEIP1234Transition},
+			)},
+			{"petersburgBlock", &bc.PetersburgBlock, []feature{
+				{"EIP1283Disable", c.GetEIP1283DisableTransition, c.SetEIP1283DisableTransition},
+			}},
+			{"istanbulBlock", &bc.IstanbulBlock, []feature{
+				{"EIP152", c.GetEIP152Transition, c.SetEIP152Transition},
+				{"EIP1108", c.GetEIP1108Transition, c.SetEIP1108Transition},
+				{"EIP1344", c.GetEIP1344Transition, c.SetEIP1344Transition},
+				{"EIP1884", c.GetEIP1884Transition, c.SetEIP1884Transition},
+				{"EIP2028", c.GetEIP2028Transition, c.SetEIP2028Transition},
+				{"EIP2200", c.GetEIP2200Transition, c.SetEIP2200Transition},
+			}},
+			{"muirGlacierBlock", &bc.MuirGlacierBlock, withEthash(nil,
+				feature{"EthashEIP2384", c.GetEthashEIP2384Transition, c.SetEthashEIP2384Transition},
+			)},
+			{"berlinBlock", &bc.BerlinBlock, []feature{
+				{"EIP2315", c.GetEIP2315Transition, c.SetEIP2315Transition},
+				{"EIP2537", c.GetEIP2537Transition, c.SetEIP2537Transition},
+				{"EIP2929", c.GetEIP2929Transition, c.SetEIP2929Transition},
+				{"EIP2930", c.GetEIP2930Transition, c.SetEIP2930Transition},
+				{"EIP2718", c.GetEIP2718Transition, c.SetEIP2718Transition},
+			}},
+		}
+		return forks, unsupported
+	}
+	forks = []fork{
+		homestead,
+		{"ecip1015Block", &bc.ECIP1015Block, []feature{
+			{"EIP150", c.GetEIP150Transition, c.SetEIP150Transition},
+		}},
+		{"diehardBlock", &bc.DieHardBlock, []feature{
+			{"EIP155", c.GetEIP155Transition, c.SetEIP155Transition},
+			{"EIP160", c.GetEIP160Transition, c.SetEIP160Transition},
+		}},
+		{"gothamBlock", &bc.GothamBlock, withEthash(nil,
+			feature{"EthashECIP1017", c.GetEthashECIP1017Transition, c.SetEthashECIP1017Transition},
+		)},
+		{"ecip1017EraRounds", &bc.ECIP1017EraRounds, withEthash(nil,
+			feature{"EthashECIP1017EraRounds", c.GetEthashECIP1017EraRounds, c.SetEthashECIP1017EraRounds},
+		)},
+		{"ecip1041Block", &bc.ECIP1041Block, withEthash(nil,
+			feature{"EthashECIP1041", c.GetEthashECIP1041Transition, c.SetEthashECIP1041Transition},
+		)},
+		{"atlantisBlock", &bc.AtlantisBlock, withEthash([]feature{
+			{"EIP161abc", c.GetEIP161abcTransition, c.SetEIP161abcTransition},
+			{"EIP161d", c.GetEIP161dTransition, c.SetEIP161dTransition},
+			{"EIP170", c.GetEIP170Transition, c.SetEIP170Transition},
+			{"EIP140", c.GetEIP140Transition, c.SetEIP140Transition},
+			{"EIP198", c.GetEIP198Transition, c.SetEIP198Transition},
+			{"EIP211", c.GetEIP211Transition, c.SetEIP211Transition},
+			{"EIP212", c.GetEIP212Transition, c.SetEIP212Transition},
+			{"EIP213", c.GetEIP213Transition, c.SetEIP213Transition},
+			{"EIP214", c.GetEIP214Transition, c.SetEIP214Transition},
+			{"EIP658", c.GetEIP658Transition, c.SetEIP658Transition},
+		},
+			feature{"EthashEIP100B", c.GetEthashEIP100BTransition, c.SetEthashEIP100BTransition},
+		)},
+		{"aghartaBlock", &bc.AghartaBlock, []feature{
+			{"EIP145", c.GetEIP145Transition, c.SetEIP145Transition},
+			{"EIP1014", c.GetEIP1014Transition, c.SetEIP1014Transition},
+			{"EIP1052", c.GetEIP1052Transition, c.SetEIP1052Transition},
+		}},
+		{"phoenixBlock", &bc.PhoenixBlock, []feature{
+			{"EIP152", c.GetEIP152Transition, c.SetEIP152Transition},
+			{"EIP1108", c.GetEIP1108Transition, c.SetEIP1108Transition},
+			{"EIP1344", c.GetEIP1344Transition, c.SetEIP1344Transition},
+			{"EIP1884", c.GetEIP1884Transition, c.SetEIP1884Transition},
+			{"EIP2028", c.GetEIP2028Transition, c.SetEIP2028Transition},
+			{"EIP2200", c.GetEIP2200Transition, c.SetEIP2200Transition},
+		}},
+		{"thanosBlock", &bc.ThanosBlock, withEthash(nil,
+			feature{"EthashECIP1099", c.GetEthashECIP1099Transition, c.SetEthashECIP1099Transition},
+		)},
+	}
+	unsupported = append(unsupported, withEthash([]feature{
+		{"EIP1283", c.GetEIP1283Transition, c.SetEIP1283Transition},
+		{"EIP1283Disable", c.GetEIP1283DisableTransition, c.SetEIP1283DisableTransition},
+		{"EIP2315", c.GetEIP2315Transition, c.SetEIP2315Transition},
+		{"EIP2537", c.GetEIP2537Transition, c.SetEIP2537Transition},
+		{"EIP2929", c.GetEIP2929Transition, c.SetEIP2929Transition},
+		{"EIP2930", c.GetEIP2930Transition, c.SetEIP2930Transition},
+		{"EIP2718", c.GetEIP2718Transition, c.SetEIP2718Transition},
+	},
+		feature{"EthashEIP779", c.GetEthashEIP779Transition, c.SetEthashEIP779Transition},
+		feature{"EthashEIP649", c.GetEthashEIP649Transition, c.SetEthashEIP649Transition},
+		feature{"EthashEIP1234", c.GetEthashEIP1234Transition, c.SetEthashEIP1234Transition},
+		feature{"EthashEIP2384", c.GetEthashEIP2384Transition, c.SetEthashEIP2384Transition},
+	)...)
+	return forks, unsupported
+}
+
+// bombPaused reports whether the difficulty bomb is still active at the given
+// fork block, so the ECIP1010 pause and continuation configured by the DieHard
+// and Gotham forks take effect.
+func bombPaused(block, ecip1041 *uint64) bool {
+	return block != nil && (ecip1041 == nil || *ecip1041 > *block)
+}
+
+// encodeConfig translates the features of a chain configuration to the forks of
+// a Besu configuration.
+func encodeConfig(c ctypes.ChainConfigurator) (*BesuConfig, error) {
+	if c.GetChainID() == nil {
+		return nil, errMissingChainID
+	}
+	bc := &BesuConfig{ChainID: new(big.Int).Set(c.GetChainID())}
+
+	switch engine := c.GetConsensusEngineType(); engine {
+	case ctypes.ConsensusEngineT_Ethash:
+		bc.Ethash = &BesuEthashConfig{}
+	case ctypes.ConsensusEngineT_Clique:
+		bc.Clique = &BesuCliqueConfig{
+			BlockPeriodSeconds: c.GetCliquePeriod(),
+			EpochLength:        c.GetCliqueEpoch(),
+		}
+	default:
+		return nil, ctypes.UnsupportedConfigError(ctypes.ErrUnsupportedConfigFatal, "consensus engine", engine)
+	}
+	classic := isClassic(c)
+	schedule, unsupported := forks(bc, c, classic)
+	for _, f := range unsupported {
+		if n := f.get(); n != nil {
+			return nil, ctypes.UnsupportedConfigError(ctypes.ErrUnsupportedConfigFatal, f.name, *n)
+		}
+	}
+	for _, fk := range schedule {
+		if len(fk.features) == 0 {
+			continue
+		}
+		first := fk.features[0]
+		n := first.get()
+		for _, f := range fk.features[1:] {
+			if m := f.get(); !equal(n, m) {
+				return nil, fmt.Errorf("%s: %s transition %s differs from %s transition %s", fk.name, f.name, str(m), first.name, str(n))
+			}
+		}
+		*fk.block = n
+	}
+	if classic {
+		// The ECIP1010 difficulty bomb pause is implied by the DieHard and Gotham forks
+		pause, cont := c.GetEthashECIP1010PauseTransition(), c.GetEthashECIP1010ContinueTransition()
+		if pause != nil && !equal(pause, bc.DieHardBlock) {
+			return nil, fmt.Errorf("diehardBlock: EthashECIP1010Pause transition %s differs from %s", str(pause), str(bc.DieHardBlock))
+		}
+		if cont != nil && !equal(cont, bc.GothamBlock) {
+			return nil, fmt.Errorf("gothamBlock: EthashECIP1010Continue transition %s differs from %s", str(cont), str(bc.GothamBlock))
+		}
+		return bc, nil
+	}
+	if bc.EIP150Block != nil {
+		if h := c.GetForkCanonHash(*bc.EIP150Block); h != (common.Hash{}) {
+			bc.EIP150Hash = &h
+		}
+	}
+	return bc, nil
+}
+
+// decodeConfig translates the forks of a Besu configuration to the features of
+// a chain configuration.
+func decodeConfig(bc *BesuConfig, c ctypes.ChainConfigurator) error {
+	if bc.ChainID == nil {
+		return errMissingChainID
+	}
+	if err := c.SetChainID(new(big.Int).Set(bc.ChainID)); err != nil {
+		return err
+	}
+	// Besu takes the network id from the command line, defaulting to the chain id
+	networkID := bc.ChainID.Uint64()
+	if err := c.SetNetworkID(&networkID); err != nil {
+		return err
+	}
+	switch {
+	case bc.Clique != nil:
+		if err := c.MustSetConsensusEngineType(ctypes.ConsensusEngineT_Clique); err != nil {
+			return err
+		}
+		if err := c.SetCliquePeriod(bc.Clique.BlockPeriodSeconds); err != nil {
+			return err
+		}
+		if err := c.SetCliqueEpoch(bc.Clique.EpochLength); err != nil {
+			return err
+		}
+	default:
+		if bc.Ethash != nil && bc.Ethash.FixedDifficulty != nil {
+			return ctypes.UnsupportedConfigError(ctypes.ErrUnsupportedConfigFatal, "fixeddifficulty", *bc.Ethash.FixedDifficulty)
+		}
+		if err := c.MustSetConsensusEngineType(ctypes.ConsensusEngineT_Ethash); err != nil {
+			return err
+		}
+	}
+	classic := bc.classic()
+	if classic {
+		ethereum, _ := forks(bc, c, false)
+		for _, fk := range ethereum[1:] {
+			if *fk.block != nil {
+				return fmt.Errorf("%w: %s", errMixedForks, fk.name)
+			}
+		}
+	}
+	schedule, _ := forks(bc, c, classic)
+	for _, fk := range schedule {
+		for _, f := range fk.features {
+			if err := f.set(*fk.block); err != nil {
+				return ctypes.UnsupportedConfigError(err, f.name, str(*fk.block))
+			}
+		}
+	}
+	if !classic {
+		if bc.EIP150Block != nil && bc.EIP150Hash != nil {
+			return c.SetForkCanonHash(*bc.EIP150Block, *bc.EIP150Hash)
+		}
+		return nil
+	}
+	if c.GetConsensusEngineType().IsEthash() {
+		if bombPaused(bc.DieHardBlock, bc.ECIP1041Block) {
+			if err := c.SetEthashECIP1010PauseTransition(bc.DieHardBlock); err != nil {
+				return err
+			}
+		}
+		if bombPaused(bc.GothamBlock, bc.ECIP1041Block) {
+			if err := c.SetEthashECIP1010ContinueTransition(bc.GothamBlock); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// equal reports whether two optional block numbers are the same.
+func equal(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// str formats an optional block number.
+func str(n *uint64) string {
+	if n == nil {
+		return "nil"
+	}
+	return fmt.Sprint(*n)
+}
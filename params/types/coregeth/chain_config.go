@@ -176,6 +176,10 @@ type CoreGethChainConfig struct {
 	ECIP1099FBlock *big.Int `json:"ecip1099FBlock,omitempty"` // ECIP1099 etchash HF block
 	ECBP1100FBlock *big.Int `json:"ecbp1100FBlock,omitempty"` // ECBP1100:MESS artificial finality
 
+	// ECBP1100CurveSchedule schedules revisions of the ECBP1100 antigravity curve by block number.
+	// ECBP1100 uses ctypes.ECBP1100CurveV until the first scheduled revision.
+	ECBP1100CurveSchedule ctypes.ECBP1100CurveSchedule `json:"ecbp1100CurveSchedule,omitempty"`
+
 	// EIP-2315: Simple Subroutines
 	// https://eips.ethereum.org/EIPS/eip-2315
 	EIP2315FBlock *big.Int `json:"eip2315FBlock,omitempty"`
@@ -184,6 +188,14 @@ type CoreGethChainConfig struct {
 	// https://eips.ethereum.org/EIPS/eip-2929
 	EIP2929FBlock *big.Int `json:"eip2929FBlock,omitempty"`
 
+	// EIP-2930: Optional access lists
+	// https://eips.ethereum.org/EIPS/eip-2930
+	EIP2930FBlock *big.Int `json:"eip2930FBlock,omitempty"`
+
+	// EIP-2718: Typed Transaction Envelope
+	// https://eips.ethereum.org/EIPS/eip-2718
+	EIP2718FBlock *big.Int `json:"eip2718FBlock,omitempty"`
+
 	DisposalBlock    *big.Int `json:"disposalBlock,omitempty"`    // Bomb disposal HF block
 	SocialBlock      *big.Int `json:"socialBlock,omitempty"`      // Ethereum Social Reward block
 	EthersocialBlock *big.Int `json:"ethersocialBlock,omitempty"` // Ethersocial Reward block
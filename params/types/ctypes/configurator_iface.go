@@ -132,10 +132,16 @@ type ProtocolSpecifier interface {
 	SetEIP2537Transition(n *uint64) error
 	GetECBP1100Transition() *uint64
 	SetECBP1100Transition(n *uint64) error
+	GetECBP1100CurveSchedule() ECBP1100CurveSchedule
+	SetECBP1100CurveSchedule(m ECBP1100CurveSchedule) error
 	GetEIP2315Transition() *uint64
 	SetEIP2315Transition(n *uint64) error
 	GetEIP2929Transition() *uint64
 	SetEIP2929Transition(n *uint64) error
+	GetEIP2930Transition() *uint64
+	SetEIP2930Transition(n *uint64) error
+	GetEIP2718Transition() *uint64
+	SetEIP2718Transition(n *uint64) error
 }
 
 type Forker interface {
@@ -299,6 +305,8 @@ func GetProtocolRules(cc ChainConfigurator, blockNum *big.Int) ProtocolRules {
 		ECBP1100Transition_Enabled:       cc.IsEnabled(cc.GetECBP1100Transition, blockNum),
 		EIP2315Transition_Enabled:        cc.IsEnabled(cc.GetEIP2315Transition, blockNum),
 		EIP2929Transition_Enabled:        cc.IsEnabled(cc.GetEIP2929Transition, blockNum),
+		EIP2930Transition_Enabled:        cc.IsEnabled(cc.GetEIP2930Transition, blockNum),
+		EIP2718Transition_Enabled:        cc.IsEnabled(cc.GetEIP2718Transition, blockNum),
 	}
 }
 
@@ -348,4 +356,6 @@ type ProtocolRules struct {
 	ECBP1100Transition_Enabled       bool
 	EIP2315Transition_Enabled        bool
 	EIP2929Transition_Enabled        bool
+	EIP2930Transition_Enabled        bool
+	EIP2718Transition_Enabled        bool
 }
@@ -0,0 +1,82 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ctypes
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ECBP1100Curve parameterizes the polynomial antigravity curve of ECBP1100 (MESS).
+//
+// The curve rises as a rescaled `3*x**2 - 2*x**3` from an antigravity of 1 at x = 0
+// to an antigravity of Ceiling at x = TimeWindow, after which it stays flat.
+// Antigravity values are computed as integer fractions over Denominator.
+type ECBP1100Curve struct {
+	Denominator uint64 `json:"denominator"` // CURVE_FUNCTION_DENOMINATOR
+	Ceiling     uint64 `json:"ceiling"`     // Maximum antigravity, ie. 1 + 2*ampl
+	TimeWindow  uint64 `json:"timeWindow"`  // Seconds until the ceiling is reached, ie. xcap
+}
+
+// ECBP1100CurveV is the curve specified by ECBP1100, and used when no other
+// curve is configured.
+// https://github.com/ethereumclassic/ECIPs/issues/374#issuecomment-694156719
+var ECBP1100CurveV = ECBP1100Curve{
+	Denominator: 128,
+	Ceiling:     31,
+	TimeWindow:  25132, // floor(8000*pi)
+}
+
+var errInvalidECBP1100Curve = errors.New("invalid ECBP1100 curve")
+
+// Validate returns an error if the curve cannot be evaluated.
+func (c ECBP1100Curve) Validate() error {
+	if c.Denominator == 0 || c.Ceiling == 0 || c.TimeWindow == 0 {
+		return errInvalidECBP1100Curve
+	}
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, rejecting curves
+// which cannot be evaluated.
+func (c *ECBP1100Curve) UnmarshalJSON(input []byte) error {
+	type curve ECBP1100Curve
+	var dec curve
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if err := ECBP1100Curve(dec).Validate(); err != nil {
+		return err
+	}
+	*c = ECBP1100Curve(dec)
+	return nil
+}
+
+// ECBP1100CurveSchedule maps block numbers to the curves activated at them.
+type ECBP1100CurveSchedule map[uint64]ECBP1100Curve
+
+// CurveAt returns the curve in effect at block number n, which is the curve
+// with the greatest activation block not above n, or ECBP1100CurveV if none is.
+func (s ECBP1100CurveSchedule) CurveAt(n uint64) ECBP1100Curve {
+	curve, activation, found := ECBP1100CurveV, uint64(0), false
+	for k, v := range s {
+		if k <= n && (!found || k > activation) {
+			curve, activation, found = v, k, true
+		}
+	}
+	return curve
+}
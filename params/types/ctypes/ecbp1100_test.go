@@ -0,0 +1,61 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package ctypes
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestECBP1100CurveSchedule_CurveAt(t *testing.T) {
+	a := ECBP1100Curve{Denominator: 64, Ceiling: 11, TimeWindow: 1000}
+	b := ECBP1100Curve{Denominator: 256, Ceiling: 61, TimeWindow: 50000}
+	s := ECBP1100CurveSchedule{100: a, 200: b}
+
+	cases := []struct {
+		n    uint64
+		want ECBP1100Curve
+	}{
+		{0, ECBP1100CurveV},
+		{99, ECBP1100CurveV},
+		{100, a},
+		{199, a},
+		{200, b},
+		{1e9, b},
+	}
+	for _, c := range cases {
+		if got := s.CurveAt(c.n); got != c.want {
+			t.Errorf("block %d: have %v, want %v", c.n, got, c.want)
+		}
+	}
+	if got := ECBP1100CurveSchedule(nil).CurveAt(100); got != ECBP1100CurveV {
+		t.Errorf("nil schedule: have %v, want %v", got, ECBP1100CurveV)
+	}
+}
+
+func TestECBP1100CurveSchedule_UnmarshalJSON(t *testing.T) {
+	var s ECBP1100CurveSchedule
+	if err := json.Unmarshal([]byte(`{"100":{"denominator":64,"ceiling":11,"timeWindow":1000}}`), &s); err != nil {
+		t.Fatal(err)
+	}
+	if want := (ECBP1100Curve{Denominator: 64, Ceiling: 11, TimeWindow: 1000}); s[100] != want {
+		t.Errorf("have %v, want %v", s[100], want)
+	}
+	if err := json.Unmarshal([]byte(`{"100":{"denominator":64,"ceiling":0,"timeWindow":1000}}`), &s); err == nil {
+		t.Error("expected error for invalid curve")
+	}
+}
@@ -560,6 +560,22 @@ func (g *Genesis) SetEIP2929Transition(n *uint64) error {
 	return g.Config.SetEIP2929Transition(n)
 }
 
+func (g *Genesis) GetEIP2930Transition() *uint64 {
+	return g.Config.GetEIP2930Transition()
+}
+
+func (g *Genesis) SetEIP2930Transition(n *uint64) error {
+	return g.Config.SetEIP2930Transition(n)
+}
+
+func (g *Genesis) GetEIP2718Transition() *uint64 {
+	return g.Config.GetEIP2718Transition()
+}
+
+func (g *Genesis) SetEIP2718Transition(n *uint64) error {
+	return g.Config.SetEIP2718Transition(n)
+}
+
 func (g *Genesis) GetECBP1100Transition() *uint64 {
 	return g.Config.GetECBP1100Transition()
 }
@@ -568,6 +584,14 @@ func (g *Genesis) SetECBP1100Transition(n *uint64) error {
 	return g.Config.SetECBP1100Transition(n)
 }
 
+func (g *Genesis) GetECBP1100CurveSchedule() ctypes.ECBP1100CurveSchedule {
+	return g.Config.GetECBP1100CurveSchedule()
+}
+
+func (g *Genesis) SetECBP1100CurveSchedule(m ctypes.ECBP1100CurveSchedule) error {
+	return g.Config.SetECBP1100CurveSchedule(m)
+}
+
 func (g *Genesis) IsEnabled(fn func() *uint64, n *big.Int) bool {
 	return g.Config.IsEnabled(fn, n)
 }
@@ -72,7 +72,8 @@ type ChainConfig struct {
 	ECIP1080Transition *big.Int `json:"-"`
 
 	// Cache types for use with testing, but will not show up in config API.
-	ecbp1100Transition *big.Int
+	ecbp1100Transition    *big.Int
+	ecbp1100CurveSchedule ctypes.ECBP1100CurveSchedule
 }
 
 // String implements the fmt.Stringer interface.
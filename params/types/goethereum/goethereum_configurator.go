@@ -400,6 +400,20 @@ func (c *ChainConfig) SetECBP1100Transition(n *uint64) error {
 	return nil
 }
 
+func (c *ChainConfig) GetECBP1100CurveSchedule() ctypes.ECBP1100CurveSchedule {
+	return c.ecbp1100CurveSchedule
+}
+
+func (c *ChainConfig) SetECBP1100CurveSchedule(m ctypes.ECBP1100CurveSchedule) error {
+	for _, v := range m {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+	c.ecbp1100CurveSchedule = m
+	return nil
+}
+
 func (c *ChainConfig) GetEIP2315Transition() *uint64 {
 	return bigNewU64(c.YoloV2Block)
 }
@@ -418,6 +432,24 @@ func (c *ChainConfig) SetEIP2929Transition(n *uint64) error {
 	return nil
 }
 
+func (c *ChainConfig) GetEIP2930Transition() *uint64 {
+	return bigNewU64(c.YoloV2Block)
+}
+
+func (c *ChainConfig) SetEIP2930Transition(n *uint64) error {
+	c.YoloV2Block = setBig(c.YoloV2Block, n)
+	return nil
+}
+
+func (c *ChainConfig) GetEIP2718Transition() *uint64 {
+	return bigNewU64(c.YoloV2Block)
+}
+
+func (c *ChainConfig) SetEIP2718Transition(n *uint64) error {
+	c.YoloV2Block = setBig(c.YoloV2Block, n)
+	return nil
+}
+
 func (c *ChainConfig) IsEnabled(fn func() *uint64, n *big.Int) bool {
 	f := fn()
 	if f == nil || n == nil {
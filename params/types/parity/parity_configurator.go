@@ -635,6 +635,17 @@ func (spec *ParityChainSpec) SetECBP1100Transition(n *uint64) error {
 	return ctypes.ErrUnsupportedConfigFatal
 }
 
+func (spec *ParityChainSpec) GetECBP1100CurveSchedule() ctypes.ECBP1100CurveSchedule {
+	return nil
+}
+
+func (spec *ParityChainSpec) SetECBP1100CurveSchedule(m ctypes.ECBP1100CurveSchedule) error {
+	if len(m) == 0 {
+		return nil
+	}
+	return ctypes.ErrUnsupportedConfigNoop
+}
+
 func (spec *ParityChainSpec) IsEnabled(fn func() *uint64, n *big.Int) bool {
 	f := fn()
 	if f == nil || n == nil {
@@ -1005,6 +1016,28 @@ func (spec *ParityChainSpec) SetEIP2929Transition(n *uint64) error {
 	return ctypes.ErrUnsupportedConfigFatal
 }
 
+func (spec *ParityChainSpec) GetEIP2930Transition() *uint64 {
+	return nil
+}
+
+func (spec *ParityChainSpec) SetEIP2930Transition(n *uint64) error {
+	if n == nil {
+		return nil
+	}
+	return ctypes.ErrUnsupportedConfigFatal
+}
+
+func (spec *ParityChainSpec) GetEIP2718Transition() *uint64 {
+	return nil
+}
+
+func (spec *ParityChainSpec) SetEIP2718Transition(n *uint64) error {
+	if n == nil {
+		return nil
+	}
+	return ctypes.ErrUnsupportedConfigFatal
+}
+
 func (spec *ParityChainSpec) GetEthashDifficultyBombDelaySchedule() ctypes.Uint64BigMapEncodesHex {
 	if spec.GetConsensusEngineType() != ctypes.ConsensusEngineT_Ethash {
 		return nil
@@ -1175,7 +1208,7 @@ func (spec *ParityChainSpec) UpdateAccount(address common.Address, bal *big.Int,
 	if !ok {
 		spec.Accounts[addr] = &ParityChainSpecAccount{}
 	}
-	spec.Accounts[addr].Balance = *math.NewHexOrDecimal256(bal.Int64())
+	spec.Accounts[addr].Balance = math.HexOrDecimal256(*new(big.Int).Set(bal))
 	spec.Accounts[addr].Nonce = math.HexOrDecimal64(nonce)
 
 	zero := uint64(0)
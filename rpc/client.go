@@ -77,6 +77,7 @@ type Client struct {
 	idgen    func() ID // for subscriptions
 	isHTTP   bool
 	services *serviceRegistry
+	connCtx  context.Context // parent context of the connection handlers
 
 	idCounter uint32
 
@@ -104,13 +105,32 @@ type reconnectFunc func(ctx context.Context) (ServerCodec, error)
 
 type clientContextKey struct{}
 
+// methodFilterKey is the context key of the filter restricting the methods
+// callable on a connection.
+type methodFilterKey struct{}
+
+// ContextWithMethodFilter returns a copy of ctx restricting the methods that
+// requests served with it may call to those accepted by allow, the others are
+// rejected with an unauthorized error. WebSocket connections inherit the filter
+// of the HTTP request they were upgraded from.
+func ContextWithMethodFilter(ctx context.Context, allow func(method string) bool) context.Context {
+	return context.WithValue(ctx, methodFilterKey{}, allow)
+}
+
+// methodFilter returns the method filter of ctx, or nil if all methods may be
+// called.
+func methodFilter(ctx context.Context) func(method string) bool {
+	allow, _ := ctx.Value(methodFilterKey{}).(func(method string) bool)
+	return allow
+}
+
 type clientConn struct {
 	codec   ServerCodec
 	handler *handler
 }
 
 func (c *Client) newClientConn(conn ServerCodec) *clientConn {
-	ctx := context.WithValue(context.Background(), clientContextKey{}, c)
+	ctx := context.WithValue(c.connCtx, clientContextKey{}, c)
 	handler := newHandler(ctx, conn, c.idgen, c.services)
 	return &clientConn{conn, handler}
 }
@@ -197,14 +217,15 @@ func newClient(initctx context.Context, connect reconnectFunc) (*Client, error)
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), new(serviceRegistry))
+	c := initClient(context.Background(), conn, randomIDGenerator(), new(serviceRegistry))
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
+func initClient(connCtx context.Context, conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
 	_, isHTTP := conn.(*httpConn)
 	c := &Client{
+		connCtx:     connCtx,
 		idgen:       idgen,
 		isHTTP:      isHTTP,
 		services:    services,
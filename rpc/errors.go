@@ -25,6 +25,11 @@ var (
 	_ Error = new(invalidRequestError)
 	_ Error = new(invalidMessageError)
 	_ Error = new(invalidParamsError)
+	_ Error = new(unauthorizedError)
+	_ Error = new(responseTooLargeError)
+	_ Error = new(batchTooLargeError)
+	_ Error = new(rateLimitError)
+	_ Error = new(concurrencyLimitError)
 )
 
 const defaultErrorCode = -32000
@@ -72,3 +77,48 @@ type invalidParamsError struct{ message string }
 func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
+
+// the caller isn't allowed to call the method
+type unauthorizedError struct{ method string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32002 }
+
+func (e *unauthorizedError) Error() string {
+	return fmt.Sprintf("the method %s is not authorized", e.method)
+}
+
+// the results of a response exceed the response size limit
+type responseTooLargeError struct{ limit int }
+
+func (e *responseTooLargeError) ErrorCode() int { return -32003 }
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response too large, limit is %d bytes", e.limit)
+}
+
+// the batch holds more requests than the batch size limit
+type batchTooLargeError struct{ items, limit int }
+
+func (e *batchTooLargeError) ErrorCode() int { return -32004 }
+
+func (e *batchTooLargeError) Error() string {
+	return fmt.Sprintf("batch of %d requests too large, limit is %d", e.items, e.limit)
+}
+
+// the caller exhausted its request budget
+type rateLimitError struct{ method string }
+
+func (e *rateLimitError) ErrorCode() int { return -32005 }
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("request rate limit exceeded calling %s", e.method)
+}
+
+// the caller runs too many calls at once
+type concurrencyLimitError struct{ limit int }
+
+func (e *concurrencyLimitError) ErrorCode() int { return -32006 }
+
+func (e *concurrencyLimitError) Error() string {
+	return fmt.Sprintf("too many concurrent calls, limit is %d", e.limit)
+}
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/tracing"
 )
 
 // handler handles JSON-RPC messages. There is one handler per connection. Note that
@@ -61,14 +62,16 @@ type handler struct {
 	conn           jsonWriter                     // where responses will be sent
 	log            log.Logger
 	allowSubscribe bool
+	limits         *connLimits // nil if the calls are unlimited
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
 }
 
 type callProc struct {
-	ctx       context.Context
-	notifiers []*Notifier
+	ctx           context.Context
+	notifiers     []*Notifier
+	responseBytes int // size of the results answered so far
 }
 
 func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry) *handler {
@@ -82,6 +85,7 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 		rootCtx:        rootCtx,
 		cancelRoot:     cancelRoot,
 		allowSubscribe: true,
+		limits:         limitsFromContext(connCtx),
 		serverSubs:     make(map[ID]*Subscription),
 		log:            log.Root(),
 	}
@@ -101,6 +105,13 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 		})
 		return
 	}
+	if h.limits != nil && h.limits.BatchItems > 0 && len(msgs) > h.limits.BatchItems {
+		rpcLimitCounter.With("batch").Inc(1)
+		h.startCallProc(func(cp *callProc) {
+			h.conn.writeJSON(cp.ctx, errorMessage(&batchTooLargeError{len(msgs), h.limits.BatchItems}))
+		})
+		return
+	}
 
 	// Handle non-call messages first:
 	calls := make([]*jsonrpcMessage, 0, len(msgs))
@@ -114,6 +125,10 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	}
 	// Process calls on a goroutine because they may block indefinitely:
 	h.startCallProc(func(cp *callProc) {
+		var span *tracing.Span
+		cp.ctx, span = tracing.StartServerSpan(cp.ctx, "rpc.batch", tracing.Int64("rpc.batch.size", int64(len(calls))))
+		defer span.End()
+
 		answers := make([]*jsonrpcMessage, 0, len(msgs))
 		for _, msg := range calls {
 			if answer := h.handleCallMsg(cp, msg); answer != nil {
@@ -295,7 +310,11 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 		h.log.Debug("Served "+msg.Method, "t", time.Since(start))
 		return nil
 	case msg.isCall():
-		resp := h.handleCall(ctx, msg)
+		if h.responseLimitReached(ctx) {
+			// Don't run the remaining calls of a batch whose response is full
+			return msg.errorResponse(&responseTooLargeError{h.limits.ResponseBytes})
+		}
+		resp := h.limitResponse(ctx, msg, h.handleCall(ctx, msg))
 		var ctx []interface{}
 		ctx = append(ctx, "reqid", idForLog{msg.ID}, "t", time.Since(start))
 		if resp.Error != nil {
@@ -317,6 +336,16 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if allow := methodFilter(cp.ctx); allow != nil && !allow(msg.Method) {
+		return msg.errorResponse(&unauthorizedError{method: msg.Method})
+	}
+	if h.limits != nil && !msg.isUnsubscribe() {
+		release, err := h.limits.acquire(h.limits.client, msg.Method)
+		if err != nil {
+			return msg.errorResponse(err)
+		}
+		defer release()
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -334,7 +363,16 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	ctx, span := tracing.StartServerSpan(cp.ctx, msg.Method,
+		tracing.String("rpc.system", "jsonrpc"),
+		tracing.String("rpc.method", msg.Method),
+	)
+	answer := h.runMethod(ctx, msg, callb, args)
+	if answer.Error != nil {
+		span.SetAttributes(tracing.Int64("rpc.jsonrpc.error_code", int64(answer.Error.Code)))
+		span.RecordError(answer.Error)
+	}
+	span.End()
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -351,6 +389,26 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	return answer
 }
 
+// responseLimitReached reports whether the results answered by a call
+// procedure already exceed the response size limit.
+func (h *handler) responseLimitReached(cp *callProc) bool {
+	return h.limits != nil && h.limits.ResponseBytes > 0 && cp.responseBytes > h.limits.ResponseBytes
+}
+
+// limitResponse accounts the result of a response to its call procedure,
+// replacing it with an error if it exceeds the response size limit.
+func (h *handler) limitResponse(cp *callProc, msg *jsonrpcMessage, resp *jsonrpcMessage) *jsonrpcMessage {
+	if h.limits == nil || h.limits.ResponseBytes <= 0 {
+		return resp
+	}
+	cp.responseBytes += len(resp.Result)
+	if cp.responseBytes > h.limits.ResponseBytes {
+		rpcLimitCounter.With("response").Inc(1)
+		return msg.errorResponse(&responseTooLargeError{h.limits.ResponseBytes})
+	}
+	return resp
+}
+
 // handleSubscribe processes *_subscribe method calls.
 func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
 	if !h.allowSubscribe {
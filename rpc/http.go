@@ -29,6 +29,8 @@ import (
 	"net/url"
 	"sync"
 	"time"
+
+	"github.com/ethereum/go-ethereum/tracing"
 )
 
 const (
@@ -187,6 +189,9 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	hc.mu.Lock()
 	req.Header = hc.headers.Clone()
 	hc.mu.Unlock()
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		req.Header.Set("traceparent", span.SpanContext().TraceParent())
+	}
 
 	// do request
 	resp, err := hc.client.Do(req)
@@ -247,6 +252,9 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if origin := r.Header.Get("Origin"); origin != "" {
 		ctx = context.WithValue(ctx, "Origin", origin)
 	}
+	// Continue the caller's trace, if any
+	ctx = tracing.ContextWithTraceParent(ctx, r.Header.Get("traceparent"))
+	ctx = s.contextWithLimits(ctx, clientKey(r))
 
 	w.Header().Set("content-type", contentType)
 	codec := newHTTPServerConn(r, w)
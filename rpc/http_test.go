@@ -17,10 +17,16 @@
 package rpc
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/tracing"
 )
 
 func confirmStatusCode(t *testing.T, got, want int) {
@@ -98,3 +104,228 @@ func confirmHTTPRequestYieldsStatusCode(t *testing.T, method, contentType, body
 func TestHTTPResponseWithEmptyGet(t *testing.T) {
 	confirmHTTPRequestYieldsStatusCode(t, http.MethodGet, "", "", http.StatusOK)
 }
+
+func TestHTTPTracing(t *testing.T) {
+	// Collect the spans exported by the server in a stand-in collector
+	type span struct {
+		TraceID      string `json:"traceId"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+		Status       struct {
+			Code int `json:"code"`
+		} `json:"status"`
+	}
+	var (
+		lock  sync.Mutex
+		spans = make(map[string]span)
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []span `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans[s.Name] = s
+				}
+			}
+		}
+	}))
+	defer collector.Close()
+
+	config := tracing.DefaultConfig
+	config.Endpoint = collector.URL
+	if err := tracing.Start(config); err != nil {
+		t.Fatal(err)
+	}
+	defer tracing.Stop()
+
+	server := newTestServer()
+	defer server.Stop()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetHeader("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+		t.Fatal(err)
+	}
+	batch := []BatchElem{{Method: "test_returnError"}}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	tracing.Flush()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	echo, ok := spans["test_echo"]
+	if !ok {
+		t.Fatalf("call span missing, have %v", spans)
+	}
+	if echo.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || echo.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("call span not continuing the remote trace: %+v", echo)
+	}
+	batchSpan, ok := spans["rpc.batch"]
+	if !ok {
+		t.Fatalf("batch span missing, have %v", spans)
+	}
+	failed, ok := spans["test_returnError"]
+	if !ok {
+		t.Fatalf("batch call span missing, have %v", spans)
+	}
+	if failed.ParentSpanID != batchSpan.SpanID || failed.Status.Code != 2 {
+		t.Errorf("batch call span mismatch: %+v", failed)
+	}
+}
+
+func TestHTTPLimits(t *testing.T) {
+	server := newTestServer()
+	server.SetLimits(Limits{
+		BatchItems:    2,
+		ResponseBytes: 100,
+		RequestRate:   0.001,
+		RequestBurst:  5,
+		MethodCosts:   map[string]int{"test_echo": 2},
+	})
+	defer server.Stop()
+
+	// Attribute the requests to the clients named by a header, standing in for
+	// an authenticating handler.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.Header.Get("X-Client"); id != "" {
+			r = r.WithContext(ContextWithClientID(r.Context(), id))
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	// post sends a request body as the given client, returning the error codes
+	// of the responses.
+	post := func(client, body string) []int {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		req.Header.Set("content-type", contentType)
+		if client != "" {
+			req.Header.Set("X-Client", client)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var raw json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+			t.Fatal(err)
+		}
+		msgs, _ := parseMessage(raw)
+		codes := make([]int, len(msgs))
+		for i, msg := range msgs {
+			if msg.Error != nil {
+				codes[i] = msg.Error.Code
+			}
+		}
+		return codes
+	}
+	echo := func(id int, str string) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"test_echo","params":["%s",1,{"S":"x"}]}`, id, str)
+	}
+	rets := func(id int) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"test_rets"}`, id)
+	}
+	check := func(name string, got []int, want ...int) {
+		t.Helper()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: error codes %v, want %v", name, got, want)
+		}
+	}
+
+	// Batches over the limit are rejected as a whole
+	check("batch", post("a", "["+rets(1)+","+rets(2)+","+rets(3)+"]"), -32004)
+
+	// Results past the response limit are replaced, later calls aren't run
+	long := strings.Repeat("x", 60)
+	check("response", post("b", "["+echo(1, long)+","+echo(2, long)+"]"), 0, -32003)
+	check("single response", post("c", "["+echo(1, strings.Repeat("x", 120))+"]"), -32003)
+
+	// The request budget is spent per client at the cost of each method
+	check("budget", post("d", "["+echo(1, "a")+","+echo(2, "a")+"]"), 0, 0)
+	check("budget spent", post("d", "["+rets(1)+","+rets(2)+"]"), 0, -32005)
+	check("other client", post("e", "["+echo(1, "a")+"]"), 0)
+}
+
+func TestHTTPLimitsUnverifiedToken(t *testing.T) {
+	server := newTestServer()
+	server.SetLimits(Limits{RequestRate: 0.001, RequestBurst: 1})
+	defer server.Stop()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// Rotating an unverified bearer token must not reset the budget of the IP
+	for i, want := range []int{0, -32005, -32005} {
+		body := `{"jsonrpc":"2.0","id":1,"method":"test_rets"}`
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		req.Header.Set("content-type", contentType)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer token%d", i))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg jsonrpcMessage
+		err = json.NewDecoder(resp.Body).Decode(&msg)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		code := 0
+		if msg.Error != nil {
+			code = msg.Error.Code
+		}
+		if code != want {
+			t.Errorf("request %d: error code %d, want %d", i, code, want)
+		}
+	}
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	l := newLimiter(Limits{ConcurrentCalls: 2})
+
+	release1, err := l.acquire("ip:1", "test_sleep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire("ip:1", "test_sleep"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire("ip:1", "test_sleep"); err == nil {
+		t.Fatal("expected concurrency limit error")
+	} else if code := err.(Error).ErrorCode(); code != -32006 {
+		t.Fatalf("wrong error code %d", code)
+	}
+	if _, err := l.acquire("ip:2", "test_sleep"); err != nil {
+		t.Fatal("limit shared between clients:", err)
+	}
+	if _, err := l.acquire("", "test_sleep"); err != nil {
+		t.Fatal("exempt client limited:", err)
+	}
+	release1()
+	if _, err := l.acquire("ip:1", "test_sleep"); err != nil {
+		t.Fatal("released call still counted:", err)
+	}
+}
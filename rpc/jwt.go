@@ -0,0 +1,126 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// jwtHS256Header is the encoded JOSE header of HS256 signed tokens.
+var jwtHS256Header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// jwtClockSkew is the leeway given to the time claims of verified tokens, to
+// tolerate clock drift between the issuer and the node.
+const jwtClockSkew = time.Minute
+
+var (
+	errJWTMalformed = errors.New("malformed token")
+	errJWTSignature = errors.New("invalid token signature")
+	errJWTExpired   = errors.New("token is expired")
+	errJWTNotYet    = errors.New("token is not valid yet")
+)
+
+// NewHS256JWT creates a JSON web token carrying the given claims, signed with
+// HMAC-SHA256 using secret. An "iat" claim holding the current time is added
+// unless claims already contains one.
+func NewHS256JWT(secret []byte, claims map[string]interface{}) (string, error) {
+	payload := make(map[string]interface{}, len(claims)+1)
+	for k, v := range claims {
+		payload[k] = v
+	}
+	if _, ok := payload["iat"]; !ok {
+		payload["iat"] = time.Now().Unix()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHS256Header + "." + base64.RawURLEncoding.EncodeToString(body)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(jwtHS256Sign(secret, unsigned)), nil
+}
+
+// jwtHS256Sign computes the HMAC-SHA256 signature of the signing input of a token.
+func jwtHS256Sign(secret []byte, input string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
+
+// ParseHS256JWT verifies a JSON web token signed with HMAC-SHA256 using secret
+// and returns its claims. Tokens past their "exp" claim, or before their "nbf"
+// or "iat" claim, are rejected.
+func ParseHS256JWT(secret []byte, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errJWTMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := jwtDecodeSegment(parts[0], &header); err != nil {
+		return nil, errJWTMalformed
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("unsupported token algorithm %q", header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errJWTMalformed
+	}
+	if !hmac.Equal(signature, jwtHS256Sign(secret, parts[0]+"."+parts[1])) {
+		return nil, errJWTSignature
+	}
+	var claims map[string]interface{}
+	if err := jwtDecodeSegment(parts[1], &claims); err != nil || claims == nil {
+		return nil, errJWTMalformed
+	}
+	now := time.Now()
+	for _, claim := range []string{"exp", "nbf", "iat"} {
+		value, ok := claims[claim]
+		if !ok {
+			continue
+		}
+		seconds, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("invalid %q claim", claim)
+		}
+		at := time.Unix(int64(seconds), 0)
+		switch {
+		case claim == "exp" && now.After(at.Add(jwtClockSkew)):
+			return nil, errJWTExpired
+		case claim != "exp" && now.Add(jwtClockSkew).Before(at):
+			return nil, errJWTNotYet
+		}
+	}
+	return claims, nil
+}
+
+// jwtDecodeSegment decodes a base64url encoded JSON segment of a token into v.
+func jwtDecodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,189 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// limiterIdleTimeout is the time after which the state of idle clients is
+// dropped, which also refills their request budget.
+const limiterIdleTimeout = time.Minute
+
+// Limits configures the resources a Server grants to its callers. Zero values
+// disable the respective limit.
+//
+// The rate and concurrency limits are tracked per client, identified by the
+// identity it authenticated as (see ContextWithClientID) if any and by its IP
+// address otherwise. They only apply to HTTP and WebSocket connections.
+type Limits struct {
+	BatchItems      int            `toml:",omitempty"` // Maximum number of requests in a batch
+	ResponseBytes   int            `toml:",omitempty"` // Maximum size of the results of a response, summed over the responses of a batch
+	RequestRate     float64        `toml:",omitempty"` // Cost units a client may spend per second
+	RequestBurst    int            `toml:",omitempty"` // Cost units a client may spend at once (default = one second's worth)
+	ConcurrentCalls int            `toml:",omitempty"` // Maximum number of calls a client may run at once
+	MethodCosts     map[string]int `toml:",omitempty"` // Cost units of methods, the others cost one unit
+}
+
+// limiter enforces the Limits of a server.
+type limiter struct {
+	Limits
+	burst int // Bucket size of the rate limiters, fits the most expensive method
+
+	lock    sync.Mutex
+	clients map[string]*clientLimiter
+	swept   time.Time // Last time idle clients were dropped
+}
+
+// clientLimiter tracks the resources used by a single client.
+type clientLimiter struct {
+	bucket   *rate.Limiter // nil if requests aren't rate limited
+	inflight int           // Number of calls being run
+	lastUsed time.Time
+}
+
+func newLimiter(limits Limits) *limiter {
+	l := &limiter{
+		Limits:  limits,
+		clients: make(map[string]*clientLimiter),
+		swept:   time.Now(),
+	}
+	if limits.RequestRate > 0 {
+		l.burst = limits.RequestBurst
+		if l.burst <= 0 {
+			l.burst = int(limits.RequestRate)
+		}
+		if l.burst < 1 {
+			l.burst = 1
+		}
+		for _, cost := range limits.MethodCosts {
+			if cost > l.burst {
+				l.burst = cost
+			}
+		}
+	}
+	return l
+}
+
+// cost returns the cost units of a method call.
+func (l *limiter) cost(method string) int {
+	if cost, ok := l.MethodCosts[method]; ok {
+		return cost
+	}
+	return 1
+}
+
+// acquire charges a call of method to the budget of client and registers it as
+// running, returning the function to call once it's done. An error is returned
+// if the call exceeds the request rate or concurrency limit of the client.
+func (l *limiter) acquire(client, method string) (func(), error) {
+	if client == "" || (l.RequestRate <= 0 && l.ConcurrentCalls <= 0) {
+		return func() {}, nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.swept) > limiterIdleTimeout {
+		for key, c := range l.clients {
+			if c.inflight == 0 && now.Sub(c.lastUsed) > limiterIdleTimeout {
+				delete(l.clients, key)
+			}
+		}
+		l.swept = now
+	}
+	c := l.clients[client]
+	if c == nil {
+		c = new(clientLimiter)
+		if l.RequestRate > 0 {
+			c.bucket = rate.NewLimiter(rate.Limit(l.RequestRate), l.burst)
+		}
+		l.clients[client] = c
+	}
+	c.lastUsed = now
+
+	if l.ConcurrentCalls > 0 && c.inflight >= l.ConcurrentCalls {
+		rpcLimitCounter.With("concurrency").Inc(1)
+		return nil, &concurrencyLimitError{l.ConcurrentCalls}
+	}
+	if c.bucket != nil && !c.bucket.AllowN(now, l.cost(method)) {
+		rpcLimitCounter.With("rate").Inc(1)
+		return nil, &rateLimitError{method}
+	}
+	c.inflight++
+	return func() {
+		l.lock.Lock()
+		c.inflight--
+		l.lock.Unlock()
+	}, nil
+}
+
+// connLimitsKey is the context key of the limits applying to a connection.
+type connLimitsKey struct{}
+
+// connLimits are the limits applying to the calls of a connection.
+type connLimits struct {
+	*limiter
+	client string // Key of the client, empty if exempt from the per-client limits
+}
+
+// contextWithLimits returns a copy of ctx enforcing the limits of the server on
+// the calls of the given client.
+func (s *Server) contextWithLimits(ctx context.Context, client string) context.Context {
+	if s.limiter == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, connLimitsKey{}, &connLimits{s.limiter, client})
+}
+
+// limitsFromContext returns the limits installed by contextWithLimits, or nil
+// if there are none.
+func limitsFromContext(ctx context.Context) *connLimits {
+	limits, _ := ctx.Value(connLimitsKey{}).(*connLimits)
+	return limits
+}
+
+// clientIDKey is the context key of the authenticated identity of a client.
+type clientIDKey struct{}
+
+// ContextWithClientID returns a copy of ctx attributing the requests served with
+// it to the client identity id, which the per-client limits are then tracked
+// under instead of the IP address of the request. The identity must have been
+// authenticated, such as the subject of a verified token, as clients could
+// otherwise evade their limits by presenting a new one with every request.
+func ContextWithClientID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, clientIDKey{}, id)
+}
+
+// clientKey identifies the client of an HTTP request, by its authenticated
+// identity or its IP address.
+func clientKey(r *http.Request) string {
+	if id, _ := r.Context().Value(clientIDKey{}).(string); id != "" {
+		return "id:" + id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -17,8 +17,6 @@
 package rpc
 
 import (
-	"fmt"
-
 	"github.com/ethereum/go-ethereum/metrics"
 )
 
@@ -27,6 +25,14 @@ var (
 	successfulRequestGauge = metrics.NewRegisteredGauge("rpc/success", nil)
 	failedReqeustGauge     = metrics.NewRegisteredGauge("rpc/failure", nil)
 	rpcServingTimer        = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	// rpcServingTimers partitions the serving time by method and outcome. Only
+	// registered methods are recorded, which bounds the method label.
+	rpcServingTimers = metrics.NewRegisteredTimerVec("rpc/duration", []string{"method", "result"}, nil)
+
+	// rpcLimitCounter counts the requests rejected by the server limits, by
+	// limit: batch, response, rate or concurrency.
+	rpcLimitCounter = metrics.NewRegisteredCounterVec("rpc/limited", []string{"limit"}, nil)
 )
 
 func newRPCServingTimer(method string, valid bool) metrics.Timer {
@@ -34,6 +40,5 @@ func newRPCServingTimer(method string, valid bool) metrics.Timer {
 	if !valid {
 		flag = "failure"
 	}
-	m := fmt.Sprintf("rpc/duration/%s/%s", method, flag)
-	return metrics.GetOrRegisterTimer(m, nil)
+	return rpcServingTimers.With(method, flag)
 }
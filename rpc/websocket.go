@@ -59,7 +59,14 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			return
 		}
 		codec := newWebsocketCodec(conn)
-		s.ServeCodec(codec, 0)
+
+		// The connection outlives the upgrade request, only carry over the
+		// method filter of its context and its client identity.
+		ctx := context.Background()
+		if allow := methodFilter(r.Context()); allow != nil {
+			ctx = ContextWithMethodFilter(ctx, allow)
+		}
+		s.serveCodec(s.contextWithLimits(ctx, clientKey(r)), codec, 0)
 	})
 }
 
@@ -120,13 +127,30 @@ func (e wsHandshakeError) Error() string {
 	return s
 }
 
+// Unwrap returns the error which failed the handshake.
+func (e wsHandshakeError) Unwrap() error {
+	return e.err
+}
+
 // DialWebsocketWithDialer creates a new RPC client that communicates with a JSON-RPC server
 // that is listening on the given endpoint using the provided dialer.
 func DialWebsocketWithDialer(ctx context.Context, endpoint, origin string, dialer websocket.Dialer) (*Client, error) {
+	return DialWebsocketWithHeader(ctx, endpoint, origin, dialer, nil)
+}
+
+// DialWebsocketWithHeader creates a new RPC client that communicates with a JSON-RPC server
+// that is listening on the given endpoint using the provided dialer, adding the given
+// HTTP headers to the websocket handshake.
+func DialWebsocketWithHeader(ctx context.Context, endpoint, origin string, dialer websocket.Dialer, extra http.Header) (*Client, error) {
 	endpoint, header, err := wsClientHeaders(endpoint, origin)
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range extra {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
 	return newClient(ctx, func(ctx context.Context) (ServerCodec, error) {
 		conn, resp, err := dialer.DialContext(ctx, endpoint, header)
 		if err != nil {
@@ -77,6 +77,38 @@ func TestWebsocketOriginCheck(t *testing.T) {
 	client.Close()
 }
 
+// This test checks that extra headers are sent with the websocket handshake.
+func TestWebsocketDialHeader(t *testing.T) {
+	t.Parallel()
+
+	var (
+		srv     = newTestServer()
+		handler = srv.WebsocketHandler([]string{"*"})
+		httpsrv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		}))
+		wsURL = "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	)
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	client, err := DialWebsocketWithHeader(context.Background(), wsURL, "", websocket.Dialer{}, nil)
+	if err == nil {
+		client.Close()
+		t.Fatal("no error for missing header")
+	}
+	header := http.Header{"Authorization": {"Bearer token"}}
+	client, err = DialWebsocketWithHeader(context.Background(), wsURL, "", websocket.Dialer{}, header)
+	if err != nil {
+		t.Fatal("error for header:", err)
+	}
+	client.Close()
+}
+
 // This test checks whether calls exceeding the request size limit are rejected.
 func TestWebsocketLargeCall(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,400 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	mrand "math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	exportedSpanMeter = metrics.NewRegisteredMeter("tracing/spans/exported", nil)
+	droppedSpanMeter  = metrics.NewRegisteredMeter("tracing/spans/dropped", nil)
+	exportFailMeter   = metrics.NewRegisteredMeter("tracing/export/failures", nil)
+)
+
+// scopeName is the instrumentation scope reported for all spans.
+const scopeName = "github.com/ethereum/go-ethereum"
+
+// Config are the settings of the span exporter.
+type Config struct {
+	Endpoint       string            // OTLP/HTTP traces endpoint of the collector
+	Headers        map[string]string // Extra HTTP headers sent with every export, e.g. authorization
+	ServiceName    string            // Reported as the service.name resource attribute
+	ServiceVersion string            // Reported as the service.version resource attribute
+	SampleRatio    float64           // Fraction of new traces recorded, in [0, 1]
+	BatchSize      int               // Maximum number of spans per export request
+	FlushInterval  time.Duration     // Maximum time a finished span waits for export
+}
+
+// DefaultConfig contains the default exporter settings, targeting a collector on
+// the local machine and recording every trace.
+var DefaultConfig = Config{
+	Endpoint:      "http://localhost:4318/v1/traces",
+	ServiceName:   "geth",
+	SampleRatio:   1,
+	BatchSize:     512,
+	FlushInterval: 5 * time.Second,
+}
+
+// Start enables tracing, exporting finished spans with the given configuration
+// until Stop is called.
+func Start(config Config) error {
+	if config.Endpoint == "" {
+		return errors.New("tracing endpoint not specified")
+	}
+	u, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid tracing endpoint: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid tracing endpoint scheme %q", u.Scheme)
+	}
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return fmt.Errorf("invalid tracing sample ratio %v", config.SampleRatio)
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultConfig.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultConfig.FlushInterval
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = DefaultConfig.ServiceName
+	}
+	exp := &exporter{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		ids:    newIDGenerator(),
+		queue:  make(chan *Span, 4*config.BatchSize),
+		flush:  make(chan chan struct{}),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if prev := current(); prev != nil {
+		prev.close()
+	}
+	active.Store(exp)
+	go exp.loop()
+
+	log.Info("Enabled tracing span export", "endpoint", config.Endpoint, "sample", config.SampleRatio)
+	return nil
+}
+
+// Flush blocks until all spans finished so far have been exported.
+func Flush() {
+	if exp := current(); exp != nil {
+		exp.flushNow()
+	}
+}
+
+// Stop disables tracing, exporting the spans still pending.
+func Stop() {
+	if exp := current(); exp != nil {
+		active.Store((*exporter)(nil))
+		exp.close()
+	}
+}
+
+// exporter batches finished spans and posts them to an OTLP/HTTP collector.
+type exporter struct {
+	config Config
+	client *http.Client
+	ids    *idGenerator
+
+	queue chan *Span
+	flush chan chan struct{}
+	quit  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+// enqueue schedules a finished span for export, dropping it if the exporter
+// can't keep up.
+func (e *exporter) enqueue(span *Span) {
+	select {
+	case e.queue <- span:
+	default:
+		droppedSpanMeter.Mark(1)
+	}
+}
+
+// flushNow exports all queued spans and waits for the export to finish.
+func (e *exporter) flushNow() {
+	ch := make(chan struct{})
+	select {
+	case e.flush <- ch:
+		<-ch
+	case <-e.done:
+	}
+}
+
+// close stops the exporter after exporting all queued spans.
+func (e *exporter) close() {
+	e.once.Do(func() { close(e.quit) })
+	<-e.done
+}
+
+func (e *exporter) loop() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, e.config.BatchSize)
+	drain := func() {
+		for {
+			select {
+			case span := <-e.queue:
+				if batch = append(batch, span); len(batch) >= e.config.BatchSize {
+					e.export(batch)
+					batch = batch[:0]
+				}
+			default:
+				e.export(batch)
+				batch = batch[:0]
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case span := <-e.queue:
+			if batch = append(batch, span); len(batch) >= e.config.BatchSize {
+				e.export(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.export(batch)
+			batch = batch[:0]
+		case ch := <-e.flush:
+			drain()
+			close(ch)
+		case <-e.quit:
+			drain()
+			return
+		}
+	}
+}
+
+// export posts a batch of spans to the collector.
+func (e *exporter) export(batch []*Span) {
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(e.request(batch))
+	if err != nil {
+		log.Warn("Failed to encode tracing spans", "err", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		log.Warn("Failed to create tracing export request", "err", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.config.Headers {
+		req.Header.Set(key, value)
+	}
+	res, err := e.client.Do(req)
+	if err != nil {
+		exportFailMeter.Mark(1)
+		log.Warn("Failed to export tracing spans", "endpoint", e.config.Endpoint, "err", err)
+		return
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		exportFailMeter.Mark(1)
+		log.Warn("Tracing collector rejected spans", "endpoint", e.config.Endpoint, "status", res.Status)
+		return
+	}
+	exportedSpanMeter.Mark(int64(len(batch)))
+}
+
+// request converts a batch of spans into an OTLP export request.
+func (e *exporter) request(batch []*Span) *otlpRequest {
+	resource := []otlpKeyValue{otlpAttribute(String("service.name", e.config.ServiceName))}
+	if e.config.ServiceVersion != "" {
+		resource = append(resource, otlpAttribute(String("service.version", e.config.ServiceVersion)))
+	}
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, span := range batch {
+		spans = append(spans, otlpFromSpan(span))
+	}
+	return &otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: resource},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: scopeName},
+				Spans: spans,
+			}},
+		}},
+	}
+}
+
+// OTLP status codes.
+const (
+	otlpStatusUnset = 0
+	otlpStatusError = 2
+)
+
+// The types below are the JSON encoding of the OTLP trace export request. IDs
+// are hex encoded and 64 bit integers are strings, as the OTLP/JSON spec asks.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func otlpFromSpan(span *Span) otlpSpan {
+	span.lock.Lock()
+	defer span.lock.Unlock()
+
+	s := otlpSpan{
+		TraceID:           span.sc.TraceID.String(),
+		SpanID:            span.sc.SpanID.String(),
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		Status:            otlpStatus{Code: otlpStatusUnset},
+	}
+	if span.parent != (SpanID{}) {
+		s.ParentSpanID = span.parent.String()
+	}
+	for _, attr := range span.attrs {
+		s.Attributes = append(s.Attributes, otlpAttribute(attr))
+	}
+	if span.errored {
+		s.Status = otlpStatus{Code: otlpStatusError, Message: span.statusMsg}
+	}
+	return s
+}
+
+func otlpAttribute(attr Attribute) otlpKeyValue {
+	kv := otlpKeyValue{Key: attr.Key}
+	switch v := attr.Value.(type) {
+	case string:
+		kv.Value.StringValue = &v
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &s
+	case bool:
+		kv.Value.BoolValue = &v
+	case float64:
+		kv.Value.DoubleValue = &v
+	default:
+		s := fmt.Sprint(v)
+		kv.Value.StringValue = &s
+	}
+	return kv
+}
+
+// idGenerator creates random trace and span IDs. It uses a math/rand source
+// seeded from crypto/rand, IDs need to be unique, not unpredictable.
+type idGenerator struct {
+	rand *mrand.Rand
+	lock sync.Mutex
+}
+
+func newIDGenerator() *idGenerator {
+	var seed [8]byte
+	crand.Read(seed[:])
+	return &idGenerator{rand: mrand.New(mrand.NewSource(int64(binary.LittleEndian.Uint64(seed[:]))))}
+}
+
+func (g *idGenerator) traceID() (id TraceID) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for id == (TraceID{}) {
+		g.rand.Read(id[:])
+	}
+	return id
+}
+
+func (g *idGenerator) spanID() (id SpanID) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for id == (SpanID{}) {
+		g.rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,306 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracing implements OpenTelemetry compatible tracing spans, exported to
+// a collector over OTLP/HTTP using the JSON encoding.
+//
+// Tracing is disabled until Start is called. While disabled, StartSpan returns
+// a nil span whose methods are all no-ops, so instrumented code needs no guards:
+//
+//	ctx, span := tracing.StartSpan(ctx, "core.insertChain")
+//	defer span.End()
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies a trace, shared by all the spans within it.
+type TraceID [16]byte
+
+// String returns the hex encoding of the trace ID.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// String returns the hex encoding of the span ID.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext is the part of a span propagated to its children, in process and
+// across process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// TraceParent returns the W3C trace context header value of the span context.
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceParent parses a W3C trace context header value.
+func ParseTraceParent(header string) (SpanContext, error) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, fmt.Errorf("invalid traceparent %q", header)
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, fmt.Errorf("invalid traceparent %q", header)
+	}
+	if len(parts[1]) != 2*len(sc.TraceID) || len(parts[2]) != 2*len(sc.SpanID) || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("invalid traceparent %q", header)
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, fmt.Errorf("invalid trace id: %v", err)
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, fmt.Errorf("invalid span id: %v", err)
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, fmt.Errorf("invalid trace flags: %v", err)
+	}
+	if sc.TraceID == (TraceID{}) || sc.SpanID == (SpanID{}) {
+		return sc, fmt.Errorf("invalid traceparent %q", header)
+	}
+	sc.Sampled = flags[0]&0x01 != 0
+	return sc, nil
+}
+
+// SpanKind describes the relationship of a span to its remote parent or
+// children, numbered as in the OTLP protocol.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// Attribute is a key/value pair annotating a span.
+type Attribute struct {
+	Key   string
+	Value interface{} // string, int64, bool or float64
+}
+
+// String creates a string valued attribute.
+func String(key, value string) Attribute { return Attribute{key, value} }
+
+// Int64 creates an integer valued attribute.
+func Int64(key string, value int64) Attribute { return Attribute{key, value} }
+
+// Uint64 creates an integer valued attribute. OTLP integers are signed, so large
+// values wrap around.
+func Uint64(key string, value uint64) Attribute { return Attribute{key, int64(value)} }
+
+// Bool creates a boolean valued attribute.
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// Float64 creates a floating point valued attribute.
+func Float64(key string, value float64) Attribute { return Attribute{key, value} }
+
+// Span is a timed operation within a trace. A nil span is valid and ignores all
+// calls, which is what StartSpan hands out while tracing is disabled.
+type Span struct {
+	name   string
+	kind   SpanKind
+	sc     SpanContext
+	parent SpanID
+	start  time.Time
+	end    time.Time
+
+	attrs     []Attribute
+	errored   bool
+	statusMsg string
+
+	exporter *exporter // nil for remote parents and unsampled spans
+	lock     sync.Mutex
+	ended    bool
+}
+
+// SpanContext returns the propagated part of the span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// IsRecording reports whether the span is sampled and will be exported.
+func (s *Span) IsRecording() bool {
+	return s != nil && s.exporter != nil
+}
+
+// SetAttributes adds attributes to the span, replacing earlier ones with the
+// same key.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if !s.IsRecording() {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, attr := range attrs {
+		replaced := false
+		for i := range s.attrs {
+			if s.attrs[i].Key == attr.Key {
+				s.attrs[i], replaced = attr, true
+				break
+			}
+		}
+		if !replaced {
+			s.attrs = append(s.attrs, attr)
+		}
+	}
+}
+
+// RecordError marks the span as failed with the given error. Nil errors are
+// ignored.
+func (s *Span) RecordError(err error) {
+	if err == nil || !s.IsRecording() {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.errored, s.statusMsg = true, err.Error()
+}
+
+// End completes the span and queues it for export. Only the first call has any
+// effect.
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.lock.Unlock()
+
+	s.exporter.enqueue(s)
+}
+
+// spanKey is the context key of the active span.
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx with the given span as the parent of any
+// span started from it.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the active span of ctx, or nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithTraceParent returns a copy of ctx continuing the remote trace given
+// by a W3C traceparent header. Invalid or empty headers, and any header while
+// tracing is disabled, leave ctx untouched.
+func ContextWithTraceParent(ctx context.Context, header string) context.Context {
+	if header == "" || current() == nil {
+		return ctx
+	}
+	sc, err := ParseTraceParent(header)
+	if err != nil {
+		return ctx
+	}
+	return ContextWithSpan(ctx, &Span{sc: sc})
+}
+
+// StartSpan starts an internal span as a child of the active span in ctx, or a
+// new trace if there is none. The returned context carries the new span.
+func StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return startSpan(ctx, name, SpanKindInternal, attrs)
+}
+
+// StartServerSpan starts a span for serving a remote request.
+func StartServerSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return startSpan(ctx, name, SpanKindServer, attrs)
+}
+
+func startSpan(ctx context.Context, name string, kind SpanKind, attrs []Attribute) (context.Context, *Span) {
+	exp := current()
+	if exp == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		name:  name,
+		kind:  kind,
+		start: time.Now(),
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.sc.TraceID = parent.sc.TraceID
+		span.sc.Sampled = parent.sc.Sampled
+		span.parent = parent.sc.SpanID
+	} else {
+		span.sc.TraceID = exp.ids.traceID()
+		span.sc.Sampled = exp.sampled(span.sc.TraceID)
+	}
+	span.sc.SpanID = exp.ids.spanID()
+
+	if span.sc.Sampled {
+		span.exporter = exp
+		span.attrs = append(span.attrs, attrs...)
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// sampled decides whether a new trace is recorded, consistently for a trace ID,
+// like the OpenTelemetry TraceIDRatioBased sampler.
+func (e *exporter) sampled(id TraceID) bool {
+	switch {
+	case e.config.SampleRatio >= 1:
+		return true
+	case e.config.SampleRatio <= 0:
+		return false
+	}
+	bound := uint64(e.config.SampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
+
+// active is the running exporter, nil while tracing is disabled.
+var active atomic.Value
+
+// current returns the running exporter, if any.
+func current() *exporter {
+	exp, _ := active.Load().(*exporter)
+	return exp
+}
+
+// Enabled reports whether tracing was started.
+func Enabled() bool {
+	return current() != nil
+}
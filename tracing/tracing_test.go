@@ -0,0 +1,194 @@
+// Copyright 2020 The core-geth Authors
+// This file is part of the core-geth library.
+//
+// The core-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The core-geth library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the core-geth library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// testCollector is an OTLP/HTTP collector stand-in, recording received spans.
+type testCollector struct {
+	*httptest.Server
+
+	lock    sync.Mutex
+	spans   []otlpSpan
+	headers http.Header
+}
+
+func newTestCollector() *testCollector {
+	c := new(testCollector)
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpRequest
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		c.headers = r.Header
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+	}))
+	return c
+}
+
+func (c *testCollector) received() map[string]otlpSpan {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	spans := make(map[string]otlpSpan)
+	for _, span := range c.spans {
+		spans[span.Name] = span
+	}
+	return spans
+}
+
+func TestExport(t *testing.T) {
+	collector := newTestCollector()
+	defer collector.Close()
+
+	config := DefaultConfig
+	config.Endpoint = collector.URL + "/v1/traces"
+	config.Headers = map[string]string{"Authorization": "Bearer secret"}
+	if err := Start(config); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	defer Stop()
+
+	ctx, root := StartServerSpan(context.Background(), "eth_call", String("rpc.method", "eth_call"))
+	_, child := StartSpan(ctx, "ethapi.DoCall", Uint64("block", 42))
+	child.RecordError(errors.New("execution reverted"))
+	child.End()
+	root.End()
+	root.End() // double end must not export twice
+	Flush()
+
+	spans := collector.received()
+	if len(spans) != 2 {
+		t.Fatalf("received span count mismatch: have %d, want 2", len(spans))
+	}
+	parent, call := spans["eth_call"], spans["ethapi.DoCall"]
+	if parent.Kind != SpanKindServer || parent.ParentSpanID != "" {
+		t.Errorf("root span mismatch: %+v", parent)
+	}
+	if call.TraceID != parent.TraceID || call.ParentSpanID != parent.SpanID {
+		t.Errorf("child span not linked to its parent: %+v", call)
+	}
+	if call.Status.Code != otlpStatusError || call.Status.Message != "execution reverted" {
+		t.Errorf("child status mismatch: %+v", call.Status)
+	}
+	if len(call.Attributes) != 1 || *call.Attributes[0].Value.IntValue != "42" {
+		t.Errorf("child attributes mismatch: %+v", call.Attributes)
+	}
+	if have := collector.headers.Get("Authorization"); have != "Bearer secret" {
+		t.Errorf("custom header mismatch: have %q", have)
+	}
+}
+
+func TestRemoteParent(t *testing.T) {
+	collector := newTestCollector()
+	defer collector.Close()
+
+	config := DefaultConfig
+	config.Endpoint = collector.URL + "/v1/traces"
+	config.SampleRatio = 0 // remote sampling decision must win
+	if err := Start(config); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	defer Stop()
+
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := ContextWithTraceParent(context.Background(), header)
+	_, span := StartSpan(ctx, "remote-child")
+	if have := span.SpanContext().TraceParent(); have[:36] != header[:36] {
+		t.Errorf("trace not continued: have %s", have)
+	}
+	span.End()
+
+	// Unsampled remote parents suppress recording
+	ctx = ContextWithTraceParent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	if _, span := StartSpan(ctx, "unsampled"); span.IsRecording() {
+		t.Error("unsampled trace recorded")
+	}
+	Flush()
+
+	spans := collector.received()
+	if len(spans) != 1 || spans["remote-child"].ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("unexpected spans exported: %+v", spans)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	ctx := context.Background()
+	if have, span := StartSpan(ctx, "noop"); span != nil || have != ctx {
+		t.Fatal("span started while tracing disabled")
+	}
+	var span *Span
+	span.SetAttributes(String("k", "v"))
+	span.RecordError(errors.New("ignored"))
+	span.End()
+}
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		header string
+		valid  bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01", false},
+		{"00-zzf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"", false},
+	}
+	for i, tt := range tests {
+		if _, err := ParseTraceParent(tt.header); (err == nil) != tt.valid {
+			t.Errorf("test %d: validity mismatch for %q: err %v", i, tt.header, err)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	e := &exporter{config: Config{SampleRatio: 0.5}, ids: newIDGenerator()}
+
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		if e.sampled(e.ids.traceID()) {
+			sampled++
+		}
+	}
+	if sampled < 4500 || sampled > 5500 {
+		t.Errorf("sampled trace count out of range: %d", sampled)
+	}
+}